@@ -0,0 +1,214 @@
+package generator
+
+import (
+	"math/rand"
+
+	"github.com/PacoDw/maze_challenge/repository"
+)
+
+// Algorithm defines how the walls of the grid are carved, all of them carve a perfect
+// maze, so there is exactly one path between any two cells.
+type Algorithm string
+
+const (
+	// Backtracker walks randomly until it gets stuck and then goes back to the last cell
+	// with unvisited neighbours, it gives long corridors with few branches.
+	Backtracker Algorithm = "backtracker"
+
+	// Prim grows the maze from a cell opening a random wall of its border each time, it
+	// gives many short dead ends.
+	Prim Algorithm = "prim"
+
+	// Kruskal opens the walls in random order when they join two separated regions.
+	Kruskal Algorithm = "kruskal"
+
+	// Wilson adds loop-erased random walks to the maze, so every perfect maze has the same
+	// chance of being generated.
+	Wilson Algorithm = "wilson"
+)
+
+// carvers contains the function of each algorithm.
+var carvers = map[Algorithm]func(g *repository.Grid, r *rand.Rand){
+	Backtracker: backtracker,
+	Prim:        prim,
+	Kruskal:     kruskal,
+	Wilson:      wilson,
+}
+
+// index returns the position of the cell in the slices of the carvers.
+func index(g *repository.Grid, c repository.Coordinate) int {
+	return int(c.Y*g.Width + c.X)
+}
+
+// coordinate returns the cell at the position of the slices of the carvers.
+func coordinate(g *repository.Grid, i int) repository.Coordinate {
+	return repository.Coordinate{X: uint(i) % g.Width, Y: uint(i) / g.Width}
+}
+
+// open removes the wall, the carvers never reach the border so it can't fail.
+func open(g *repository.Grid, c repository.Coordinate, d repository.Direction) {
+	_ = g.SetWall(c, d, false)
+}
+
+func backtracker(g *repository.Grid, r *rand.Rand) {
+	visited := make([]bool, g.Width*g.Height)
+	start := coordinate(g, r.Intn(len(visited)))
+	stack := []repository.Coordinate{start}
+
+	visited[index(g, start)] = true
+
+	for len(stack) > 0 {
+		c := stack[len(stack)-1]
+
+		var options []repository.Direction
+
+		for _, d := range repository.Directions {
+			if next, ok := g.Move(c, d); ok && !visited[index(g, next)] {
+				options = append(options, d)
+			}
+		}
+
+		if len(options) == 0 {
+			stack = stack[:len(stack)-1]
+
+			continue
+		}
+
+		d := options[r.Intn(len(options))]
+		next, _ := g.Move(c, d)
+
+		open(g, c, d)
+
+		visited[index(g, next)] = true
+		stack = append(stack, next)
+	}
+}
+
+// wall represents the side of a cell that can be opened.
+type wall struct {
+	c repository.Coordinate
+	d repository.Direction
+}
+
+func prim(g *repository.Grid, r *rand.Rand) {
+	visited := make([]bool, g.Width*g.Height)
+
+	var frontier []wall
+
+	add := func(c repository.Coordinate) {
+		visited[index(g, c)] = true
+
+		for _, d := range repository.Directions {
+			if next, ok := g.Move(c, d); ok && !visited[index(g, next)] {
+				frontier = append(frontier, wall{c: c, d: d})
+			}
+		}
+	}
+
+	add(coordinate(g, r.Intn(len(visited))))
+
+	for len(frontier) > 0 {
+		i := r.Intn(len(frontier))
+		w := frontier[i]
+
+		frontier[i] = frontier[len(frontier)-1]
+		frontier = frontier[:len(frontier)-1]
+
+		next, _ := g.Move(w.c, w.d)
+		if visited[index(g, next)] {
+			continue
+		}
+
+		open(g, w.c, w.d)
+		add(next)
+	}
+}
+
+func kruskal(g *repository.Grid, r *rand.Rand) {
+	var walls []wall
+
+	for y := uint(0); y < g.Height; y++ {
+		for x := uint(0); x < g.Width; x++ {
+			c := repository.Coordinate{X: x, Y: y}
+
+			if x+1 < g.Width {
+				walls = append(walls, wall{c: c, d: repository.East})
+			}
+
+			if y+1 < g.Height {
+				walls = append(walls, wall{c: c, d: repository.South})
+			}
+		}
+	}
+
+	r.Shuffle(len(walls), func(i, j int) {
+		walls[i], walls[j] = walls[j], walls[i]
+	})
+
+	// each cell points to another cell of its region until the root of the region
+	parents := make([]int, g.Width*g.Height)
+	for i := range parents {
+		parents[i] = i
+	}
+
+	root := func(i int) int {
+		for parents[i] != i {
+			parents[i] = parents[parents[i]]
+			i = parents[i]
+		}
+
+		return i
+	}
+
+	for _, w := range walls {
+		next, _ := g.Move(w.c, w.d)
+
+		a, b := root(index(g, w.c)), root(index(g, next))
+		if a == b {
+			continue
+		}
+
+		parents[a] = b
+
+		open(g, w.c, w.d)
+	}
+}
+
+func wilson(g *repository.Grid, r *rand.Rand) {
+	cells := int(g.Width * g.Height)
+	inMaze := make([]bool, cells)
+	inMaze[r.Intn(cells)] = true
+
+	// exits keeps the last direction taken from each cell in the current walk, so the
+	// loops are erased when the walk comes back to a cell.
+	exits := make([]repository.Direction, cells)
+
+	for _, start := range r.Perm(cells) {
+		if inMaze[start] {
+			continue
+		}
+
+		for c := coordinate(g, start); !inMaze[index(g, c)]; {
+			var options []repository.Direction
+
+			for _, d := range repository.Directions {
+				if _, ok := g.Move(c, d); ok {
+					options = append(options, d)
+				}
+			}
+
+			d := options[r.Intn(len(options))]
+			exits[index(g, c)] = d
+			c, _ = g.Move(c, d)
+		}
+
+		for c := coordinate(g, start); !inMaze[index(g, c)]; {
+			d := exits[index(g, c)]
+			inMaze[index(g, c)] = true
+
+			open(g, c, d)
+
+			c, _ = g.Move(c, d)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+// Package generator builds complete mazes from a seed, the walls of the grid are carved
+// by one of the algorithms and then the entrance, the exit, the gold and the traps are
+// placed in its cells. The same config always gives the same maze.
+package generator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/PacoDw/maze_challenge/repository"
+)
+
+// maxSide is the biggest width and height that can be generated, the one of the mazes.
+const maxSide = repository.MaxMazeSide
+
+// maxSpots is the biggest number of gold spots and traps of a generated maze, they are
+// stored with the maze in a single write.
+const maxSpots = 10000
+
+// defaultTrapPenalty is the penalty of the traps when the config doesn't set it.
+const defaultTrapPenalty = 10
+
+// Distribution defines how the gold amounts are drawn between Min and Max.
+type Distribution string
+
+const (
+	// Uniform gives the same chance to every amount.
+	Uniform Distribution = "uniform"
+
+	// Normal gives more chances to the amounts around the middle of the range.
+	Normal Distribution = "normal"
+
+	// Exponential gives more chances to the small amounts, so there are a few rich spots.
+	Exponential Distribution = "exponential"
+)
+
+// Gold represents the amounts of gold of the generated spots.
+type Gold struct {
+	Distribution Distribution `json:"distribution"`
+	Min          uint         `json:"min"`
+	Max          uint         `json:"max"`
+}
+
+// Config represents the parameters of a generated maze.
+type Config struct {
+	Seed        int64     `json:"seed"`
+	Algorithm   Algorithm `json:"algorithm"`
+	Name        string    `json:"name" binding:"required"`
+	Width       uint      `json:"width" binding:"required"`
+	Height      uint      `json:"height" binding:"required"`
+	GoldSpots   uint      `json:"gold_spots"`
+	Gold        Gold      `json:"gold"`
+	TrapDensity float64   `json:"trap_density"`
+	TrapPenalty uint      `json:"trap_penalty"`
+}
+
+// Result represents a generated maze that hasn't been stored yet.
+type Result struct {
+	Maze  *repository.Maze
+	Grid  *repository.Grid
+	Spots []repository.Spot
+}
+
+// validate checks the config and sets the default values.
+func (cfg *Config) validate() error {
+	ve := &repository.ValidationError{Entity: "maze"}
+
+	add := func(field, format string, args ...interface{}) {
+		ve.Fields = append(ve.Fields, repository.FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if cfg.Algorithm == "" {
+		cfg.Algorithm = Backtracker
+	}
+
+	if _, ok := carvers[cfg.Algorithm]; !ok {
+		add("algorithm", "must be one of %s, %s, %s and %s", Backtracker, Prim, Kruskal, Wilson)
+	}
+
+	if cfg.Width < 2 || cfg.Width > maxSide {
+		add("width", "must be between 2 and %d", maxSide)
+	}
+
+	if cfg.Height < 2 || cfg.Height > maxSide {
+		add("height", "must be between 2 and %d", maxSide)
+	}
+
+	if cfg.Gold.Distribution == "" {
+		cfg.Gold.Distribution = Uniform
+	}
+
+	switch cfg.Gold.Distribution {
+	case Uniform, Normal, Exponential:
+	default:
+		add("gold.distribution", "must be one of %s, %s and %s", Uniform, Normal, Exponential)
+	}
+
+	if cfg.Gold.Min == 0 {
+		cfg.Gold.Min = 1
+	}
+
+	if cfg.Gold.Max == 0 {
+		cfg.Gold.Max = cfg.Gold.Min
+	}
+
+	if cfg.Gold.Max < cfg.Gold.Min {
+		add("gold.max", "must be greater than or equal to the min")
+	}
+
+	if cfg.TrapDensity < 0 || cfg.TrapDensity > 1 {
+		add("trap_density", "must be between 0 and 1")
+	}
+
+	if cfg.TrapPenalty == 0 {
+		cfg.TrapPenalty = defaultTrapPenalty
+	}
+
+	if cfg.GoldSpots > maxSpots {
+		add("gold_spots", "must be at most %d", maxSpots)
+	}
+
+	// the entrance and the exit take two cells
+	if len(ve.Fields) == 0 && uint64(cfg.GoldSpots)+2 > uint64(cfg.Width)*uint64(cfg.Height) {
+		add("gold_spots", "must leave room for the entrance and the exit in the %d cells", cfg.Width*cfg.Height)
+	}
+
+	if len(ve.Fields) == 0 {
+		if traps := cfg.traps(); uint64(cfg.GoldSpots)+uint64(traps) > maxSpots {
+			add("trap_density", "places %d traps in the free cells, the gold spots and the traps must be at most %d", traps, maxSpots)
+		}
+	}
+
+	if len(ve.Fields) > 0 {
+		return ve
+	}
+
+	return nil
+}
+
+// Generate builds the maze described by the config, the entrance is placed in the top
+// left cell and the exit in the bottom right one, the gold and then the traps are placed
+// in random free cells.
+func Generate(cfg Config) (*Result, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	r := rand.New(rand.NewSource(cfg.Seed)) // nolint:gosec
+
+	g := repository.NewGrid("", cfg.Width, cfg.Height, true)
+	carvers[cfg.Algorithm](g, r)
+
+	res := &Result{
+		Maze: &repository.Maze{Name: cfg.Name, Width: cfg.Width, Height: cfg.Height},
+		Grid: g,
+		Spots: []repository.Spot{
+			{Name: "entrance", Kind: repository.Entrance, Coordinate: &repository.Coordinate{X: 0, Y: 0}},
+			{Name: "exit", Kind: repository.Exit, Coordinate: &repository.Coordinate{X: cfg.Width - 1, Y: cfg.Height - 1}},
+		},
+	}
+
+	// the free cells are drawn without repetition, the first and last ones are the
+	// entrance and the exit
+	cells := int(cfg.Width * cfg.Height)
+	free := r.Perm(cells - 2)
+
+	next := func() *repository.Coordinate {
+		c := coordinate(g, free[0]+1)
+		free = free[1:]
+
+		return &c
+	}
+
+	for i := uint(1); i <= cfg.GoldSpots; i++ {
+		res.Spots = append(res.Spots, repository.Spot{
+			Name:       fmt.Sprintf("gold-%d", i),
+			Kind:       repository.Treasure,
+			GoldAmount: cfg.Gold.draw(r),
+			Coordinate: next(),
+		})
+	}
+
+	for i := 1; i <= cfg.traps(); i++ {
+		res.Spots = append(res.Spots, repository.Spot{
+			Name:       fmt.Sprintf("trap-%d", i),
+			Kind:       repository.Trap,
+			Penalty:    cfg.TrapPenalty,
+			Coordinate: next(),
+		})
+	}
+
+	return res, nil
+}
+
+// traps returns the number of traps placed in the cells left free by the entrance, the
+// exit and the gold spots.
+func (cfg *Config) traps() int {
+	free := cfg.Width*cfg.Height - 2 - cfg.GoldSpots
+
+	return int(math.Round(cfg.TrapDensity * float64(free)))
+}
+
+// draw returns an amount of gold between the min and the max of the distribution.
+func (gold *Gold) draw(r *rand.Rand) uint {
+	span := float64(gold.Max - gold.Min)
+
+	var v float64
+
+	switch gold.Distribution {
+	case Normal:
+		// most of the amounts fall within three deviations of the middle
+		v = span/2 + r.NormFloat64()*span/6
+	case Exponential:
+		v = r.ExpFloat64() * span / 4
+	default:
+		v = r.Float64() * (span + 1)
+	}
+
+	v = math.Max(0, math.Min(math.Floor(v), span))
+
+	return gold.Min + uint(v)
+}
+
+// Save stores the maze with its quadrants, grid and spots in a single transaction, the
+// spots are placed in the quadrants that contain them and written together.
+func (res *Result) Save(ctx context.Context, repo *repository.Repository) error {
+	return repo.Transaction(ctx, func(ctx context.Context) error {
+		if _, err := repo.Maze.Create(ctx, res.Maze); err != nil {
+			return err
+		}
+
+		res.Grid.MazeID = res.Maze.ID
+
+		if err := repo.Grid.Put(ctx, res.Grid); err != nil {
+			return err
+		}
+
+		for i := range res.Spots {
+			res.Spots[i].MazeID = res.Maze.ID
+		}
+
+		err := repo.Spot.CreateMany(ctx, res.Spots)
+
+		var se *repository.SpotError
+		if errors.As(err, &se) {
+			return fmt.Errorf("creating the spot %s: %w", res.Spots[se.Index].Name, se.Err)
+		}
+
+		return err
+	})
+}
@@ -0,0 +1,206 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/PacoDw/maze_challenge/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+// reachable counts the cells that can be reached from the top left one.
+func reachable(g *repository.Grid) int {
+	visited := map[repository.Coordinate]bool{{X: 0, Y: 0}: true}
+	queue := []repository.Coordinate{{X: 0, Y: 0}}
+
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+
+		for _, d := range repository.Directions {
+			next, ok := g.Move(c, d)
+			if !ok || g.HasWall(c, d) || visited[next] {
+				continue
+			}
+
+			visited[next] = true
+			queue = append(queue, next)
+		}
+	}
+
+	return len(visited)
+}
+
+// passages counts the walls that were opened.
+func passages(g *repository.Grid) int {
+	var n int
+
+	for y := uint(0); y < g.Height; y++ {
+		for x := uint(0); x < g.Width; x++ {
+			c := repository.Coordinate{X: x, Y: y}
+
+			if !g.HasWall(c, repository.East) {
+				n++
+			}
+
+			if !g.HasWall(c, repository.South) {
+				n++
+			}
+		}
+	}
+
+	return n
+}
+
+func TestGenerate_algorithms(t *testing.T) {
+	for _, algorithm := range []Algorithm{Backtracker, Prim, Kruskal, Wilson} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			cfg := Config{Seed: 7, Algorithm: algorithm, Name: "labyrinth", Width: 12, Height: 9}
+
+			res, err := Generate(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// a perfect maze connects all the cells with a single path between them
+			assert.Equal(t, 12*9, reachable(res.Grid))
+			assert.Equal(t, 12*9-1, passages(res.Grid))
+
+			// the same seed gives the same maze
+			again, err := Generate(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, res.Grid.Rows, again.Grid.Rows)
+
+			cfg.Seed = 8
+
+			other, err := Generate(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.NotEqual(t, res.Grid.Rows, other.Grid.Rows)
+		})
+	}
+}
+
+func TestGenerate_spots(t *testing.T) {
+	for _, distribution := range []Distribution{Uniform, Normal, Exponential} {
+		cfg := Config{
+			Seed:        42,
+			Name:        "labyrinth",
+			Width:       10,
+			Height:      10,
+			GoldSpots:   20,
+			Gold:        Gold{Distribution: distribution, Min: 10, Max: 50},
+			TrapDensity: 0.25,
+		}
+
+		res, err := Generate(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the entrance, the exit, the gold and a quarter of the 78 cells left as traps
+		assert.Len(t, res.Spots, 2+20+20, distribution)
+		assert.NoError(t, repository.ValidateMazeSpots(res.Spots), distribution)
+
+		used := make(map[repository.Coordinate]bool)
+
+		for _, s := range res.Spots {
+			assert.False(t, used[*s.Coordinate], distribution)
+			used[*s.Coordinate] = true
+
+			switch s.Kind {
+			case repository.Treasure:
+				assert.True(t, s.GoldAmount >= 10 && s.GoldAmount <= 50, s.GoldAmount)
+			case repository.Trap:
+				assert.Equal(t, uint(defaultTrapPenalty), s.Penalty)
+			}
+		}
+
+		again, err := Generate(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, res.Spots, again.Spots, distribution)
+	}
+}
+
+func TestGenerate_validation(t *testing.T) {
+	var ve *repository.ValidationError
+
+	_, err := Generate(Config{Name: "labyrinth", Algorithm: "eller", Width: 1, Height: 2000, TrapDensity: 2})
+	if !errors.As(err, &ve) {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+
+	assert.Len(t, ve.Fields, 4)
+
+	_, err = Generate(Config{Name: "labyrinth", Width: 2, Height: 2, GoldSpots: 3})
+	assert.True(t, errors.As(err, &ve))
+
+	_, err = Generate(Config{Name: "labyrinth", Width: 2, Height: 2, Gold: Gold{Min: 5, Max: 2}})
+	assert.True(t, errors.As(err, &ve))
+
+	// the spots are stored in a single write so they are capped
+	_, err = Generate(Config{Name: "labyrinth", Width: 1000, Height: 1000, GoldSpots: maxSpots + 1})
+	assert.True(t, errors.As(err, &ve))
+	assert.Equal(t, "gold_spots", ve.Fields[0].Field)
+
+	_, err = Generate(Config{Name: "labyrinth", Width: 1000, Height: 1000, TrapDensity: 0.5})
+	assert.True(t, errors.As(err, &ve))
+	assert.Equal(t, "trap_density", ve.Fields[0].Field)
+}
+
+func TestResult_Save(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemory()
+
+	res, err := Generate(Config{Seed: 1, Algorithm: Wilson, Name: "labyrinth", Width: 8, Height: 6, GoldSpots: 5, TrapDensity: 0.1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := res.Save(ctx, repo); err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := repo.Grid.Get(ctx, &repository.GridFilter{MazeID: res.Maze.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, res.Grid.Rows, g.Rows)
+
+	spots, err := repo.Spot.List(ctx, &repository.SpotFilter{MazeID: res.Maze.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, spots, len(res.Spots))
+
+	// each spot is kept by the quadrant that contains it
+	m, err := repo.Maze.Get(ctx, &repository.MazeFilter{ID: res.Maze.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var placed int
+
+	for _, q := range m.Quadrants {
+		placed += len(q.SpotIDs)
+
+		for _, s := range spots {
+			if s.QuadrantID == q.ID {
+				assert.True(t, q.Contains(*s.Coordinate))
+			}
+		}
+	}
+
+	assert.Equal(t, len(spots), placed)
+}
@@ -1,7 +1,15 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/PacoDw/maze_challenge/repository"
 	"github.com/PacoDw/maze_challenge/routes"
@@ -9,29 +17,137 @@ import (
 	_ "github.com/joho/godotenv/autoload"
 )
 
+const shutdownTimeout = 10 * time.Second
+
+// idempotencySweepInterval is how often the expired idempotency keys are removed.
+const idempotencySweepInterval = time.Hour
+
 func main() {
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run serves the API until an interrupt signal is received, the database is closed before
+// it returns so main can exit with its error afterwards.
+func run() error {
+	// The mazes must pass the validation before they are published unless it is turned off
+	routes.PublishRequiresValidation = os.Getenv("PUBLISH_VALIDATION") != "off"
+
+	// The responses of the requests sent with an Idempotency-Key are replayed for a day by default
+	if ttl := os.Getenv("IDEMPOTENCY_TTL"); ttl != "" {
+		d, err := time.ParseDuration(ttl)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("IDEMPOTENCY_TTL must be a positive duration, e.g. 24h: %q", ttl)
+		}
+
+		routes.IdempotencyTTL = d
+	}
+
+	// Create the repository once, it is shared by all the requests
+	repo, err := openRepository(context.Background())
+	if err != nil {
+		return fmt.Errorf("opening the database: %w", err)
+	}
+
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		if err := repo.Close(ctx); err != nil {
+			log.Printf("closing the database: %s", err)
+		}
+	}()
+
+	// Update the documents stored by previous versions before serving any request
+	if err := repo.Migrate(repository.DBNameSet(context.Background(), os.Getenv("DB_NAME"))); err != nil {
+		return fmt.Errorf("migrating the database: %w", err)
+	}
+
+	// The key/value backends don't expire the idempotency keys by themselves
+	sweepCtx, stopSweep := context.WithCancel(repository.DBNameSet(context.Background(), os.Getenv("DB_NAME")))
+	defer stopSweep()
+
+	go sweepIdempotencyKeys(sweepCtx, repo)
+
 	// Set the router as the default one shipped with Gin
 	router := gin.Default()
 
 	router.Use(
-		repository.GinMiddleware(os.Getenv("MOGODB_CONN")),
+		routes.RequestID(),
+		repository.GinMiddleware(repo),
 	)
 
-	spot := router.Group("/spot")
-	{
-		spot.POST("/create", routes.CreateSpot)
-		spot.GET("/read/:id", routes.GetSpot)
-		spot.PATCH("/update", routes.UpdateSpot)
-		spot.DELETE("/delete/:id", routes.DeleteSpot)
+	routes.Register(router)
+
+	srv := &http.Server{
+		Addr:    ":3000",
+		Handler: router,
+	}
+
+	// The error of the server is sent back so the database is closed before exiting
+	serveErr := make(chan error, 1)
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- err
+		}
+	}()
+
+	// Wait for an interrupt signal to shut down the server gracefully
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("listening: %w", err)
+	case <-quit:
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("shutting down the server: %s", err)
 	}
 
-	quadrant := router.Group("/quadrant")
-	{
-		quadrant.POST("/create", routes.CreateQuadrant)
-		quadrant.GET("/read/:id", routes.GetQuadrant)
-		quadrant.PATCH("/update", routes.UpdateQuadrant)
-		quadrant.DELETE("/delete/:id", routes.DeleteQuadrant)
+	return nil
+}
+
+// openRepository creates the repository using the backend set in REPOSITORY_BACKEND,
+// the available ones are mongodb (default), bolt and memory.
+func openRepository(ctx context.Context) (*repository.Repository, error) {
+	switch backend := os.Getenv("REPOSITORY_BACKEND"); backend {
+	case "", "mongodb":
+		return repository.OpenMongoDB(ctx, repository.MongoDBConfigFromEnv())
+	case "bolt":
+		path := os.Getenv("BOLT_PATH")
+		if path == "" {
+			path = "maze.db"
+		}
+
+		return repository.NewBolt(path)
+	case "memory":
+		return repository.NewMemory(), nil
+	default:
+		return nil, fmt.Errorf("unknown repository backend %q", backend)
 	}
+}
 
-	router.Run(":3000")
+// sweepIdempotencyKeys removes the expired idempotency keys periodically until the context
+// is done.
+func sweepIdempotencyKeys(ctx context.Context, repo *repository.Repository) {
+	ticker := time.NewTicker(idempotencySweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := repo.Idempotency.Sweep(ctx); err != nil {
+				log.Printf("sweeping the idempotency keys: %s", err)
+			}
+		}
+	}
 }
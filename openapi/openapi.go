@@ -0,0 +1,184 @@
+// Package openapi builds OpenAPI 3 documents, the schemas of the bodies are derived from
+// the Go types so they can't drift from the code.
+package openapi
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Version is the version of the OpenAPI specification used by the documents.
+const Version = "3.0.3"
+
+// Document represents an OpenAPI document.
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+
+	enums map[reflect.Type][]interface{}
+	names map[string]reflect.Type
+}
+
+// Info represents the metadata of the API.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// PathItem contains the operations of a path by their method in lower case.
+type PathItem map[string]*Operation
+
+// Operation represents a method of a path.
+type Operation struct {
+	Summary     string              `json:"summary"`
+	Description string              `json:"description,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
+	Deprecated  bool                `json:"deprecated,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+// Parameter represents a parameter of the path or the query of an operation.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody represents the body of an operation by its content type.
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response represents a response of an operation by its content type.
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType contains the schema of a content type.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components contains the schemas referenced by the operations.
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Schema represents the subset of JSON schema used by the documents.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// New creates an empty document.
+func New(info Info) *Document {
+	return &Document{
+		OpenAPI:    Version,
+		Info:       info,
+		Paths:      make(map[string]PathItem),
+		Components: Components{Schemas: make(map[string]*Schema)},
+		enums:      make(map[reflect.Type][]interface{}),
+		names:      make(map[string]reflect.Type),
+	}
+}
+
+// Enum sets the values allowed by the type of v, e.g. the constants of a string type.
+func (d *Document) Enum(v interface{}, values ...interface{}) {
+	d.enums[reflect.TypeOf(v)] = values
+}
+
+// Add adds the operation to the path, the path uses the syntax of OpenAPI, e.g.
+// /spots/{id}.
+func (d *Document) Add(method, path string, op *Operation) {
+	item, ok := d.Paths[path]
+	if !ok {
+		item = make(PathItem)
+		d.Paths[path] = item
+	}
+
+	item[strings.ToLower(method)] = op
+}
+
+// Has reports if the document contains the operation.
+func (d *Document) Has(method, path string) bool {
+	_, ok := d.Paths[path][strings.ToLower(method)]
+
+	return ok
+}
+
+// Operations returns the operations of the document as METHOD /path sorted by path.
+func (d *Document) Operations() []string {
+	ops := make([]string, 0, len(d.Paths))
+
+	for path, item := range d.Paths {
+		for method := range item {
+			ops = append(ops, strings.ToUpper(method)+" "+path)
+		}
+	}
+
+	sort.Strings(ops)
+
+	return ops
+}
+
+// JSONContent returns the content of a JSON body with the schema of v.
+func (d *Document) JSONContent(v interface{}) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: d.Schema(v)}}
+}
+
+// QueryParameters returns the query parameters described by the attributes of the struct
+// v named by their json tags, the structs are received as strings in the query so their
+// format must be given in the descriptions, which are set by the name of the parameter.
+func (d *Document) QueryParameters(v interface{}, descriptions map[string]string) []Parameter {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	params := make([]Parameter, 0, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		name, ok := jsonName(f)
+		if !ok {
+			continue
+		}
+
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		schema := &Schema{Type: "string"}
+		if ft.Kind() != reflect.Struct {
+			schema = d.schemaOf(ft)
+		}
+
+		params = append(params, Parameter{
+			Name:        name,
+			In:          "query",
+			Description: descriptions[name],
+			Schema:      schema,
+		})
+	}
+
+	return params
+}
@@ -0,0 +1,157 @@
+package openapi
+
+import (
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Schema returns the schema of the type of v. The structs are added to the components and
+// referenced by their name, their attributes are named by the json tags and the ones with
+// binding:"required" are required since the same schemas describe the request bodies.
+func (d *Document) Schema(v interface{}) *Schema {
+	return d.schemaOf(reflect.TypeOf(v))
+}
+
+func (d *Document) schemaOf(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if values, ok := d.enums[t]; ok {
+		s := d.kindSchema(t)
+		s.Enum = values
+
+		return s
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Struct:
+		return d.structRef(t)
+	default:
+		return d.kindSchema(t)
+	}
+}
+
+// kindSchema returns the schema of the types that aren't structs.
+func (d *Document) kindSchema(t reflect.Type) *Schema {
+	zero := float64(0)
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Minimum: &zero}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+
+		return &Schema{Type: "array", Items: d.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: d.schemaOf(t.Elem())}
+	default:
+		// the interfaces accept any value
+		return &Schema{}
+	}
+}
+
+// structRef adds the schema of the struct to the components and returns its reference.
+func (d *Document) structRef(t reflect.Type) *Schema {
+	name := d.componentName(t)
+
+	if _, ok := d.Components.Schemas[name]; !ok {
+		// the placeholder stops the recursion of the types that reference themselves
+		s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		d.Components.Schemas[name] = s
+
+		d.addFields(s, t)
+	}
+
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// componentName returns the name of the struct in the components, the names used by
+// types of different packages are prefixed with the package.
+func (d *Document) componentName(t reflect.Type) string {
+	name := exported(t.Name())
+
+	if other, ok := d.names[name]; ok && other != t {
+		pkg := t.PkgPath()
+		name = exported(pkg[strings.LastIndex(pkg, "/")+1:]) + name
+	}
+
+	d.names[name] = t
+
+	return name
+}
+
+// addFields adds the attributes of the struct to the schema, the embedded structs are
+// flattened like encoding/json does.
+func (d *Document) addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		name, ok := jsonName(f)
+		if !ok {
+			continue
+		}
+
+		if f.Anonymous && f.Tag.Get("json") == "" && f.Type.Kind() == reflect.Struct {
+			d.addFields(s, f.Type)
+
+			continue
+		}
+
+		s.Properties[name] = d.schemaOf(f.Type)
+
+		if strings.Contains(f.Tag.Get("binding"), "required") {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+// jsonName returns the name of the attribute in JSON, the second value is false when the
+// attribute isn't encoded.
+func jsonName(f reflect.StructField) (string, bool) {
+	if f.PkgPath != "" && !f.Anonymous {
+		return "", false
+	}
+
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+
+	name := strings.Split(tag, ",")[0]
+	if name == "" {
+		name = f.Name
+	}
+
+	return name, true
+}
+
+// exported returns the name with its first letter in upper case.
+func exported(name string) string {
+	if name == "" {
+		return name
+	}
+
+	r := []rune(name)
+	r[0] = unicode.ToUpper(r[0])
+
+	return string(r)
+}
@@ -0,0 +1,89 @@
+package openapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type color string
+
+type point struct {
+	X uint `json:"x"`
+	Y uint `json:"y"`
+}
+
+type base struct {
+	ID string `json:"id,omitempty"`
+}
+
+type shape struct {
+	base
+	Name    string            `json:"name" binding:"required"`
+	Color   color             `json:"color,omitempty"`
+	Points  []point           `json:"points"`
+	Center  *point            `json:"center,omitempty"`
+	Parent  *shape            `json:"parent,omitempty"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Raw     []byte            `json:"raw,omitempty"`
+	Ignored string            `json:"-"`
+	private string
+}
+
+func TestSchema(t *testing.T) {
+	doc := New(Info{Title: "test", Version: "1"})
+	doc.Enum(color(""), "red", "blue")
+
+	ref := doc.Schema(&shape{})
+	assert.Equal(t, "#/components/schemas/Shape", ref.Ref)
+
+	s := doc.Components.Schemas["Shape"]
+	assert.Equal(t, "object", s.Type)
+	assert.Equal(t, []string{"name"}, s.Required)
+
+	// the embedded structs are flattened and the hidden attributes are left out
+	assert.Contains(t, s.Properties, "id")
+	assert.NotContains(t, s.Properties, "Ignored")
+	assert.NotContains(t, s.Properties, "private")
+	assert.Len(t, s.Properties, 8)
+
+	assert.Equal(t, []interface{}{"red", "blue"}, s.Properties["color"].Enum)
+	assert.Equal(t, "array", s.Properties["points"].Type)
+	assert.Equal(t, "#/components/schemas/Point", s.Properties["points"].Items.Ref)
+	assert.Equal(t, "#/components/schemas/Point", s.Properties["center"].Ref)
+	assert.Equal(t, "#/components/schemas/Shape", s.Properties["parent"].Ref)
+	assert.Equal(t, "string", s.Properties["labels"].AdditionalProperties.Type)
+	assert.Equal(t, "byte", s.Properties["raw"].Format)
+
+	p := doc.Components.Schemas["Point"]
+	assert.Equal(t, "integer", p.Properties["x"].Type)
+	assert.Equal(t, float64(0), *p.Properties["x"].Minimum)
+}
+
+func TestDocument_QueryParameters(t *testing.T) {
+	doc := New(Info{Title: "test", Version: "1"})
+
+	params := doc.QueryParameters(shape{}, map[string]string{"center": "x,y"})
+
+	names := make([]string, 0, len(params))
+
+	for _, p := range params {
+		names = append(names, p.Name)
+		assert.Equal(t, "query", p.In)
+	}
+
+	assert.Contains(t, names, "center")
+	assert.Equal(t, "x,y", params[4].Description)
+	assert.Equal(t, "string", params[4].Schema.Type)
+}
+
+func TestDocument_Operations(t *testing.T) {
+	doc := New(Info{Title: "test", Version: "1"})
+
+	doc.Add("GET", "/shapes/{id}", &Operation{Summary: "get"})
+	doc.Add("DELETE", "/shapes/{id}", &Operation{Summary: "delete"})
+
+	assert.True(t, doc.Has("get", "/shapes/{id}"))
+	assert.False(t, doc.Has("PUT", "/shapes/{id}"))
+	assert.Equal(t, []string{"DELETE /shapes/{id}", "GET /shapes/{id}"}, doc.Operations())
+}
@@ -0,0 +1,348 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Operation represents an operation of a JSON patch, value is required by add, replace
+// and test, and from by move and copy.
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Apply applies the operations of the JSON patch to the document in order, the document
+// isn't changed when any of them fails.
+func Apply(doc []byte, ops []Operation) ([]byte, error) {
+	d, err := decode(doc)
+	if err != nil {
+		return nil, fmt.Errorf("decoding the document: %s", err)
+	}
+
+	for i := range ops {
+		d, err = apply(d, &ops[i])
+		if err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+
+	return json.Marshal(d)
+}
+
+func apply(doc interface{}, op *Operation) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, fmt.Errorf("the %s operation requires a value", op.Op)
+		}
+
+		value, err := decode(op.Value)
+		if err != nil {
+			return nil, fmt.Errorf("decoding the value: %s", err)
+		}
+
+		switch op.Op {
+		case "add":
+			return add(doc, path, value)
+		case "replace":
+			if doc, _, err = remove(doc, path); err != nil {
+				return nil, err
+			}
+
+			return add(doc, path, value)
+		default:
+			current, err := get(doc, path)
+			if err != nil {
+				return nil, err
+			}
+
+			if !equal(current, value) {
+				return nil, &TestError{Path: op.Path}
+			}
+
+			return doc, nil
+		}
+	case "remove":
+		doc, _, err = remove(doc, path)
+
+		return doc, err
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, fmt.Errorf("from: %s", err)
+		}
+
+		if op.Op == "move" && isPrefix(from, path) && len(from) < len(path) {
+			return nil, errors.New("a value can't be moved into one of its children")
+		}
+
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, fmt.Errorf("from: %s", err)
+		}
+
+		if op.Op == "move" {
+			if doc, _, err = remove(doc, from); err != nil {
+				return nil, err
+			}
+		} else {
+			// the copy can't share the maps and slices of the original value
+			if value, err = clone(value); err != nil {
+				return nil, err
+			}
+		}
+
+		return add(doc, path, value)
+	default:
+		return nil, fmt.Errorf("unknown operation %q", op.Op)
+	}
+}
+
+// parsePointer splits a JSON pointer (RFC 6901) into its reference tokens.
+func parsePointer(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+
+	if !strings.HasPrefix(p, "/") {
+		return nil, fmt.Errorf("the path %q must start with /", p)
+	}
+
+	tokens := strings.Split(p[1:], "/")
+
+	for i := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(tokens[i], "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+// isPrefix reports if the pointer a is a prefix of b.
+func isPrefix(a, b []string) bool {
+	if len(a) > len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// index parses an array index, end allows the index one past the last element which is
+// written as - as well.
+func index(token string, length int, end bool) (int, error) {
+	if token == "-" && end {
+		return length, nil
+	}
+
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%q isn't an array index", token)
+	}
+
+	if i > length || (i == length && !end) {
+		return 0, fmt.Errorf("the index %d is out of range", i)
+	}
+
+	return i, nil
+}
+
+// get returns the value referenced by the path.
+func get(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch v := doc.(type) {
+		case map[string]interface{}:
+			child, ok := v[token]
+			if !ok {
+				return nil, fmt.Errorf("the member %q doesn't exist", token)
+			}
+
+			doc = child
+		case []interface{}:
+			i, err := index(token, len(v), false)
+			if err != nil {
+				return nil, err
+			}
+
+			doc = v[i]
+		default:
+			return nil, fmt.Errorf("the member %q doesn't exist", token)
+		}
+	}
+
+	return doc, nil
+}
+
+// add adds the value at the path and returns the new document, the members of the
+// objects are replaced and the values of the arrays are inserted.
+func add(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	token := path[len(path)-1]
+
+	switch v := parent.(type) {
+	case map[string]interface{}:
+		v[token] = value
+
+		return doc, nil
+	case []interface{}:
+		i, err := index(token, len(v), true)
+		if err != nil {
+			return nil, err
+		}
+
+		v = append(v, nil)
+		copy(v[i+1:], v[i:])
+		v[i] = value
+
+		return set(doc, path[:len(path)-1], v)
+	default:
+		return nil, fmt.Errorf("the member %q can't be added to a %T", token, parent)
+	}
+}
+
+// remove removes the value at the path and returns the new document and the removed value.
+func remove(doc interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, doc, nil
+	}
+
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	token := path[len(path)-1]
+
+	switch v := parent.(type) {
+	case map[string]interface{}:
+		old, ok := v[token]
+		if !ok {
+			return nil, nil, fmt.Errorf("the member %q doesn't exist", token)
+		}
+
+		delete(v, token)
+
+		return doc, old, nil
+	case []interface{}:
+		i, err := index(token, len(v), false)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		old := v[i]
+		v = append(v[:i:i], v[i+1:]...)
+
+		doc, err = set(doc, path[:len(path)-1], v)
+
+		return doc, old, err
+	default:
+		return nil, nil, fmt.Errorf("the member %q doesn't exist", token)
+	}
+}
+
+// set replaces the value at the path, it is used to store the arrays that were resized.
+func set(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	token := path[len(path)-1]
+
+	switch v := parent.(type) {
+	case map[string]interface{}:
+		v[token] = value
+	case []interface{}:
+		i, err := index(token, len(v), false)
+		if err != nil {
+			return nil, err
+		}
+
+		v[i] = value
+	}
+
+	return doc, nil
+}
+
+// clone returns a deep copy of the value.
+func clone(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return decode(data)
+}
+
+// equal reports if both values are equal, the numbers are compared by their value so 1
+// and 1.0 are equal.
+func equal(a, b interface{}) bool {
+	switch x := a.(type) {
+	case json.Number:
+		y, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+
+		fx, errX := x.Float64()
+		fy, errY := y.Float64()
+
+		return errX == nil && errY == nil && fx == fy
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+
+		for k := range x {
+			if _, ok := y[k]; !ok || !equal(x[k], y[k]) {
+				return false
+			}
+		}
+
+		return true
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+
+		for i := range x {
+			if !equal(x[i], y[i]) {
+				return false
+			}
+		}
+
+		return true
+	default:
+		return reflect.DeepEqual(a, b)
+	}
+}
@@ -0,0 +1,88 @@
+// Package patch applies JSON merge patches (RFC 7396) and JSON patches (RFC 6902) to JSON
+// documents, the resources are encoded to JSON, patched and decoded again so the patches
+// can set any attribute, including its zero value.
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	// MergePatchType is the content type of the JSON merge patches.
+	MergePatchType = "application/merge-patch+json"
+
+	// JSONPatchType is the content type of the JSON patches.
+	JSONPatchType = "application/json-patch+json"
+)
+
+// TestError is returned when a test operation of a JSON patch fails, the document was
+// changed after the client read it.
+type TestError struct {
+	Path string
+}
+
+// Error implements the error interface.
+func (te *TestError) Error() string {
+	return fmt.Sprintf("the value at %q doesn't match the test operation", te.Path)
+}
+
+// decode decodes a JSON value keeping the numbers as json.Number so they aren't rounded.
+func decode(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v interface{}
+
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	if dec.More() {
+		return nil, fmt.Errorf("unexpected data after the JSON value")
+	}
+
+	return v, nil
+}
+
+// Merge applies the JSON merge patch to the document, the members of the patch replace
+// the ones of the document, the null members remove them and the objects are merged
+// recursively.
+func Merge(doc, patch []byte) ([]byte, error) {
+	d, err := decode(doc)
+	if err != nil {
+		return nil, fmt.Errorf("decoding the document: %s", err)
+	}
+
+	p, err := decode(patch)
+	if err != nil {
+		return nil, fmt.Errorf("the merge patch must be a JSON value: %s", err)
+	}
+
+	return json.Marshal(merge(d, p))
+}
+
+func merge(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = make(map[string]interface{})
+	}
+
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+
+			continue
+		}
+
+		t[k] = merge(t[k], v)
+	}
+
+	return t
+}
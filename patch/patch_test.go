@@ -0,0 +1,122 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge(t *testing.T) {
+	// the examples of the appendix A of RFC 7396
+	cases := []struct {
+		doc, patch, want string
+	}{
+		{doc: `{"a":"b"}`, patch: `{"a":"c"}`, want: `{"a":"c"}`},
+		{doc: `{"a":"b"}`, patch: `{"b":"c"}`, want: `{"a":"b","b":"c"}`},
+		{doc: `{"a":"b"}`, patch: `{"a":null}`, want: `{}`},
+		{doc: `{"a":"b","b":"c"}`, patch: `{"a":null}`, want: `{"b":"c"}`},
+		{doc: `{"a":["b"]}`, patch: `{"a":"c"}`, want: `{"a":"c"}`},
+		{doc: `{"a":"c"}`, patch: `{"a":["b"]}`, want: `{"a":["b"]}`},
+		{doc: `{"a":{"b":"c"}}`, patch: `{"a":{"b":"d","c":null}}`, want: `{"a":{"b":"d"}}`},
+		{doc: `{"a":[{"b":"c"}]}`, patch: `{"a":[1]}`, want: `{"a":[1]}`},
+		{doc: `["a","b"]`, patch: `["c","d"]`, want: `["c","d"]`},
+		{doc: `{"a":"b"}`, patch: `["c"]`, want: `["c"]`},
+		{doc: `{"a":"foo"}`, patch: `null`, want: `null`},
+		{doc: `{"e":null}`, patch: `{"a":1}`, want: `{"a":1,"e":null}`},
+		{doc: `[1,2]`, patch: `{"a":"b","c":null}`, want: `{"a":"b"}`},
+		{doc: `{}`, patch: `{"a":{"bb":{"ccc":null}}}`, want: `{"a":{"bb":{}}}`},
+		// the zero values are set like the rest
+		{doc: `{"gold_amount":10,"x":5}`, patch: `{"gold_amount":0,"x":0}`, want: `{"gold_amount":0,"x":0}`},
+	}
+
+	for _, tc := range cases {
+		got, err := Merge([]byte(tc.doc), []byte(tc.patch))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.JSONEq(t, tc.want, string(got), "patch %s", tc.patch)
+	}
+
+	_, err := Merge([]byte(`{}`), []byte(`{"a":`))
+	assert.Error(t, err)
+}
+
+func TestApply(t *testing.T) {
+	// most of the examples of the appendix A of RFC 6902
+	cases := []struct {
+		doc, patch, want string
+	}{
+		{doc: `{"foo":"bar"}`, patch: `[{"op":"add","path":"/baz","value":"qux"}]`, want: `{"baz":"qux","foo":"bar"}`},
+		{doc: `{"foo":["bar","baz"]}`, patch: `[{"op":"add","path":"/foo/1","value":"qux"}]`, want: `{"foo":["bar","qux","baz"]}`},
+		{doc: `{"baz":"qux","foo":"bar"}`, patch: `[{"op":"remove","path":"/baz"}]`, want: `{"foo":"bar"}`},
+		{doc: `{"foo":["bar","qux","baz"]}`, patch: `[{"op":"remove","path":"/foo/1"}]`, want: `{"foo":["bar","baz"]}`},
+		{doc: `{"baz":"qux","foo":"bar"}`, patch: `[{"op":"replace","path":"/baz","value":"boo"}]`, want: `{"baz":"boo","foo":"bar"}`},
+		{
+			doc:   `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			patch: `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			want:  `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`,
+		},
+		{doc: `{"foo":["all","grass","cows","eat"]}`, patch: `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, want: `{"foo":["all","cows","eat","grass"]}`},
+		{doc: `{"foo":{"bar":"baz"}}`, patch: `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`, want: `{"foo":{"bar":"baz"},"child":{"grandchild":{}}}`},
+		{doc: `{"foo":["bar"]}`, patch: `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, want: `{"foo":["bar",["abc","def"]]}`},
+		{doc: `{"foo":"bar"}`, patch: `[{"op":"add","path":"/baz","value":null}]`, want: `{"baz":null,"foo":"bar"}`},
+		{doc: `{"foo":{"bar":[1]}}`, patch: `[{"op":"copy","from":"/foo","path":"/baz"},{"op":"add","path":"/baz/bar/-","value":2}]`, want: `{"foo":{"bar":[1]},"baz":{"bar":[1,2]}}`},
+		{doc: `{"/":9,"~1":10}`, patch: `[{"op":"test","path":"/~01","value":10},{"op":"replace","path":"/~1","value":0}]`, want: `{"/":0,"~1":10}`},
+		{doc: `{"baz":"qux","foo":["a",2,"c"]}`, patch: `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2.0}]`, want: `{"baz":"qux","foo":["a",2,"c"]}`},
+		{doc: `{"foo":1}`, patch: `[{"op":"replace","path":"","value":[1]}]`, want: `[1]`},
+	}
+
+	for _, tc := range cases {
+		var ops []Operation
+
+		if err := json.Unmarshal([]byte(tc.patch), &ops); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := Apply([]byte(tc.doc), ops)
+		if err != nil {
+			t.Fatalf("patch %s: %s", tc.patch, err)
+		}
+
+		assert.JSONEq(t, tc.want, string(got), "patch %s", tc.patch)
+	}
+}
+
+func TestApply_Errors(t *testing.T) {
+	cases := []struct {
+		doc, patch string
+	}{
+		{doc: `{"foo":"bar"}`, patch: `[{"op":"add","path":"/baz/bat","value":"qux"}]`},
+		{doc: `{"foo":"bar"}`, patch: `[{"op":"remove","path":"/baz"}]`},
+		{doc: `{"foo":"bar"}`, patch: `[{"op":"replace","path":"/baz","value":1}]`},
+		{doc: `{"foo":"bar"}`, patch: `[{"op":"add","path":"/baz"}]`},
+		{doc: `{"foo":[1]}`, patch: `[{"op":"add","path":"/foo/2","value":2}]`},
+		{doc: `{"foo":[1]}`, patch: `[{"op":"add","path":"/foo/01","value":2}]`},
+		{doc: `{"foo":{"bar":1}}`, patch: `[{"op":"move","from":"/foo","path":"/foo/bar/baz"}]`},
+		{doc: `{"foo":"bar"}`, patch: `[{"op":"inc","path":"/foo"}]`},
+		{doc: `{"foo":"bar"}`, patch: `[{"op":"add","path":"foo","value":1}]`},
+	}
+
+	for _, tc := range cases {
+		var ops []Operation
+
+		if err := json.Unmarshal([]byte(tc.patch), &ops); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := Apply([]byte(tc.doc), ops)
+		assert.Error(t, err, "patch %s", tc.patch)
+	}
+
+	ops := []Operation{{Op: "test", Path: "/foo", Value: json.RawMessage(`"baz"`)}}
+
+	_, err := Apply([]byte(`{"foo":"bar"}`), ops)
+
+	var te *TestError
+
+	assert.True(t, errors.As(err, &te))
+	assert.Equal(t, "/foo", te.Path)
+}
@@ -0,0 +1,117 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+
+	"github.com/PacoDw/maze_challenge/repository"
+)
+
+// maxPNGSide is the biggest side of the PNG in pixels, the cells are shrunk to fit it.
+const maxPNGSide = 4000
+
+// pngCellSize returns the size in pixels of the cells of the grid.
+func pngCellSize(g *repository.Grid) int {
+	side := g.Width
+	if g.Height > side {
+		side = g.Height
+	}
+
+	size := maxPNGSide / int(side)
+
+	switch {
+	case size > 24:
+		return 24
+	case size < 3:
+		return 3
+	default:
+		return size
+	}
+}
+
+// fill paints the rectangle of the image.
+func fill(img *image.RGBA, r image.Rectangle, c color.Color) {
+	draw.Draw(img, r, &image.Uniform{C: c}, image.Point{}, draw.Src)
+}
+
+// renderPNG draws each cell tinted by its quadrant, the walls as lines, the path as a
+// line between the centers of its cells and the spots as squares with the color of their
+// kind.
+func renderPNG(w io.Writer, s *Scene) error {
+	var (
+		g    = s.Grid
+		size = pngCellSize(g)
+		img  = image.NewRGBA(image.Rect(0, 0, int(g.Width)*size+1, int(g.Height)*size+1))
+		ink  = color.RGBA{R: 0x21, G: 0x21, B: 0x21, A: 0xff}
+	)
+
+	fill(img, img.Bounds(), color.White)
+
+	cell := func(c repository.Coordinate) image.Rectangle {
+		return image.Rect(int(c.X)*size, int(c.Y)*size, int(c.X+1)*size, int(c.Y+1)*size)
+	}
+
+	for y := uint(0); y < g.Height; y++ {
+		for x := uint(0); x < g.Width; x++ {
+			c := repository.Coordinate{X: x, Y: y}
+
+			if i := s.quadrantAt(c); i >= 0 {
+				fill(img, cell(c), quadrantColors[s.Quadrants[i].Type])
+			}
+		}
+	}
+
+	if len(s.Path) > 0 {
+		thickness := size/4 + 1
+		center := func(c repository.Coordinate) image.Point {
+			return image.Pt(int(c.X)*size+size/2, int(c.Y)*size+size/2)
+		}
+
+		for i := range s.Path {
+			a, b := center(s.Path[i]), center(s.Path[i])
+			if i > 0 {
+				a = center(s.Path[i-1])
+			}
+
+			// the steps are horizontal or vertical so the segment is a rectangle
+			r := image.Rectangle{Min: a, Max: b}.Canon()
+			r.Min = r.Min.Sub(image.Pt(thickness/2, thickness/2))
+			r.Max = r.Max.Add(image.Pt(thickness-thickness/2, thickness-thickness/2))
+
+			fill(img, r, pathColor)
+		}
+	}
+
+	for _, spot := range s.Spots {
+		if spot.Coordinate == nil {
+			continue
+		}
+
+		fill(img, cell(*spot.Coordinate).Inset(size/5), colors[spot.Kind])
+	}
+
+	for y := uint(0); y < g.Height; y++ {
+		for x := uint(0); x < g.Width; x++ {
+			c := repository.Coordinate{X: x, Y: y}
+			r := cell(c)
+
+			if g.HasWall(c, repository.North) {
+				fill(img, image.Rect(r.Min.X, r.Min.Y, r.Max.X+1, r.Min.Y+1), ink)
+			}
+
+			if g.HasWall(c, repository.West) {
+				fill(img, image.Rect(r.Min.X, r.Min.Y, r.Min.X+1, r.Max.Y+1), ink)
+			}
+		}
+	}
+
+	// the east and south borders
+	b := img.Bounds()
+	fill(img, image.Rect(b.Max.X-1, 0, b.Max.X, b.Max.Y), ink)
+	fill(img, image.Rect(0, b.Max.Y-1, b.Max.X, b.Max.Y), ink)
+
+	return png.Encode(w, img)
+}
@@ -0,0 +1,129 @@
+// Package render draws a maze with its walls, the boundaries of its quadrants, its spots
+// and optionally a path as plain text, SVG or PNG, only using the standard library.
+package render
+
+import (
+	"fmt"
+	"image/color"
+	"io"
+
+	"github.com/PacoDw/maze_challenge/repository"
+)
+
+// Format defines the output of the renderer.
+type Format string
+
+const (
+	// Text draws the maze with ASCII characters followed by the legend of the spots.
+	Text Format = "txt"
+
+	// SVG draws the maze as a vector image with the labels of the spots.
+	SVG Format = "svg"
+
+	// PNG draws the maze as a raster image, the spots are told apart by the color of their
+	// kind since the standard library can't draw text.
+	PNG Format = "png"
+)
+
+// ContentType returns the media type of the format.
+func (f Format) ContentType() string {
+	switch f {
+	case SVG:
+		return "image/svg+xml"
+	case PNG:
+		return "image/png"
+	default:
+		return "text/plain; charset=utf-8"
+	}
+}
+
+// Scene represents what is drawn, the path is optional.
+type Scene struct {
+	Grid      *repository.Grid
+	Quadrants []repository.Quadrant
+	Spots     []repository.Spot
+	Path      []repository.Coordinate
+}
+
+// Render writes the scene in the format.
+func Render(w io.Writer, f Format, s *Scene) error {
+	switch f {
+	case Text, "":
+		return renderText(w, s)
+	case SVG:
+		return renderSVG(w, s)
+	case PNG:
+		return renderPNG(w, s)
+	default:
+		return repository.BadRequest("unknown format %q, it must be %s, %s or %s", f, Text, SVG, PNG)
+	}
+}
+
+// symbols contains the character used for each spot kind in the text output.
+var symbols = map[repository.SpotKind]byte{
+	repository.Entrance: 'E',
+	repository.Exit:     'X',
+	repository.Treasure: '$',
+	repository.Trap:     '^',
+	repository.Wall:     '#',
+}
+
+// colors contains the color used for each spot kind in the images.
+var colors = map[repository.SpotKind]color.RGBA{
+	repository.Entrance: {R: 0x2e, G: 0x7d, B: 0x32, A: 0xff},
+	repository.Exit:     {R: 0xc6, G: 0x28, B: 0x28, A: 0xff},
+	repository.Treasure: {R: 0xf9, G: 0xa8, B: 0x25, A: 0xff},
+	repository.Trap:     {R: 0x6a, G: 0x1b, B: 0x9a, A: 0xff},
+	repository.Wall:     {R: 0x42, G: 0x42, B: 0x42, A: 0xff},
+}
+
+// quadrantColors contains the tint of each quadrant in the images.
+var quadrantColors = map[repository.QuadrantType]color.RGBA{
+	repository.TopLeft:     {R: 0xe3, G: 0xf2, B: 0xfd, A: 0xff},
+	repository.TopRight:    {R: 0xe8, G: 0xf5, B: 0xe9, A: 0xff},
+	repository.BottomLeft:  {R: 0xff, G: 0xf3, B: 0xe0, A: 0xff},
+	repository.BottomRight: {R: 0xf3, G: 0xe5, B: 0xf5, A: 0xff},
+}
+
+// pathColor is the color of the path in the images.
+var pathColor = color.RGBA{R: 0x15, G: 0x65, B: 0xc0, A: 0xff}
+
+// hex returns the color in the #rrggbb notation.
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// quadrantAt returns the index of the quadrant that contains the cell, or -1.
+func (s *Scene) quadrantAt(c repository.Coordinate) int {
+	for i := range s.Quadrants {
+		if s.Quadrants[i].Contains(c) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// spotsAt returns the spots by their cell.
+func (s *Scene) spotsAt() map[repository.Coordinate]*repository.Spot {
+	spots := make(map[repository.Coordinate]*repository.Spot)
+
+	for i := range s.Spots {
+		if s.Spots[i].Coordinate != nil {
+			spots[*s.Spots[i].Coordinate] = &s.Spots[i]
+		}
+	}
+
+	return spots
+}
+
+// onPath returns the cells of the path.
+func (s *Scene) onPath() map[repository.Coordinate]bool {
+	path := make(map[repository.Coordinate]bool, len(s.Path))
+
+	for _, c := range s.Path {
+		path[c] = true
+	}
+
+	return path
+}
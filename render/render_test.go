@@ -0,0 +1,123 @@
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image/png"
+	"testing"
+
+	"github.com/PacoDw/maze_challenge/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestScene creates a 4x2 maze with a wall between the columns 1 and 2 of the first
+// row, two quadrants and a path along the second row.
+func newTestScene(t *testing.T) *Scene {
+	g := repository.NewGrid("", 4, 2, false)
+
+	if err := g.SetWall(repository.Coordinate{X: 1, Y: 0}, repository.East, true); err != nil {
+		t.Fatal(err)
+	}
+
+	return &Scene{
+		Grid: g,
+		Quadrants: []repository.Quadrant{
+			{ID: "left", Type: repository.TopLeft, StartPoint: &repository.Coordinate{X: 0, Y: 0}, LimitPoint: &repository.Coordinate{X: 2, Y: 2}},
+			{ID: "right", Type: repository.TopRight, StartPoint: &repository.Coordinate{X: 2, Y: 0}, LimitPoint: &repository.Coordinate{X: 4, Y: 2}},
+		},
+		Spots: []repository.Spot{
+			{Name: "in", Kind: repository.Entrance, Coordinate: &repository.Coordinate{X: 0, Y: 1}},
+			{Name: "out", Kind: repository.Exit, Coordinate: &repository.Coordinate{X: 3, Y: 1}},
+			{Name: "<gold>", Kind: repository.Treasure, GoldAmount: 70, Coordinate: &repository.Coordinate{X: 2, Y: 0}},
+		},
+		Path: []repository.Coordinate{{X: 0, Y: 1}, {X: 1, Y: 1}, {X: 2, Y: 1}, {X: 3, Y: 1}},
+	}
+}
+
+func TestRender_text(t *testing.T) {
+	var buf bytes.Buffer
+
+	if err := Render(&buf, Text, newTestScene(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, ""+
+		"+---+---+---+---+\n"+
+		"|       | $     |\n"+
+		"+   +   +   +   +\n"+
+		"| E   . : .   X |\n"+
+		"+---+---+---+---+\n"+
+		"\n"+
+		"Quadrants:\n"+
+		"  TOP_LEFT     0,0 - 2,2\n"+
+		"  TOP_RIGHT    2,0 - 4,2\n"+
+		"\n"+
+		"Spots:\n"+
+		"  E ENTRANCE 0,1 gold 0 in\n"+
+		"  X EXIT     3,1 gold 0 out\n"+
+		"  $ TREASURE 2,0 gold 70 <gold>\n"+
+		"\n"+
+		"Path: 3 steps\n", buf.String())
+}
+
+func TestRender_quadrantBoundaries(t *testing.T) {
+	var buf bytes.Buffer
+
+	s := newTestScene(t)
+	s.Grid = repository.NewGrid("", 4, 2, false)
+	s.Spots, s.Path = nil, nil
+
+	if err := Render(&buf, Text, s); err != nil {
+		t.Fatal(err)
+	}
+
+	// the quadrants without wall between them are split with :
+	assert.Contains(t, buf.String(), "|       :       |\n")
+}
+
+func TestRender_images(t *testing.T) {
+	s := newTestScene(t)
+
+	var buf bytes.Buffer
+
+	if err := Render(&buf, SVG, s); err != nil {
+		t.Fatal(err)
+	}
+
+	// the output is well formed and the labels are escaped
+	dec := xml.NewDecoder(bytes.NewReader(buf.Bytes()))
+
+	for {
+		if _, err := dec.Token(); err != nil {
+			assert.Equal(t, "EOF", err.Error())
+
+			break
+		}
+	}
+
+	assert.Contains(t, buf.String(), "&lt;gold&gt; TREASURE gold 70")
+	assert.Contains(t, buf.String(), "<polyline")
+
+	buf.Reset()
+
+	if err := Render(&buf, PNG, s); err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 4*24+1, img.Bounds().Dx())
+	assert.Equal(t, 2*24+1, img.Bounds().Dy())
+
+	// the center of the exit has the color of its kind
+	r, g, b, _ := img.At(3*24+12, 1*24+12).RGBA()
+	want := colors[repository.Exit]
+
+	assert.Equal(t, [3]uint32{uint32(want.R) * 0x101, uint32(want.G) * 0x101, uint32(want.B) * 0x101}, [3]uint32{r, g, b})
+
+	assert.Error(t, Render(&buf, "gif", s))
+	assert.Equal(t, "image/svg+xml", SVG.ContentType())
+}
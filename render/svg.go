@@ -0,0 +1,99 @@
+package render
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/PacoDw/maze_challenge/repository"
+)
+
+// svgCell is the size of a cell in the SVG output, the viewBox lets the viewers scale it.
+const svgCell = 24
+
+// escape returns the text escaped to be used in the SVG.
+func escape(s string) string {
+	var sb strings.Builder
+
+	_ = xml.EscapeText(&sb, []byte(s))
+
+	return sb.String()
+}
+
+// renderSVG draws the quadrants as tinted dashed rectangles, the walls as lines, the path
+// as a polyline and the spots as circles with a label and a tooltip.
+func renderSVG(w io.Writer, s *Scene) error {
+	var (
+		bw     = bufio.NewWriter(w)
+		g      = s.Grid
+		width  = g.Width * svgCell
+		height = g.Height * svgCell
+	)
+
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="-2 -2 %d %d" font-family="sans-serif">`+"\n",
+		width+4, height+4, width+4, height+4)
+	fmt.Fprintf(bw, `<rect x="0" y="0" width="%d" height="%d" fill="#ffffff"/>`+"\n", width, height)
+
+	for _, q := range s.Quadrants {
+		if q.StartPoint == nil || q.LimitPoint == nil {
+			continue
+		}
+
+		fmt.Fprintf(bw, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s" stroke="#9e9e9e" stroke-dasharray="4 3"><title>%s</title></rect>`+"\n",
+			q.StartPoint.X*svgCell, q.StartPoint.Y*svgCell,
+			(q.LimitPoint.X-q.StartPoint.X)*svgCell, (q.LimitPoint.Y-q.StartPoint.Y)*svgCell,
+			hex(quadrantColors[q.Type]), escape(string(q.Type)))
+	}
+
+	bw.WriteString(`<g stroke="#212121" stroke-width="2" stroke-linecap="square">` + "\n")
+
+	for y := uint(0); y < g.Height; y++ {
+		for x := uint(0); x < g.Width; x++ {
+			c := repository.Coordinate{X: x, Y: y}
+			px, py := x*svgCell, y*svgCell
+
+			if g.HasWall(c, repository.North) {
+				fmt.Fprintf(bw, `<line x1="%d" y1="%d" x2="%d" y2="%d"/>`+"\n", px, py, px+svgCell, py)
+			}
+
+			if g.HasWall(c, repository.West) {
+				fmt.Fprintf(bw, `<line x1="%d" y1="%d" x2="%d" y2="%d"/>`+"\n", px, py, px, py+svgCell)
+			}
+		}
+	}
+
+	// the east and south borders
+	fmt.Fprintf(bw, `<line x1="%d" y1="0" x2="%d" y2="%d"/>`+"\n", width, width, height)
+	fmt.Fprintf(bw, `<line x1="0" y1="%d" x2="%d" y2="%d"/>`+"\n", height, width, height)
+	bw.WriteString("</g>\n")
+
+	if len(s.Path) > 0 {
+		points := make([]string, 0, len(s.Path))
+
+		for _, c := range s.Path {
+			points = append(points, fmt.Sprintf("%d,%d", c.X*svgCell+svgCell/2, c.Y*svgCell+svgCell/2))
+		}
+
+		fmt.Fprintf(bw, `<polyline points="%s" fill="none" stroke="%s" stroke-width="3" stroke-linejoin="round" opacity="0.8"/>`+"\n",
+			strings.Join(points, " "), hex(pathColor))
+	}
+
+	for _, spot := range s.Spots {
+		if spot.Coordinate == nil {
+			continue
+		}
+
+		cx, cy := spot.Coordinate.X*svgCell+svgCell/2, spot.Coordinate.Y*svgCell+svgCell/2
+		label := escape(fmt.Sprintf("%s %s gold %d", spot.Name, spot.Kind, spot.GoldAmount))
+
+		fmt.Fprintf(bw, `<g><title>%s</title><circle cx="%d" cy="%d" r="%d" fill="%s"/>`, label, cx, cy, svgCell/3, hex(colors[spot.Kind]))
+		fmt.Fprintf(bw, `<text x="%d" y="%d" font-size="7" text-anchor="middle">%s</text></g>`+"\n",
+			cx, cy+svgCell/2-1, escape(fmt.Sprintf("%s (%d)", spot.Name, spot.GoldAmount)))
+	}
+
+	bw.WriteString("</svg>\n")
+
+	return bw.Flush()
+}
@@ -0,0 +1,104 @@
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/PacoDw/maze_challenge/repository"
+)
+
+// renderText draws each cell with three characters, the walls with - and |, the
+// boundaries of the quadrants without wall with ~ and :, the path with dots and the
+// spots with the symbol of their kind, then it lists the quadrants and the spots.
+func renderText(w io.Writer, s *Scene) error {
+	var (
+		bw    = bufio.NewWriter(w)
+		g     = s.Grid
+		spots = s.spotsAt()
+		path  = s.onPath()
+	)
+
+	for y := uint(0); y <= g.Height; y++ {
+		// the line above the row, the last one is the bottom border
+		for x := uint(0); x < g.Width; x++ {
+			c := repository.Coordinate{X: x, Y: y}
+			above := repository.Coordinate{X: x, Y: y - 1}
+
+			switch {
+			case y == g.Height || g.HasWall(c, repository.North):
+				bw.WriteString("+---")
+			case s.quadrantAt(c) != s.quadrantAt(above):
+				bw.WriteString("+~~~")
+			default:
+				bw.WriteString("+   ")
+			}
+		}
+
+		bw.WriteString("+\n")
+
+		if y == g.Height {
+			break
+		}
+
+		for x := uint(0); x < g.Width; x++ {
+			c := repository.Coordinate{X: x, Y: y}
+
+			switch {
+			case g.HasWall(c, repository.West):
+				bw.WriteByte('|')
+			case s.quadrantAt(c) != s.quadrantAt(repository.Coordinate{X: x - 1, Y: y}):
+				bw.WriteByte(':')
+			default:
+				bw.WriteByte(' ')
+			}
+
+			content := byte(' ')
+
+			if path[c] {
+				content = '.'
+			}
+
+			if spot, ok := spots[c]; ok {
+				content = symbols[spot.Kind]
+			}
+
+			bw.WriteByte(' ')
+			bw.WriteByte(content)
+			bw.WriteByte(' ')
+		}
+
+		bw.WriteString("|\n")
+	}
+
+	if len(s.Quadrants) > 0 {
+		bw.WriteString("\nQuadrants:\n")
+	}
+
+	for _, q := range s.Quadrants {
+		if q.StartPoint == nil || q.LimitPoint == nil {
+			continue
+		}
+
+		fmt.Fprintf(bw, "  %-12s %d,%d - %d,%d\n", q.Type, q.StartPoint.X, q.StartPoint.Y, q.LimitPoint.X, q.LimitPoint.Y)
+	}
+
+	if len(s.Spots) > 0 {
+		bw.WriteString("\nSpots:\n")
+	}
+
+	for _, spot := range s.Spots {
+		if spot.Coordinate == nil {
+			continue
+		}
+
+		fmt.Fprintf(bw, "  %c %-8s %d,%d gold %d %s\n",
+			symbols[spot.Kind], spot.Kind, spot.Coordinate.X, spot.Coordinate.Y, spot.GoldAmount, spot.Name)
+	}
+
+	if len(s.Path) > 0 {
+		fmt.Fprintf(bw, "\nPath: %d steps\n", len(s.Path)-1)
+	}
+
+	return bw.Flush()
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// boltOpenTimeout is the time to wait for the lock of the file, only one process can
+// open the same file at the same time.
+const boltOpenTimeout = time.Second
+
+// NewBolt creates a new Repository that stores all the data in an embedded key/value
+// file (BoltDB), it is meant for single-node deployments where MongoDB can't be run.
+// The file is created when it doesn't exist and it must be closed with Close.
+func NewBolt(path string) (*Repository, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: boltOpenTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("opening the bolt file %s: %w", path, err)
+	}
+
+	return newKV(&boltEngine{db: db}), nil
+}
+
+// boltEngine represents a kvEngine that saves each collection in a bucket.
+type boltEngine struct {
+	db *bolt.DB
+}
+
+var _ kvEngine = &boltEngine{}
+
+func (be *boltEngine) begin(writable bool) (kvTx, error) {
+	tx, err := be.db.Begin(writable)
+	if err != nil {
+		return nil, err
+	}
+
+	return &boltTx{tx: tx}, nil
+}
+
+func (be *boltEngine) ping(ctx context.Context) error {
+	return be.db.View(func(tx *bolt.Tx) error {
+		return nil
+	})
+}
+
+func (be *boltEngine) close(ctx context.Context) error {
+	return be.db.Close()
+}
+
+// boltTx represents a bolt transaction.
+type boltTx struct {
+	tx *bolt.Tx
+}
+
+func (bt *boltTx) get(collection, id string) ([]byte, error) {
+	b := bt.tx.Bucket([]byte(collection))
+	if b == nil {
+		return nil, nil
+	}
+
+	raw := b.Get([]byte(id))
+	if raw == nil {
+		return nil, nil
+	}
+
+	// the values returned by bolt are only valid while the transaction is open
+	return append([]byte{}, raw...), nil
+}
+
+func (bt *boltTx) put(collection, id string, raw []byte) error {
+	b, err := bt.tx.CreateBucketIfNotExists([]byte(collection))
+	if err != nil {
+		return err
+	}
+
+	return b.Put([]byte(id), raw)
+}
+
+func (bt *boltTx) delete(collection, id string) error {
+	b := bt.tx.Bucket([]byte(collection))
+	if b == nil {
+		return nil
+	}
+
+	return b.Delete([]byte(id))
+}
+
+func (bt *boltTx) forEach(collection string, fn func(id string, raw []byte) error) error {
+	b := bt.tx.Bucket([]byte(collection))
+	if b == nil {
+		return nil
+	}
+
+	// the keys are read before calling fn since a bucket must not be modified while it
+	// is iterated
+	ids := make([]string, 0)
+
+	err := b.ForEach(func(k, v []byte) error {
+		ids = append(ids, string(k))
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		raw, err := bt.get(collection, id)
+		if err != nil {
+			return err
+		}
+
+		if raw == nil {
+			// it was deleted by fn in a previous iteration
+			continue
+		}
+
+		if err := fn(id, raw); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (bt *boltTx) writable() bool {
+	return bt.tx.Writable()
+}
+
+func (bt *boltTx) commit() error {
+	return bt.tx.Commit()
+}
+
+func (bt *boltTx) rollback() error {
+	return bt.tx.Rollback()
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBolt_dataIsKeptAfterReopening(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		path = filepath.Join(t.TempDir(), "maze.db")
+	)
+
+	conn, err := NewBolt(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	qID, err := conn.Quadrant.Create(ctx, &Quadrant{
+		Type:       BottomLeft,
+		StartPoint: &Coordinate{X: 0, Y: 25},
+		LimitPoint: &Coordinate{X: 25, Y: 50},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sID, err := conn.Spot.Create(ctx, &Spot{
+		Name:       "exit",
+		GoldAmount: 4000,
+		Coordinate: &Coordinate{X: 9, Y: 30},
+		QuadrantID: qID,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := conn.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err = NewBolt(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		assert.NoError(t, conn.Close(ctx))
+	}()
+
+	assert.NoError(t, conn.Ping(ctx))
+
+	q, err := conn.Quadrant.Get(ctx, &QuadrantFilter{ID: qID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []string{sID}, q.SpotIDs)
+	assert.Len(t, q.Spots, 1)
+	assert.Equal(t, "exit", q.Spots[0].Name)
+}
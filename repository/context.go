@@ -9,6 +9,13 @@ import (
 var (
 	// ContextDBName represent the value that contains the current context.
 	ContextDBName = contextKey("DB_NAME")
+
+	// ContextRepoConn represent the key of the repository set in the current gin context.
+	ContextRepoConn = contextKey("REPO_CONN")
+
+	// contextMazeDeletion marks the context of the deletion of a maze, the only write that
+	// can delete its quadrants.
+	contextMazeDeletion = contextKey("MAZE_DELETION")
 )
 
 type contextKey string
@@ -18,6 +25,18 @@ func DBNameSet(ctx context.Context, dbName string) context.Context {
 	return context.WithValue(ctx, string(ContextDBName), dbName)
 }
 
+// withMazeDeletion returns a context that allows deleting the quadrants of a maze.
+func withMazeDeletion(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextMazeDeletion, true)
+}
+
+// isMazeDeletion reports if the context belongs to the deletion of a maze.
+func isMazeDeletion(ctx context.Context) bool {
+	deleting, _ := ctx.Value(contextMazeDeletion).(bool)
+
+	return deleting
+}
+
 // DBName retrieves the db name that exists in current context.
 func DBName(ctx context.Context) string {
 	return cast.ToString(ctx.Value(string(ContextDBName)))
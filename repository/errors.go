@@ -0,0 +1,348 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// FieldError represents an attribute that doesn't satisfy an invariant of its entity, the
+// field is the name used in the JSON representation.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError represents the attributes of an entity that don't satisfy its
+// invariants, so the clients can know which of them must be fixed.
+type ValidationError struct {
+	Entity string       `json:"entity"`
+	Fields []FieldError `json:"fields"`
+}
+
+// Error implements the error interface.
+func (ve *ValidationError) Error() string {
+	msgs := make([]string, 0, len(ve.Fields))
+
+	for i := range ve.Fields {
+		msgs = append(msgs, fmt.Sprintf("%s %s", ve.Fields[i].Field, ve.Fields[i].Message))
+	}
+
+	return fmt.Sprintf("invalid %s: %s", ve.Entity, strings.Join(msgs, "; "))
+}
+
+// add appends a new field error.
+func (ve *ValidationError) add(field, format string, args ...interface{}) {
+	ve.Fields = append(ve.Fields, FieldError{
+		Field:   field,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// err returns the validation error when at least one field error was added, otherwise
+// it returns nil.
+func (ve *ValidationError) err() error {
+	if len(ve.Fields) == 0 {
+		return nil
+	}
+
+	return ve
+}
+
+// RequestError represents a request that can't be processed as it was sent, e.g. a body
+// that can't be decoded or a query param that isn't a number.
+type RequestError struct {
+	Err error
+}
+
+// Error implements the error interface.
+func (re *RequestError) Error() string {
+	return re.Err.Error()
+}
+
+// Unwrap returns the cause of the error.
+func (re *RequestError) Unwrap() error {
+	return re.Err
+}
+
+// BadRequest returns a RequestError with the message formatted like fmt.Errorf does, so
+// the cause can be wrapped with %w.
+func BadRequest(format string, args ...interface{}) error {
+	return &RequestError{Err: fmt.Errorf(format, args...)}
+}
+
+// invalidField returns a ValidationError with a single attribute of the entity.
+func invalidField(entity, field, format string, args ...interface{}) error {
+	ve := &ValidationError{Entity: entity}
+	ve.add(field, format, args...)
+
+	return ve
+}
+
+// ErrNoConnection is returned when the repository has no connection with the database.
+var ErrNoConnection = errors.New("no connection with database")
+
+// ErrorCode identifies the kind of an error, it is sent to the clients with the message.
+type ErrorCode string
+
+const (
+	// CodeBadRequest represents a request that can't be processed, e.g. a malformed body.
+	CodeBadRequest = ErrorCode("BAD_REQUEST")
+
+	// CodeValidation represents the attributes of an entity that must be fixed.
+	CodeValidation = ErrorCode("VALIDATION_FAILED")
+
+	// CodeNotFound represents an entity that doesn't exist.
+	CodeNotFound = ErrorCode("NOT_FOUND")
+
+	// CodeConflict represents a write that clashes with the stored entities.
+	CodeConflict = ErrorCode("CONFLICT")
+
+	// CodePreconditionFailed represents a write conditioned by the client, e.g. with the
+	// If-Match header, on a version that isn't the stored one.
+	CodePreconditionFailed = ErrorCode("PRECONDITION_FAILED")
+
+	// CodeIdempotencyKeyReused represents an idempotency key sent again with another
+	// request, e.g. with a different body.
+	CodeIdempotencyKeyReused = ErrorCode("IDEMPOTENCY_KEY_REUSED")
+
+	// CodeUnavailable represents a database that can't be reached for now.
+	CodeUnavailable = ErrorCode("UNAVAILABLE")
+
+	// CodeInternal represents a failure of the server.
+	CodeInternal = ErrorCode("INTERNAL")
+)
+
+// NotFoundError represents an entity that doesn't exist, it matches mongo.ErrNoDocuments
+// as well so all the backends keep the same semantics.
+type NotFoundError struct {
+	Entity string
+	ID     string
+}
+
+// Error implements the error interface.
+func (nf *NotFoundError) Error() string {
+	if nf.ID == "" {
+		return fmt.Sprintf("%s not found", nf.Entity)
+	}
+
+	return fmt.Sprintf("%s %s not found", nf.Entity, nf.ID)
+}
+
+// Is reports if the target is mongo.ErrNoDocuments.
+func (nf *NotFoundError) Is(target error) bool {
+	return target == mongo.ErrNoDocuments
+}
+
+// notFound returns a NotFoundError when err means that the document doesn't exist,
+// otherwise it returns err.
+func notFound(entity, id string, err error) error {
+	var nf *NotFoundError
+
+	if errors.Is(err, mongo.ErrNoDocuments) && !errors.As(err, &nf) {
+		return &NotFoundError{Entity: entity, ID: id}
+	}
+
+	return err
+}
+
+// ConflictError represents a write that clashes with the stored entities, e.g. a spot
+// placed in the coordinate of another one.
+type ConflictError struct {
+	Entity string       `json:"entity"`
+	Fields []FieldError `json:"fields"`
+}
+
+// Error implements the error interface.
+func (ce *ConflictError) Error() string {
+	msgs := make([]string, 0, len(ce.Fields))
+
+	for i := range ce.Fields {
+		msgs = append(msgs, fmt.Sprintf("%s %s", ce.Fields[i].Field, ce.Fields[i].Message))
+	}
+
+	return fmt.Sprintf("conflicting %s: %s", ce.Entity, strings.Join(msgs, "; "))
+}
+
+// add appends a new field error.
+func (ce *ConflictError) add(field, format string, args ...interface{}) {
+	ce.Fields = append(ce.Fields, FieldError{
+		Field:   field,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// err returns the conflict error when at least one field error was added, otherwise it
+// returns nil.
+func (ce *ConflictError) err() error {
+	if len(ce.Fields) == 0 {
+		return nil
+	}
+
+	return ce
+}
+
+// VersionError is returned by the conditional writes when the version of the entity isn't
+// the expected one, it was changed by another write after the client read it. Version is
+// zero when the stored version isn't known.
+type VersionError struct {
+	Entity   string `json:"entity"`
+	ID       string `json:"id"`
+	Version  uint64 `json:"version,omitempty"`
+	Expected uint64 `json:"expected"`
+}
+
+// Error implements the error interface.
+func (ve *VersionError) Error() string {
+	if ve.Version == 0 {
+		return fmt.Sprintf("the %s %s was changed by another write, the version %d was expected",
+			ve.Entity, ve.ID, ve.Expected)
+	}
+
+	return fmt.Sprintf("the %s %s is at version %d but the version %d was expected",
+		ve.Entity, ve.ID, ve.Version, ve.Expected)
+}
+
+// checkVersion returns a VersionError when the expected version isn't the stored one, a
+// zero expected version means that the write isn't conditional.
+func checkVersion(entity, id string, version, expected uint64) error {
+	if expected == 0 || expected == version {
+		return nil
+	}
+
+	return &VersionError{Entity: entity, ID: id, Version: version, Expected: expected}
+}
+
+// versionFilter matches the documents at the version in a MongoDB filter, the ones stored
+// before the versions existed don't have it and they are at version zero.
+func versionFilter(version uint64) interface{} {
+	if version == 0 {
+		return bson.M{"$in": bson.A{0, nil}}
+	}
+
+	return version
+}
+
+// IdempotencyError is returned when an idempotency key can't be used by a request, either
+// it was sent before with another request or the first request sent with it is still
+// being handled.
+type IdempotencyError struct {
+	Key        string `json:"key"`
+	InProgress bool   `json:"in_progress"`
+}
+
+// Error implements the error interface.
+func (ie *IdempotencyError) Error() string {
+	if ie.InProgress {
+		return fmt.Sprintf("the request with the idempotency key %s is still being handled, retry it later", ie.Key)
+	}
+
+	return fmt.Sprintf("the idempotency key %s was already used by a different request", ie.Key)
+}
+
+// UnavailableError represents a database that can't be reached or didn't answer in time,
+// the request can be retried later.
+type UnavailableError struct {
+	Err error
+}
+
+// Error implements the error interface.
+func (ue *UnavailableError) Error() string {
+	return fmt.Sprintf("the database is unavailable: %s", ue.Err)
+}
+
+// Unwrap returns the error of the database.
+func (ue *UnavailableError) Unwrap() error {
+	return ue.Err
+}
+
+// duplicateKeyCode is the code of the MongoDB errors raised by the unique indexes.
+const duplicateKeyCode = 11000
+
+// isDuplicateKey reports if the error was raised by a unique index of MongoDB.
+func isDuplicateKey(err error) bool {
+	var (
+		we mongo.WriteException
+		ce mongo.CommandError
+	)
+
+	switch {
+	case errors.As(err, &we):
+		for i := range we.WriteErrors {
+			if we.WriteErrors[i].Code == duplicateKeyCode {
+				return true
+			}
+		}
+
+		return false
+	case errors.As(err, &ce):
+		return ce.Code == duplicateKeyCode
+	default:
+		return false
+	}
+}
+
+// isUnavailable reports if the error means that the database can't be reached or didn't
+// answer in time, the driver doesn't wrap the errors of the server selection so they are
+// recognized by their message, even when the stores wrap them.
+func isUnavailable(err error) bool {
+	var (
+		ce mongo.CommandError
+		ne net.Error
+	)
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, mongo.ErrClientDisconnected),
+		strings.Contains(err.Error(), "server selection error"):
+		return true
+	case errors.As(err, &ce):
+		return ce.HasErrorLabel("NetworkError") || ce.IsMaxTimeMSExpiredError()
+	case errors.As(err, &ne):
+		return ne.Timeout()
+	default:
+		return false
+	}
+}
+
+// Code returns the code of the error. Besides the errors of this package, the errors of
+// the database are recognized: the missing documents are NotFound, the duplicated keys
+// Conflict and the timeouts and network failures Unavailable. The rest of the errors are
+// failures of the server, the ones caused by the request must be typed, e.g. with
+// BadRequest.
+func Code(err error) ErrorCode {
+	var (
+		ve *ValidationError
+		ce *ConflictError
+		vr *VersionError
+		ie *IdempotencyError
+		ue *UnavailableError
+		re *RequestError
+	)
+
+	switch {
+	case errors.As(err, &ve):
+		return CodeValidation
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return CodeNotFound
+	case errors.As(err, &ie):
+		if ie.InProgress {
+			return CodeConflict
+		}
+
+		return CodeIdempotencyKeyReused
+	case errors.As(err, &ce), errors.As(err, &vr), isDuplicateKey(err):
+		return CodeConflict
+	case errors.As(err, &ue), isUnavailable(err):
+		return CodeUnavailable
+	case errors.As(err, &re):
+		return CodeBadRequest
+	default:
+		return CodeInternal
+	}
+}
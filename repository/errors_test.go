@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		code ErrorCode
+	}{
+		{err: &ValidationError{Entity: "spot"}, code: CodeValidation},
+		{err: &NotFoundError{Entity: "spot", ID: "1"}, code: CodeNotFound},
+		{err: fmt.Errorf("reading: %w", mongo.ErrNoDocuments), code: CodeNotFound},
+		{err: &ConflictError{Entity: "spot"}, code: CodeConflict},
+		{err: mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: duplicateKeyCode}}}, code: CodeConflict},
+		{err: &IdempotencyError{Key: "retry", InProgress: true}, code: CodeConflict},
+		{err: &IdempotencyError{Key: "retry"}, code: CodeIdempotencyKeyReused},
+		{err: &UnavailableError{Err: errors.New("connection refused")}, code: CodeUnavailable},
+		{err: context.DeadlineExceeded, code: CodeUnavailable},
+		{err: errors.New("server selection error: server selection timeout"), code: CodeUnavailable},
+		{err: fmt.Errorf("finding all spots: %w", errors.New("server selection error: server selection timeout")), code: CodeUnavailable},
+		{err: fmt.Errorf("can't reach the maze: %w", &NotFoundError{Entity: "maze", ID: "1"}), code: CodeNotFound},
+		{err: ErrNoConnection, code: CodeInternal},
+		{err: BadRequest("query param limit must be a number"), code: CodeBadRequest},
+		{err: fmt.Errorf("decoding: %w", BadRequest("the body must be a JSON document")), code: CodeBadRequest},
+		{err: errors.New("can't write in a read-only transaction"), code: CodeInternal},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.code, Code(tt.err), tt.err.Error())
+	}
+}
+
+func TestNotFoundError(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, ctx context.Context, conn *Repository) {
+		missing := "000000000000000000000000"
+
+		var nf *NotFoundError
+
+		_, err := conn.Spot.Get(ctx, &SpotFilter{ID: missing})
+		assert.True(t, errors.As(err, &nf))
+		assert.Equal(t, "spot", nf.Entity)
+		assert.Equal(t, missing, nf.ID)
+		assert.True(t, errors.Is(err, mongo.ErrNoDocuments))
+
+		_, err = conn.Quadrant.Get(ctx, &QuadrantFilter{ID: missing})
+		assert.True(t, errors.As(err, &nf))
+		assert.Equal(t, "quadrant", nf.Entity)
+
+		_, err = conn.Maze.Get(ctx, &MazeFilter{ID: missing})
+		assert.True(t, errors.As(err, &nf))
+		assert.Equal(t, "maze", nf.Entity)
+	})
+}
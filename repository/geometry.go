@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+)
+
+// Contains reports if the coordinate is inside the quadrant, the start point is inclusive
+// and the limit point is exclusive.
+func (q *Quadrant) Contains(c Coordinate) bool {
+	if q.StartPoint == nil || q.LimitPoint == nil {
+		return false
+	}
+
+	return c.X >= q.StartPoint.X && c.X < q.LimitPoint.X &&
+		c.Y >= q.StartPoint.Y && c.Y < q.LimitPoint.Y
+}
+
+// area returns the number of cells covered by the quadrant.
+func (q *Quadrant) area() uint {
+	return (q.LimitPoint.X - q.StartPoint.X) * (q.LimitPoint.Y - q.StartPoint.Y)
+}
+
+// overlaps reports if both quadrants share at least one cell.
+func (q *Quadrant) overlaps(o *Quadrant) bool {
+	return q.StartPoint.X < o.LimitPoint.X && o.StartPoint.X < q.LimitPoint.X &&
+		q.StartPoint.Y < o.LimitPoint.Y && o.StartPoint.Y < q.LimitPoint.Y
+}
+
+// isValidQuadrantType reports if the type is one of the four existing quadrants.
+func isValidQuadrantType(qt QuadrantType) bool {
+	switch qt {
+	case TopLeft, TopRight, BottomLeft, BottomRight:
+		return true
+	default:
+		return false
+	}
+}
+
+// validate checks the geometry of the quadrant, when the maze is given it is also checked
+// against the maze area and the rest of its quadrants:
+//   - the quadrant must be inside the maze.
+//   - it must sit in the corner of the maze given by its type without reaching the
+//     opposite sides, e.g. a TOP_LEFT quadrant starts at 0,0.
+//   - it can't overlap the rest of the quadrants.
+//   - once the four quadrants exist they must cover the whole maze.
+func (q *Quadrant) validate(m *Maze) error {
+	ve := &ValidationError{Entity: "quadrant"}
+
+	if !isValidQuadrantType(q.Type) {
+		ve.add("type", "must be one of %s, %s, %s and %s", TopLeft, TopRight, BottomLeft, BottomRight)
+	}
+
+	if q.StartPoint == nil {
+		ve.add("start_point", "must be specified")
+	}
+
+	if q.LimitPoint == nil {
+		ve.add("limit_point", "must be specified")
+	}
+
+	if len(ve.Fields) > 0 {
+		return ve
+	}
+
+	if q.StartPoint.X >= q.LimitPoint.X || q.StartPoint.Y >= q.LimitPoint.Y {
+		ve.add("limit_point", "must be greater than the start point on both axes")
+
+		return ve
+	}
+
+	if m == nil {
+		return nil
+	}
+
+	if q.LimitPoint.X > m.Width || q.LimitPoint.Y > m.Height {
+		ve.add("limit_point", "must be inside the maze of %dx%d", m.Width, m.Height)
+
+		return ve
+	}
+
+	q.validateCorner(m, ve)
+
+	area := q.area()
+	quadrants := 1
+
+	for i := range m.Quadrants {
+		sibling := &m.Quadrants[i]
+
+		if q.ID != "" && sibling.ID == q.ID {
+			continue
+		}
+
+		if sibling.Type == q.Type {
+			ve.add("type", "the maze already has a %s quadrant", q.Type)
+		}
+
+		if q.overlaps(sibling) {
+			ve.add("start_point", "overlaps the %s quadrant", sibling.Type)
+		}
+
+		area += sibling.area()
+		quadrants++
+	}
+
+	if len(ve.Fields) == 0 && quadrants == 4 && area != m.Width*m.Height {
+		ve.add("limit_point", "the quadrants must cover the whole maze")
+	}
+
+	return ve.err()
+}
+
+// validateCorner checks that the quadrant sits in the corner of the maze given by its type.
+func (q *Quadrant) validateCorner(m *Maze, ve *ValidationError) {
+	var left, top bool
+
+	switch q.Type {
+	case TopLeft:
+		left, top = true, true
+	case TopRight:
+		left, top = false, true
+	case BottomLeft:
+		left, top = true, false
+	case BottomRight:
+		left, top = false, false
+	}
+
+	if left && (q.StartPoint.X != 0 || q.LimitPoint.X == m.Width) {
+		ve.add("start_point", "a %s quadrant must start at x 0 and end before x %d", q.Type, m.Width)
+	}
+
+	if !left && (q.StartPoint.X == 0 || q.LimitPoint.X != m.Width) {
+		ve.add("limit_point", "a %s quadrant must end at x %d and start after x 0", q.Type, m.Width)
+	}
+
+	if top && (q.StartPoint.Y != 0 || q.LimitPoint.Y == m.Height) {
+		ve.add("start_point", "a %s quadrant must start at y 0 and end before y %d", q.Type, m.Height)
+	}
+
+	if !top && (q.StartPoint.Y == 0 || q.LimitPoint.Y != m.Height) {
+		ve.add("limit_point", "a %s quadrant must end at y %d and start after y 0", q.Type, m.Height)
+	}
+}
+
+// validateQuadrant checks the geometry of the quadrant against its maze, the quadrants
+// that don't belong to a maze are only checked by themselves.
+func validateQuadrant(ctx context.Context, repo *Repository, q *Quadrant) error {
+	if q.MazeID == "" {
+		return q.validate(nil)
+	}
+
+	m, err := repo.Maze.Get(ctx, &MazeFilter{ID: q.MazeID})
+	if err != nil {
+		return fmt.Errorf("can't reach the maze: %w", err)
+	}
+
+	return q.validate(m)
+}
+
+// validateSpotsInside checks that the spots of the quadrant are still inside it once its
+// start and limit points have changed.
+func validateSpotsInside(ctx context.Context, repo *Repository, q *Quadrant) error {
+	if len(q.SpotIDs) == 0 {
+		return nil
+	}
+
+	spots, err := repo.Spot.List(ctx, &SpotFilter{SpotsIDs: q.SpotIDs})
+	if err != nil {
+		return err
+	}
+
+	ve := &ValidationError{Entity: "quadrant"}
+
+	for i := range spots {
+		if spots[i].Coordinate != nil && !q.Contains(*spots[i].Coordinate) {
+			ve.add("limit_point", "the spot %s at %d,%d must stay inside the quadrant",
+				spots[i].ID, spots[i].Coordinate.X, spots[i].Coordinate.Y)
+		}
+	}
+
+	return ve.err()
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuadrant_validate(t *testing.T) {
+	maze := &Maze{Name: "labyrinth", Width: 50, Height: 40}
+	maze.Quadrants = maze.NewQuadrants()[1:]
+
+	for i := range maze.Quadrants {
+		maze.Quadrants[i].ID = string(maze.Quadrants[i].Type)
+	}
+
+	tests := []struct {
+		name   string
+		q      Quadrant
+		fields []string
+	}{
+		{
+			name: "valid",
+			q:    Quadrant{Type: TopLeft, StartPoint: &Coordinate{X: 0, Y: 0}, LimitPoint: &Coordinate{X: 25, Y: 20}},
+		},
+		{
+			name:   "unknown type",
+			q:      Quadrant{Type: "CENTER", StartPoint: &Coordinate{X: 0, Y: 0}, LimitPoint: &Coordinate{X: 25, Y: 20}},
+			fields: []string{"type"},
+		},
+		{
+			name:   "missing points",
+			q:      Quadrant{Type: TopLeft},
+			fields: []string{"start_point", "limit_point"},
+		},
+		{
+			name:   "start exceeds limit",
+			q:      Quadrant{Type: TopLeft, StartPoint: &Coordinate{X: 30, Y: 0}, LimitPoint: &Coordinate{X: 25, Y: 20}},
+			fields: []string{"limit_point"},
+		},
+		{
+			name:   "out of the maze",
+			q:      Quadrant{Type: TopLeft, StartPoint: &Coordinate{X: 0, Y: 0}, LimitPoint: &Coordinate{X: 25, Y: 60}},
+			fields: []string{"limit_point"},
+		},
+		{
+			name:   "wrong corner",
+			q:      Quadrant{Type: TopLeft, StartPoint: &Coordinate{X: 25, Y: 20}, LimitPoint: &Coordinate{X: 50, Y: 40}},
+			fields: []string{"start_point", "start_point", "start_point"},
+		},
+		{
+			name:   "overlap",
+			q:      Quadrant{Type: TopLeft, StartPoint: &Coordinate{X: 0, Y: 0}, LimitPoint: &Coordinate{X: 30, Y: 20}},
+			fields: []string{"start_point"},
+		},
+		{
+			name:   "gap",
+			q:      Quadrant{Type: TopLeft, StartPoint: &Coordinate{X: 0, Y: 0}, LimitPoint: &Coordinate{X: 20, Y: 20}},
+			fields: []string{"limit_point"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.q.validate(maze)
+
+			if len(tt.fields) == 0 {
+				assert.NoError(t, err)
+
+				return
+			}
+
+			var ve *ValidationError
+			if !errors.As(err, &ve) {
+				t.Fatalf("expected a validation error, got %v", err)
+			}
+
+			fields := make([]string, 0, len(ve.Fields))
+			for i := range ve.Fields {
+				fields = append(fields, ve.Fields[i].Field)
+			}
+
+			assert.Equal(t, tt.fields, fields)
+		})
+	}
+}
+
+func TestQuadrant_placementInAMaze(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, ctx context.Context, conn *Repository) {
+		maze := &Maze{Name: "labyrinth", Width: 50, Height: 50}
+
+		if _, err := conn.Maze.Create(ctx, maze); err != nil {
+			t.Fatal(err)
+		}
+
+		// the maze already has its four quadrants
+		_, err := conn.Quadrant.Create(ctx, &Quadrant{
+			MazeID:     maze.ID,
+			Type:       TopLeft,
+			StartPoint: &Coordinate{X: 0, Y: 0},
+			LimitPoint: &Coordinate{X: 25, Y: 25},
+		})
+
+		var ve *ValidationError
+		assert.True(t, errors.As(err, &ve))
+
+		// resizing a quadrant leaves a gap or overlaps its siblings
+		_, err = conn.Quadrant.Update(ctx, &Quadrant{
+			ID:         maze.QuadrantIDs[0],
+			LimitPoint: &Coordinate{X: 20, Y: 25},
+		})
+
+		assert.True(t, errors.As(err, &ve))
+
+		q, err := conn.Quadrant.Get(ctx, &QuadrantFilter{ID: maze.QuadrantIDs[0]})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, &Coordinate{X: 25, Y: 25}, q.LimitPoint)
+
+		if _, err := conn.Maze.Delete(ctx, &MazeFilter{ID: maze.ID}); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
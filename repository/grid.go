@@ -0,0 +1,298 @@
+package repository
+
+// Direction defines the four sides of a cell.
+type Direction uint8
+
+const (
+	// North represents the side of a cell that faces the row above it.
+	North Direction = iota
+
+	// East represents the side of a cell that faces the next column.
+	East
+
+	// South represents the side of a cell that faces the row below it.
+	South
+
+	// West represents the side of a cell that faces the previous column.
+	West
+)
+
+// Directions contains the four directions in clockwise order.
+var Directions = []Direction{North, East, South, West}
+
+// Cell represents a cell of the grid with the walls of its four sides.
+type Cell struct {
+	X     uint `json:"x"`
+	Y     uint `json:"y"`
+	North bool `json:"north"`
+	East  bool `json:"east"`
+	South bool `json:"south"`
+	West  bool `json:"west"`
+}
+
+// Region represents a rectangle of cells, the start point is inclusive and the limit
+// point is exclusive like in the quadrants.
+type Region struct {
+	StartPoint Coordinate `json:"start_point"`
+	LimitPoint Coordinate `json:"limit_point"`
+}
+
+// Grid represents the cells of a maze and the walls between them.
+//
+// Two neighbour cells share the wall between them, so each cell only keeps the bits of its
+// north and west walls and the east and south ones are read from its neighbours. The
+// border of the grid is always closed. Each row is stored as a bitset with two bits per
+// cell, so a grid of 1000x1000 cells takes about 250KB.
+type Grid struct {
+	MazeID string   `json:"maze_id" bson:"_id"`
+	Width  uint     `json:"width" bson:"width"`
+	Height uint     `json:"height" bson:"height"`
+	Rows   [][]byte `json:"-" bson:"rows"`
+}
+
+// bits of each cell in a row.
+const (
+	northBit    = 0
+	westBit     = 1
+	bitsPerCell = 2
+)
+
+// NewGrid creates a grid for the maze, when closed is true all the walls exist, which is
+// the starting point of the generators, otherwise there are only the walls of the border.
+func NewGrid(mazeID string, width, height uint, closed bool) *Grid {
+	g := &Grid{
+		MazeID: mazeID,
+		Width:  width,
+		Height: height,
+		Rows:   make([][]byte, height),
+	}
+
+	var fill byte
+
+	if closed {
+		fill = 0xff
+	}
+
+	for y := range g.Rows {
+		g.Rows[y] = make([]byte, (width*bitsPerCell+7)/8)
+
+		for i := range g.Rows[y] {
+			g.Rows[y][i] = fill
+		}
+	}
+
+	return g
+}
+
+// Move returns the coordinate next to c in the direction, ok is false when it is outside
+// of the grid.
+func (g *Grid) Move(c Coordinate, d Direction) (next Coordinate, ok bool) {
+	switch d {
+	case North:
+		if c.Y == 0 {
+			return c, false
+		}
+
+		c.Y--
+	case East:
+		if c.X+1 >= g.Width {
+			return c, false
+		}
+
+		c.X++
+	case South:
+		if c.Y+1 >= g.Height {
+			return c, false
+		}
+
+		c.Y++
+	case West:
+		if c.X == 0 {
+			return c, false
+		}
+
+		c.X--
+	}
+
+	return c, true
+}
+
+// Contains reports if the coordinate is inside the grid.
+func (g *Grid) Contains(c Coordinate) bool {
+	return c.X < g.Width && c.Y < g.Height
+}
+
+func (g *Grid) bit(x, y uint, b uint) bool {
+	i := x*bitsPerCell + b
+
+	return g.Rows[y][i/8]&(1<<(i%8)) != 0
+}
+
+func (g *Grid) setBit(x, y uint, b uint, value bool) {
+	i := x*bitsPerCell + b
+
+	if value {
+		g.Rows[y][i/8] |= 1 << (i % 8)
+	} else {
+		g.Rows[y][i/8] &^= 1 << (i % 8)
+	}
+}
+
+// HasWall reports if there is a wall in the side of the cell, the sides of the border of
+// the grid and the coordinates outside of it are always walls.
+func (g *Grid) HasWall(c Coordinate, d Direction) bool {
+	if !g.Contains(c) {
+		return true
+	}
+
+	switch d {
+	case North:
+		return c.Y == 0 || g.bit(c.X, c.Y, northBit)
+	case East:
+		return c.X+1 >= g.Width || g.bit(c.X+1, c.Y, westBit)
+	case South:
+		return c.Y+1 >= g.Height || g.bit(c.X, c.Y+1, northBit)
+	case West:
+		return c.X == 0 || g.bit(c.X, c.Y, westBit)
+	default:
+		return true
+	}
+}
+
+// SetWall adds or removes the wall in the side of the cell, the neighbour that shares the
+// side is changed as well. The walls of the border can't be removed.
+func (g *Grid) SetWall(c Coordinate, d Direction, wall bool) error {
+	ve := &ValidationError{Entity: "cell"}
+
+	if !g.Contains(c) {
+		ve.add("x", "the cell %d,%d must be inside the grid of %dx%d", c.X, c.Y, g.Width, g.Height)
+
+		return ve
+	}
+
+	next, ok := g.Move(c, d)
+	if !ok {
+		if !wall {
+			ve.add(d.String(), "the wall of the border of the grid can't be removed")
+
+			return ve
+		}
+
+		return nil
+	}
+
+	switch d {
+	case North:
+		g.setBit(c.X, c.Y, northBit, wall)
+	case East:
+		g.setBit(next.X, next.Y, westBit, wall)
+	case South:
+		g.setBit(next.X, next.Y, northBit, wall)
+	case West:
+		g.setBit(c.X, c.Y, westBit, wall)
+	}
+
+	return nil
+}
+
+// String returns the name of the direction used in the JSON representation of the cells.
+func (d Direction) String() string {
+	switch d {
+	case North:
+		return "north"
+	case East:
+		return "east"
+	case South:
+		return "south"
+	case West:
+		return "west"
+	default:
+		return "unknown"
+	}
+}
+
+// Cell returns the cell with its walls.
+func (g *Grid) Cell(c Coordinate) Cell {
+	return Cell{
+		X:     c.X,
+		Y:     c.Y,
+		North: g.HasWall(c, North),
+		East:  g.HasWall(c, East),
+		South: g.HasWall(c, South),
+		West:  g.HasWall(c, West),
+	}
+}
+
+// SetCell sets the four walls of the cell, the neighbours share them so they are changed
+// as well.
+func (g *Grid) SetCell(cell Cell) error {
+	c := Coordinate{X: cell.X, Y: cell.Y}
+
+	walls := []bool{cell.North, cell.East, cell.South, cell.West}
+
+	for i, d := range Directions {
+		if err := g.SetWall(c, d, walls[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateRegion checks that the region is inside the grid.
+func (g *Grid) validateRegion(r *Region) error {
+	ve := &ValidationError{Entity: "region"}
+
+	if r.StartPoint.X >= r.LimitPoint.X || r.StartPoint.Y >= r.LimitPoint.Y {
+		ve.add("limit_point", "must be greater than the start point on both axes")
+	} else if r.LimitPoint.X > g.Width || r.LimitPoint.Y > g.Height {
+		ve.add("limit_point", "must be inside the grid of %dx%d", g.Width, g.Height)
+	}
+
+	return ve.err()
+}
+
+// Cells returns the cells of the region sorted by row, when the region is nil all the
+// cells of the grid are returned.
+func (g *Grid) Cells(r *Region) ([]Cell, error) {
+	if r == nil {
+		r = &Region{LimitPoint: Coordinate{X: g.Width, Y: g.Height}}
+	}
+
+	if err := g.validateRegion(r); err != nil {
+		return nil, err
+	}
+
+	cells := make([]Cell, 0, (r.LimitPoint.X-r.StartPoint.X)*(r.LimitPoint.Y-r.StartPoint.Y))
+
+	for y := r.StartPoint.Y; y < r.LimitPoint.Y; y++ {
+		for x := r.StartPoint.X; x < r.LimitPoint.X; x++ {
+			cells = append(cells, g.Cell(Coordinate{X: x, Y: y}))
+		}
+	}
+
+	return cells, nil
+}
+
+// validate checks that the rows have the size given by the dimensions of the grid.
+func (g *Grid) validate() error {
+	ve := &ValidationError{Entity: "grid"}
+
+	if uint(len(g.Rows)) != g.Height {
+		ve.add("rows", "must contain %d rows", g.Height)
+
+		return ve
+	}
+
+	size := int((g.Width*bitsPerCell + 7) / 8)
+
+	for y := range g.Rows {
+		if len(g.Rows[y]) != size {
+			ve.add("rows", "the row %d must contain %d bytes", y, size)
+
+			return ve
+		}
+	}
+
+	return nil
+}
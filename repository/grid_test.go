@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGrid_walls(t *testing.T) {
+	g := NewGrid("", 3, 2, false)
+
+	// only the border is closed
+	assert.Equal(t, Cell{X: 0, Y: 0, North: true, West: true}, g.Cell(Coordinate{X: 0, Y: 0}))
+	assert.Equal(t, Cell{X: 2, Y: 1, East: true, South: true}, g.Cell(Coordinate{X: 2, Y: 1}))
+
+	// the neighbours share the walls
+	if err := g.SetWall(Coordinate{X: 1, Y: 0}, East, true); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.True(t, g.HasWall(Coordinate{X: 2, Y: 0}, West))
+
+	if err := g.SetCell(Cell{X: 1, Y: 1, North: true, East: false, South: true, West: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.True(t, g.HasWall(Coordinate{X: 1, Y: 0}, South))
+	assert.True(t, g.HasWall(Coordinate{X: 0, Y: 1}, East))
+	assert.False(t, g.HasWall(Coordinate{X: 2, Y: 1}, West))
+
+	// the border can't be opened
+	var ve *ValidationError
+
+	err := g.SetWall(Coordinate{X: 0, Y: 0}, North, false)
+	assert.True(t, errors.As(err, &ve))
+
+	err = g.SetWall(Coordinate{X: 3, Y: 0}, North, true)
+	assert.True(t, errors.As(err, &ve))
+}
+
+func TestGrid_Cells(t *testing.T) {
+	g := NewGrid("", 11, 3, true)
+
+	assert.Len(t, g.Rows[0], 3)
+
+	cells, err := g.Cells(&Region{StartPoint: Coordinate{X: 9, Y: 1}, LimitPoint: Coordinate{X: 11, Y: 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, cells, 4)
+
+	for _, c := range cells {
+		assert.True(t, c.North && c.East && c.South && c.West, c)
+	}
+
+	assert.Equal(t, uint(10), cells[3].X)
+	assert.Equal(t, uint(2), cells[3].Y)
+
+	_, err = g.Cells(&Region{LimitPoint: Coordinate{X: 12, Y: 3}})
+	assert.Error(t, err)
+
+	all, err := g.Cells(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, all, 33)
+}
+
+func TestGrid_store(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, ctx context.Context, conn *Repository) {
+		maze := &Maze{Name: "labyrinth", Width: 10, Height: 8}
+
+		if _, err := conn.Maze.Create(ctx, maze); err != nil {
+			t.Fatal(err)
+		}
+
+		filter := &GridFilter{MazeID: maze.ID}
+
+		g, err := conn.Grid.Get(ctx, filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, uint(10), g.Width)
+		assert.Equal(t, uint(8), g.Height)
+
+		err = conn.Grid.SetCells(ctx, filter, []Cell{
+			{X: 4, Y: 4, North: true, East: true, South: true, West: false},
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cells, err := conn.Grid.Cells(ctx, filter, &Region{
+			StartPoint: Coordinate{X: 4, Y: 4},
+			LimitPoint: Coordinate{X: 5, Y: 6},
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, []Cell{
+			{X: 4, Y: 4, North: true, East: true, South: true},
+			{X: 4, Y: 5, North: true},
+		}, cells)
+
+		// a wrong cell doesn't change the grid
+		err = conn.Grid.SetCells(ctx, filter, []Cell{
+			{X: 0, Y: 0, North: true, East: true, South: false, West: true},
+			{X: 0, Y: 7, North: true, East: true, South: false, West: true},
+		})
+
+		assert.Error(t, err)
+
+		cell, err := conn.Grid.Cells(ctx, filter, &Region{LimitPoint: Coordinate{X: 1, Y: 1}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.False(t, cell[0].East)
+
+		if _, err := conn.Maze.Delete(ctx, &MazeFilter{ID: maze.ID}); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = conn.Grid.Get(ctx, filter)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// The collections are shared by all the backends, in the key/value ones each collection
+// contains the documents encoded as BSON by its id.
+const (
+	mazesCollection     = "mazes"
+	quadrantsCollection = "quadrants"
+	spotsCollection     = "spots"
+	gridsCollection     = "grids"
+
+	idempotencyCollection = "idempotency_keys"
+)
+
+// kvEngine defines the interface that a key/value storage must satisfy to be used as a
+// backend by the kvService.
+type kvEngine interface {
+	begin(writable bool) (kvTx, error)
+	ping(ctx context.Context) error
+	close(ctx context.Context) error
+}
+
+// kvTx represents a transaction opened in a kvEngine, the writable transactions are
+// exclusive so the stores can read and write several documents consistently.
+type kvTx interface {
+	get(collection, id string) ([]byte, error)
+	put(collection, id string, raw []byte) error
+	delete(collection, id string) error
+	// forEach iterates the documents of the collection sorted by id.
+	forEach(collection string, fn func(id string, raw []byte) error) error
+	writable() bool
+	commit() error
+	rollback() error
+}
+
+// nolint
+// kvService represents a type for each service created over a key/value storage, it is
+// the counterpart of the mongoService.
+type kvService struct {
+	db   kvEngine
+	repo *Repository
+}
+
+// kvBackend represents the lifecycle of the key/value storage.
+type kvBackend struct {
+	db kvEngine
+}
+
+func (kb *kvBackend) ping(ctx context.Context) error {
+	return kb.db.ping(ctx)
+}
+
+func (kb *kvBackend) close(ctx context.Context) error {
+	return kb.db.close(ctx)
+}
+
+func (kb *kvBackend) transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return kvUpdate(ctx, kb.db, func(ctx context.Context, tx kvTx) error {
+		return fn(ctx)
+	})
+}
+
+// newKV creates a new Repository with all the services backed by the key/value storage.
+func newKV(db kvEngine) *Repository {
+	repo := &Repository{backend: &kvBackend{db: db}}
+
+	repo.Maze = &MazeKVService{db: db, repo: repo}
+	repo.Quadrant = &QuadrantKVService{db: db, repo: repo}
+	repo.Spot = &SpotKVService{db: db, repo: repo}
+	repo.Grid = &GridKVService{db: db, repo: repo}
+	repo.Idempotency = &IdempotencyKVService{db: db, repo: repo}
+
+	return repo
+}
+
+type kvTxKey struct {
+	db kvEngine
+}
+
+// kvView runs fn in a read transaction, the transaction that already exists in the context
+// is reused so the stores can call each other without opening nested transactions.
+func kvView(ctx context.Context, db kvEngine, fn func(ctx context.Context, tx kvTx) error) error {
+	return kvRun(ctx, db, false, fn)
+}
+
+// kvUpdate runs fn in a writable transaction, it is committed when fn returns without
+// errors, otherwise it is rolled back.
+func kvUpdate(ctx context.Context, db kvEngine, fn func(ctx context.Context, tx kvTx) error) error {
+	return kvRun(ctx, db, true, fn)
+}
+
+func kvRun(ctx context.Context, db kvEngine, writable bool, fn func(ctx context.Context, tx kvTx) error) error {
+	if tx, ok := ctx.Value(kvTxKey{db: db}).(kvTx); ok {
+		if writable && !tx.writable() {
+			return errors.New("can't write in a read-only transaction")
+		}
+
+		return fn(ctx, tx)
+	}
+
+	tx, err := db.begin(writable)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(context.WithValue(ctx, kvTxKey{db: db}, tx), tx); err != nil {
+		_ = tx.rollback()
+
+		return err
+	}
+
+	if !writable {
+		return tx.rollback()
+	}
+
+	return tx.commit()
+}
+
+// kvGet decodes the document stored with the id, it returns a NotFoundError, which matches
+// mongo.ErrNoDocuments, when it doesn't exist so all the backends have the same semantics.
+func kvGet(tx kvTx, collection, id string, doc interface{}) error {
+	raw, err := tx.get(collection, id)
+	if err != nil {
+		return err
+	}
+
+	if raw == nil {
+		// the entity is the singular of the collection
+		return &NotFoundError{Entity: strings.TrimSuffix(collection, "s"), ID: id}
+	}
+
+	return bson.Unmarshal(raw, doc)
+}
+
+// kvPut encodes the document and stores it with the id.
+func kvPut(tx kvTx, collection, id string, doc interface{}) error {
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	return tx.put(collection, id, raw)
+}
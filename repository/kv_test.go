@@ -0,0 +1,294 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+)
+
+// failingEngine represents a kvEngine whose transactions fail when a document is written
+// in the collection, it is used to check that the writes are rolled back.
+type failingEngine struct {
+	kvEngine
+	collection string
+}
+
+type failingTx struct {
+	kvTx
+	collection string
+}
+
+func (fe *failingEngine) begin(writable bool) (kvTx, error) {
+	tx, err := fe.kvEngine.begin(writable)
+	if err != nil {
+		return nil, err
+	}
+
+	return &failingTx{kvTx: tx, collection: fe.collection}, nil
+}
+
+func (ft *failingTx) put(collection, id string, raw []byte) error {
+	if collection == ft.collection {
+		return errors.New("writing failed")
+	}
+
+	return ft.kvTx.put(collection, id, raw)
+}
+
+func testEngines(t *testing.T) map[string]kvEngine {
+	t.Helper()
+
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "maze.db"), 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		assert.NoError(t, db.Close())
+	})
+
+	return map[string]kvEngine{
+		"memory": &memoryEngine{collections: make(map[string]map[string][]byte)},
+		"bolt":   &boltEngine{db: db},
+	}
+}
+
+func TestKV_updateRollsBackOnError(t *testing.T) {
+	for name, db := range testEngines(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			err := kvUpdate(ctx, db, func(ctx context.Context, tx kvTx) error {
+				if err := tx.put(spotsCollection, "1", []byte("first")); err != nil {
+					return err
+				}
+
+				return errors.New("second write failed")
+			})
+
+			assert.Error(t, err)
+
+			err = kvView(ctx, db, func(ctx context.Context, tx kvTx) error {
+				raw, err := tx.get(spotsCollection, "1")
+				assert.Nil(t, raw)
+
+				return err
+			})
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestKV_nestedCallsShareTheTransaction(t *testing.T) {
+	for name, db := range testEngines(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			err := kvUpdate(ctx, db, func(ctx context.Context, tx kvTx) error {
+				if err := tx.put(spotsCollection, "1", []byte("first")); err != nil {
+					return err
+				}
+
+				// the nested call sees the pending write and doesn't block
+				return kvView(ctx, db, func(ctx context.Context, nested kvTx) error {
+					raw, err := nested.get(spotsCollection, "1")
+					assert.Equal(t, []byte("first"), raw)
+
+					return err
+				})
+			})
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestKV_forEachSkipsTheDeletedDocuments(t *testing.T) {
+	for name, db := range testEngines(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			err := kvUpdate(ctx, db, func(ctx context.Context, tx kvTx) error {
+				for _, id := range []string{"1", "2", "3"} {
+					if err := tx.put(spotsCollection, id, []byte("spot "+id)); err != nil {
+						return err
+					}
+				}
+
+				var seen []string
+
+				// the first document deletes the last one and changes the second one
+				err := tx.forEach(spotsCollection, func(id string, raw []byte) error {
+					seen = append(seen, string(raw))
+
+					if id != "1" {
+						return nil
+					}
+
+					if err := tx.delete(spotsCollection, "3"); err != nil {
+						return err
+					}
+
+					return tx.put(spotsCollection, "2", []byte("changed"))
+				})
+
+				assert.Equal(t, []string{"spot 1", "changed"}, seen)
+
+				return err
+			})
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestKV_spotCreateIsAtomic(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		mem  = &memoryEngine{collections: make(map[string]map[string][]byte)}
+		conn = newKV(mem)
+	)
+
+	qID, err := conn.Quadrant.Create(ctx, &Quadrant{
+		Type:       TopLeft,
+		StartPoint: &Coordinate{X: 0, Y: 0},
+		LimitPoint: &Coordinate{X: 25, Y: 25},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the quadrant can't be written, so the spot inserted before must be rolled back
+	failing := newKV(&failingEngine{kvEngine: mem, collection: quadrantsCollection})
+
+	_, err = failing.Spot.Create(ctx, &Spot{
+		Name:       "exit",
+		GoldAmount: 4000,
+		Coordinate: &Coordinate{X: 9, Y: 0},
+		QuadrantID: qID,
+	})
+
+	assert.Error(t, err)
+
+	spots, err := conn.Spot.List(ctx, &SpotFilter{QuadrantID: qID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Empty(t, spots)
+
+	q, err := conn.Quadrant.Get(ctx, &QuadrantFilter{ID: qID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Empty(t, q.SpotIDs)
+}
+
+func TestKV_quadrantDeleteIsAtomic(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		mem  = &memoryEngine{collections: make(map[string]map[string][]byte)}
+		conn = newKV(mem)
+	)
+
+	q1ID, err := conn.Quadrant.Create(ctx, &Quadrant{
+		Type:       TopLeft,
+		StartPoint: &Coordinate{X: 0, Y: 0},
+		LimitPoint: &Coordinate{X: 25, Y: 25},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q2ID, err := conn.Quadrant.Create(ctx, &Quadrant{
+		Type:       TopRight,
+		StartPoint: &Coordinate{X: 25, Y: 0},
+		LimitPoint: &Coordinate{X: 50, Y: 25},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sID, err := conn.Spot.Create(ctx, &Spot{Name: "exit", Coordinate: &Coordinate{X: 1, Y: 1}, QuadrantID: q1ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a spot of the deleted quadrant is also listed in another one, so the cascade must
+	// rewrite it and that write fails
+	err = kvUpdate(ctx, mem, func(ctx context.Context, tx kvTx) error {
+		q := &Quadrant{}
+
+		if err := kvGet(tx, quadrantsCollection, q2ID, q); err != nil {
+			return err
+		}
+
+		q.SpotIDs = append(q.SpotIDs, sID)
+
+		return kvPut(tx, quadrantsCollection, q2ID, q)
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	failing := newKV(&failingEngine{kvEngine: mem, collection: quadrantsCollection})
+
+	_, err = failing.Quadrant.Delete(ctx, &QuadrantFilter{ID: q1ID})
+	assert.Error(t, err)
+
+	q, err := conn.Quadrant.Get(ctx, &QuadrantFilter{ID: q1ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []string{sID}, q.SpotIDs)
+	assert.Len(t, q.Spots, 1)
+}
+
+func TestKV_repositoryTransaction(t *testing.T) {
+	for name, db := range testEngines(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newKV(db)
+
+			var id string
+
+			err := repo.Transaction(ctx, func(ctx context.Context) error {
+				var err error
+
+				id, err = repo.Maze.Create(ctx, &Maze{Name: "labyrinth", Width: 4, Height: 4})
+				if err != nil {
+					return err
+				}
+
+				return errors.New("generation failed")
+			})
+
+			assert.Error(t, err)
+
+			// neither the maze nor its quadrants and grid are kept
+			_, err = repo.Maze.Get(ctx, &MazeFilter{ID: id})
+			assert.Error(t, err)
+
+			_, err = repo.Grid.Get(ctx, &GridFilter{MazeID: id})
+			assert.Error(t, err)
+
+			mazes, err := repo.Maze.List(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Empty(t, mazes)
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+)
+
+// NewMemory creates a new Repository that keeps all the data in memory, it is safe
+// for concurrent use and it is meant for tests and demos since the data is lost when
+// the application stops.
+func NewMemory() *Repository {
+	return newKV(&memoryEngine{
+		collections: make(map[string]map[string][]byte),
+	})
+}
+
+// memoryEngine represents a kvEngine that keeps the documents in maps.
+type memoryEngine struct {
+	mu          sync.RWMutex
+	collections map[string]map[string][]byte
+}
+
+var _ kvEngine = &memoryEngine{}
+
+func (me *memoryEngine) begin(writable bool) (kvTx, error) {
+	if writable {
+		me.mu.Lock()
+	} else {
+		me.mu.RLock()
+	}
+
+	return &memoryTx{db: me, isWritable: writable}, nil
+}
+
+func (me *memoryEngine) ping(ctx context.Context) error {
+	return nil
+}
+
+func (me *memoryEngine) close(ctx context.Context) error {
+	return nil
+}
+
+// memoryTx represents a transaction that holds the lock of the memoryEngine until it is
+// committed or rolled back. The writes are kept as a unit of work that is only applied to
+// the engine on commit, so a rolled back transaction doesn't leave any change behind.
+type memoryTx struct {
+	db         *memoryEngine
+	isWritable bool
+	done       bool
+	// changes contains the documents written by collection and id, the deleted ones
+	// are kept with a nil value.
+	changes map[string]map[string][]byte
+}
+
+// get returns a copy of the document since the decoded documents can share the memory
+// of the raw one, e.g. the binary fields.
+func (mt *memoryTx) get(collection, id string) ([]byte, error) {
+	raw, ok := mt.changes[collection][id]
+	if !ok {
+		raw = mt.db.collections[collection][id]
+	}
+
+	if raw == nil {
+		return nil, nil
+	}
+
+	return append([]byte{}, raw...), nil
+}
+
+func (mt *memoryTx) put(collection, id string, raw []byte) error {
+	return mt.write(collection, id, raw)
+}
+
+func (mt *memoryTx) delete(collection, id string) error {
+	return mt.write(collection, id, nil)
+}
+
+func (mt *memoryTx) write(collection, id string, raw []byte) error {
+	if !mt.isWritable {
+		return errors.New("can't write in a read-only transaction")
+	}
+
+	if mt.changes == nil {
+		mt.changes = make(map[string]map[string][]byte)
+	}
+
+	docs, ok := mt.changes[collection]
+	if !ok {
+		docs = make(map[string][]byte)
+		mt.changes[collection] = docs
+	}
+
+	docs[id] = raw
+
+	return nil
+}
+
+func (mt *memoryTx) forEach(collection string, fn func(id string, raw []byte) error) error {
+	var (
+		docs    = mt.db.collections[collection]
+		changes = mt.changes[collection]
+		ids     = make([]string, 0, len(docs)+len(changes))
+	)
+
+	for id := range docs {
+		if _, ok := changes[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+
+	for id, raw := range changes {
+		if raw != nil {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		raw, _ := mt.get(collection, id)
+		if raw == nil {
+			// it was deleted by fn in a previous iteration
+			continue
+		}
+
+		if err := fn(id, raw); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (mt *memoryTx) writable() bool {
+	return mt.isWritable
+}
+
+func (mt *memoryTx) commit() error {
+	if mt.done {
+		return errors.New("the transaction has already been closed")
+	}
+
+	for collection, changes := range mt.changes {
+		docs, ok := mt.db.collections[collection]
+		if !ok {
+			docs = make(map[string][]byte)
+			mt.db.collections[collection] = docs
+		}
+
+		for id, raw := range changes {
+			if raw == nil {
+				delete(docs, id)
+
+				continue
+			}
+
+			docs[id] = raw
+		}
+	}
+
+	return mt.release()
+}
+
+func (mt *memoryTx) rollback() error {
+	mt.changes = nil
+
+	return mt.release()
+}
+
+func (mt *memoryTx) release() error {
+	if mt.done {
+		return errors.New("the transaction has already been closed")
+	}
+
+	mt.done = true
+
+	if mt.isWritable {
+		mt.db.mu.Unlock()
+	} else {
+		mt.db.mu.RUnlock()
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemory_concurrentSpotCreates(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		conn = NewMemory()
+		wg   sync.WaitGroup
+	)
+
+	qID, err := conn.Quadrant.Create(ctx, &Quadrant{
+		Type:       TopLeft,
+		StartPoint: &Coordinate{X: 0, Y: 0},
+		LimitPoint: &Coordinate{X: 25, Y: 25},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			_, err := conn.Spot.Create(ctx, &Spot{
+				Name:       "treasure",
+				GoldAmount: 10,
+				Coordinate: &Coordinate{X: uint(i), Y: 1},
+				QuadrantID: qID,
+			})
+
+			assert.NoError(t, err)
+		}(i)
+	}
+
+	wg.Wait()
+
+	q, err := conn.Quadrant.Get(ctx, &QuadrantFilter{ID: qID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, q.SpotIDs, 20)
+	assert.Len(t, q.Spots, 20)
+}
+
+func TestMemory_getReturnsCopies(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		conn = NewMemory()
+	)
+
+	qID, err := conn.Quadrant.Create(ctx, &Quadrant{
+		Type:       TopLeft,
+		StartPoint: &Coordinate{X: 0, Y: 0},
+		LimitPoint: &Coordinate{X: 25, Y: 25},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q, err := conn.Quadrant.Get(ctx, &QuadrantFilter{ID: qID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q.StartPoint.X = 10
+
+	got, err := conn.Quadrant.Get(ctx, &QuadrantFilter{ID: qID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.EqualValues(t, 0, got.StartPoint.X)
+}
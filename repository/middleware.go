@@ -6,13 +6,14 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// GinMiddleware is used to set the database in the current gin context.
-func GinMiddleware(connString string) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		conn := NewByConnString(connString)
+// GinMiddleware is used to set the shared repository in the current gin context, the
+// repository must be created once when the application starts, e.g. with OpenMongoDB.
+func GinMiddleware(repo *Repository) gin.HandlerFunc {
+	dbName := os.Getenv("DB_NAME")
 
-		c.Set("mongoRepoConn", conn)
-		c.Set(string(ContextDBName), os.Getenv("DB_NAME"))
+	return func(c *gin.Context) {
+		c.Set(string(ContextRepoConn), repo)
+		c.Set(string(ContextDBName), dbName)
 
 		c.Next()
 	}
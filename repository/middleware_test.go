@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGinMiddleware_sharesTheService(t *testing.T) {
+	var (
+		repo   = New(nil)
+		router = gin.New()
+		got    = make([]*Repository, 0)
+	)
+
+	router.Use(GinMiddleware(repo))
+	router.GET("/", func(c *gin.Context) {
+		got = append(got, c.MustGet(string(ContextRepoConn)).(*Repository))
+	})
+
+	for i := 0; i < 3; i++ {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+
+	assert.Len(t, got, 3)
+
+	for i := range got {
+		assert.Same(t, repo, got[i])
+	}
+}
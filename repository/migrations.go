@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Migrate updates the documents stored by previous versions of the application, it is
+// meant to be called once when the application starts and it can be called several times
+// since the documents already migrated are skipped.
+func (r *Repository) Migrate(ctx context.Context) error {
+	return r.backend.migrate(ctx)
+}
+
+// legacyGoldAmountKey is the key used by the first versions to store the gold amount of
+// the spots as a string.
+const legacyGoldAmountKey = "gold_mount"
+
+// parseLegacyGoldAmount converts the gold amount stored as a string, an empty one is zero.
+func parseLegacyGoldAmount(v interface{}) (uint, error) {
+	s, ok := v.(string)
+	if !ok {
+		return 0, fmt.Errorf("unexpected %s type %T", legacyGoldAmountKey, v)
+	}
+
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+
+	amount, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s %q is not a non-negative integer", legacyGoldAmountKey, s)
+	}
+
+	return uint(amount), nil
+}
+
+func (mb *mongoBackend) migrate(ctx context.Context) error {
+	spots := mb.db.Database(DBName(ctx)).Collection("spots")
+
+	cursor, err := spots.Find(ctx, bson.M{legacyGoldAmountKey: bson.M{"$exists": true}})
+	if err != nil {
+		return fmt.Errorf("finding the spots to migrate: %w", err)
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var doc bson.M
+
+		if err := cursor.Decode(&doc); err != nil {
+			return err
+		}
+
+		amount, err := parseLegacyGoldAmount(doc[legacyGoldAmountKey])
+		if err != nil {
+			return fmt.Errorf("migrating the spot %v: %w", doc["_id"], err)
+		}
+
+		_, err = spots.UpdateOne(ctx, bson.M{"_id": doc["_id"]}, bson.M{
+			"$set":   bson.M{"gold_amount": amount},
+			"$unset": bson.M{legacyGoldAmountKey: ""},
+		})
+
+		if err != nil {
+			return fmt.Errorf("migrating the spot %v: %w", doc["_id"], err)
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	// the spots created before the kinds existed are treasures
+	_, err = spots.UpdateMany(ctx,
+		bson.M{"kind": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"kind": Treasure}},
+	)
+
+	if err != nil {
+		return fmt.Errorf("migrating the kind of the spots: %w", err)
+	}
+
+	// the spots and quadrants created before the versions existed start at version 1
+	for _, name := range []string{"spots", "quadrants"} {
+		_, err = mb.db.Database(DBName(ctx)).Collection(name).UpdateMany(ctx,
+			bson.M{"version": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"version": 1}},
+		)
+
+		if err != nil {
+			return fmt.Errorf("migrating the version of the %s: %w", name, err)
+		}
+	}
+
+	// the first versions stored the BottomRight quadrants with a misspelled type
+	_, err = mb.db.Database(DBName(ctx)).Collection("quadrants").UpdateMany(ctx,
+		bson.M{"type": legacyBottomRight},
+		bson.M{"$set": bson.M{"type": BottomRight}},
+	)
+
+	if err != nil {
+		return fmt.Errorf("migrating the type of the quadrants: %w", err)
+	}
+
+	// the spatial queries use a 2d index on the coordinates, its bounds cover the cells of
+	// the mazes instead of the default longitude and latitude ones
+	_, err = spots.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "Coordinate", Value: "2d"}},
+		Options: options.Index().SetMin(-1).SetMax(maxIndexedCoordinate).SetBits(32),
+	})
+
+	if err != nil {
+		return fmt.Errorf("creating the index of the spot coordinates: %w", err)
+	}
+
+	// the idempotency keys are removed by MongoDB once they expire
+	_, err = mb.db.Database(DBName(ctx)).Collection("idempotency_keys").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+
+	if err != nil {
+		return fmt.Errorf("creating the index of the idempotency keys: %w", err)
+	}
+
+	return nil
+}
+
+func (kb *kvBackend) migrate(ctx context.Context) error {
+	return kvUpdate(ctx, kb.db, func(ctx context.Context, tx kvTx) error {
+		// the quadrants created before the versions existed start at version 1
+		err := tx.forEach(quadrantsCollection, func(id string, raw []byte) error {
+			var doc bson.M
+
+			if err := bson.Unmarshal(raw, &doc); err != nil {
+				return err
+			}
+
+			changed := false
+
+			if _, ok := doc["version"]; !ok {
+				doc["version"] = int64(1)
+				changed = true
+			}
+
+			// the first versions stored the BottomRight quadrants with a misspelled type
+			if doc["type"] == string(legacyBottomRight) {
+				doc["type"] = BottomRight
+				changed = true
+			}
+
+			if !changed {
+				return nil
+			}
+
+			return kvPut(tx, quadrantsCollection, id, doc)
+		})
+
+		if err != nil {
+			return err
+		}
+
+		return tx.forEach(spotsCollection, func(id string, raw []byte) error {
+			var doc bson.M
+
+			if err := bson.Unmarshal(raw, &doc); err != nil {
+				return err
+			}
+
+			changed := false
+
+			if v, ok := doc[legacyGoldAmountKey]; ok {
+				amount, err := parseLegacyGoldAmount(v)
+				if err != nil {
+					return fmt.Errorf("migrating the spot %s: %w", id, err)
+				}
+
+				delete(doc, legacyGoldAmountKey)
+				doc["gold_amount"] = amount
+				changed = true
+			}
+
+			// the spots created before the kinds existed are treasures
+			if _, ok := doc["kind"]; !ok {
+				doc["kind"] = Treasure
+				changed = true
+			}
+
+			if _, ok := doc["version"]; !ok {
+				doc["version"] = int64(1)
+				changed = true
+			}
+
+			if !changed {
+				return nil
+			}
+
+			return kvPut(tx, spotsCollection, id, doc)
+		})
+	})
+}
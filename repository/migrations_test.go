@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMigrate_legacyGoldAmount(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		mem  = &memoryEngine{collections: make(map[string]map[string][]byte)}
+		conn = newKV(mem)
+		id   = primitive.NewObjectID().Hex()
+	)
+
+	err := kvUpdate(ctx, mem, func(ctx context.Context, tx kvTx) error {
+		return kvPut(tx, spotsCollection, id, bson.M{"_id": id, "name": "exit", "gold_mount": " 4000"})
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the second run skips the spots already migrated
+	for i := 0; i < 2; i++ {
+		if err := conn.Migrate(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s, err := conn.Spot.Get(ctx, &SpotFilter{ID: id})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, uint(4000), s.GoldAmount)
+	assert.Equal(t, Treasure, s.Kind)
+	assert.Equal(t, uint64(1), s.Version)
+
+	err = kvView(ctx, mem, func(ctx context.Context, tx kvTx) error {
+		var doc bson.M
+
+		if err := kvGet(tx, spotsCollection, id, &doc); err != nil {
+			return err
+		}
+
+		assert.NotContains(t, doc, legacyGoldAmountKey)
+
+		return nil
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMigrate_invalidGoldAmount(t *testing.T) {
+	for _, v := range []interface{}{"-5", "lots", int32(5)} {
+		_, err := parseLegacyGoldAmount(v)
+		assert.Error(t, err, v)
+	}
+
+	amount, err := parseLegacyGoldAmount("")
+	assert.NoError(t, err)
+	assert.Zero(t, amount)
+}
+
+func TestMigrate_version(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		mem  = &memoryEngine{collections: make(map[string]map[string][]byte)}
+		conn = newKV(mem)
+		id   = primitive.NewObjectID().Hex()
+	)
+
+	err := kvUpdate(ctx, mem, func(ctx context.Context, tx kvTx) error {
+		return kvPut(tx, quadrantsCollection, id, bson.M{"_id": id, "type": TopLeft})
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := conn.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	q, err := conn.Quadrant.Get(ctx, &QuadrantFilter{ID: id})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, uint64(1), q.Version)
+}
+
+func TestMigrate_legacyBottomRight(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		mem  = &memoryEngine{collections: make(map[string]map[string][]byte)}
+		conn = newKV(mem)
+		id   = primitive.NewObjectID().Hex()
+	)
+
+	err := kvUpdate(ctx, mem, func(ctx context.Context, tx kvTx) error {
+		return kvPut(tx, quadrantsCollection, id, bson.M{"_id": id, "type": "BOTTOM_RIGTH", "version": int64(3)})
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := conn.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	q, err := conn.Quadrant.Get(ctx, &QuadrantFilter{ID: id})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, BottomRight, q.Type)
+	assert.Equal(t, uint64(3), q.Version)
+
+	// the legacy name is still accepted on input
+	var qf QuadrantFilter
+
+	if err := json.Unmarshal([]byte(`{"type": "BOTTOM_RIGTH"}`), &qf); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, BottomRight, qf.Type)
+	assert.Equal(t, TopLeft, ParseQuadrantType("TOP_LEFT"))
+}
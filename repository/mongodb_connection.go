@@ -2,22 +2,110 @@ package repository
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"os"
+	"time"
 
+	"github.com/spf13/cast"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// MongoDBConfig represents the settings used to create the MongoDB client, the zero
+// values keep the defaults of the mongo driver.
+type MongoDBConfig struct {
+	ConnString      string
+	MaxPoolSize     uint64
+	MinPoolSize     uint64
+	MaxConnIdleTime time.Duration
+	ConnectTimeout  time.Duration
+}
+
+// MongoDBConfigFromEnv creates a MongoDBConfig using the environment variables:
+// MOGODB_CONN, MOGODB_MAX_POOL_SIZE, MOGODB_MIN_POOL_SIZE, MOGODB_MAX_CONN_IDLE_TIME
+// and MOGODB_CONNECT_TIMEOUT, the durations use the time.ParseDuration format e.g. 30s.
+func MongoDBConfigFromEnv() *MongoDBConfig {
+	return &MongoDBConfig{
+		ConnString:      os.Getenv("MOGODB_CONN"),
+		MaxPoolSize:     cast.ToUint64(os.Getenv("MOGODB_MAX_POOL_SIZE")),
+		MinPoolSize:     cast.ToUint64(os.Getenv("MOGODB_MIN_POOL_SIZE")),
+		MaxConnIdleTime: cast.ToDuration(os.Getenv("MOGODB_MAX_CONN_IDLE_TIME")),
+		ConnectTimeout:  cast.ToDuration(os.Getenv("MOGODB_CONNECT_TIMEOUT")),
+	}
+}
+
+func (cfg *MongoDBConfig) clientOptions() *options.ClientOptions {
+	opts := options.Client().ApplyURI(cfg.ConnString)
+
+	if cfg.MaxPoolSize > 0 {
+		opts.SetMaxPoolSize(cfg.MaxPoolSize)
+	}
+
+	if cfg.MinPoolSize > 0 {
+		opts.SetMinPoolSize(cfg.MinPoolSize)
+	}
+
+	if cfg.MaxConnIdleTime > 0 {
+		opts.SetMaxConnIdleTime(cfg.MaxConnIdleTime)
+	}
+
+	if cfg.ConnectTimeout > 0 {
+		opts.SetConnectTimeout(cfg.ConnectTimeout)
+	}
+
+	return opts
+}
+
+// NewMongoDBClient creates a new mongo db client using the config and connects it
+// with the database.
+func NewMongoDBClient(ctx context.Context, cfg *MongoDBConfig) (*mongo.Client, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("mongodb config must not be nil")
+	}
+
+	client, err := mongo.NewClient(cfg.clientOptions())
+	if err != nil {
+		return nil, fmt.Errorf("wrong connection with MongoDB: %w", err)
+	}
+
+	if err := client.Connect(ctx); err != nil {
+		return nil, fmt.Errorf("the MongoDB connection can't be reached: %w", err)
+	}
+
+	return client, nil
+}
+
 // NewMongoDBConn creates a new mongo db client to connect with the database.
 func NewMongoDBConn(connString string) *mongo.Client {
-	client, err := mongo.NewClient(options.Client().ApplyURI(connString))
+	client, err := NewMongoDBClient(context.Background(), &MongoDBConfig{ConnString: connString})
 	if err != nil {
-		log.Panicf("wrong connection with MongoDB: %s", err)
+		log.Panic(err)
 	}
 
-	if err := client.Connect(context.Background()); err != nil {
-		log.Panicf("the MongoDB connection can be reached: %s", err)
+	return client
+}
+
+// mongoTransaction runs fn in a multi-document transaction, so all the writes made with
+// the context received by fn are applied or rolled back together. When the context
+// already belongs to a session its transaction is reused, that way the services can
+// call each other without opening nested transactions.
+// Note: MongoDB only supports transactions in replica sets, e.g. a single node one.
+func mongoTransaction(ctx context.Context, db *mongo.Client, fn func(ctx context.Context) error) error {
+	if mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
 	}
 
-	return client
+	session, err := db.StartSession()
+	if err != nil {
+		return fmt.Errorf("starting a session: %w", err)
+	}
+
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+
+	return err
 }
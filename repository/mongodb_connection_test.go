@@ -3,22 +3,78 @@ package repository
 import (
 	"context"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/stretchr/testify/assert"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func Test_EnvMongoDBConnectionString(t *testing.T) {
+// loadMongoDBEnv tries to read the MongoDB env variables from the .env file when they
+// are not set, it reports if the MongoDB tests can be run.
+func loadMongoDBEnv(t *testing.T) bool {
+	t.Helper()
+
 	// check connection string is not empty
 	if os.Getenv("MOGODB_CONN") == "" {
 		t.Logf("we can't read env variable trying find it in .env file...")
 
 		if err := godotenv.Load("../.env"); err != nil {
-			t.Fatalf("error loading .env file %+v", err)
+			t.Logf("error loading .env file %+v", err)
+
+			return false
 		}
 	}
+
+	return os.Getenv("MOGODB_CONN") != ""
+}
+
+// forEachRepository runs the test against the memory and bolt repositories and against the
+// MongoDB one when its connection string is available.
+func forEachRepository(t *testing.T, test func(t *testing.T, ctx context.Context, conn *Repository)) {
+	t.Helper()
+
+	t.Run("memory", func(t *testing.T) {
+		test(t, context.Background(), NewMemory())
+	})
+
+	t.Run("bolt", func(t *testing.T) {
+		conn, err := NewBolt(filepath.Join(t.TempDir(), "maze.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer func() {
+			assert.NoError(t, conn.Close(context.Background()))
+		}()
+
+		test(t, context.Background(), conn)
+	})
+
+	t.Run("mongodb", func(t *testing.T) {
+		if !loadMongoDBEnv(t) {
+			t.Skip("MongoDB is not configured")
+		}
+
+		var (
+			ctx  = DBNameSet(context.Background(), os.Getenv("DB_NAME"))
+			conn = NewByConnString(os.Getenv("MOGODB_CONN"))
+		)
+
+		defer func() {
+			assert.NoError(t, conn.Close(ctx))
+		}()
+
+		test(t, ctx, conn)
+	})
+}
+
+func Test_EnvMongoDBConnectionString(t *testing.T) {
+	if !loadMongoDBEnv(t) {
+		t.Skip("MongoDB is not configured")
+	}
 }
 
 func Test_NewMongoDBConnection_withRightCredentials(t *testing.T) {
@@ -37,3 +93,41 @@ func Test_NewMongoDBConnection_withRightCredentials(t *testing.T) {
 	// check we are returning the right value
 	assert.IsType(t, client, &mongo.Client{})
 }
+
+func TestMongoDBConfigFromEnv(t *testing.T) {
+	env := map[string]string{
+		"MOGODB_MAX_POOL_SIZE":      "50",
+		"MOGODB_MIN_POOL_SIZE":      "5",
+		"MOGODB_MAX_CONN_IDLE_TIME": "1m",
+		"MOGODB_CONNECT_TIMEOUT":    "10s",
+	}
+
+	for k, v := range env {
+		os.Setenv(k, v)
+
+		defer os.Unsetenv(k)
+	}
+
+	cfg := MongoDBConfigFromEnv()
+
+	assert.EqualValues(t, 50, cfg.MaxPoolSize)
+	assert.EqualValues(t, 5, cfg.MinPoolSize)
+	assert.Equal(t, time.Minute, cfg.MaxConnIdleTime)
+	assert.Equal(t, 10*time.Second, cfg.ConnectTimeout)
+
+	opts := cfg.clientOptions()
+
+	assert.EqualValues(t, 50, *opts.MaxPoolSize)
+	assert.EqualValues(t, 5, *opts.MinPoolSize)
+	assert.Equal(t, time.Minute, *opts.MaxConnIdleTime)
+	assert.Equal(t, 10*time.Second, *opts.ConnectTimeout)
+}
+
+func TestMongoDBConfig_defaultClientOptions(t *testing.T) {
+	opts := (&MongoDBConfig{ConnString: "mongodb://localhost:27017"}).clientOptions()
+
+	assert.Nil(t, opts.MaxPoolSize)
+	assert.Nil(t, opts.MinPoolSize)
+	assert.Nil(t, opts.MaxConnIdleTime)
+	assert.Nil(t, opts.ConnectTimeout)
+}
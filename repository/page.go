@@ -0,0 +1,471 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// defaultPageLimit is the number of items of a page when the limit isn't given.
+	defaultPageLimit = 20
+
+	// maxPageLimit is the biggest number of items of a page.
+	maxPageLimit = 100
+)
+
+// ListOptions represents the order and the page of a list. Sort is the name of a field
+// prefixed with - to sort it in descending order, e.g. -gold_amount, the items with the
+// same value are sorted by id in the same direction. Cursor is the next_cursor of the
+// previous page.
+type ListOptions struct {
+	Sort   string `json:"sort,omitempty"`
+	Limit  int    `json:"limit,omitempty"`
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// SpotQuery represents the filters of the spot pages, the zero values are ignored. The
+// json tags are the names of the query params.
+type SpotQuery struct {
+	MazeID     string   `json:"maze_id,omitempty"`
+	QuadrantID string   `json:"quadrant_id,omitempty"`
+	Kind       SpotKind `json:"kind,omitempty"`
+	NamePrefix string   `json:"name_prefix,omitempty"`
+	MinGold    *uint    `json:"min_gold,omitempty"`
+	MaxGold    *uint    `json:"max_gold,omitempty"`
+	Box        *Region  `json:"box,omitempty"`
+}
+
+// QuadrantQuery represents the filters of the quadrant pages, the quadrants that overlap
+// the box are returned.
+type QuadrantQuery struct {
+	MazeID string       `json:"maze_id,omitempty"`
+	Type   QuadrantType `json:"type,omitempty"`
+	Box    *Region      `json:"box,omitempty"`
+}
+
+// SpotPage represents a page of spots, NextCursor is empty in the last page and Total is
+// the number of spots that satisfy the query in all the pages.
+type SpotPage struct {
+	Items      []Spot `json:"items"`
+	NextCursor string `json:"next_cursor"`
+	Total      int64  `json:"total"`
+}
+
+// QuadrantPage represents a page of quadrants.
+type QuadrantPage struct {
+	Items      []Quadrant `json:"items"`
+	NextCursor string     `json:"next_cursor"`
+	Total      int64      `json:"total"`
+}
+
+// sortField represents a field that can be used to sort a list, key is the name of the
+// field in MongoDB.
+type sortField struct {
+	key      string
+	spot     func(s *Spot) interface{}
+	quadrant func(q *Quadrant) interface{}
+}
+
+// coordinateValue returns the axis of the coordinate, the missing ones are sorted first.
+func coordinateValue(c *Coordinate, axis int) interface{} {
+	if c == nil {
+		return uint(0)
+	}
+
+	return axisValue(*c, axis)
+}
+
+var spotSortFields = map[string]sortField{
+	"id":          {key: "_id", spot: func(s *Spot) interface{} { return s.ID }},
+	"name":        {key: "name", spot: func(s *Spot) interface{} { return s.Name }},
+	"maze_id":     {key: "maze_id", spot: func(s *Spot) interface{} { return s.MazeID }},
+	"quadrant_id": {key: "quadrant_id", spot: func(s *Spot) interface{} { return s.QuadrantID }},
+	"kind":        {key: "kind", spot: func(s *Spot) interface{} { return string(s.Kind) }},
+	"gold_amount": {key: "gold_amount", spot: func(s *Spot) interface{} { return s.GoldAmount }},
+	"penalty":     {key: "penalty", spot: func(s *Spot) interface{} { return s.Penalty }},
+	"x":           {key: "Coordinate.x", spot: func(s *Spot) interface{} { return coordinateValue(s.Coordinate, 0) }},
+	"y":           {key: "Coordinate.y", spot: func(s *Spot) interface{} { return coordinateValue(s.Coordinate, 1) }},
+}
+
+var quadrantSortFields = map[string]sortField{
+	"id":      {key: "_id", quadrant: func(q *Quadrant) interface{} { return q.ID }},
+	"maze_id": {key: "maze_id", quadrant: func(q *Quadrant) interface{} { return q.MazeID }},
+	"type":    {key: "type", quadrant: func(q *Quadrant) interface{} { return string(q.Type) }},
+	"x":       {key: "start_point.x", quadrant: func(q *Quadrant) interface{} { return coordinateValue(q.StartPoint, 0) }},
+	"y":       {key: "start_point.y", quadrant: func(q *Quadrant) interface{} { return coordinateValue(q.StartPoint, 1) }},
+}
+
+// pageCursor represents the position after the last item of a page, it keeps the sort
+// so the cursor can't be used with another one.
+type pageCursor struct {
+	Sort  string      `json:"s"`
+	Value interface{} `json:"v"`
+	ID    string      `json:"id"`
+}
+
+// page represents the parsed options of a list.
+type page struct {
+	sort   string
+	field  sortField
+	desc   bool
+	limit  int
+	cursor *pageCursor
+}
+
+// newPage validates the options against the fields that can be sorted.
+func newPage(entity string, opts *ListOptions, fields map[string]sortField) (*page, error) {
+	if opts == nil {
+		opts = &ListOptions{}
+	}
+
+	ve := &ValidationError{Entity: entity}
+	p := &page{sort: opts.Sort, limit: opts.Limit}
+
+	if p.sort == "" {
+		p.sort = "id"
+	}
+
+	name := strings.TrimPrefix(p.sort, "-")
+	p.desc = name != p.sort
+
+	field, ok := fields[name]
+	if !ok {
+		names := make([]string, 0, len(fields))
+
+		for n := range fields {
+			names = append(names, n)
+		}
+
+		sort.Strings(names)
+		ve.add("sort", "must be one of %s, prefixed with - to sort in descending order", strings.Join(names, ", "))
+	}
+
+	p.field = field
+
+	if p.limit == 0 {
+		p.limit = defaultPageLimit
+	}
+
+	if p.limit < 0 || p.limit > maxPageLimit {
+		ve.add("limit", "must be between 1 and %d", maxPageLimit)
+	}
+
+	if opts.Cursor != "" {
+		c, err := decodeCursor(opts.Cursor)
+
+		switch {
+		case err != nil:
+			ve.add("cursor", "is not valid")
+		case c.Sort != p.sort:
+			ve.add("cursor", "was created with the sort %s", c.Sort)
+		default:
+			p.cursor = c
+		}
+	}
+
+	if err := ve.err(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func encodeCursor(c *pageCursor) string {
+	raw, _ := json.Marshal(c)
+
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(s string) (*pageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &pageCursor{}
+
+	if err := json.Unmarshal(raw, c); err != nil {
+		return nil, err
+	}
+
+	if _, err := primitive.ObjectIDFromHex(c.ID); err != nil {
+		return nil, err
+	}
+
+	switch c.Value.(type) {
+	case string, float64:
+		return c, nil
+	default:
+		return nil, fmt.Errorf("wrong cursor value %v", c.Value)
+	}
+}
+
+// next returns the cursor of the item.
+func (p *page) next(value interface{}, id string) string {
+	return encodeCursor(&pageCursor{Sort: p.sort, Value: value, ID: id})
+}
+
+// compareValues compares two values of a sort field, the numbers decoded from a cursor
+// are float64 while the ones of the documents are uint.
+func compareValues(a, b interface{}) int {
+	if as, ok := a.(string); ok {
+		bs, _ := b.(string)
+
+		return strings.Compare(as, bs)
+	}
+
+	af, bf := toFloat(a), toFloat(b)
+
+	switch {
+	case af < bf:
+		return -1
+	case af > bf:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func toFloat(v interface{}) float64 {
+	switch n := v.(type) {
+	case uint:
+		return float64(n)
+	case float64:
+		return n
+	default:
+		return 0
+	}
+}
+
+// compare compares two items by the sort of the page and then by id.
+func (p *page) compare(av interface{}, aid string, bv interface{}, bid string) int {
+	c := compareValues(av, bv)
+	if c == 0 {
+		c = strings.Compare(aid, bid)
+	}
+
+	if p.desc {
+		return -c
+	}
+
+	return c
+}
+
+// afterCursor reports if the item goes after the cursor.
+func (p *page) afterCursor(value interface{}, id string) bool {
+	return p.cursor == nil || p.compare(value, id, p.cursor.Value, p.cursor.ID) > 0
+}
+
+// paginate sorts the items by the page using their values and ids and returns the
+// positions of the items of the page and the cursor of the next one.
+func (p *page) paginate(values []interface{}, ids []string) ([]int, string) {
+	positions := make([]int, 0, len(values))
+
+	for i := range values {
+		if p.afterCursor(values[i], ids[i]) {
+			positions = append(positions, i)
+		}
+	}
+
+	sort.Slice(positions, func(i, j int) bool {
+		a, b := positions[i], positions[j]
+
+		return p.compare(values[a], ids[a], values[b], ids[b]) < 0
+	})
+
+	if len(positions) <= p.limit {
+		return positions, ""
+	}
+
+	positions = positions[:p.limit]
+	last := positions[len(positions)-1]
+
+	return positions, p.next(values[last], ids[last])
+}
+
+// mongoSort returns the sort of the page for MongoDB.
+func (p *page) mongoSort() bson.D {
+	dir := 1
+	if p.desc {
+		dir = -1
+	}
+
+	if p.field.key == "_id" {
+		return bson.D{{Key: "_id", Value: dir}}
+	}
+
+	return bson.D{{Key: p.field.key, Value: dir}, {Key: "_id", Value: dir}}
+}
+
+// mongoCursor returns the condition of the items after the cursor for MongoDB, the empty
+// strings and the zero numbers match the missing fields too since they are omitted in the
+// documents, e.g. the penalty of the treasures.
+func (p *page) mongoCursor() bson.M {
+	op := "$gt"
+	if p.desc {
+		op = "$lt"
+	}
+
+	id, _ := primitive.ObjectIDFromHex(p.cursor.ID)
+
+	if p.field.key == "_id" {
+		return bson.M{"_id": bson.M{op: id}}
+	}
+
+	zero := p.cursor.Value == "" || p.cursor.Value == float64(0)
+
+	var equal interface{} = p.cursor.Value
+	if zero {
+		equal = bson.M{"$in": bson.A{nil, p.cursor.Value}}
+	}
+
+	after := bson.A{
+		bson.M{p.field.key: bson.M{op: p.cursor.Value}},
+		bson.M{p.field.key: equal, "_id": bson.M{op: id}},
+	}
+
+	// the missing fields are the smallest values but MongoDB doesn't compare them with
+	// $lt, so they are added to the descending pages
+	if p.desc && !zero {
+		after = append(after, bson.M{p.field.key: nil})
+	}
+
+	return bson.M{"$or": after}
+}
+
+// validate checks the filters of the query.
+func (sq *SpotQuery) validate() error {
+	ve := &ValidationError{Entity: "spot"}
+
+	if sq.Kind != "" && !isValidSpotKind(sq.Kind) {
+		ve.add("kind", "must be one of %s, %s, %s, %s and %s", Entrance, Exit, Treasure, Trap, Wall)
+	}
+
+	if sq.MinGold != nil && sq.MaxGold != nil && *sq.MinGold > *sq.MaxGold {
+		ve.add("max_gold", "must be greater than or equal to min_gold")
+	}
+
+	if sq.Box != nil {
+		if err := validateBox(sq.Box); err != nil {
+			return err
+		}
+	}
+
+	return ve.err()
+}
+
+// matches reports if the spot satisfies the query.
+func (sq *SpotQuery) matches(s *Spot) bool {
+	switch {
+	case sq.MazeID != "" && sq.MazeID != s.MazeID,
+		sq.QuadrantID != "" && sq.QuadrantID != s.QuadrantID,
+		sq.Kind != "" && sq.Kind != s.Kind,
+		!strings.HasPrefix(s.Name, sq.NamePrefix),
+		sq.MinGold != nil && s.GoldAmount < *sq.MinGold,
+		sq.MaxGold != nil && s.GoldAmount > *sq.MaxGold,
+		sq.Box != nil && (s.Coordinate == nil || !inBox(*s.Coordinate, sq.Box)):
+		return false
+	default:
+		return true
+	}
+}
+
+func (sq *SpotQuery) toMongoFilter() bson.M {
+	filter := bson.M{}
+
+	if sq.MazeID != "" {
+		filter["maze_id"] = sq.MazeID
+	}
+
+	if sq.QuadrantID != "" {
+		filter["quadrant_id"] = sq.QuadrantID
+	}
+
+	if sq.Kind != "" {
+		filter["kind"] = sq.Kind
+	}
+
+	if sq.NamePrefix != "" {
+		filter["name"] = bson.M{"$regex": "^" + regexp.QuoteMeta(sq.NamePrefix)}
+	}
+
+	gold := bson.M{}
+
+	if sq.MinGold != nil {
+		gold["$gte"] = *sq.MinGold
+	}
+
+	if sq.MaxGold != nil {
+		gold["$lte"] = *sq.MaxGold
+	}
+
+	if len(gold) > 0 {
+		filter["gold_amount"] = gold
+	}
+
+	if sq.Box != nil {
+		filter["Coordinate.x"] = bson.M{"$gte": sq.Box.StartPoint.X, "$lt": sq.Box.LimitPoint.X}
+		filter["Coordinate.y"] = bson.M{"$gte": sq.Box.StartPoint.Y, "$lt": sq.Box.LimitPoint.Y}
+	}
+
+	return filter
+}
+
+// validate checks the filters of the query.
+func (qq *QuadrantQuery) validate() error {
+	ve := &ValidationError{Entity: "quadrant"}
+
+	if qq.Type != "" && !isValidQuadrantType(qq.Type) {
+		ve.add("type", "must be one of %s, %s, %s and %s", TopLeft, TopRight, BottomLeft, BottomRight)
+	}
+
+	if qq.Box != nil {
+		if err := validateBox(qq.Box); err != nil {
+			return err
+		}
+	}
+
+	return ve.err()
+}
+
+// matches reports if the quadrant satisfies the query.
+func (qq *QuadrantQuery) matches(q *Quadrant) bool {
+	switch {
+	case qq.MazeID != "" && qq.MazeID != q.MazeID,
+		qq.Type != "" && qq.Type != q.Type:
+		return false
+	case qq.Box != nil:
+		return q.StartPoint != nil && q.LimitPoint != nil &&
+			q.overlaps(&Quadrant{StartPoint: &qq.Box.StartPoint, LimitPoint: &qq.Box.LimitPoint})
+	default:
+		return true
+	}
+}
+
+func (qq *QuadrantQuery) toMongoFilter() bson.M {
+	filter := bson.M{}
+
+	if qq.MazeID != "" {
+		filter["maze_id"] = qq.MazeID
+	}
+
+	if qq.Type != "" {
+		filter["type"] = qq.Type
+	}
+
+	if qq.Box != nil {
+		filter["start_point.x"] = bson.M{"$lt": qq.Box.LimitPoint.X}
+		filter["start_point.y"] = bson.M{"$lt": qq.Box.LimitPoint.Y}
+		filter["limit_point.x"] = bson.M{"$gt": qq.Box.StartPoint.X}
+		filter["limit_point.y"] = bson.M{"$gt": qq.Box.StartPoint.Y}
+	}
+
+	return filter
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestPage_mongoCursor(t *testing.T) {
+	id := primitive.NewObjectID()
+
+	cursor := func(sort string, value interface{}) bson.M {
+		p, err := newPage("spot", &ListOptions{
+			Sort:   sort,
+			Cursor: encodeCursor(&pageCursor{Sort: sort, Value: value, ID: id.Hex()}),
+		}, spotSortFields)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return p.mongoCursor()
+	}
+
+	// the zero penalties are omitted, so the spots without one are tied with the cursor
+	assert.Equal(t, bson.M{"$or": bson.A{
+		bson.M{"penalty": bson.M{"$gt": float64(0)}},
+		bson.M{"penalty": bson.M{"$in": bson.A{nil, float64(0)}}, "_id": bson.M{"$gt": id}},
+	}}, cursor("penalty", 0))
+
+	assert.Equal(t, bson.M{"$or": bson.A{
+		bson.M{"name": bson.M{"$gt": "gold"}},
+		bson.M{"name": "gold", "_id": bson.M{"$gt": id}},
+	}}, cursor("name", "gold"))
+
+	// in descending order the spots without penalty go after the rest
+	assert.Equal(t, bson.M{"$or": bson.A{
+		bson.M{"penalty": bson.M{"$lt": float64(3)}},
+		bson.M{"penalty": float64(3), "_id": bson.M{"$lt": id}},
+		bson.M{"penalty": nil},
+	}}, cursor("-penalty", 3))
+
+	assert.Equal(t, bson.M{"_id": bson.M{"$lt": id}}, cursor("-id", id.Hex()))
+}
+
+func TestPage_mongoSort(t *testing.T) {
+	p, err := newPage("spot", &ListOptions{Sort: "-gold_amount"}, spotSortFields)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the ties are sorted by id in the same direction
+	assert.Equal(t, bson.D{{Key: "gold_amount", Value: -1}, {Key: "_id", Value: -1}}, p.mongoSort())
+
+	// the sort by id doesn't repeat the key
+	p, err = newPage("quadrant", nil, quadrantSortFields)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, bson.D{{Key: "_id", Value: 1}}, p.mongoSort())
+}
+
+func TestPage_mongoFilters(t *testing.T) {
+	min, max := uint(5), uint(10)
+
+	sq := &SpotQuery{
+		MazeID:     "maze",
+		Kind:       Trap,
+		NamePrefix: "a.b",
+		MinGold:    &min,
+		MaxGold:    &max,
+		Box:        &Region{StartPoint: Coordinate{X: 1, Y: 2}, LimitPoint: Coordinate{X: 3, Y: 4}},
+	}
+
+	assert.Equal(t, bson.M{
+		"maze_id":      "maze",
+		"kind":         Trap,
+		"name":         bson.M{"$regex": `^a\.b`},
+		"gold_amount":  bson.M{"$gte": min, "$lte": max},
+		"Coordinate.x": bson.M{"$gte": uint(1), "$lt": uint(3)},
+		"Coordinate.y": bson.M{"$gte": uint(2), "$lt": uint(4)},
+	}, sq.toMongoFilter())
+
+	assert.Equal(t, bson.M{}, (&SpotQuery{}).toMongoFilter())
+
+	// the quadrants that overlap the box
+	qq := &QuadrantQuery{
+		Type: BottomRight,
+		Box:  &Region{StartPoint: Coordinate{X: 1, Y: 2}, LimitPoint: Coordinate{X: 3, Y: 4}},
+	}
+
+	assert.Equal(t, bson.M{
+		"type":          BottomRight,
+		"start_point.x": bson.M{"$lt": uint(3)},
+		"start_point.y": bson.M{"$lt": uint(4)},
+		"limit_point.x": bson.M{"$gt": uint(1)},
+		"limit_point.y": bson.M{"$gt": uint(2)},
+	}, qq.toMongoFilter())
+}
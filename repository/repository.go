@@ -1,33 +1,124 @@
 package repository
 
 import (
+	"context"
+
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
 // nolint
 // mongoService represents a type for each service created, so all the servies like
 // device in device.go must implement this type.
 type mongoService struct {
-	db *mongo.Client
+	db   *mongo.Client
+	repo *Repository
 }
 
-// MongoDBService represenst the MongoDBService that contains services created.
-// Note: If you has been created a new service it must be listed in this struct.
-type MongoDBService struct {
-	Quadrant QuadrantMongoDBService
-	Spot     SpotMongoDBService
+// Repository represents the storage that contains the stores created, no matter what
+// backend is used to save the data.
+// Note: If you has been created a new store it must be listed in this struct.
+type Repository struct {
+	Maze     MazeStore
+	Quadrant QuadrantStore
+	Spot     SpotStore
+	Grid     GridStore
+
+	// Idempotency keeps the responses of the requests sent with an idempotency key.
+	Idempotency IdempotencyStore
+
+	backend backend
 }
 
-// New creates a new MongoDBService with all services in it
+// backend represents the lifecycle of the connection used by the stores.
+type backend interface {
+	ping(ctx context.Context) error
+	close(ctx context.Context) error
+	migrate(ctx context.Context) error
+	transaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// New creates a new Repository backed by MongoDB with all services in it
 // Note: If you has been created a new service it must be listed in this struct.
-func New(db *mongo.Client) *MongoDBService {
-	return &MongoDBService{
-		Quadrant: &QuadrantService{db: db},
-		Spot:     &SpotService{db: db},
-	}
+func New(db *mongo.Client) *Repository {
+	repo := &Repository{backend: &mongoBackend{db: db}}
+
+	repo.Maze = &MazeService{db: db, repo: repo}
+	repo.Quadrant = &QuadrantService{db: db, repo: repo}
+	repo.Spot = &SpotService{db: db, repo: repo}
+	repo.Grid = &GridService{db: db, repo: repo}
+	repo.Idempotency = &IdempotencyService{db: db, repo: repo}
+
+	return repo
 }
 
-// NewByConnString creates a new MongoDBService using a connection string.
-func NewByConnString(connString string) *MongoDBService {
+// NewByConnString creates a new Repository backed by MongoDB using a connection string.
+func NewByConnString(connString string) *Repository {
 	return New(NewMongoDBConn(connString))
 }
+
+// OpenMongoDB creates a new Repository backed by MongoDB using the config, it is meant to
+// be called once when the application starts and the returned repository must be shared
+// and closed with Close when the application stops.
+func OpenMongoDB(ctx context.Context, cfg *MongoDBConfig) (*Repository, error) {
+	client, err := NewMongoDBClient(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := New(client)
+
+	if err := repo.Ping(ctx); err != nil {
+		_ = client.Disconnect(ctx)
+
+		return nil, err
+	}
+
+	return repo, nil
+}
+
+// Ping checks that the storage can be reached.
+func (r *Repository) Ping(ctx context.Context) error {
+	return r.backend.ping(ctx)
+}
+
+// Close releases the resources used by the storage, e.g. the connections of the pool.
+func (r *Repository) Close(ctx context.Context) error {
+	return r.backend.close(ctx)
+}
+
+// Transaction runs fn in a transaction, the calls to the stores made with the context
+// given to fn are committed together when it returns without errors, otherwise none of
+// them is kept.
+func (r *Repository) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return r.backend.transaction(ctx, fn)
+}
+
+// mongoBackend represents the lifecycle of the MongoDB client.
+type mongoBackend struct {
+	db *mongo.Client
+}
+
+func (mb *mongoBackend) ping(ctx context.Context) error {
+	if mb.db == nil {
+		return ErrNoConnection
+	}
+
+	if err := mb.db.Ping(ctx, readpref.Primary()); err != nil {
+		return &UnavailableError{Err: err}
+	}
+
+	return nil
+}
+
+func (mb *mongoBackend) close(ctx context.Context) error {
+	if mb.db == nil {
+		return nil
+	}
+
+	return mb.db.Disconnect(ctx)
+}
+
+func (mb *mongoBackend) transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return mongoTransaction(ctx, mb.db, fn)
+}
@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GridStore defines the interface that each grid backend must satisfy.
+type GridStore interface {
+	Get(ctx context.Context, gf *GridFilter) (g *Grid, err error)
+	Put(ctx context.Context, g *Grid) (err error)
+	Cells(ctx context.Context, gf *GridFilter, r *Region) (cells []Cell, err error)
+	SetCells(ctx context.Context, gf *GridFilter, cells []Cell) (err error)
+	Delete(ctx context.Context, gf *GridFilter) (isRemoved bool, err error)
+}
+
+// GridService represents a mongoServie that contains the MongoDB client.
+type GridService mongoService
+
+// GridService validate if it satisfy the own interface, that means
+// that all mongoService can be implement its own interface but it must be
+// a mongoService type.
+var _ GridStore = &GridService{}
+
+// GridFilter represents the filter that can be used to create a mongo query, each maze
+// has one grid so it is found by the maze id.
+type GridFilter struct {
+	MazeID string `json:"maze_id,omitempty"`
+}
+
+func (gf *GridFilter) validate() error {
+	if gf == nil {
+		return errors.New("grid filter must not be nil")
+	}
+
+	ve := &ValidationError{Entity: "grid"}
+
+	if gf.MazeID == "" {
+		ve.add("maze_id", "must be specified")
+	} else if _, err := primitive.ObjectIDFromHex(gf.MazeID); err != nil {
+		ve.add("maze_id", "must be a valid id")
+	}
+
+	return ve.err()
+}
+
+func (gf *GridFilter) toMongoFilter() (bson.M, error) {
+	if err := gf.validate(); err != nil {
+		return nil, err
+	}
+
+	return bson.M{"_id": gf.MazeID}, nil
+}
+
+// setCells sets the cells in the grid and returns the rows that were changed.
+func (g *Grid) setCells(cells []Cell) ([]uint, error) {
+	var (
+		rows    = make([]uint, 0)
+		changed = make(map[uint]bool)
+	)
+
+	for i := range cells {
+		if err := g.SetCell(cells[i]); err != nil {
+			return nil, err
+		}
+
+		// the south wall of a cell is kept by the cell below it
+		for _, y := range []uint{cells[i].Y, cells[i].Y + 1} {
+			if y < g.Height && !changed[y] {
+				changed[y] = true
+				rows = append(rows, y)
+			}
+		}
+	}
+
+	return rows, nil
+}
+
+// Get gets the grid of a maze.
+func (gs *GridService) Get(ctx context.Context, gf *GridFilter) (*Grid, error) {
+	filter, err := gf.toMongoFilter()
+	if err != nil {
+		return nil, err
+	}
+
+	grid := &Grid{}
+
+	err = gs.db.Database(DBName(ctx)).Collection("grids").FindOne(ctx, filter).Decode(grid)
+	if err != nil {
+		return nil, notFound("grid", gf.MazeID, err)
+	}
+
+	return grid, nil
+}
+
+// Put creates or replaces the grid of a maze.
+func (gs *GridService) Put(ctx context.Context, g *Grid) error {
+	if err := g.validate(); err != nil {
+		return err
+	}
+
+	filter, err := (&GridFilter{MazeID: g.MazeID}).toMongoFilter()
+	if err != nil {
+		return err
+	}
+
+	if err := gs.repo.checkUnpublished(ctx, g.MazeID); err != nil {
+		return err
+	}
+
+	_, err = gs.db.Database(DBName(ctx)).Collection("grids").ReplaceOne(ctx, filter, g,
+		options.Replace().SetUpsert(true),
+	)
+
+	if err != nil {
+		return fmt.Errorf("saving the grid: %w", err)
+	}
+
+	return nil
+}
+
+// Cells gets the cells of a region of the grid, when the region is nil all the cells
+// are returned.
+func (gs *GridService) Cells(ctx context.Context, gf *GridFilter, r *Region) ([]Cell, error) {
+	g, err := gs.Get(ctx, gf)
+	if err != nil {
+		return nil, err
+	}
+
+	return g.Cells(r)
+}
+
+// SetCells sets the walls of the cells, since the neighbour cells share their walls the
+// last cell given wins when two of them disagree.
+func (gs *GridService) SetCells(ctx context.Context, gf *GridFilter, cells []Cell) error {
+	filter, err := gf.toMongoFilter()
+	if err != nil {
+		return err
+	}
+
+	// only the rows that were changed are written
+	return mongoTransaction(ctx, gs.db, func(ctx context.Context) error {
+		if err := gs.repo.checkUnpublished(ctx, gf.MazeID); err != nil {
+			return err
+		}
+
+		g, err := gs.Get(ctx, gf)
+		if err != nil {
+			return err
+		}
+
+		rows, err := g.setCells(cells)
+		if err != nil {
+			return err
+		}
+
+		if len(rows) == 0 {
+			return nil
+		}
+
+		set := bson.M{}
+
+		for _, y := range rows {
+			set[fmt.Sprintf("rows.%d", y)] = g.Rows[y]
+		}
+
+		_, err = gs.db.Database(DBName(ctx)).Collection("grids").UpdateOne(ctx, filter, bson.M{"$set": set})
+
+		return err
+	})
+}
+
+// Delete deletes the grid of a maze.
+func (gs *GridService) Delete(ctx context.Context, gf *GridFilter) (bool, error) {
+	filter, err := gf.toMongoFilter()
+	if err != nil {
+		return false, err
+	}
+
+	res, err := gs.db.Database(DBName(ctx)).Collection("grids").DeleteOne(ctx, filter)
+	if err != nil {
+		return false, err
+	}
+
+	return res.DeletedCount > 0, nil
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+)
+
+// GridKVService represents a kvService that contains the key/value storage.
+type GridKVService kvService
+
+// GridKVService validate if it satisfy the GridStore interface.
+var _ GridStore = &GridKVService{}
+
+// Get gets the grid of a maze.
+func (gs *GridKVService) Get(ctx context.Context, gf *GridFilter) (*Grid, error) {
+	if err := gf.validate(); err != nil {
+		return nil, err
+	}
+
+	grid := &Grid{}
+
+	err := kvView(ctx, gs.db, func(ctx context.Context, tx kvTx) error {
+		return kvGet(tx, gridsCollection, gf.MazeID, grid)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return grid, nil
+}
+
+// Put creates or replaces the grid of a maze.
+func (gs *GridKVService) Put(ctx context.Context, g *Grid) error {
+	if err := g.validate(); err != nil {
+		return err
+	}
+
+	if err := (&GridFilter{MazeID: g.MazeID}).validate(); err != nil {
+		return err
+	}
+
+	err := kvUpdate(ctx, gs.db, func(ctx context.Context, tx kvTx) error {
+		if err := gs.repo.checkUnpublished(ctx, g.MazeID); err != nil {
+			return err
+		}
+
+		return kvPut(tx, gridsCollection, g.MazeID, g)
+	})
+
+	if err != nil {
+		return fmt.Errorf("saving the grid: %w", err)
+	}
+
+	return nil
+}
+
+// Cells gets the cells of a region of the grid, when the region is nil all the cells
+// are returned.
+func (gs *GridKVService) Cells(ctx context.Context, gf *GridFilter, r *Region) ([]Cell, error) {
+	g, err := gs.Get(ctx, gf)
+	if err != nil {
+		return nil, err
+	}
+
+	return g.Cells(r)
+}
+
+// SetCells sets the walls of the cells, since the neighbour cells share their walls the
+// last cell given wins when two of them disagree.
+func (gs *GridKVService) SetCells(ctx context.Context, gf *GridFilter, cells []Cell) error {
+	if err := gf.validate(); err != nil {
+		return err
+	}
+
+	return kvUpdate(ctx, gs.db, func(ctx context.Context, tx kvTx) error {
+		if err := gs.repo.checkUnpublished(ctx, gf.MazeID); err != nil {
+			return err
+		}
+
+		g := &Grid{}
+
+		if err := kvGet(tx, gridsCollection, gf.MazeID, g); err != nil {
+			return err
+		}
+
+		if _, err := g.setCells(cells); err != nil {
+			return err
+		}
+
+		return kvPut(tx, gridsCollection, g.MazeID, g)
+	})
+}
+
+// Delete deletes the grid of a maze.
+func (gs *GridKVService) Delete(ctx context.Context, gf *GridFilter) (bool, error) {
+	if err := gf.validate(); err != nil {
+		return false, err
+	}
+
+	var isRemoved bool
+
+	err := kvUpdate(ctx, gs.db, func(ctx context.Context, tx kvTx) error {
+		raw, err := tx.get(gridsCollection, gf.MazeID)
+		if err != nil || raw == nil {
+			return err
+		}
+
+		isRemoved = true
+
+		return tx.delete(gridsCollection, gf.MazeID)
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	return isRemoved, nil
+}
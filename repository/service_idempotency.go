@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// IdempotencyStore defines the interface that each backend must satisfy to keep the
+// responses of the requests sent with an idempotency key.
+type IdempotencyStore interface {
+	Reserve(ctx context.Context, r *IdempotencyRecord) (stored *IdempotencyRecord, err error)
+	Complete(ctx context.Context, r *IdempotencyRecord) (err error)
+	Release(ctx context.Context, key string) (err error)
+	Sweep(ctx context.Context) (removed int, err error)
+}
+
+// IdempotencyService represents a mongoServie that contains the MongoDB client.
+type IdempotencyService mongoService
+
+// IdempotencyService validate if it satisfy the own interface, that means
+// that all mongoService can be implement its own interface but it must be
+// a mongoService type.
+var _ IdempotencyStore = &IdempotencyService{}
+
+// IdempotencyRecord represents a request sent with an idempotency key, it is reserved
+// before the request is handled and completed with its response, which is replayed to the
+// retries of the request until the record expires. The fingerprint identifies the request,
+// e.g. a hash of its method, path and body, so the key can't be reused by another one.
+type IdempotencyRecord struct {
+	Key         string            `bson:"_id"`
+	Fingerprint string            `bson:"fingerprint"`
+	Status      int               `bson:"status"`
+	Header      map[string]string `bson:"header,omitempty"`
+	Body        []byte            `bson:"body,omitempty"`
+	ExpiresAt   time.Time         `bson:"expires_at"`
+}
+
+// Completed reports if the response of the request was stored, otherwise the request is
+// still being handled.
+func (ir *IdempotencyRecord) Completed() bool {
+	return ir.Status != 0
+}
+
+func (ir *IdempotencyRecord) validate() error {
+	if ir == nil {
+		return errors.New("idempotency record must not be nil")
+	}
+
+	if ir.Key == "" {
+		return errors.New("the IdempotencyRecord.Key attribute must be specified")
+	}
+
+	if ir.ExpiresAt.IsZero() {
+		return errors.New("the IdempotencyRecord.ExpiresAt attribute must be specified")
+	}
+
+	return nil
+}
+
+// expired reports if the record can't be replayed anymore at the time.
+func (ir *IdempotencyRecord) expired(now time.Time) bool {
+	return !now.Before(ir.ExpiresAt)
+}
+
+// replay returns the stored record when the reserved one can be answered with it, a
+// request with another fingerprint or a request whose first attempt hasn't finished yet
+// are IdempotencyErrors.
+func (ir *IdempotencyRecord) replay(stored *IdempotencyRecord) (*IdempotencyRecord, error) {
+	if stored.Fingerprint != ir.Fingerprint {
+		return nil, &IdempotencyError{Key: ir.Key}
+	}
+
+	if !stored.Completed() {
+		return nil, &IdempotencyError{Key: ir.Key, InProgress: true}
+	}
+
+	return stored, nil
+}
+
+// Reserve stores the record of a new request, when the key was already used by a record
+// that didn't expire the stored one is returned so its response can be replayed.
+func (is *IdempotencyService) Reserve(ctx context.Context, r *IdempotencyRecord) (*IdempotencyRecord, error) {
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+
+	r.Status = 0
+	keys := is.db.Database(DBName(ctx)).Collection("idempotency_keys")
+
+	_, err := keys.InsertOne(ctx, r)
+
+	switch {
+	case err == nil:
+		return nil, nil
+	case !isDuplicateKey(err):
+		return nil, fmt.Errorf("reserving the idempotency key: %w", err)
+	}
+
+	stored := &IdempotencyRecord{}
+
+	if err := keys.FindOne(ctx, bson.M{"_id": r.Key}).Decode(stored); err != nil {
+		return nil, fmt.Errorf("finding the idempotency key: %w", err)
+	}
+
+	if !stored.expired(time.Now()) {
+		return r.replay(stored)
+	}
+
+	// the TTL index removes the expired records about once a minute, in the meantime the
+	// key is taken over unless another request did it first
+	res, err := keys.ReplaceOne(ctx, bson.M{"_id": r.Key, "expires_at": stored.ExpiresAt}, r)
+	if err != nil {
+		return nil, fmt.Errorf("reserving the idempotency key: %w", err)
+	}
+
+	if res.MatchedCount == 0 {
+		return nil, &IdempotencyError{Key: r.Key, InProgress: true}
+	}
+
+	return nil, nil
+}
+
+// Complete stores the response of the reserved record.
+func (is *IdempotencyService) Complete(ctx context.Context, r *IdempotencyRecord) error {
+	if err := r.validate(); err != nil {
+		return err
+	}
+
+	res, err := is.db.Database(DBName(ctx)).Collection("idempotency_keys").ReplaceOne(ctx,
+		bson.M{"_id": r.Key, "fingerprint": r.Fingerprint, "status": 0},
+		r,
+	)
+
+	if err != nil {
+		return fmt.Errorf("saving the idempotent response: %w", err)
+	}
+
+	if res.MatchedCount == 0 {
+		return &NotFoundError{Entity: "idempotency key", ID: r.Key}
+	}
+
+	return nil
+}
+
+// Release removes the record of a request that wasn't completed, so it can be retried
+// with the same key.
+func (is *IdempotencyService) Release(ctx context.Context, key string) error {
+	_, err := is.db.Database(DBName(ctx)).Collection("idempotency_keys").DeleteOne(ctx,
+		bson.M{"_id": key, "status": 0},
+	)
+
+	if err != nil {
+		return fmt.Errorf("releasing the idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// Sweep removes the expired records, the TTL index already does it about once a minute so
+// it only removes the ones it didn't reach yet.
+func (is *IdempotencyService) Sweep(ctx context.Context) (int, error) {
+	res, err := is.db.Database(DBName(ctx)).Collection("idempotency_keys").DeleteMany(ctx,
+		bson.M{"expires_at": bson.M{"$lte": time.Now()}},
+	)
+
+	if err != nil {
+		return 0, fmt.Errorf("removing the expired idempotency keys: %w", err)
+	}
+
+	return int(res.DeletedCount), nil
+}
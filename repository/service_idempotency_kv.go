@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// IdempotencyKVService represents a kvService that contains the key/value storage.
+type IdempotencyKVService kvService
+
+// IdempotencyKVService validate if it satisfy the IdempotencyStore interface.
+var _ IdempotencyStore = &IdempotencyKVService{}
+
+// Reserve stores the record of a new request, when the key was already used by a record
+// that didn't expire the stored one is returned so its response can be replayed. The
+// key/value storages don't expire the documents by themselves, so an expired record is
+// replaced by the reservation of its key and the rest are removed by Sweep.
+func (is *IdempotencyKVService) Reserve(ctx context.Context, r *IdempotencyRecord) (*IdempotencyRecord, error) {
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+
+	r.Status = 0
+
+	var stored *IdempotencyRecord
+
+	err := kvUpdate(ctx, is.db, func(ctx context.Context, tx kvTx) error {
+		raw, err := tx.get(idempotencyCollection, r.Key)
+		if err != nil {
+			return err
+		}
+
+		if raw != nil {
+			doc := &IdempotencyRecord{}
+
+			if err := bson.Unmarshal(raw, doc); err != nil {
+				return err
+			}
+
+			if !doc.expired(time.Now()) {
+				stored = doc
+
+				return nil
+			}
+		}
+
+		return kvPut(tx, idempotencyCollection, r.Key, r)
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("reserving the idempotency key: %w", err)
+	}
+
+	if stored != nil {
+		return r.replay(stored)
+	}
+
+	return nil, nil
+}
+
+// Complete stores the response of the reserved record.
+func (is *IdempotencyKVService) Complete(ctx context.Context, r *IdempotencyRecord) error {
+	if err := r.validate(); err != nil {
+		return err
+	}
+
+	return kvUpdate(ctx, is.db, func(ctx context.Context, tx kvTx) error {
+		stored := &IdempotencyRecord{}
+
+		if err := kvGet(tx, idempotencyCollection, r.Key, stored); err != nil {
+			return err
+		}
+
+		if stored.Fingerprint != r.Fingerprint || stored.Completed() {
+			return &NotFoundError{Entity: "idempotency key", ID: r.Key}
+		}
+
+		if err := kvPut(tx, idempotencyCollection, r.Key, r); err != nil {
+			return fmt.Errorf("saving the idempotent response: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// Release removes the record of a request that wasn't completed, so it can be retried
+// with the same key.
+func (is *IdempotencyKVService) Release(ctx context.Context, key string) error {
+	return kvUpdate(ctx, is.db, func(ctx context.Context, tx kvTx) error {
+		raw, err := tx.get(idempotencyCollection, key)
+		if err != nil || raw == nil {
+			return err
+		}
+
+		stored := &IdempotencyRecord{}
+
+		if err := bson.Unmarshal(raw, stored); err != nil {
+			return err
+		}
+
+		if stored.Completed() {
+			return nil
+		}
+
+		if err := tx.delete(idempotencyCollection, key); err != nil {
+			return fmt.Errorf("releasing the idempotency key: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// Sweep removes the expired records, the keys are collected before they are deleted.
+func (is *IdempotencyKVService) Sweep(ctx context.Context) (int, error) {
+	var expired []string
+
+	err := kvUpdate(ctx, is.db, func(ctx context.Context, tx kvTx) error {
+		now := time.Now()
+
+		err := tx.forEach(idempotencyCollection, func(key string, raw []byte) error {
+			doc := &IdempotencyRecord{}
+
+			if err := bson.Unmarshal(raw, doc); err == nil && doc.expired(now) {
+				expired = append(expired, key)
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			return err
+		}
+
+		for _, key := range expired {
+			if err := tx.delete(idempotencyCollection, key); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return 0, fmt.Errorf("removing the expired idempotency keys: %w", err)
+	}
+
+	return len(expired), nil
+}
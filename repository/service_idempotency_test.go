@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotency_ReserveAndComplete(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, ctx context.Context, conn *Repository) {
+		record := &IdempotencyRecord{
+			Key:         "create-" + time.Now().Format(time.RFC3339Nano),
+			Fingerprint: "first",
+			ExpiresAt:   time.Now().Add(time.Hour),
+		}
+
+		stored, err := conn.Idempotency.Reserve(ctx, record)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Nil(t, stored)
+
+		var ie *IdempotencyError
+
+		// the retries of a request that is being handled must wait
+		_, err = conn.Idempotency.Reserve(ctx, &IdempotencyRecord{Key: record.Key, Fingerprint: "first", ExpiresAt: record.ExpiresAt})
+		assert.True(t, errors.As(err, &ie))
+		assert.True(t, ie.InProgress)
+		assert.Equal(t, CodeConflict, Code(err))
+
+		record.Status = 201
+		record.Header = map[string]string{"Content-Type": "application/json"}
+		record.Body = []byte(`{"id":"1"}`)
+
+		if err := conn.Idempotency.Complete(ctx, record); err != nil {
+			t.Fatal(err)
+		}
+
+		stored, err = conn.Idempotency.Reserve(ctx, &IdempotencyRecord{Key: record.Key, Fingerprint: "first", ExpiresAt: record.ExpiresAt})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, 201, stored.Status)
+		assert.Equal(t, record.Header, stored.Header)
+		assert.Equal(t, record.Body, stored.Body)
+
+		_, err = conn.Idempotency.Reserve(ctx, &IdempotencyRecord{Key: record.Key, Fingerprint: "second", ExpiresAt: record.ExpiresAt})
+		assert.True(t, errors.As(err, &ie))
+		assert.False(t, ie.InProgress)
+		assert.Equal(t, CodeIdempotencyKeyReused, Code(err))
+
+		// the completed records are kept
+		if err := conn.Idempotency.Release(ctx, record.Key); err != nil {
+			t.Fatal(err)
+		}
+
+		stored, err = conn.Idempotency.Reserve(ctx, &IdempotencyRecord{Key: record.Key, Fingerprint: "first", ExpiresAt: record.ExpiresAt})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.NotNil(t, stored)
+	})
+}
+
+func TestIdempotency_ReleaseAndExpire(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, ctx context.Context, conn *Repository) {
+		key := "delete-" + time.Now().Format(time.RFC3339Nano)
+
+		if _, err := conn.Idempotency.Reserve(ctx, &IdempotencyRecord{
+			Key:         key,
+			Fingerprint: "first",
+			ExpiresAt:   time.Now().Add(time.Hour),
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		// a released key can be reserved by any request
+		if err := conn.Idempotency.Release(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := conn.Idempotency.Reserve(ctx, &IdempotencyRecord{
+			Key:         key,
+			Fingerprint: "second",
+			ExpiresAt:   time.Now().Add(-time.Second),
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		// and so can an expired one
+		stored, err := conn.Idempotency.Reserve(ctx, &IdempotencyRecord{
+			Key:         key,
+			Fingerprint: "third",
+			ExpiresAt:   time.Now().Add(time.Hour),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Nil(t, stored)
+
+		if err := conn.Idempotency.Release(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestIdempotency_Sweep(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, ctx context.Context, conn *Repository) {
+		prefix := "sweep-" + time.Now().Format(time.RFC3339Nano)
+
+		for i, expiresAt := range []time.Time{time.Now().Add(-time.Second), time.Now().Add(-time.Minute), time.Now().Add(time.Hour)} {
+			if _, err := conn.Idempotency.Reserve(ctx, &IdempotencyRecord{
+				Key:         prefix + string(rune('a'+i)),
+				Fingerprint: "first",
+				ExpiresAt:   expiresAt,
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		removed, err := conn.Idempotency.Sweep(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, 2, removed)
+
+		// the record that didn't expire is kept
+		_, err = conn.Idempotency.Reserve(ctx, &IdempotencyRecord{Key: prefix + "c", Fingerprint: "first", ExpiresAt: time.Now().Add(time.Hour)})
+		assert.Equal(t, CodeConflict, Code(err))
+
+		if err := conn.Idempotency.Release(ctx, prefix+"c"); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
@@ -0,0 +1,381 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MazeStore defines the interface that each maze backend must satisfy.
+type MazeStore interface {
+	Create(ctx context.Context, m *Maze) (id string, err error)
+	Get(ctx context.Context, mf *MazeFilter) (m *Maze, err error)
+	List(ctx context.Context) (mazes []Maze, err error)
+	Update(ctx context.Context, mu *Maze) (m *Maze, err error)
+	Delete(ctx context.Context, mf *MazeFilter) (isRemoved bool, err error)
+	Publish(ctx context.Context, mf *MazeFilter, published bool) (m *Maze, err error)
+	Published(ctx context.Context, mf *MazeFilter) (published bool, err error)
+}
+
+// MazeService represents a mongoServie that contains the MongoDB client.
+type MazeService mongoService
+
+// MazeService validate if it satisfy the own interface, that means
+// that all mongoService can be implement its own interface but it must be
+// a mongoService type.
+var _ MazeStore = &MazeService{}
+
+// Maze represents a maze that owns its four quadrants, one per QuadrantType.
+type Maze struct {
+	ID          string     `json:"id,omitempty" bson:"_id,omitempty"`
+	Name        string     `json:"name,omitempty" bson:"name" binding:"required"`
+	Width       uint       `json:"width,omitempty" bson:"width" binding:"required"`
+	Height      uint       `json:"height,omitempty" bson:"height" binding:"required"`
+	QuadrantIDs []string   `json:"quadrant_ids,omitempty" bson:"quadrant_ids"`
+	Quadrants   []Quadrant `json:"quadrants,omitempty" bson:"-"`
+	Published   bool       `json:"published" bson:"published"`
+}
+
+// MazeFilter represents the filter that can be used to create a mongo query.
+type MazeFilter struct {
+	ID string `json:"id,omitempty"`
+}
+
+func (mf *MazeFilter) validate() error {
+	if mf == nil {
+		return errors.New("maze filter must not be nil")
+	}
+
+	ve := &ValidationError{Entity: "maze"}
+
+	if mf.ID == "" {
+		ve.add("id", "must be specified")
+	} else if _, err := primitive.ObjectIDFromHex(mf.ID); err != nil {
+		ve.add("id", "must be a valid id")
+	}
+
+	return ve.err()
+}
+
+func (mf *MazeFilter) toMongoFilter() (bson.M, error) {
+	if err := mf.validate(); err != nil {
+		return nil, err
+	}
+
+	id, _ := primitive.ObjectIDFromHex(mf.ID)
+
+	return bson.M{"_id": id}, nil
+}
+
+// minMazeSide is the minimum width and height of a maze, each quadrant needs at least
+// one column and one row.
+const minMazeSide = 2
+
+// MaxMazeSide is the maximum width and height of a maze, the grid of the maze is kept in
+// memory and in a single document so it must stay small.
+const MaxMazeSide = 1000
+
+func (m *Maze) validate() error {
+	ve := &ValidationError{Entity: "maze"}
+
+	if m.Name == "" {
+		ve.add("name", "must be specified")
+	}
+
+	for _, side := range []struct {
+		name string
+		n    uint
+	}{{"width", m.Width}, {"height", m.Height}} {
+		switch {
+		case side.n < minMazeSide:
+			ve.add(side.name, "must be at least %d", minMazeSide)
+		case side.n > MaxMazeSide:
+			ve.add(side.name, "must be at most %d", MaxMazeSide)
+		}
+	}
+
+	return ve.err()
+}
+
+// NewQuadrants splits the area of the maze into its four quadrants, the start point of
+// each quadrant is inclusive and its limit point is exclusive.
+func (m *Maze) NewQuadrants() []Quadrant {
+	var (
+		halfWidth  = m.Width / 2
+		halfHeight = m.Height / 2
+	)
+
+	newQuadrant := func(qt QuadrantType, startX, startY, limitX, limitY uint) Quadrant {
+		return Quadrant{
+			MazeID:     m.ID,
+			Type:       qt,
+			SpotIDs:    []string{},
+			StartPoint: &Coordinate{X: startX, Y: startY},
+			LimitPoint: &Coordinate{X: limitX, Y: limitY},
+		}
+	}
+
+	return []Quadrant{
+		newQuadrant(TopLeft, 0, 0, halfWidth, halfHeight),
+		newQuadrant(TopRight, halfWidth, 0, m.Width, halfHeight),
+		newQuadrant(BottomLeft, 0, halfHeight, halfWidth, m.Height),
+		newQuadrant(BottomRight, halfWidth, halfHeight, m.Width, m.Height),
+	}
+}
+
+// Create creates a new maze with its four quadrants and a grid without inner walls.
+func (ms *MazeService) Create(ctx context.Context, m *Maze) (string, error) {
+	if err := m.validate(); err != nil {
+		return "", err
+	}
+
+	// the mazes are published once they are complete
+	m.Published = false
+
+	// the maze and its quadrants are written in the same transaction
+	err := mongoTransaction(ctx, ms.db, func(ctx context.Context) error {
+		mazes := ms.db.Database(DBName(ctx)).Collection("mazes")
+
+		doc := *m
+		doc.ID = ""
+		doc.QuadrantIDs = []string{}
+
+		res, err := mazes.InsertOne(ctx, &doc)
+		if err != nil {
+			return fmt.Errorf("inserting a maze: %w", err)
+		}
+
+		m.ID = res.InsertedID.(primitive.ObjectID).Hex()
+		m.QuadrantIDs = make([]string, 0, 4)
+		m.Quadrants = m.NewQuadrants()
+
+		for i := range m.Quadrants {
+			id, err := ms.repo.Quadrant.Create(ctx, &m.Quadrants[i])
+			if err != nil {
+				return fmt.Errorf("creating a quadrant: %w", err)
+			}
+
+			m.QuadrantIDs = append(m.QuadrantIDs, id)
+		}
+
+		if err := ms.repo.Grid.Put(ctx, NewGrid(m.ID, m.Width, m.Height, false)); err != nil {
+			return fmt.Errorf("creating the grid: %w", err)
+		}
+
+		_, err = mazes.UpdateOne(ctx,
+			bson.M{"_id": res.InsertedID},
+			bson.M{"$set": bson.M{"quadrant_ids": m.QuadrantIDs}},
+		)
+
+		return err
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return m.ID, nil
+}
+
+// Get gets a maze with its quadrants.
+func (ms *MazeService) Get(ctx context.Context, mf *MazeFilter) (*Maze, error) {
+	filter, err := mf.toMongoFilter()
+	if err != nil {
+		return nil, err
+	}
+
+	maze := &Maze{}
+
+	err = ms.db.Database(DBName(ctx)).Collection("mazes").FindOne(ctx, filter).Decode(maze)
+	if err != nil {
+		return nil, notFound("maze", mf.ID, err)
+	}
+
+	quadrants, err := ms.repo.Quadrant.List(ctx, &QuadrantFilter{MazeID: maze.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	maze.Quadrants = quadrants
+
+	return maze, nil
+}
+
+// List gets all the mazes without their quadrants.
+func (ms *MazeService) List(ctx context.Context) ([]Maze, error) {
+	cursor, err := ms.db.Database(DBName(ctx)).Collection("mazes").Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("finding all mazes: %w", err)
+	}
+
+	defer cursor.Close(ctx)
+
+	mazes := make([]Maze, 0)
+
+	if err := cursor.All(ctx, &mazes); err != nil {
+		return nil, fmt.Errorf("can't decode mazes: %w", err)
+	}
+
+	return mazes, nil
+}
+
+// Update updates the name of a maze, its dimensions can't be changed since its quadrants
+// are built from them.
+func (ms *MazeService) Update(ctx context.Context, mu *Maze) (*Maze, error) {
+	if mu == nil {
+		return nil, errors.New("maze parameter must be specified")
+	}
+
+	filter := &MazeFilter{ID: mu.ID}
+
+	cm, err := ms.Get(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cm.applyUpdate(mu); err != nil {
+		return nil, err
+	}
+
+	f, err := filter.toMongoFilter()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = ms.db.Database(DBName(ctx)).Collection("mazes").UpdateOne(ctx, f, bson.M{"$set": bson.M{"name": cm.Name}})
+	if err != nil {
+		return nil, err
+	}
+
+	return cm, nil
+}
+
+// applyUpdate sets the attributes of mu that can be updated in the maze.
+func (m *Maze) applyUpdate(mu *Maze) error {
+	ve := &ValidationError{Entity: "maze"}
+
+	if mu.Width != 0 && mu.Width != m.Width {
+		ve.add("width", "can't be updated")
+	}
+
+	if mu.Height != 0 && mu.Height != m.Height {
+		ve.add("height", "can't be updated")
+	}
+
+	if err := ve.err(); err != nil {
+		return err
+	}
+
+	if mu.Name != "" {
+		m.Name = mu.Name
+	}
+
+	return nil
+}
+
+// Publish marks the maze as published or unpublished, the published attribute can only
+// be changed here so the callers can check the maze before.
+func (ms *MazeService) Publish(ctx context.Context, mf *MazeFilter, published bool) (*Maze, error) {
+	cm, err := ms.Get(ctx, mf)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := mf.toMongoFilter()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = ms.db.Database(DBName(ctx)).Collection("mazes").UpdateOne(ctx, f, bson.M{"$set": bson.M{"published": published}})
+	if err != nil {
+		return nil, err
+	}
+
+	cm.Published = published
+
+	return cm, nil
+}
+
+// Published reports if the maze is published, only its published attribute is read.
+func (ms *MazeService) Published(ctx context.Context, mf *MazeFilter) (bool, error) {
+	filter, err := mf.toMongoFilter()
+	if err != nil {
+		return false, err
+	}
+
+	maze := &Maze{}
+	opts := options.FindOne().SetProjection(bson.M{"published": 1})
+
+	err = ms.db.Database(DBName(ctx)).Collection("mazes").FindOne(ctx, filter, opts).Decode(maze)
+	if err != nil {
+		return false, notFound("maze", mf.ID, err)
+	}
+
+	return maze.Published, nil
+}
+
+// checkUnpublished fails when the maze is published, it was validated as it is so its
+// spots, walls and quadrants can't change until it is unpublished. The spots without
+// maze and the deletion of the maze are always allowed.
+func (r *Repository) checkUnpublished(ctx context.Context, mazeID string) error {
+	if mazeID == "" || isMazeDeletion(ctx) {
+		return nil
+	}
+
+	published, err := r.Maze.Published(ctx, &MazeFilter{ID: mazeID})
+
+	var nf *NotFoundError
+	if errors.As(err, &nf) {
+		return nil
+	}
+
+	if err != nil || !published {
+		return err
+	}
+
+	ce := &ConflictError{Entity: "maze"}
+	ce.add("published", "the maze %s must be unpublished before changing its spots, walls or quadrants", mazeID)
+
+	return ce.err()
+}
+
+// Delete deletes a maze with its quadrants, spots and grid.
+func (ms *MazeService) Delete(ctx context.Context, mf *MazeFilter) (bool, error) {
+	filter, err := mf.toMongoFilter()
+	if err != nil {
+		return false, err
+	}
+
+	// the maze, its quadrants and its spots are removed in the same transaction
+	err = mongoTransaction(ctx, ms.db, func(ctx context.Context) error {
+		cm, err := ms.Get(ctx, mf)
+		if err != nil {
+			return err
+		}
+
+		// the quadrants of a maze can only be deleted along with it
+		for i := range cm.Quadrants {
+			if _, err := ms.repo.Quadrant.Delete(withMazeDeletion(ctx), &QuadrantFilter{ID: cm.Quadrants[i].ID}); err != nil {
+				return err
+			}
+		}
+
+		if _, err := ms.repo.Grid.Delete(ctx, &GridFilter{MazeID: cm.ID}); err != nil {
+			return err
+		}
+
+		_, err = ms.db.Database(DBName(ctx)).Collection("mazes").DeleteOne(ctx, filter)
+
+		return err
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MazeKVService represents a kvService that contains the key/value storage.
+type MazeKVService kvService
+
+// MazeKVService validate if it satisfy the MazeStore interface.
+var _ MazeStore = &MazeKVService{}
+
+// Create creates a new maze with its four quadrants and a grid without inner walls.
+func (ms *MazeKVService) Create(ctx context.Context, m *Maze) (string, error) {
+	if err := m.validate(); err != nil {
+		return "", err
+	}
+
+	// the mazes are published once they are complete
+	m.Published = false
+
+	err := kvUpdate(ctx, ms.db, func(ctx context.Context, tx kvTx) error {
+		m.ID = primitive.NewObjectID().Hex()
+		m.QuadrantIDs = make([]string, 0, 4)
+		m.Quadrants = m.NewQuadrants()
+
+		// the maze must exist before its quadrants so they can be validated against it
+		if err := kvPut(tx, mazesCollection, m.ID, m); err != nil {
+			return err
+		}
+
+		for i := range m.Quadrants {
+			id, err := ms.repo.Quadrant.Create(ctx, &m.Quadrants[i])
+			if err != nil {
+				return fmt.Errorf("creating a quadrant: %w", err)
+			}
+
+			m.QuadrantIDs = append(m.QuadrantIDs, id)
+		}
+
+		if err := ms.repo.Grid.Put(ctx, NewGrid(m.ID, m.Width, m.Height, false)); err != nil {
+			return fmt.Errorf("creating the grid: %w", err)
+		}
+
+		return kvPut(tx, mazesCollection, m.ID, m)
+	})
+
+	if err != nil {
+		m.ID = ""
+		m.QuadrantIDs = nil
+		m.Quadrants = nil
+
+		return "", err
+	}
+
+	return m.ID, nil
+}
+
+// Get gets a maze with its quadrants.
+func (ms *MazeKVService) Get(ctx context.Context, mf *MazeFilter) (*Maze, error) {
+	if err := mf.validate(); err != nil {
+		return nil, err
+	}
+
+	maze := &Maze{}
+
+	err := kvView(ctx, ms.db, func(ctx context.Context, tx kvTx) error {
+		if err := kvGet(tx, mazesCollection, mf.ID, maze); err != nil {
+			return err
+		}
+
+		quadrants, err := ms.repo.Quadrant.List(ctx, &QuadrantFilter{MazeID: maze.ID})
+		if err != nil {
+			return err
+		}
+
+		maze.Quadrants = quadrants
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return maze, nil
+}
+
+// List gets all the mazes without their quadrants.
+func (ms *MazeKVService) List(ctx context.Context) ([]Maze, error) {
+	mazes := make([]Maze, 0)
+
+	err := kvView(ctx, ms.db, func(ctx context.Context, tx kvTx) error {
+		return tx.forEach(mazesCollection, func(id string, raw []byte) error {
+			var m Maze
+
+			if err := bson.Unmarshal(raw, &m); err != nil {
+				return err
+			}
+
+			mazes = append(mazes, m)
+
+			return nil
+		})
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("finding all mazes: %w", err)
+	}
+
+	return mazes, nil
+}
+
+// Update updates the name of a maze, its dimensions can't be changed since its quadrants
+// are built from them.
+func (ms *MazeKVService) Update(ctx context.Context, mu *Maze) (*Maze, error) {
+	if mu == nil {
+		return nil, errors.New("maze parameter must be specified")
+	}
+
+	filter := &MazeFilter{ID: mu.ID}
+
+	var maze *Maze
+
+	err := kvUpdate(ctx, ms.db, func(ctx context.Context, tx kvTx) error {
+		cm, err := ms.Get(ctx, filter)
+		if err != nil {
+			return err
+		}
+
+		if err := cm.applyUpdate(mu); err != nil {
+			return err
+		}
+
+		if err := kvPut(tx, mazesCollection, cm.ID, cm); err != nil {
+			return err
+		}
+
+		maze = cm
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return maze, nil
+}
+
+// Publish marks the maze as published or unpublished.
+func (ms *MazeKVService) Publish(ctx context.Context, mf *MazeFilter, published bool) (*Maze, error) {
+	var maze *Maze
+
+	err := kvUpdate(ctx, ms.db, func(ctx context.Context, tx kvTx) error {
+		cm, err := ms.Get(ctx, mf)
+		if err != nil {
+			return err
+		}
+
+		cm.Published = published
+
+		if err := kvPut(tx, mazesCollection, cm.ID, cm); err != nil {
+			return err
+		}
+
+		maze = cm
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return maze, nil
+}
+
+// Published reports if the maze is published, only its published attribute is read.
+func (ms *MazeKVService) Published(ctx context.Context, mf *MazeFilter) (bool, error) {
+	if err := mf.validate(); err != nil {
+		return false, err
+	}
+
+	maze := &Maze{}
+
+	err := kvView(ctx, ms.db, func(ctx context.Context, tx kvTx) error {
+		return kvGet(tx, mazesCollection, mf.ID, maze)
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	return maze.Published, nil
+}
+
+// Delete deletes a maze with its quadrants, spots and grid.
+func (ms *MazeKVService) Delete(ctx context.Context, mf *MazeFilter) (bool, error) {
+	err := kvUpdate(ctx, ms.db, func(ctx context.Context, tx kvTx) error {
+		cm, err := ms.Get(ctx, mf)
+		if err != nil {
+			return err
+		}
+
+		// the quadrants of a maze can only be deleted along with it
+		for i := range cm.Quadrants {
+			if _, err := ms.repo.Quadrant.Delete(withMazeDeletion(ctx), &QuadrantFilter{ID: cm.Quadrants[i].ID}); err != nil {
+				return err
+			}
+		}
+
+		if _, err := ms.repo.Grid.Delete(ctx, &GridFilter{MazeID: cm.ID}); err != nil {
+			return err
+		}
+
+		return tx.delete(mazesCollection, cm.ID)
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,261 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaze_CreateGetDelete(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, ctx context.Context, conn *Repository) {
+		maze := &Maze{Name: "labyrinth", Width: 50, Height: 40}
+
+		id, err := conn.Maze.Create(ctx, maze)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.NotEmpty(t, id)
+		assert.Len(t, maze.QuadrantIDs, 4)
+
+		got, err := conn.Maze.Get(ctx, &MazeFilter{ID: id})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, "labyrinth", got.Name)
+		assert.Equal(t, maze.QuadrantIDs, got.QuadrantIDs)
+		assert.Len(t, got.Quadrants, 4)
+
+		expected := map[QuadrantType][2]Coordinate{
+			TopLeft:     {{X: 0, Y: 0}, {X: 25, Y: 20}},
+			TopRight:    {{X: 25, Y: 0}, {X: 50, Y: 20}},
+			BottomLeft:  {{X: 0, Y: 20}, {X: 25, Y: 40}},
+			BottomRight: {{X: 25, Y: 20}, {X: 50, Y: 40}},
+		}
+
+		for _, q := range got.Quadrants {
+			assert.Equal(t, id, q.MazeID)
+			assert.Equal(t, expected[q.Type][0], *q.StartPoint)
+			assert.Equal(t, expected[q.Type][1], *q.LimitPoint)
+		}
+
+		sID, err := conn.Spot.Create(ctx, &Spot{
+			Name:       "exit",
+			GoldAmount: 4000,
+			Coordinate: &Coordinate{X: 9, Y: 0},
+			QuadrantID: got.Quadrants[0].ID,
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		spots, err := conn.Spot.List(ctx, &SpotFilter{MazeID: id})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Len(t, spots, 1)
+		assert.Equal(t, sID, spots[0].ID)
+
+		isRemoved, err := conn.Maze.Delete(ctx, &MazeFilter{ID: id})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.True(t, isRemoved)
+
+		_, err = conn.Quadrant.Get(ctx, &QuadrantFilter{ID: got.Quadrants[0].ID})
+		assert.Error(t, err)
+
+		_, err = conn.Spot.Get(ctx, &SpotFilter{ID: sID})
+		assert.Error(t, err)
+	})
+}
+
+func TestMaze_Update(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, ctx context.Context, conn *Repository) {
+		id, err := conn.Maze.Create(ctx, &Maze{Name: "labyrinth", Width: 50, Height: 50})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := conn.Maze.Update(ctx, &Maze{ID: id, Name: "maze"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, "maze", got.Name)
+
+		var ve *ValidationError
+
+		_, err = conn.Maze.Update(ctx, &Maze{ID: id, Width: 20})
+		assert.True(t, errors.As(err, &ve))
+		assert.Equal(t, []FieldError{{Field: "width", Message: "can't be updated"}}, ve.Fields)
+
+		mazes, err := conn.Maze.List(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Len(t, mazes, 1)
+		assert.Equal(t, "maze", mazes[0].Name)
+
+		if _, err := conn.Maze.Delete(ctx, &MazeFilter{ID: id}); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestMaze_CreateTooLarge(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, ctx context.Context, conn *Repository) {
+		var ve *ValidationError
+
+		// the grid isn't allocated when a side is over the maximum
+		_, err := conn.Maze.Create(ctx, &Maze{Name: "labyrinth", Width: 1 << 30, Height: MaxMazeSide + 1})
+		assert.True(t, errors.As(err, &ve))
+		assert.Len(t, ve.Fields, 2)
+
+		mazes, err := conn.Maze.List(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Empty(t, mazes)
+	})
+}
+
+func TestMaze_validate(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, ctx context.Context, conn *Repository) {
+		var ve *ValidationError
+
+		_, err := conn.Maze.Create(ctx, &Maze{Width: 1, Height: 10})
+		assert.True(t, errors.As(err, &ve))
+		assert.Equal(t, []FieldError{
+			{Field: "name", Message: "must be specified"},
+			{Field: "width", Message: fmt.Sprintf("must be at least %d", minMazeSide)},
+		}, ve.Fields)
+
+		_, err = conn.Maze.Get(ctx, &MazeFilter{ID: "labyrinth"})
+		assert.True(t, errors.As(err, &ve))
+		assert.Equal(t, []FieldError{{Field: "id", Message: "must be a valid id"}}, ve.Fields)
+	})
+}
+
+func TestMaze_Publish(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, ctx context.Context, conn *Repository) {
+		id, err := conn.Maze.Create(ctx, &Maze{Name: "labyrinth", Width: 50, Height: 50, Published: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		m, err := conn.Maze.Get(ctx, &MazeFilter{ID: id})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.False(t, m.Published)
+
+		// the update doesn't change it
+		m, err = conn.Maze.Update(ctx, &Maze{ID: id, Name: "maze", Published: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.False(t, m.Published)
+
+		if _, err := conn.Maze.Publish(ctx, &MazeFilter{ID: id}, true); err != nil {
+			t.Fatal(err)
+		}
+
+		m, err = conn.Maze.Get(ctx, &MazeFilter{ID: id})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.True(t, m.Published)
+		assert.Equal(t, "maze", m.Name)
+
+		var ce *ConflictError
+
+		// the published maze was validated as it is so its spots and walls can't change
+		_, err = conn.Spot.Create(ctx, &Spot{Name: "gold", MazeID: id, Coordinate: &Coordinate{X: 2, Y: 3}})
+		assert.True(t, errors.As(err, &ce))
+		assert.Equal(t, "published", ce.Fields[0].Field)
+
+		err = conn.Grid.SetCells(ctx, &GridFilter{MazeID: id}, []Cell{{X: 1, Y: 1, North: true}})
+		assert.True(t, errors.As(err, &ce))
+
+		if _, err := conn.Maze.Publish(ctx, &MazeFilter{ID: id}, false); err != nil {
+			t.Fatal(err)
+		}
+
+		sID, err := conn.Spot.Create(ctx, &Spot{Name: "gold", MazeID: id, Coordinate: &Coordinate{X: 2, Y: 3}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := conn.Maze.Publish(ctx, &MazeFilter{ID: id}, true); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = conn.Spot.Delete(ctx, &SpotFilter{ID: sID})
+		assert.True(t, errors.As(err, &ce))
+
+		// the published maze is deleted with its spots anyway
+		if _, err := conn.Maze.Delete(ctx, &MazeFilter{ID: id}); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestMaze_spotsAreScopedToTheirMaze(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, ctx context.Context, conn *Repository) {
+		m1 := &Maze{Name: "first", Width: 10, Height: 10}
+		if _, err := conn.Maze.Create(ctx, m1); err != nil {
+			t.Fatal(err)
+		}
+
+		m2 := &Maze{Name: "second", Width: 10, Height: 10}
+		if _, err := conn.Maze.Create(ctx, m2); err != nil {
+			t.Fatal(err)
+		}
+
+		// a quadrant of another maze can't be used
+		_, err := conn.Spot.Create(ctx, &Spot{
+			MazeID:     m1.ID,
+			Name:       "exit",
+			Coordinate: &Coordinate{X: 1, Y: 1},
+			QuadrantID: m2.QuadrantIDs[0],
+		})
+
+		assert.Error(t, err)
+
+		sID, err := conn.Spot.Create(ctx, &Spot{
+			MazeID:     m1.ID,
+			Name:       "exit",
+			Coordinate: &Coordinate{X: 1, Y: 1},
+			QuadrantID: m1.QuadrantIDs[0],
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = conn.Spot.Get(ctx, &SpotFilter{ID: sID, MazeID: m2.ID})
+		assert.Error(t, err)
+
+		_, err = conn.Spot.Update(ctx, &Spot{ID: sID, QuadrantID: m2.QuadrantIDs[1]})
+		assert.Error(t, err)
+
+		for _, id := range []string{m1.ID, m2.ID} {
+			if _, err := conn.Maze.Delete(ctx, &MazeFilter{ID: id}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+}
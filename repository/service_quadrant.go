@@ -7,14 +7,18 @@ import (
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-// QuadrantMongoDBService defines the interface that device must satisfy.
-type QuadrantMongoDBService interface {
+// QuadrantStore defines the interface that each quadrant backend must satisfy.
+type QuadrantStore interface {
 	Create(ctx context.Context, s *Quadrant) (id string, err error)
 	Get(ctx context.Context, qf *QuadrantFilter) (q *Quadrant, err error)
+	List(ctx context.Context, qf *QuadrantFilter) (quadrants []Quadrant, err error)
 	Update(ctx context.Context, s *Quadrant) (q *Quadrant, err error)
+	Replace(ctx context.Context, rq *Quadrant) (q *Quadrant, err error)
 	Delete(ctx context.Context, qf *QuadrantFilter) (isRemoved bool, err error)
+	Page(ctx context.Context, qq *QuadrantQuery, opts *ListOptions) (page *QuadrantPage, err error)
 }
 
 // QuadrantService represents a mongoServie that contains the MongoDB client.
@@ -23,16 +27,20 @@ type QuadrantService mongoService
 // QuadrantService validate if it satisfy the own interface, that means
 // that all mongoService can be implement its own interface but it must be
 // a mongoService type.
-var _ QuadrantMongoDBService = &QuadrantService{}
+var _ QuadrantStore = &QuadrantService{}
 
 // Quadrant represents a quadrant information that is in the maze.
 type Quadrant struct {
 	ID         string       `json:"id,omitempty" bson:"_id,omitempty"`
+	MazeID     string       `json:"maze_id,omitempty" bson:"maze_id,omitempty"`
 	Type       QuadrantType `json:"type,omitempty" bson:"type"`
 	Spots      []Spot       `json:"spots,omitempty"`
 	SpotIDs    []string     `json:"spot_ids,omitempty" bson:"spot_ids"`
 	StartPoint *Coordinate  `json:"start_point,omitempty" bson:"start_point"`
 	LimitPoint *Coordinate  `json:"limit_point,omitempty" bson:"limit_point"`
+	// Version is incremented by every write of the quadrant or its spots, the writes that
+	// send a version other than zero fail with a VersionError when it isn't the stored one.
+	Version uint64 `json:"version" bson:"version"`
 }
 
 // Coordinate represents a specific point/location in the maze.
@@ -55,35 +63,100 @@ const (
 	BottomLeft = QuadrantType("BOTTOM_LEFT")
 
 	// BottomRight represents a quadrant location.
-	BottomRight = QuadrantType("BOTTOM_RIGTH")
+	BottomRight = QuadrantType("BOTTOM_RIGHT")
+
+	// legacyBottomRight is the misspelled BottomRight stored and accepted by the first
+	// versions, it is migrated and still read as BottomRight.
+	legacyBottomRight = QuadrantType("BOTTOM_RIGTH")
 )
 
+// ParseQuadrantType returns the type named s, the legacy BOTTOM_RIGTH is BottomRight.
+func ParseQuadrantType(s string) QuadrantType {
+	if qt := QuadrantType(s); qt != legacyBottomRight {
+		return qt
+	}
+
+	return BottomRight
+}
+
+// UnmarshalText reads the type with ParseQuadrantType, so the JSON and YAML bodies can
+// keep sending the legacy name.
+func (qt *QuadrantType) UnmarshalText(text []byte) error {
+	*qt = ParseQuadrantType(string(text))
+
+	return nil
+}
+
 // QuadrantFilter represents the filter that can be used to create a mongo query.
 type QuadrantFilter struct {
-	ID   string       `json:"id,omitempty"`
-	Type QuadrantType `json:"type,omitempty"`
+	ID     string       `json:"id,omitempty"`
+	MazeID string       `json:"maze_id,omitempty"`
+	Type   QuadrantType `json:"type,omitempty"`
+	// Version is the version the quadrant of ID must be at to be deleted, Delete fails with
+	// a VersionError otherwise. Zero deletes it at any version.
+	Version uint64 `json:"-"`
 }
 
-func (qf *QuadrantFilter) toMongoFilter() (bson.M, error) {
+func (qf *QuadrantFilter) validate() error {
 	if qf == nil {
-		return nil, errors.New("quadrant filter must not be nil")
+		return errors.New("quadrant filter must not be nil")
 	}
 
-	if qf.ID == "" && qf.Type == "" {
-		return nil, errors.New("at least one of QuadrantFilter.ID and QuadrantFilter.Type must be specified")
+	if qf.ID == "" && qf.MazeID == "" && qf.Type == "" {
+		return BadRequest("at least one of QuadrantFilter.ID, QuadrantFilter.MazeID and QuadrantFilter.Type must be specified")
 	}
 
-	filter := bson.M{}
+	if qf.Version != 0 && qf.ID == "" {
+		return BadRequest("QuadrantFilter.Version requires QuadrantFilter.ID")
+	}
 
 	if qf.ID != "" {
-		id, err := primitive.ObjectIDFromHex(qf.ID)
-		if err != nil {
-			return nil, fmt.Errorf("wrong id%s", qf.ID)
+		if _, err := primitive.ObjectIDFromHex(qf.ID); err != nil {
+			ve := &ValidationError{Entity: "quadrant"}
+			ve.add("id", "must be a valid id")
+
+			return ve
 		}
+	}
+
+	return nil
+}
+
+// matches reports if the quadrant satisfies the filter, it is used by the backends
+// that can't translate the filter into a query.
+func (qf *QuadrantFilter) matches(q *Quadrant) bool {
+	if qf.ID != "" && qf.ID != q.ID {
+		return false
+	}
+
+	if qf.MazeID != "" && qf.MazeID != q.MazeID {
+		return false
+	}
+
+	if qf.Type != "" && qf.Type != q.Type {
+		return false
+	}
+
+	return true
+}
+
+func (qf *QuadrantFilter) toMongoFilter() (bson.M, error) {
+	if err := qf.validate(); err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{}
+
+	if qf.ID != "" {
+		id, _ := primitive.ObjectIDFromHex(qf.ID)
 
 		filter["_id"] = id
 	}
 
+	if qf.MazeID != "" {
+		filter["maze_id"] = qf.MazeID
+	}
+
 	if qf.Type != "" {
 		filter["type"] = qf.Type
 	}
@@ -93,18 +166,33 @@ func (qf *QuadrantFilter) toMongoFilter() (bson.M, error) {
 
 // Create creates a new quadrant in a maze.
 func (qs *QuadrantService) Create(ctx context.Context, q *Quadrant) (string, error) {
-	dbName := DBName(ctx)
-
 	if len(q.SpotIDs) == 0 {
 		q.SpotIDs = []string{}
 	}
 
-	res, err := qs.db.Database(dbName).Collection("quadrants").InsertOne(ctx, q)
+	// the siblings used to validate the quadrant are read in the same transaction
+	err := mongoTransaction(ctx, qs.db, func(ctx context.Context) error {
+		if err := validateQuadrant(ctx, qs.repo, q); err != nil {
+			return err
+		}
+
+		q.Version = 1
+
+		res, err := qs.db.Database(DBName(ctx)).Collection("quadrants").InsertOne(ctx, q)
+		if err != nil {
+			return err
+		}
+
+		q.ID = res.InsertedID.(primitive.ObjectID).Hex()
+
+		return nil
+	})
+
 	if err != nil {
 		return "", err
 	}
 
-	return res.InsertedID.(primitive.ObjectID).Hex(), nil
+	return q.ID, nil
 }
 
 // Get gets a specific quadrant by its type.
@@ -120,11 +208,11 @@ func (qs *QuadrantService) Get(ctx context.Context, qf *QuadrantFilter) (*Quadra
 
 	err = qs.db.Database(DBName(ctx)).Collection("quadrants").FindOne(ctx, filter).Decode(quadrant)
 	if err != nil {
-		return nil, err
+		return nil, notFound("quadrant", qf.ID, err)
 	}
 
 	if len(quadrant.SpotIDs) > 0 {
-		spots, err := New(qs.db).Spot.List(ctx, &SpotFilter{
+		spots, err := qs.repo.Spot.List(ctx, &SpotFilter{
 			SpotsIDs: quadrant.SpotIDs,
 		})
 
@@ -138,45 +226,199 @@ func (qs *QuadrantService) Get(ctx context.Context, qf *QuadrantFilter) (*Quadra
 	return quadrant, nil
 }
 
-// Update creates a new quadrant in a maze.
+// List gets all the quadrants that satisfy the filter with their spots.
+func (qs *QuadrantService) List(ctx context.Context, qf *QuadrantFilter) ([]Quadrant, error) {
+	filter, err := qf.toMongoFilter()
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := qs.db.Database(DBName(ctx)).Collection("quadrants").Find(ctx, filter,
+		options.Find().SetSort(bson.M{"_id": 1}),
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("finding all quadrants: %w", err)
+	}
+
+	defer cursor.Close(ctx)
+
+	quadrants := make([]Quadrant, 0)
+
+	if err := cursor.All(ctx, &quadrants); err != nil {
+		return nil, fmt.Errorf("can't decode quadrants: %w", err)
+	}
+
+	for i := range quadrants {
+		if len(quadrants[i].SpotIDs) == 0 {
+			continue
+		}
+
+		spots, err := qs.repo.Spot.List(ctx, &SpotFilter{
+			SpotsIDs: quadrants[i].SpotIDs,
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		quadrants[i].Spots = spots
+	}
+
+	return quadrants, nil
+}
+
+// Update updates the start and limit points and the spots of the quadrant, the spots that
+// aren't in uq.SpotIDs are deleted and the zero values are left unchanged.
 func (qs *QuadrantService) Update(ctx context.Context, uq *Quadrant) (*Quadrant, error) {
 	if uq == nil {
 		return nil, errors.New("quadrant parameter must be specified")
 	}
 
 	if uq.Type == "" && uq.ID == "" {
-		return nil, errors.New("at least one of Quadrant.ID and Quadrant.Type must be specified")
+		return nil, BadRequest("at least one of Quadrant.ID and Quadrant.Type must be specified")
+	}
+
+	// the type of a quadrant is only unique in its maze
+	if uq.ID == "" && uq.MazeID == "" {
+		return nil, invalidField("quadrant", "maze_id", "must be specified to find the quadrant by type")
 	}
 
 	filter := &QuadrantFilter{
-		ID:   uq.ID,
-		Type: uq.Type,
+		ID:     uq.ID,
+		MazeID: uq.MazeID,
+		Type:   uq.Type,
 	}
 
-	cq, err := qs.Get(ctx, filter)
+	return qs.save(ctx, filter, uq.Version, func(cq *Quadrant) ([]string, error) {
+		return applyUpdate(cq, uq)
+	})
+}
 
-	if err != nil {
-		return nil, fmt.Errorf("this is the get error %s", err)
+// Replace replaces the type, the start and limit points and the spots of the quadrant with
+// the ones of rq, the spots that aren't in rq.SpotIDs are deleted.
+func (qs *QuadrantService) Replace(ctx context.Context, rq *Quadrant) (*Quadrant, error) {
+	if rq == nil {
+		return nil, errors.New("quadrant parameter must be specified")
 	}
 
-	if len(uq.SpotIDs) != 0 {
-		idsToRemove := CompareStringSlices(cq.SpotIDs, uq.SpotIDs)
+	if rq.ID == "" {
+		return nil, invalidField("quadrant", "id", "must be specified")
+	}
+
+	return qs.save(ctx, &QuadrantFilter{ID: rq.ID}, rq.Version, func(cq *Quadrant) ([]string, error) {
+		return applyReplace(cq, rq)
+	})
+}
+
+// save writes the quadrant of the filter once apply has changed it, the spots returned by
+// apply are deleted. The write fails when the expected version isn't the stored one.
+func (qs *QuadrantService) save(ctx context.Context, filter *QuadrantFilter, expected uint64, apply applyFunc) (*Quadrant, error) {
+	var quadrant *Quadrant
+
+	// the removed spots and the quadrant are written in the same transaction
+	err := mongoTransaction(ctx, qs.db, func(ctx context.Context) error {
+		cq, err := qs.Get(ctx, filter)
+		if err != nil {
+			return err
+		}
+
+		if err := checkVersion("quadrant", cq.ID, cq.Version, expected); err != nil {
+			return err
+		}
+
+		if err := qs.repo.checkUnpublished(ctx, cq.MazeID); err != nil {
+			return err
+		}
+
+		idsToRemove, err := apply(cq)
+		if err != nil {
+			return err
+		}
+
+		if err := validateQuadrant(ctx, qs.repo, cq); err != nil {
+			return err
+		}
+
+		if err := validateSpotsInside(ctx, qs.repo, cq); err != nil {
+			return err
+		}
 
 		if len(idsToRemove) > 0 {
 			spotFilter := SpotFilter{SpotsIDs: idsToRemove}
 			f, err := spotFilter.toMongoFilter()
 
 			if err != nil {
-				return nil, err
+				return err
 			}
 
 			_, err = qs.db.Database(DBName(ctx)).Collection("spots").DeleteMany(ctx, f)
 
 			if err != nil {
-				return nil, err
+				return err
 			}
 		}
 
+		update := bson.M{
+			"maze_id":     cq.MazeID,
+			"type":        cq.Type,
+			"spot_ids":    cq.SpotIDs,
+			"start_point": cq.StartPoint,
+			"limit_point": cq.LimitPoint,
+			"version":     cq.Version + 1,
+		}
+
+		qf, err := (&QuadrantFilter{ID: cq.ID}).toMongoFilter()
+		if err != nil {
+			return err
+		}
+
+		// the version in the filter makes the write fail if another one happened meanwhile
+		qf["version"] = versionFilter(cq.Version)
+
+		res, err := qs.db.Database(DBName(ctx)).Collection("quadrants").ReplaceOne(ctx, qf, update)
+		if err != nil {
+			return err
+		}
+
+		if res.MatchedCount == 0 {
+			return &VersionError{Entity: "quadrant", ID: cq.ID, Expected: cq.Version}
+		}
+
+		quadrant, err = qs.Get(ctx, &QuadrantFilter{ID: cq.ID})
+		if err != nil {
+			return fmt.Errorf("reading the updated quadrant %s: %w", cq.ID, err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return quadrant, nil
+}
+
+// applyFunc applies the changes of a write to the stored quadrant and returns the spots
+// that must be deleted.
+type applyFunc func(cq *Quadrant) (idsToRemove []string, err error)
+
+// applyUpdate applies the attributes of uq that aren't zero values to the quadrant and
+// returns the spots that must be deleted, the quadrant can only keep its own spots.
+func applyUpdate(cq, uq *Quadrant) ([]string, error) {
+	ve := &ValidationError{Entity: "quadrant"}
+	validateSpotIDs(cq, uq.SpotIDs, ve)
+	validateMazeGeometry(cq, uq.Type, uq.StartPoint, uq.LimitPoint, ve)
+
+	if err := ve.err(); err != nil {
+		return nil, err
+	}
+
+	var idsToRemove []string
+
+	if len(uq.SpotIDs) != 0 {
+		idsToRemove = CompareStringSlices(append([]string{}, cq.SpotIDs...), uq.SpotIDs)
 		cq.SpotIDs = uq.SpotIDs
 	}
 
@@ -188,54 +430,128 @@ func (qs *QuadrantService) Update(ctx context.Context, uq *Quadrant) (*Quadrant,
 		cq.LimitPoint = uq.LimitPoint
 	}
 
-	update := bson.M{
-		"type":        cq.Type,
-		"spot_ids":    cq.SpotIDs,
-		"start_point": cq.StartPoint,
-		"limit_point": cq.LimitPoint,
+	return idsToRemove, nil
+}
+
+// applyReplace replaces the attributes of the quadrant with the ones of rq and returns the
+// spots that must be deleted, the quadrant can't be moved to another maze and it can only
+// keep its own spots.
+func applyReplace(cq, rq *Quadrant) ([]string, error) {
+	if rq.MazeID != "" && rq.MazeID != cq.MazeID {
+		ce := &ConflictError{Entity: "quadrant"}
+		ce.add("maze_id", "can't be changed, the quadrants of a maze are created and deleted with it")
+
+		return nil, ce.err()
 	}
 
-	qf, err := filter.toMongoFilter()
-	if err != nil {
+	ve := &ValidationError{Entity: "quadrant"}
+	validateSpotIDs(cq, rq.SpotIDs, ve)
+	validateMazeGeometry(cq, rq.Type, rq.StartPoint, rq.LimitPoint, ve)
+
+	if err := ve.err(); err != nil {
 		return nil, err
 	}
 
-	_, err = qs.db.Database(DBName(ctx)).Collection("quadrants").ReplaceOne(ctx, qf, update)
-	if err != nil {
-		return nil, err
+	idsToRemove := removeString(cq.SpotIDs, rq.SpotIDs...)
+
+	cq.Type = rq.Type
+	cq.StartPoint = rq.StartPoint
+	cq.LimitPoint = rq.LimitPoint
+	cq.SpotIDs = append([]string{}, rq.SpotIDs...)
+
+	return idsToRemove, nil
+}
+
+// validateSpotIDs adds an error to ve for each spot id that isn't one of the quadrant.
+func validateSpotIDs(cq *Quadrant, spotIDs []string, ve *ValidationError) {
+	for i := range spotIDs {
+		if !containsString(cq.SpotIDs, spotIDs[i]) {
+			ve.add("spot_ids", "the spot %s doesn't belong to the quadrant", spotIDs[i])
+		}
 	}
+}
 
-	cq, err = qs.Get(ctx, filter)
-	if err != nil {
-		return nil, fmt.Errorf("this is the end error %s", err)
+// checkDeletable fails when the quadrant belongs to a maze, the four quadrants always
+// cover the maze so they are only deleted along with it.
+func checkDeletable(ctx context.Context, cq *Quadrant) error {
+	if cq.MazeID == "" || isMazeDeletion(ctx) {
+		return nil
 	}
 
-	return cq, nil
+	ce := &ConflictError{Entity: "quadrant"}
+	ce.add("maze_id", "the quadrant belongs to the maze %s, it is only deleted with the maze", cq.MazeID)
+
+	return ce.err()
 }
 
-// Delete deletes a quadrant by quadrant type.
-func (qs *QuadrantService) Delete(ctx context.Context, qf *QuadrantFilter) (bool, error) {
-	filter, err := qf.toMongoFilter()
-	if err != nil {
-		return false, err
+// validateMazeGeometry adds an error to ve for each change of the type or the geometry of
+// a quadrant of a maze. The four quadrants split the maze between them, so one of them
+// can't be moved or resized on its own without leaving cells uncovered or overlapping.
+func validateMazeGeometry(cq *Quadrant, qt QuadrantType, start, limit *Coordinate, ve *ValidationError) {
+	if cq.MazeID == "" {
+		return
 	}
 
-	var (
-		dbName = DBName(ctx)
-	)
+	if qt != "" && qt != cq.Type {
+		ve.add("type", "can't be changed in a quadrant of a maze")
+	}
 
-	cq, err := qs.Get(ctx, qf)
-	if err != nil {
+	if start != nil && (cq.StartPoint == nil || *start != *cq.StartPoint) {
+		ve.add("start_point", "can't be changed in a quadrant of a maze, its quadrants are split when it is created")
+	}
+
+	if limit != nil && (cq.LimitPoint == nil || *limit != *cq.LimitPoint) {
+		ve.add("limit_point", "can't be changed in a quadrant of a maze, its quadrants are split when it is created")
+	}
+}
+
+// Delete deletes a quadrant by quadrant type, the quadrants of a maze are only deleted
+// with it.
+func (qs *QuadrantService) Delete(ctx context.Context, qf *QuadrantFilter) (bool, error) {
+	if err := qf.validate(); err != nil {
 		return false, err
 	}
 
-	if len(cq.SpotIDs) > 0 {
-		if _, err := New(qs.db).Spot.Delete(ctx, &SpotFilter{SpotsIDs: cq.SpotIDs}); err != nil {
-			return false, err
+	// the quadrant is deleted at the version read and its spots are removed in the same
+	// transaction
+	err := mongoTransaction(ctx, qs.db, func(ctx context.Context) error {
+		cq, err := qs.Get(ctx, qf)
+		if err != nil {
+			return err
+		}
+
+		if err := checkVersion("quadrant", cq.ID, cq.Version, qf.Version); err != nil {
+			return err
 		}
-	}
 
-	_, err = qs.db.Database(dbName).Collection("quadrants").DeleteOne(ctx, filter)
+		if err := checkDeletable(ctx, cq); err != nil {
+			return err
+		}
+
+		id, _ := primitive.ObjectIDFromHex(cq.ID)
+
+		res, err := qs.db.Database(DBName(ctx)).Collection("quadrants").DeleteOne(ctx, bson.M{
+			"_id":     id,
+			"version": versionFilter(cq.Version),
+		})
+
+		if err != nil {
+			return err
+		}
+
+		if res.DeletedCount == 0 {
+			return &VersionError{Entity: "quadrant", ID: cq.ID, Expected: cq.Version}
+		}
+
+		if len(cq.SpotIDs) > 0 {
+			if _, err := qs.repo.Spot.Delete(ctx, &SpotFilter{SpotsIDs: cq.SpotIDs}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
 	if err != nil {
 		return false, err
 	}
@@ -264,3 +580,91 @@ func CompareStringSlices(x, y []string) []string {
 
 	return fn(y, x)
 }
+
+// containsString reports if the value exists in the slice.
+func containsString(slice []string, value string) bool {
+	for i := range slice {
+		if slice[i] == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// removeString returns a new slice without the values keeping the order of the rest.
+func removeString(slice []string, values ...string) []string {
+	res := make([]string, 0, len(slice))
+
+	for i := range slice {
+		if !containsString(values, slice[i]) {
+			res = append(res, slice[i])
+		}
+	}
+
+	return res
+}
+
+// Page gets a page of the quadrants that satisfy the query with their spots, the query can
+// be nil to use all the quadrants.
+func (qs *QuadrantService) Page(ctx context.Context, qq *QuadrantQuery, opts *ListOptions) (*QuadrantPage, error) {
+	if qq == nil {
+		qq = &QuadrantQuery{}
+	}
+
+	if err := qq.validate(); err != nil {
+		return nil, err
+	}
+
+	p, err := newPage("quadrant", opts, quadrantSortFields)
+	if err != nil {
+		return nil, err
+	}
+
+	quadrants := qs.db.Database(DBName(ctx)).Collection("quadrants")
+	filter := qq.toMongoFilter()
+
+	total, err := quadrants.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("counting the quadrants: %w", err)
+	}
+
+	if p.cursor != nil {
+		filter = bson.M{"$and": bson.A{filter, p.mongoCursor()}}
+	}
+
+	// one more quadrant is read to know if there is a next page
+	cursor, err := quadrants.Find(ctx, filter, options.Find().SetSort(p.mongoSort()).SetLimit(int64(p.limit+1)))
+	if err != nil {
+		return nil, fmt.Errorf("finding the quadrants: %w", err)
+	}
+
+	defer cursor.Close(ctx)
+
+	res := &QuadrantPage{Items: make([]Quadrant, 0), Total: total}
+
+	if err := cursor.All(ctx, &res.Items); err != nil {
+		return nil, fmt.Errorf("can't decode quadrants: %w", err)
+	}
+
+	if len(res.Items) > p.limit {
+		res.Items = res.Items[:p.limit]
+		last := &res.Items[p.limit-1]
+		res.NextCursor = p.next(p.field.quadrant(last), last.ID)
+	}
+
+	for i := range res.Items {
+		if len(res.Items[i].SpotIDs) == 0 {
+			continue
+		}
+
+		spots, err := qs.repo.Spot.List(ctx, &SpotFilter{SpotsIDs: res.Items[i].SpotIDs})
+		if err != nil {
+			return nil, err
+		}
+
+		res.Items[i].Spots = spots
+	}
+
+	return res, nil
+}
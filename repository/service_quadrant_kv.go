@@ -0,0 +1,406 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// QuadrantKVService represents a kvService that contains the key/value storage.
+type QuadrantKVService kvService
+
+// QuadrantKVService validate if it satisfy the QuadrantStore interface.
+var _ QuadrantStore = &QuadrantKVService{}
+
+// Create creates a new quadrant in a maze.
+func (qs *QuadrantKVService) Create(ctx context.Context, q *Quadrant) (string, error) {
+	if len(q.SpotIDs) == 0 {
+		q.SpotIDs = []string{}
+	}
+
+	err := kvUpdate(ctx, qs.db, func(ctx context.Context, tx kvTx) error {
+		if err := validateQuadrant(ctx, qs.repo, q); err != nil {
+			return err
+		}
+
+		q.Version = 1
+
+		doc := *q
+		doc.ID = primitive.NewObjectID().Hex()
+		doc.Spots = nil
+
+		if err := kvPut(tx, quadrantsCollection, doc.ID, &doc); err != nil {
+			return err
+		}
+
+		q.ID = doc.ID
+
+		return nil
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return q.ID, nil
+}
+
+// Get gets a specific quadrant by its type.
+func (qs *QuadrantKVService) Get(ctx context.Context, qf *QuadrantFilter) (*Quadrant, error) {
+	if err := qf.validate(); err != nil {
+		return nil, err
+	}
+
+	var quadrant *Quadrant
+
+	err := kvView(ctx, qs.db, func(ctx context.Context, tx kvTx) error {
+		q, err := qs.find(tx, qf)
+		if err != nil {
+			return err
+		}
+
+		if len(q.SpotIDs) > 0 {
+			spots, err := qs.repo.Spot.List(ctx, &SpotFilter{
+				SpotsIDs: q.SpotIDs,
+			})
+
+			if err != nil {
+				return err
+			}
+
+			q.Spots = spots
+		}
+
+		quadrant = q
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return quadrant, nil
+}
+
+// find returns the first quadrant that satisfies the filter.
+func (qs *QuadrantKVService) find(tx kvTx, qf *QuadrantFilter) (*Quadrant, error) {
+	if qf.ID != "" {
+		q := &Quadrant{}
+
+		if err := kvGet(tx, quadrantsCollection, qf.ID, q); err != nil {
+			return nil, err
+		}
+
+		if !qf.matches(q) {
+			return nil, &NotFoundError{Entity: "quadrant", ID: qf.ID}
+		}
+
+		return q, nil
+	}
+
+	quadrants, err := qs.filter(tx, qf, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(quadrants) == 0 {
+		return nil, &NotFoundError{Entity: "quadrant"}
+	}
+
+	return &quadrants[0], nil
+}
+
+// filter returns the quadrants that satisfy the filter sorted by id, limit is the maximum
+// number of quadrants returned when it is greater than zero.
+func (qs *QuadrantKVService) filter(tx kvTx, qf *QuadrantFilter, limit int) ([]Quadrant, error) {
+	var (
+		quadrants = make([]Quadrant, 0)
+		errLimit  = errors.New("limit reached")
+	)
+
+	err := tx.forEach(quadrantsCollection, func(id string, raw []byte) error {
+		var q Quadrant
+
+		if err := bson.Unmarshal(raw, &q); err != nil {
+			return err
+		}
+
+		if !qf.matches(&q) {
+			return nil
+		}
+
+		quadrants = append(quadrants, q)
+
+		if limit > 0 && len(quadrants) == limit {
+			return errLimit
+		}
+
+		return nil
+	})
+
+	if err != nil && !errors.Is(err, errLimit) {
+		return nil, err
+	}
+
+	return quadrants, nil
+}
+
+// List gets all the quadrants that satisfy the filter with their spots.
+func (qs *QuadrantKVService) List(ctx context.Context, qf *QuadrantFilter) ([]Quadrant, error) {
+	if err := qf.validate(); err != nil {
+		return nil, err
+	}
+
+	var quadrants []Quadrant
+
+	err := kvView(ctx, qs.db, func(ctx context.Context, tx kvTx) error {
+		var err error
+
+		quadrants, err = qs.filter(tx, qf, 0)
+		if err != nil {
+			return err
+		}
+
+		for i := range quadrants {
+			if len(quadrants[i].SpotIDs) == 0 {
+				continue
+			}
+
+			spots, err := qs.repo.Spot.List(ctx, &SpotFilter{
+				SpotsIDs: quadrants[i].SpotIDs,
+			})
+
+			if err != nil {
+				return err
+			}
+
+			quadrants[i].Spots = spots
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("finding all quadrants: %w", err)
+	}
+
+	return quadrants, nil
+}
+
+// Update updates the start and limit points and the spots of the quadrant, the spots that
+// aren't in uq.SpotIDs are deleted and the zero values are left unchanged.
+func (qs *QuadrantKVService) Update(ctx context.Context, uq *Quadrant) (*Quadrant, error) {
+	if uq == nil {
+		return nil, errors.New("quadrant parameter must be specified")
+	}
+
+	if uq.Type == "" && uq.ID == "" {
+		return nil, BadRequest("at least one of Quadrant.ID and Quadrant.Type must be specified")
+	}
+
+	// the type of a quadrant is only unique in its maze
+	if uq.ID == "" && uq.MazeID == "" {
+		return nil, invalidField("quadrant", "maze_id", "must be specified to find the quadrant by type")
+	}
+
+	filter := &QuadrantFilter{
+		ID:     uq.ID,
+		MazeID: uq.MazeID,
+		Type:   uq.Type,
+	}
+
+	return qs.save(ctx, filter, uq.Version, func(cq *Quadrant) ([]string, error) {
+		return applyUpdate(cq, uq)
+	})
+}
+
+// Replace replaces the type, the start and limit points and the spots of the quadrant with
+// the ones of rq, the spots that aren't in rq.SpotIDs are deleted.
+func (qs *QuadrantKVService) Replace(ctx context.Context, rq *Quadrant) (*Quadrant, error) {
+	if rq == nil {
+		return nil, errors.New("quadrant parameter must be specified")
+	}
+
+	if rq.ID == "" {
+		return nil, invalidField("quadrant", "id", "must be specified")
+	}
+
+	return qs.save(ctx, &QuadrantFilter{ID: rq.ID}, rq.Version, func(cq *Quadrant) ([]string, error) {
+		return applyReplace(cq, rq)
+	})
+}
+
+// save writes the quadrant of the filter once apply has changed it, the spots returned by
+// apply are deleted. The write fails when the expected version isn't the stored one.
+func (qs *QuadrantKVService) save(ctx context.Context, filter *QuadrantFilter, expected uint64, apply applyFunc) (*Quadrant, error) {
+	if err := filter.validate(); err != nil {
+		return nil, err
+	}
+
+	var quadrant *Quadrant
+
+	err := kvUpdate(ctx, qs.db, func(ctx context.Context, tx kvTx) error {
+		cq, err := qs.find(tx, filter)
+		if err != nil {
+			return err
+		}
+
+		if err := checkVersion("quadrant", cq.ID, cq.Version, expected); err != nil {
+			return err
+		}
+
+		if err := qs.repo.checkUnpublished(ctx, cq.MazeID); err != nil {
+			return err
+		}
+
+		idsToRemove, err := apply(cq)
+		if err != nil {
+			return err
+		}
+
+		if err := validateQuadrant(ctx, qs.repo, cq); err != nil {
+			return err
+		}
+
+		if err := validateSpotsInside(ctx, qs.repo, cq); err != nil {
+			return err
+		}
+
+		for i := range idsToRemove {
+			if err := tx.delete(spotsCollection, idsToRemove[i]); err != nil {
+				return err
+			}
+		}
+
+		cq.Version++
+
+		if err := kvPut(tx, quadrantsCollection, cq.ID, cq); err != nil {
+			return err
+		}
+
+		quadrant, err = qs.Get(ctx, &QuadrantFilter{ID: cq.ID})
+		if err != nil {
+			return fmt.Errorf("reading the updated quadrant %s: %w", cq.ID, err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return quadrant, nil
+}
+
+// Delete deletes a quadrant by quadrant type, the quadrants of a maze are only deleted
+// with it.
+func (qs *QuadrantKVService) Delete(ctx context.Context, qf *QuadrantFilter) (bool, error) {
+	if err := qf.validate(); err != nil {
+		return false, err
+	}
+
+	err := kvUpdate(ctx, qs.db, func(ctx context.Context, tx kvTx) error {
+		cq, err := qs.find(tx, qf)
+		if err != nil {
+			return err
+		}
+
+		if err := checkVersion("quadrant", cq.ID, cq.Version, qf.Version); err != nil {
+			return err
+		}
+
+		if err := checkDeletable(ctx, cq); err != nil {
+			return err
+		}
+
+		if len(cq.SpotIDs) > 0 {
+			if _, err := qs.repo.Spot.Delete(ctx, &SpotFilter{SpotsIDs: cq.SpotIDs}); err != nil {
+				return err
+			}
+		}
+
+		return tx.delete(quadrantsCollection, cq.ID)
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Page gets a page of the quadrants that satisfy the query with their spots, the query can
+// be nil to use all the quadrants.
+func (qs *QuadrantKVService) Page(ctx context.Context, qq *QuadrantQuery, opts *ListOptions) (*QuadrantPage, error) {
+	if qq == nil {
+		qq = &QuadrantQuery{}
+	}
+
+	if err := qq.validate(); err != nil {
+		return nil, err
+	}
+
+	p, err := newPage("quadrant", opts, quadrantSortFields)
+	if err != nil {
+		return nil, err
+	}
+
+	var res *QuadrantPage
+
+	err = kvView(ctx, qs.db, func(ctx context.Context, tx kvTx) error {
+		quadrants := make([]Quadrant, 0)
+
+		err := tx.forEach(quadrantsCollection, func(id string, raw []byte) error {
+			var q Quadrant
+
+			if err := bson.Unmarshal(raw, &q); err != nil {
+				return err
+			}
+
+			if qq.matches(&q) {
+				quadrants = append(quadrants, q)
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			return err
+		}
+
+		values := make([]interface{}, len(quadrants))
+		ids := make([]string, len(quadrants))
+
+		for i := range quadrants {
+			values[i], ids[i] = p.field.quadrant(&quadrants[i]), quadrants[i].ID
+		}
+
+		positions, next := p.paginate(values, ids)
+		res = &QuadrantPage{Items: make([]Quadrant, 0, len(positions)), NextCursor: next, Total: int64(len(quadrants))}
+
+		for _, i := range positions {
+			q := quadrants[i]
+
+			if len(q.SpotIDs) > 0 {
+				if q.Spots, err = qs.repo.Spot.List(ctx, &SpotFilter{SpotsIDs: q.SpotIDs}); err != nil {
+					return err
+				}
+			}
+
+			res.Items = append(res.Items, q)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("finding the quadrants: %w", err)
+	}
+
+	return res, nil
+}
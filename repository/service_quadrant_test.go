@@ -2,129 +2,392 @@ package repository
 
 import (
 	"context"
-	"os"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
 func TestQuadrant_CreateAndDelete(t *testing.T) {
-	Test_EnvMongoDBConnectionString(t)
+	forEachRepository(t, func(t *testing.T, ctx context.Context, conn *Repository) {
+		id, err := conn.Quadrant.Create(ctx, &Quadrant{
+			Type:       TopRight,
+			StartPoint: &Coordinate{X: 0, Y: 0},
+			LimitPoint: &Coordinate{X: 25, Y: 25},
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.NotEmpty(t, id)
+		assert.IsType(t, id, "")
+
+		isRemoved, err := conn.Quadrant.Delete(ctx, &QuadrantFilter{ID: id})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.True(t, isRemoved)
+		assert.EqualValues(t, true, isRemoved)
+	})
+}
 
-	var (
-		ctx  = DBNameSet(context.Background(), os.Getenv("DB_NAME"))
-		conn = NewByConnString(os.Getenv("MOGODB_CONN"))
-	)
+func TestQuadrant_Get(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, ctx context.Context, conn *Repository) {
+		expected := &Quadrant{
+			Type:       TopRight,
+			StartPoint: &Coordinate{X: 0, Y: 0},
+			LimitPoint: &Coordinate{X: 25, Y: 25},
+		}
 
-	id, err := conn.Quadrant.Create(ctx, &Quadrant{
-		Type:       TopRight,
-		StartPoint: &Coordinate{X: 0, Y: 0},
-		LimitPoint: &Coordinate{X: 25, Y: 25},
-	})
+		id, err := conn.Quadrant.Create(ctx, expected)
 
-	if err != nil {
-		t.Fatal(err)
-	}
+		if err != nil {
+			t.Fatal(err)
+		}
 
-	assert.NotEmpty(t, id)
-	assert.IsType(t, id, "")
+		assert.NotEmpty(t, id)
+		assert.IsType(t, id, "")
 
-	isRemoved, err := conn.Quadrant.Delete(ctx, &QuadrantFilter{ID: id})
-	if err != nil {
-		t.Fatal(err)
-	}
+		got, err := conn.Quadrant.Get(ctx, &QuadrantFilter{ID: id})
 
-	assert.True(t, isRemoved)
-	assert.EqualValues(t, true, isRemoved)
-}
+		if err != nil {
+			t.Fatal(err)
+		}
 
-func TestQuadrant_Get(t *testing.T) {
-	Test_EnvMongoDBConnectionString(t)
+		assert.IsType(t, &Quadrant{}, got)
+		assert.NotNil(t, got)
+		assert.EqualValues(t, expected, got)
 
-	var (
-		ctx  = DBNameSet(context.Background(), os.Getenv("DB_NAME"))
-		conn = NewByConnString(os.Getenv("MOGODB_CONN"))
-	)
+		isRemoved, err := conn.Quadrant.Delete(ctx, &QuadrantFilter{ID: id})
 
-	expected := &Quadrant{
-		Type:       TopRight,
-		StartPoint: &Coordinate{X: 0, Y: 0},
-		LimitPoint: &Coordinate{X: 25, Y: 25},
-	}
+		if err != nil {
+			t.Fatal(err)
+		}
 
-	id, err := conn.Quadrant.Create(ctx, expected)
+		assert.True(t, isRemoved)
+		assert.EqualValues(t, true, isRemoved)
+	})
+}
 
-	if err != nil {
-		t.Fatal(err)
-	}
+func TestQuadrant_Update(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, ctx context.Context, conn *Repository) {
+		id, err := conn.Quadrant.Create(ctx, &Quadrant{
+			Type:       TopRight,
+			StartPoint: &Coordinate{X: 0, Y: 0},
+			LimitPoint: &Coordinate{X: 25, Y: 25},
+		})
 
-	assert.NotEmpty(t, id)
-	assert.IsType(t, id, "")
+		if err != nil {
+			t.Fatal(err)
+		}
 
-	got, err := conn.Quadrant.Get(ctx, &QuadrantFilter{ID: id})
+		assert.NotEmpty(t, id)
+		assert.IsType(t, id, "")
 
-	if err != nil {
-		t.Fatal(err)
-	}
+		expected := &Quadrant{
+			ID:         id,
+			Type:       TopRight,
+			StartPoint: &Coordinate{X: 0, Y: 4},
+			LimitPoint: &Coordinate{X: 25, Y: 30},
+			SpotIDs:    []string{},
+		}
 
-	assert.IsType(t, &Quadrant{}, got)
-	assert.NotNil(t, got)
-	assert.EqualValues(t, expected, got)
+		got, err := conn.Quadrant.Update(ctx, expected)
 
-	isRemoved, err := conn.Quadrant.Delete(ctx, &QuadrantFilter{ID: id})
+		if err != nil {
+			t.Fatal(err)
+		}
 
-	if err != nil {
-		t.Fatal(err)
-	}
+		assert.IsType(t, &Quadrant{}, got)
+		assert.NotNil(t, got)
+		// the quadrant was created at version 1 and the update incremented it
+		expected.Version = 2
 
-	assert.True(t, isRemoved)
-	assert.EqualValues(t, true, isRemoved)
+		assert.EqualValues(t, expected, got)
+
+		isRemoved, err := conn.Quadrant.Delete(ctx, &QuadrantFilter{ID: id})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.True(t, isRemoved)
+		assert.EqualValues(t, true, isRemoved)
+	})
 }
 
-func TestQuadrant_Update(t *testing.T) {
-	Test_EnvMongoDBConnectionString(t)
+func TestQuadrant_UpdateByType(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, ctx context.Context, conn *Repository) {
+		mazes := []*Maze{
+			{Name: "labyrinth", Width: 50, Height: 40},
+			{Name: "another", Width: 50, Height: 40},
+		}
+
+		for _, m := range mazes {
+			if _, err := conn.Maze.Create(ctx, m); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// the first quadrant of a maze is the top left one
+		first, second := mazes[0].QuadrantIDs[0], mazes[1].QuadrantIDs[0]
+		spotIDs := make([]string, 0, 3)
+
+		for i, qID := range []string{first, second, second} {
+			sID, err := conn.Spot.Create(ctx, &Spot{Name: "gold", Coordinate: &Coordinate{X: uint(i), Y: 10}, QuadrantID: qID})
+			if err != nil {
+				t.Fatal(err)
+			}
 
-	var (
-		ctx  = DBNameSet(context.Background(), os.Getenv("DB_NAME"))
-		conn = NewByConnString(os.Getenv("MOGODB_CONN"))
-	)
+			spotIDs = append(spotIDs, sID)
+		}
 
-	id, err := conn.Quadrant.Create(ctx, &Quadrant{
-		Type:       TopRight,
-		StartPoint: &Coordinate{X: 0, Y: 0},
-		LimitPoint: &Coordinate{X: 25, Y: 25},
+		// the type is only unique in a maze
+		_, err := conn.Quadrant.Update(ctx, &Quadrant{Type: TopLeft, SpotIDs: spotIDs[1:2]})
+		assert.Error(t, err)
+
+		got, err := conn.Quadrant.Update(ctx, &Quadrant{MazeID: mazes[1].ID, Type: TopLeft, SpotIDs: spotIDs[1:2]})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, second, got.ID)
+		assert.Equal(t, spotIDs[1:2], got.SpotIDs)
+
+		var ve *ValidationError
+
+		// the quadrant can only keep its own spots
+		_, err = conn.Quadrant.Update(ctx, &Quadrant{ID: first, SpotIDs: spotIDs[:2]})
+		assert.True(t, errors.As(err, &ve))
+
+		got, err = conn.Quadrant.Get(ctx, &QuadrantFilter{ID: first})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, spotIDs[:1], got.SpotIDs)
+	})
+}
+
+func TestQuadrant_Replace(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, ctx context.Context, conn *Repository) {
+		id, err := conn.Quadrant.Create(ctx, &Quadrant{
+			Type:       TopLeft,
+			StartPoint: &Coordinate{X: 0, Y: 0},
+			LimitPoint: &Coordinate{X: 25, Y: 25},
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sID, err := conn.Spot.Create(ctx, &Spot{Name: "gold", Coordinate: &Coordinate{X: 20, Y: 20}, QuadrantID: id})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var ve *ValidationError
+
+		// the spots must stay inside the quadrant
+		_, err = conn.Quadrant.Replace(ctx, &Quadrant{
+			ID:         id,
+			Type:       TopLeft,
+			StartPoint: &Coordinate{X: 0, Y: 0},
+			LimitPoint: &Coordinate{X: 10, Y: 10},
+			SpotIDs:    []string{sID},
+		})
+		assert.True(t, errors.As(err, &ve))
+
+		// the quadrant can only keep its own spots
+		_, err = conn.Quadrant.Replace(ctx, &Quadrant{
+			ID:         id,
+			Type:       TopLeft,
+			StartPoint: &Coordinate{X: 0, Y: 0},
+			LimitPoint: &Coordinate{X: 25, Y: 25},
+			SpotIDs:    []string{sID, "000000000000000000000000"},
+		})
+		assert.True(t, errors.As(err, &ve))
+
+		// the spots that aren't sent are deleted
+		got, err := conn.Quadrant.Replace(ctx, &Quadrant{
+			ID:         id,
+			Type:       BottomRight,
+			StartPoint: &Coordinate{X: 0, Y: 0},
+			LimitPoint: &Coordinate{X: 10, Y: 10},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, BottomRight, got.Type)
+		assert.Empty(t, got.SpotIDs)
+
+		_, err = conn.Spot.Get(ctx, &SpotFilter{ID: sID})
+		assert.Equal(t, CodeNotFound, Code(err))
+
+		if _, err := conn.Quadrant.Delete(ctx, &QuadrantFilter{ID: id}); err != nil {
+			t.Fatal(err)
+		}
 	})
+}
 
-	if err != nil {
-		t.Fatal(err)
-	}
+func TestQuadrant_UpdateGeometryOfMaze(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, ctx context.Context, conn *Repository) {
+		maze := &Maze{Name: "labyrinth", Width: 50, Height: 40}
 
-	assert.NotEmpty(t, id)
-	assert.IsType(t, id, "")
+		if _, err := conn.Maze.Create(ctx, maze); err != nil {
+			t.Fatal(err)
+		}
 
-	expected := &Quadrant{
-		Type:       TopRight,
-		StartPoint: &Coordinate{X: 0, Y: 4},
-		LimitPoint: &Coordinate{X: 25, Y: 30},
-		SpotIDs:    []string{},
-	}
+		cq, err := conn.Quadrant.Get(ctx, &QuadrantFilter{ID: maze.QuadrantIDs[0]})
+		if err != nil {
+			t.Fatal(err)
+		}
 
-	got, err := conn.Quadrant.Update(ctx, expected)
+		var ve *ValidationError
 
-	if err != nil {
-		t.Fatal(err)
-	}
+		// the quadrants split the maze so one of them can't be resized on its own
+		_, err = conn.Quadrant.Update(ctx, &Quadrant{ID: cq.ID, LimitPoint: &Coordinate{X: 20, Y: 20}})
+		assert.True(t, errors.As(err, &ve))
+		assert.Equal(t, "limit_point", ve.Fields[0].Field)
 
-	assert.IsType(t, &Quadrant{}, got)
-	assert.NotNil(t, got)
-	assert.EqualValues(t, expected, got)
+		_, err = conn.Quadrant.Replace(ctx, &Quadrant{ID: cq.ID, Type: BottomRight, StartPoint: cq.StartPoint, LimitPoint: cq.LimitPoint})
+		assert.True(t, errors.As(err, &ve))
+		assert.Equal(t, "type", ve.Fields[0].Field)
 
-	isRemoved, err := conn.Quadrant.Delete(ctx, &QuadrantFilter{ID: id})
+		// the same geometry can be sent along with the rest of the quadrant
+		got, err := conn.Quadrant.Replace(ctx, &Quadrant{ID: cq.ID, Type: cq.Type, StartPoint: cq.StartPoint, LimitPoint: cq.LimitPoint})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, cq.LimitPoint, got.LimitPoint)
+	})
+}
 
-	if err != nil {
-		t.Fatal(err)
-	}
+func TestQuadrant_DeleteOfMaze(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, ctx context.Context, conn *Repository) {
+		mazes := []*Maze{
+			{Name: "labyrinth", Width: 50, Height: 40},
+			{Name: "another", Width: 50, Height: 40},
+		}
+
+		for _, m := range mazes {
+			if _, err := conn.Maze.Create(ctx, m); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		var ce *ConflictError
+
+		// the four quadrants always cover the maze so they are only deleted with it
+		_, err := conn.Quadrant.Delete(ctx, &QuadrantFilter{ID: mazes[0].QuadrantIDs[0]})
+		assert.True(t, errors.As(err, &ce))
+		assert.Equal(t, "maze_id", ce.Fields[0].Field)
+
+		got, err := conn.Quadrant.Get(ctx, &QuadrantFilter{ID: mazes[0].QuadrantIDs[0]})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// nor moved to another maze
+		_, err = conn.Quadrant.Replace(ctx, &Quadrant{
+			ID:         got.ID,
+			MazeID:     mazes[1].ID,
+			Type:       got.Type,
+			StartPoint: got.StartPoint,
+			LimitPoint: got.LimitPoint,
+		})
+		assert.True(t, errors.As(err, &ce))
+
+		if _, err := conn.Maze.Delete(ctx, &MazeFilter{ID: mazes[0].ID}); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = conn.Quadrant.Get(ctx, &QuadrantFilter{ID: got.ID})
+		assert.Equal(t, CodeNotFound, Code(err))
+	})
+}
 
-	assert.True(t, isRemoved)
-	assert.EqualValues(t, true, isRemoved)
+func TestQuadrant_Page(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, ctx context.Context, conn *Repository) {
+		maze := &Maze{Name: "labyrinth", Width: 50, Height: 40}
+
+		if _, err := conn.Maze.Create(ctx, maze); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := conn.Maze.Create(ctx, &Maze{Name: "another", Width: 10, Height: 10}); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := conn.Spot.Create(ctx, &Spot{
+			Name:       "gold",
+			MazeID:     maze.ID,
+			GoldAmount: 10,
+			Coordinate: &Coordinate{X: 1, Y: 1},
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		page, err := conn.Quadrant.Page(ctx, &QuadrantQuery{MazeID: maze.ID}, &ListOptions{Sort: "-y", Limit: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, int64(4), page.Total)
+		assert.Len(t, page.Items, 2)
+		assert.NotEmpty(t, page.NextCursor)
+
+		for _, q := range page.Items {
+			assert.Equal(t, uint(20), q.StartPoint.Y)
+		}
+
+		page, err = conn.Quadrant.Page(ctx, &QuadrantQuery{MazeID: maze.ID}, &ListOptions{
+			Sort:   "-y",
+			Limit:  2,
+			Cursor: page.NextCursor,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Len(t, page.Items, 2)
+		assert.Empty(t, page.NextCursor)
+
+		for _, q := range page.Items {
+			assert.Equal(t, uint(0), q.StartPoint.Y)
+		}
+
+		// the quadrants that overlap the box come with their spots
+		page, err = conn.Quadrant.Page(ctx, &QuadrantQuery{
+			MazeID: maze.ID,
+			Box:    &Region{LimitPoint: Coordinate{X: 10, Y: 30}},
+		}, &ListOptions{Sort: "y"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Len(t, page.Items, 2)
+		assert.Equal(t, TopLeft, page.Items[0].Type)
+		assert.Len(t, page.Items[0].Spots, 1)
+		assert.Equal(t, BottomLeft, page.Items[1].Type)
+
+		page, err = conn.Quadrant.Page(ctx, &QuadrantQuery{Type: BottomRight}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, int64(2), page.Total)
+
+		var ve *ValidationError
+
+		_, err = conn.Quadrant.Page(ctx, &QuadrantQuery{Type: "MIDDLE"}, nil)
+		assert.True(t, errors.As(err, &ve))
+
+		_, err = conn.Quadrant.Page(ctx, nil, &ListOptions{Limit: maxPageLimit + 1})
+		assert.True(t, errors.As(err, &ve))
+	})
 }
@@ -7,15 +7,29 @@ import (
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-// SpotMongoDBService defines the interface that device must satisfy.
-type SpotMongoDBService interface {
+// SpotStore defines the interface that each spot backend must satisfy.
+type SpotStore interface {
 	Create(ctx context.Context, s *Spot) (id string, err error)
 	Update(ctx context.Context, su *Spot) (s *Spot, err error)
+	Replace(ctx context.Context, s *Spot) (rs *Spot, err error)
 	Get(ctx context.Context, sf *SpotFilter) (s *Spot, err error)
 	List(ctx context.Context, sf *SpotFilter) (spots []Spot, err error)
 	Delete(ctx context.Context, sf *SpotFilter) (isRemoved bool, err error)
+	TotalGoldByQuadrant(ctx context.Context, sf *SpotFilter) (totals []GoldTotal, err error)
+	TotalGoldByMaze(ctx context.Context, sf *SpotFilter) (totals []GoldTotal, err error)
+	Richest(ctx context.Context, sf *SpotFilter, limit int) (spots []Spot, err error)
+	InBox(ctx context.Context, sf *SpotFilter, r *Region) (spots []Spot, err error)
+	InRadius(ctx context.Context, sf *SpotFilter, center Coordinate, radius float64) (spots []Spot, err error)
+	Nearest(ctx context.Context, sf *SpotFilter, point Coordinate, limit int) (spots []Spot, err error)
+	Page(ctx context.Context, sq *SpotQuery, opts *ListOptions) (page *SpotPage, err error)
+	BatchCreate(ctx context.Context, spots []Spot) (results []BatchResult, err error)
+	BatchUpdate(ctx context.Context, spots []Spot) (results []BatchResult, err error)
+	BatchDelete(ctx context.Context, spots []Spot) (results []BatchResult, err error)
+	CreateMany(ctx context.Context, spots []Spot) (err error)
 }
 
 // SpotService represents a mongoServie that contains the MongoDB client.
@@ -24,44 +38,110 @@ type SpotService mongoService
 // SpotService validate if it satisfy the own interface, that means
 // that all mongoService can be implement its own interface but it must be
 // a mongoService type.
-var _ SpotMongoDBService = &SpotService{}
+var _ SpotStore = &SpotService{}
+
+// placeFunc applies the changes of a write to the stored spot and resolves the quadrant
+// where it must be placed.
+type placeFunc func(ctx context.Context, spot *Spot) (*Quadrant, error)
 
 // Spot represents a spot information that is in the maze.
 type Spot struct {
 	ID         string      `json:"id,omitempty" bson:"_id,omitempty"`
+	MazeID     string      `json:"maze_id,omitempty" bson:"maze_id,omitempty"`
 	Name       string      `json:"name,omitempty" bson:"name,omitempty" binding:"required"`
-	GoldAmount string      `json:"gold_mount,omitempty" bson:"gold_mount,omitempty" binding:"required"`
+	GoldAmount uint        `json:"gold_amount" bson:"gold_amount"`
 	Coordinate *Coordinate `json:"Coordinate,omitempty" bson:"Coordinate,omitempty" binding:"required"`
-	QuadrantID string      `json:"quadrant_id,omitempty" bson:"quadrant_id,omitempty" binding:"required"`
+	QuadrantID string      `json:"quadrant_id,omitempty" bson:"quadrant_id,omitempty"`
+	Kind       SpotKind    `json:"kind,omitempty" bson:"kind,omitempty"`
+	Penalty    uint        `json:"penalty,omitempty" bson:"penalty,omitempty"`
+	// Version is incremented by every write, the writes that send a version other than
+	// zero fail with a VersionError when it isn't the stored one.
+	Version uint64 `json:"version" bson:"version"`
 }
 
 // SpotFilter represents the filter that can be used to create a mongo query.
 type SpotFilter struct {
 	ID         string   `json:"id,omitempty" bson:"id,omitempty"`
+	MazeID     string   `json:"maze_id,omitempty" bson:"maze_id,omitempty"`
 	QuadrantID string   `json:"quadrant_id,omitempty" bson:"quadrant_id,omitempty"`
 	SpotsIDs   []string `json:"spot_ids,omitempty"`
+	Kind       SpotKind `json:"kind,omitempty" bson:"kind,omitempty"`
+	// Version is the version the spot of ID must be at to be deleted, Delete fails with a
+	// VersionError otherwise. Zero deletes it at any version.
+	Version uint64 `json:"-" bson:"-"`
 }
 
-func (qf *SpotFilter) toMongoFilter() (bson.M, error) {
+func (qf *SpotFilter) validate() error {
 	if qf == nil {
-		return nil, errors.New("quadrant filter must not be nil")
+		return errors.New("quadrant filter must not be nil")
 	}
 
-	if qf.ID == "" && qf.QuadrantID == "" && len(qf.SpotsIDs) == 0 {
-		return nil, errors.New("at least one of SpotFilter.ID, SpotFilter.QuadrantID and SpotFilter.SpotIDs must be specified")
+	if qf.ID == "" && qf.MazeID == "" && qf.QuadrantID == "" && len(qf.SpotsIDs) == 0 && qf.Kind == "" {
+		return BadRequest("at least one of SpotFilter.ID, SpotFilter.MazeID, SpotFilter.QuadrantID, SpotFilter.SpotIDs and SpotFilter.Kind must be specified")
 	}
 
-	filter := bson.M{}
+	if qf.Version != 0 && qf.ID == "" {
+		return BadRequest("SpotFilter.Version requires SpotFilter.ID")
+	}
+
+	ve := &ValidationError{Entity: "spot"}
+
+	if qf.Kind != "" && !isValidSpotKind(qf.Kind) {
+		ve.add("kind", "must be one of %s, %s, %s, %s and %s", Entrance, Exit, Treasure, Trap, Wall)
+	}
 
 	if qf.ID != "" {
-		id, err := primitive.ObjectIDFromHex(qf.ID)
-		if err != nil {
-			return nil, fmt.Errorf("wrong id%s", qf.ID)
+		if _, err := primitive.ObjectIDFromHex(qf.ID); err != nil {
+			ve.add("id", "must be a valid id")
 		}
+	}
+
+	return ve.err()
+}
+
+// matches reports if the spot satisfies the filter, it is used by the backends
+// that can't translate the filter into a query.
+func (qf *SpotFilter) matches(s *Spot) bool {
+	if qf.ID != "" && qf.ID != s.ID {
+		return false
+	}
+
+	if qf.MazeID != "" && qf.MazeID != s.MazeID {
+		return false
+	}
+
+	if qf.QuadrantID != "" && qf.QuadrantID != s.QuadrantID {
+		return false
+	}
+
+	if len(qf.SpotsIDs) > 0 && !containsString(qf.SpotsIDs, s.ID) {
+		return false
+	}
+
+	if qf.Kind != "" && qf.Kind != s.Kind {
+		return false
+	}
+
+	return true
+}
+
+func (qf *SpotFilter) toMongoFilter() (bson.M, error) {
+	if err := qf.validate(); err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{}
+
+	if qf.ID != "" {
+		id, _ := primitive.ObjectIDFromHex(qf.ID)
 
 		filter["_id"] = id
 	}
 
+	if qf.MazeID != "" {
+		filter["maze_id"] = qf.MazeID
+	}
+
 	if qf.QuadrantID != "" {
 		filter["quadrant_id"] = qf.QuadrantID
 	}
@@ -79,50 +159,308 @@ func (qf *SpotFilter) toMongoFilter() (bson.M, error) {
 		filter["_id"] = bson.M{"$in": ids}
 	}
 
+	if qf.Kind != "" {
+		filter["kind"] = qf.Kind
+	}
+
 	return filter, nil
 }
 
-// Create creates a new spot in a maze.
-func (ss *SpotService) Create(ctx context.Context, s *Spot) (string, error) {
-	if s.QuadrantID == "" {
-		return "", errors.New("the Spot.QuadrantID attribute must be specified")
+// quadrantReader reads the quadrants where the spots are placed, the batches read each
+// quadrant once and keep the spots they write in it.
+type quadrantReader interface {
+	quadrant(ctx context.Context, id string) (*Quadrant, error)
+	mazeQuadrants(ctx context.Context, mazeID string) ([]Quadrant, error)
+	kindSpots(ctx context.Context, mazeID string, kind SpotKind) ([]Spot, error)
+	checkUnpublished(ctx context.Context, mazeID string) error
+}
+
+func (r *Repository) quadrant(ctx context.Context, id string) (*Quadrant, error) {
+	return r.Quadrant.Get(ctx, &QuadrantFilter{ID: id})
+}
+
+func (r *Repository) mazeQuadrants(ctx context.Context, mazeID string) ([]Quadrant, error) {
+	return r.Quadrant.List(ctx, &QuadrantFilter{MazeID: mazeID})
+}
+
+func (r *Repository) kindSpots(ctx context.Context, mazeID string, kind SpotKind) ([]Spot, error) {
+	return r.Spot.List(ctx, &SpotFilter{MazeID: mazeID, Kind: kind})
+}
+
+// placeIn sets the maze of the spot from its quadrant, when the maze is already set it
+// must be the same one.
+func (s *Spot) placeIn(q *Quadrant) error {
+	if s.MazeID != "" && s.MazeID != q.MazeID {
+		return invalidField("spot", "quadrant_id", "the quadrant %s doesn't belong to the maze %s", q.ID, s.MazeID)
 	}
 
-	if s.Name == "" {
-		return "", errors.New("the Spot.Name attribute must be specified")
+	s.MazeID = q.MazeID
+
+	return nil
+}
+
+// placeSpot resolves the quadrant of the spot and checks that the spot can be placed in
+// it, the coordinate must be inside the quadrant and it can't be used by another spot.
+// When the quadrant isn't given, the spot is placed in the quadrant of its maze that
+// contains the coordinate. The rules of its kind are checked as well, a spot without kind
+// is a treasure.
+func placeSpot(ctx context.Context, qr quadrantReader, s *Spot) (*Quadrant, error) {
+	ve := &ValidationError{Entity: "spot"}
+
+	q, err := locateSpot(ctx, qr, s, ve)
+	if err != nil {
+		return nil, err
 	}
 
+	if err := validateEntrance(ctx, qr, s, q, ve); err != nil {
+		return nil, err
+	}
+
+	if err := ve.err(); err != nil {
+		return nil, err
+	}
+
+	// the quadrants of a maze don't overlap, so only the spots of the quadrant can collide
+	ce := &ConflictError{Entity: "spot"}
+
+	for i := range q.Spots {
+		other := &q.Spots[i]
+
+		if other.ID != s.ID && other.Coordinate != nil && *other.Coordinate == *s.Coordinate {
+			ce.add("Coordinate", "is already used by the spot %s", other.ID)
+		}
+	}
+
+	if err := ce.err(); err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+// locateSpot resolves the quadrant of the spot and checks that its coordinate is inside
+// it, the errors of the attributes of the spot are added to ve.
+func locateSpot(ctx context.Context, qr quadrantReader, s *Spot, ve *ValidationError) (*Quadrant, error) {
+	if s.Kind == "" {
+		s.Kind = Treasure
+	}
+
+	s.validateKind(ve)
+
 	if s.Coordinate == nil {
-		return "", errors.New("the Spot.Coordinate attribute must be specified")
+		ve.add("Coordinate", "must be specified")
+
+		return nil, ve
 	}
 
-	if _, err := New(ss.db).Quadrant.Get(ctx, &QuadrantFilter{ID: s.QuadrantID}); err != nil {
-		return "", fmt.Errorf("can't reach quadrant: %s", err)
+	var q *Quadrant
+
+	switch {
+	case s.QuadrantID != "":
+		var err error
+
+		q, err = qr.quadrant(ctx, s.QuadrantID)
+		if err != nil {
+			return nil, fmt.Errorf("can't reach quadrant: %w", err)
+		}
+	case s.MazeID != "":
+		quadrants, err := qr.mazeQuadrants(ctx, s.MazeID)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range quadrants {
+			if quadrants[i].Contains(*s.Coordinate) {
+				q = &quadrants[i]
+
+				break
+			}
+		}
+
+		if q == nil {
+			ve.add("Coordinate", "isn't inside any quadrant of the maze %s", s.MazeID)
+
+			return nil, ve
+		}
+	default:
+		ve.add("quadrant_id", "must be specified when the spot doesn't belong to a maze")
+
+		return nil, ve
 	}
 
-	res, err := ss.db.Database(DBName(ctx)).Collection("spots").InsertOne(ctx, s)
-	if err != nil {
-		return "", fmt.Errorf("inserting a spot: %s", err)
+	if err := s.placeIn(q); err != nil {
+		return nil, err
 	}
 
-	q, err := New(ss.db).Quadrant.Get(ctx, &QuadrantFilter{ID: s.QuadrantID})
-	if err != nil {
-		return "", fmt.Errorf("getting a quadrant: %s", err)
+	if err := qr.checkUnpublished(ctx, q.MazeID); err != nil {
+		return nil, err
+	}
+
+	s.QuadrantID = q.ID
+
+	if !q.Contains(*s.Coordinate) {
+		ve.add("Coordinate", "must be inside the quadrant from %d,%d to %d,%d",
+			q.StartPoint.X, q.StartPoint.Y, q.LimitPoint.X, q.LimitPoint.Y)
+
+		return nil, ve
+	}
+
+	return q, nil
+}
+
+// placeUpdate applies the attributes of su to the spot and resolves the quadrant where it
+// must be placed, the zero values of su leave the attributes unchanged.
+func placeUpdate(ctx context.Context, qr quadrantReader, spot, su *Spot) (*Quadrant, error) {
+	stored := *spot
+
+	if su.Name != "" {
+		spot.Name = su.Name
+	}
+
+	if su.Coordinate != nil {
+		spot.Coordinate = su.Coordinate
+	}
+
+	if su.GoldAmount != 0 {
+		spot.GoldAmount = su.GoldAmount
+	}
+
+	if su.Kind != "" && su.Kind != spot.Kind {
+		spot.Kind = su.Kind
+		// the penalty only belongs to the traps
+		spot.Penalty = 0
 	}
 
-	q.SpotIDs = append(q.SpotIDs, res.InsertedID.(primitive.ObjectID).Hex())
+	if su.Penalty != 0 {
+		spot.Penalty = su.Penalty
+	}
+
+	if spot.Kind == "" {
+		spot.Kind = Treasure
+	}
+
+	if err := validateKept(ctx, qr, &stored, spot); err != nil {
+		return nil, err
+	}
+
+	return relocate(ctx, qr, spot, su.QuadrantID, su.Coordinate != nil)
+}
+
+// placeReplace replaces the attributes of the spot with the ones of s, including the zero
+// values, and resolves the quadrant where it must be placed. The spot can't be moved to
+// another maze.
+func placeReplace(ctx context.Context, qr quadrantReader, spot, s *Spot) (*Quadrant, error) {
+	ve := &ValidationError{Entity: "spot"}
+
+	if s.Name == "" {
+		ve.add("name", "must be specified")
+	}
+
+	if s.MazeID != "" && s.MazeID != spot.MazeID {
+		ve.add("maze_id", "can't be changed")
+	}
 
-	if _, err := New(ss.db).Quadrant.Update(ctx, q); err != nil {
-		return "", fmt.Errorf("updating a quadrant: %s", err)
+	if err := ve.err(); err != nil {
+		return nil, err
+	}
+
+	stored := *spot
+
+	spot.Name = s.Name
+	spot.GoldAmount = s.GoldAmount
+	spot.Coordinate = s.Coordinate
+	spot.Kind = s.Kind
+	spot.Penalty = s.Penalty
+
+	if spot.Kind == "" {
+		spot.Kind = Treasure
+	}
+
+	if err := validateKept(ctx, qr, &stored, spot); err != nil {
+		return nil, err
 	}
 
-	return res.InsertedID.(primitive.ObjectID).Hex(), nil
+	return relocate(ctx, qr, spot, s.QuadrantID, true)
+}
+
+// relocate resolves the quadrant where the spot must be placed, the spot stays in its
+// quadrant unless another one is given or it was moved to a coordinate of its maze
+// without quadrant.
+func relocate(ctx context.Context, qr quadrantReader, spot *Spot, quadrantID string, moved bool) (*Quadrant, error) {
+	target := *spot
+	target.QuadrantID = quadrantID
+
+	if target.QuadrantID == "" && (!moved || spot.MazeID == "") {
+		target.QuadrantID = spot.QuadrantID
+	}
+
+	return placeSpot(ctx, qr, &target)
+}
+
+// Create creates a new spot in a maze, when the quadrant isn't given it is placed in the
+// quadrant of the maze that contains its coordinate.
+func (ss *SpotService) Create(ctx context.Context, s *Spot) (string, error) {
+	ve := &ValidationError{Entity: "spot"}
+
+	if s.Name == "" {
+		ve.add("name", "must be specified")
+	}
+
+	if s.Coordinate == nil {
+		ve.add("Coordinate", "must be specified")
+	}
+
+	if err := ve.err(); err != nil {
+		return "", err
+	}
+
+	// the spot and the spot_ids of its quadrant are written in the same transaction
+	err := mongoTransaction(ctx, ss.db, func(ctx context.Context) error {
+		q, err := placeSpot(ctx, ss.repo, s)
+		if err != nil {
+			return err
+		}
+
+		qID, err := primitive.ObjectIDFromHex(q.ID)
+		if err != nil {
+			return fmt.Errorf("wrong quadrant id%s", q.ID)
+		}
+
+		s.Version = 1
+
+		doc := *s
+		doc.ID = ""
+
+		res, err := ss.db.Database(DBName(ctx)).Collection("spots").InsertOne(ctx, &doc)
+		if err != nil {
+			return fmt.Errorf("inserting a spot: %w", err)
+		}
+
+		s.ID = res.InsertedID.(primitive.ObjectID).Hex()
+
+		_, err = ss.db.Database(DBName(ctx)).Collection("quadrants").UpdateOne(ctx,
+			bson.M{"_id": qID},
+			bson.M{"$addToSet": bson.M{"spot_ids": s.ID}, "$inc": bson.M{"version": 1}},
+		)
+
+		if err != nil {
+			return fmt.Errorf("updating a quadrant: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return s.ID, nil
 }
 
 // Get gets a spot in a maze.
 func (ss *SpotService) Get(ctx context.Context, sf *SpotFilter) (*Spot, error) {
 	if sf.ID == "" {
-		return nil, errors.New("the SpotFilter.ID attribute must be specified")
+		return nil, invalidField("spot", "id", "must be specified")
 	}
 
 	filter, err := sf.toMongoFilter()
@@ -137,109 +475,168 @@ func (ss *SpotService) Get(ctx context.Context, sf *SpotFilter) (*Spot, error) {
 
 	err = ss.db.Database(dbName).Collection("spots").FindOne(ctx, filter).Decode(spot)
 	if err != nil {
-		return nil, err
+		return nil, notFound("spot", sf.ID, err)
 	}
 
 	return spot, nil
 }
 
-// Update creates a spot in a maze.
+// Update updates the attributes of the spot given in su, the zero values are left
+// unchanged.
 func (ss *SpotService) Update(ctx context.Context, su *Spot) (*Spot, error) {
 	if su.ID == "" {
-		return nil, errors.New("the Spot.ID attribute must be specified")
-	}
-
-	if su.QuadrantID == "" {
-		return nil, errors.New("the Spot.QuadrantID attribute must be specified")
+		return nil, invalidField("spot", "id", "must be specified")
 	}
 
-	filter := &SpotFilter{ID: su.ID}
+	return ss.save(ctx, su.ID, su.Version, func(ctx context.Context, spot *Spot) (*Quadrant, error) {
+		return placeUpdate(ctx, ss.repo, spot, su)
+	})
+}
 
-	spot, err := ss.Get(ctx, filter)
-	if err != nil {
-		return nil, fmt.Errorf("can't find the spot: %s", err)
+// Replace replaces all the attributes of the spot with the ones of s, the zero values
+// are set as well.
+func (ss *SpotService) Replace(ctx context.Context, s *Spot) (*Spot, error) {
+	if s.ID == "" {
+		return nil, invalidField("spot", "id", "must be specified")
 	}
 
-	if su.Name != "" {
-		spot.Name = su.Name
-	}
+	return ss.save(ctx, s.ID, s.Version, func(ctx context.Context, spot *Spot) (*Quadrant, error) {
+		return placeReplace(ctx, ss.repo, spot, s)
+	})
+}
 
-	if su.Coordinate != nil {
-		spot.Coordinate = su.Coordinate
-	}
+// save writes the spot once place has applied the changes to the stored one and has
+// resolved its quadrant, the write fails when the expected version isn't the stored one.
+// The versions of the quadrants are incremented as well since their spots change.
+func (ss *SpotService) save(ctx context.Context, id string, expected uint64, place placeFunc) (*Spot, error) {
+	filter := &SpotFilter{ID: id}
 
-	if su.GoldAmount != "" {
-		spot.GoldAmount = su.GoldAmount
-	}
+	var spot *Spot
 
-	update := bson.M{
-		"name":        spot.Name,
-		"gold_mount":  spot.GoldAmount,
-		"Coordinate":  spot.Coordinate,
-		"quadrant_id": su.QuadrantID,
-	}
+	// the spot and the spot_ids of both quadrants are written in the same transaction
+	err := mongoTransaction(ctx, ss.db, func(ctx context.Context) error {
+		var err error
 
-	sf, err := filter.toMongoFilter()
-	if err != nil {
-		return nil, fmt.Errorf("parsing internal filter: %s", err)
-	}
+		spot, err = ss.Get(ctx, filter)
+		if err != nil {
+			return err
+		}
 
-	_, err = ss.db.Database(DBName(ctx)).Collection("spots").ReplaceOne(ctx, sf, update)
-	if err != nil {
-		return nil, err
-	}
+		if err := checkVersion("spot", id, spot.Version, expected); err != nil {
+			return err
+		}
 
-	if spot.QuadrantID != su.QuadrantID {
-		q, err := New(ss.db).Quadrant.Get(ctx, &QuadrantFilter{ID: spot.QuadrantID})
+		q, err := place(ctx, spot)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		newSpotIDs := make([]string, 0)
+		// the spot is written like the creates and the batches do, without the empty attributes
+		written := *spot
+		written.QuadrantID = q.ID
+		written.Version++
 
-		for i := range q.SpotIDs {
-			if spot.ID != q.SpotIDs[i] {
-				newSpotIDs = append(newSpotIDs, spot.ID)
-			}
+		sf, err := filter.toMongoFilter()
+		if err != nil {
+			return fmt.Errorf("parsing internal filter: %w", err)
 		}
 
-		if _, err := New(ss.db).Quadrant.Update(ctx, &Quadrant{ID: spot.ID, SpotIDs: newSpotIDs}); err != nil {
-			return nil, err
-		}
+		// the version in the filter makes the write fail if another one happened meanwhile
+		sf["version"] = versionFilter(spot.Version)
 
-		q, err = New(ss.db).Quadrant.Get(ctx, &QuadrantFilter{ID: su.QuadrantID})
+		res, err := ss.db.Database(DBName(ctx)).Collection("spots").ReplaceOne(ctx, sf, written.mongoDocument())
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		q.SpotIDs = append(q.SpotIDs, spot.ID)
+		if res.MatchedCount == 0 {
+			return &VersionError{Entity: "spot", ID: id, Expected: spot.Version}
+		}
 
-		if _, err := New(ss.db).Quadrant.Update(ctx, q); err != nil {
-			return nil, err
+		spot.Version++
+
+		if spot.QuadrantID != q.ID {
+			return ss.move(ctx, spot, q.ID)
 		}
+
+		return ss.touch(ctx, q.ID)
+	})
+
+	if err != nil {
+		return nil, err
 	}
 
 	return spot, nil
 }
 
+// touch increments the version of the quadrant after a write of one of its spots.
+func (ss *SpotService) touch(ctx context.Context, quadrantID string) error {
+	id, err := primitive.ObjectIDFromHex(quadrantID)
+	if err != nil {
+		return fmt.Errorf("wrong quadrant id%s", quadrantID)
+	}
+
+	_, err = ss.db.Database(DBName(ctx)).Collection("quadrants").UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$inc": bson.M{"version": 1}},
+	)
+
+	return err
+}
+
+// move moves the spot id from the spot_ids of its current quadrant to the new one.
+func (ss *SpotService) move(ctx context.Context, spot *Spot, quadrantID string) error {
+	quadrants := ss.db.Database(DBName(ctx)).Collection("quadrants")
+
+	oldID, err := primitive.ObjectIDFromHex(spot.QuadrantID)
+	if err != nil {
+		return fmt.Errorf("wrong quadrant id%s", spot.QuadrantID)
+	}
+
+	_, err = quadrants.UpdateOne(ctx, bson.M{"_id": oldID}, bson.M{
+		"$pull": bson.M{"spot_ids": spot.ID},
+		"$inc":  bson.M{"version": 1},
+	})
+	if err != nil {
+		return err
+	}
+
+	newID, err := primitive.ObjectIDFromHex(quadrantID)
+	if err != nil {
+		return fmt.Errorf("wrong quadrant id%s", quadrantID)
+	}
+
+	_, err = quadrants.UpdateOne(ctx, bson.M{"_id": newID}, bson.M{
+		"$addToSet": bson.M{"spot_ids": spot.ID},
+		"$inc":      bson.M{"version": 1},
+	})
+	if err != nil {
+		return err
+	}
+
+	spot.QuadrantID = quadrantID
+
+	return nil
+}
+
 // List gets all spots by quadrant id in a maze.
 func (ss *SpotService) List(ctx context.Context, sf *SpotFilter) ([]Spot, error) {
 	if sf.ID != "" {
-		return nil, errors.New("the SpotFilter.ID attribute must not be specified")
+		return nil, BadRequest("the SpotFilter.ID attribute must not be specified")
 	}
 
-	if sf.QuadrantID == "" && len(sf.SpotsIDs) == 0 {
-		return nil, errors.New("at least one of SpotFilter.QuadrantID and SpotFilter.SpotIDs attributes must be specified")
+	if sf.MazeID == "" && sf.QuadrantID == "" && len(sf.SpotsIDs) == 0 {
+		return nil, BadRequest("at least one of SpotFilter.MazeID, SpotFilter.QuadrantID and SpotFilter.SpotIDs attributes must be specified")
 	}
 
 	filter, err := sf.toMongoFilter()
 	if err != nil {
-		return nil, fmt.Errorf("decoding filter for Spot.List: %s", err)
+		return nil, fmt.Errorf("decoding filter for Spot.List: %w", err)
 	}
 
 	cursor, err := ss.db.Database(DBName(ctx)).Collection("spots").Find(ctx, filter)
 	if err != nil {
-		return nil, fmt.Errorf("finding all spots: %s", err)
+		return nil, fmt.Errorf("finding all spots: %w", err)
 	}
 
 	defer cursor.Close(ctx)
@@ -256,20 +653,20 @@ func (ss *SpotService) List(ctx context.Context, sf *SpotFilter) ([]Spot, error)
 	}
 
 	if err := cursor.Err(); err != nil {
-		return nil, fmt.Errorf("can't decode spots: %s", err)
+		return nil, fmt.Errorf("can't decode spots: %w", err)
 	}
 
 	return spots, nil
 }
 
-// Delete deletes a spot.
+// Delete deletes the spots of the filter, isRemoved is false when none of them existed.
 func (ss *SpotService) Delete(ctx context.Context, sf *SpotFilter) (bool, error) {
 	spotIDs := make([]string, 0)
 
 	switch {
 	case sf.ID != "":
 		spotIDs = append(spotIDs, sf.ID)
-	case sf.QuadrantID != "":
+	case sf.MazeID != "", sf.QuadrantID != "":
 		spots, err := ss.List(ctx, sf)
 		if err != nil {
 			return false, err
@@ -293,24 +690,485 @@ func (ss *SpotService) Delete(ctx context.Context, sf *SpotFilter) (bool, error)
 			return false, err
 		}
 
-		_, err = ss.db.Database(DBName(ctx)).Collection("spots").DeleteMany(ctx, f)
+		if sf.Version != 0 {
+			f["version"] = sf.Version
+		}
+
+		var removed int64
+
+		// the spots and the spot_ids of the quadrants are written in the same transaction
+		err = mongoTransaction(ctx, ss.db, func(ctx context.Context) error {
+			if sf.ID != "" {
+				var nf *NotFoundError
+
+				stored, err := ss.Get(ctx, &SpotFilter{ID: sf.ID})
+				if errors.As(err, &nf) {
+					return nil
+				}
+
+				if err != nil {
+					return err
+				}
+
+				if err := checkVersion("spot", sf.ID, stored.Version, sf.Version); err != nil {
+					return err
+				}
+
+				if err := ss.repo.checkUnpublished(ctx, stored.MazeID); err != nil {
+					return err
+				}
+
+				if err := validateKept(ctx, ss.repo, stored, nil); err != nil {
+					return err
+				}
+			}
+
+			res, err := ss.db.Database(DBName(ctx)).Collection("spots").DeleteMany(ctx, f)
+			if err != nil {
+				return err
+			}
+
+			removed = res.DeletedCount
+
+			// the spot was changed by another write since it was read
+			if removed == 0 && sf.Version != 0 {
+				return &VersionError{Entity: "spot", ID: sf.ID, Expected: sf.Version}
+			}
+
+			_, err = ss.db.Database(DBName(ctx)).Collection("quadrants").UpdateMany(ctx,
+				bson.M{"spot_ids": bson.M{"$in": spotIDs}},
+				bson.M{
+					"$pullAll": bson.M{"spot_ids": spotIDs},
+					"$inc":     bson.M{"version": 1},
+				},
+			)
+
+			return err
+		})
+
 		if err != nil {
 			return false, err
 		}
 
-		_, err = ss.db.Database(DBName(ctx)).Collection("quadrants").UpdateMany(ctx,
-			bson.M{},
-			bson.M{"$pullAll": bson.M{
-				"spot_ids": spotIDs,
-			}},
-		)
+		return removed > 0, nil
+	}
+
+	return false, BadRequest("filter is wrong")
+}
+
+// GoldTotal represents the gold amount of the spots of a quadrant or a maze.
+type GoldTotal struct {
+	ID         string `json:"id" bson:"_id"`
+	GoldAmount uint   `json:"gold_amount" bson:"gold_amount"`
+	Spots      uint   `json:"spots" bson:"spots"`
+}
+
+// toMongoMatch works like toMongoFilter but a nil or empty filter matches all the spots.
+func (qf *SpotFilter) toMongoMatch() (bson.M, error) {
+	if qf == nil || (qf.ID == "" && qf.MazeID == "" && qf.QuadrantID == "" && len(qf.SpotsIDs) == 0 && qf.Kind == "") {
+		return bson.M{}, nil
+	}
+
+	return qf.toMongoFilter()
+}
+
+// validateLimit checks the number of spots requested by Richest.
+func validateLimit(limit int) error {
+	if limit <= 0 {
+		ve := &ValidationError{Entity: "spot"}
+		ve.add("limit", "must be greater than zero")
+
+		return ve
+	}
+
+	return nil
+}
+
+// TotalGoldByQuadrant sums the gold of the spots that satisfy the filter by quadrant, the
+// filter can be nil to use all the spots.
+func (ss *SpotService) TotalGoldByQuadrant(ctx context.Context, sf *SpotFilter) ([]GoldTotal, error) {
+	return ss.totalGold(ctx, sf, "quadrant_id")
+}
+
+// TotalGoldByMaze sums the gold of the spots that satisfy the filter by maze, the filter
+// can be nil to use all the spots.
+func (ss *SpotService) TotalGoldByMaze(ctx context.Context, sf *SpotFilter) ([]GoldTotal, error) {
+	return ss.totalGold(ctx, sf, "maze_id")
+}
+
+// totalGold groups the spots by the key using an aggregation pipeline, the spots without
+// the key are skipped.
+func (ss *SpotService) totalGold(ctx context.Context, sf *SpotFilter, key string) ([]GoldTotal, error) {
+	match, err := sf.toMongoMatch()
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := ss.db.Database(DBName(ctx)).Collection("spots").Aggregate(ctx, totalGoldPipeline(match, key))
+	if err != nil {
+		return nil, fmt.Errorf("aggregating the gold by %s: %w", key, err)
+	}
+
+	defer cursor.Close(ctx)
+
+	totals := make([]GoldTotal, 0)
+
+	if err := cursor.All(ctx, &totals); err != nil {
+		return nil, fmt.Errorf("can't decode the gold totals: %w", err)
+	}
+
+	return totals, nil
+}
+
+// totalGoldPipeline returns the aggregation pipeline that sums the gold of the spots that
+// satisfy the match by the key, the spots without the key are skipped.
+func totalGoldPipeline(match bson.M, key string) mongo.Pipeline {
+	return mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$match", Value: bson.M{key: bson.M{"$nin": bson.A{nil, ""}}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":         "$" + key,
+			"gold_amount": bson.M{"$sum": "$gold_amount"},
+			"spots":       bson.M{"$sum": 1},
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+}
+
+// Richest gets the spots with more gold that satisfy the filter, the filter can be nil to
+// use all the spots.
+func (ss *SpotService) Richest(ctx context.Context, sf *SpotFilter, limit int) ([]Spot, error) {
+	if err := validateLimit(limit); err != nil {
+		return nil, err
+	}
+
+	match, err := sf.toMongoMatch()
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := ss.db.Database(DBName(ctx)).Collection("spots").Aggregate(ctx, richestPipeline(match, limit))
+	if err != nil {
+		return nil, fmt.Errorf("aggregating the richest spots: %w", err)
+	}
+
+	defer cursor.Close(ctx)
+
+	spots := make([]Spot, 0)
+
+	if err := cursor.All(ctx, &spots); err != nil {
+		return nil, fmt.Errorf("can't decode spots: %w", err)
+	}
+
+	return spots, nil
+}
+
+// richestPipeline returns the aggregation pipeline of the spots with more gold that
+// satisfy the match, the ties are sorted by id.
+func richestPipeline(match bson.M, limit int) mongo.Pipeline {
+	return mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$sort", Value: bson.D{{Key: "gold_amount", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+}
+
+// findNear finds the spots that satisfy the filter and the condition on their coordinate.
+func (ss *SpotService) findNear(ctx context.Context, sf *SpotFilter, cond bson.M, opts ...*options.FindOptions) ([]Spot, error) {
+	match, err := sf.toMongoMatch()
+	if err != nil {
+		return nil, err
+	}
+
+	match["Coordinate"] = cond
+
+	cursor, err := ss.db.Database(DBName(ctx)).Collection("spots").Find(ctx, match, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("finding the spots: %w", err)
+	}
+
+	defer cursor.Close(ctx)
+
+	spots := make([]Spot, 0)
+
+	if err := cursor.All(ctx, &spots); err != nil {
+		return nil, fmt.Errorf("can't decode spots: %w", err)
+	}
+
+	return spots, nil
+}
+
+// InBox gets the spots inside the region sorted by id, the filter can be nil to use all
+// the spots.
+func (ss *SpotService) InBox(ctx context.Context, sf *SpotFilter, r *Region) ([]Spot, error) {
+	if err := validateBox(r); err != nil {
+		return nil, err
+	}
+
+	return ss.findNear(ctx, sf, boxCondition(r), options.Find().SetSort(bson.M{"_id": 1}))
+}
+
+// boxCondition returns the condition of the coordinates inside the region.
+func boxCondition(r *Region) bson.M {
+	// the limit point is exclusive and the coordinates are integers
+	box := bson.A{
+		bson.A{r.StartPoint.X, r.StartPoint.Y},
+		bson.A{float64(r.LimitPoint.X) - 0.5, float64(r.LimitPoint.Y) - 0.5},
+	}
+
+	return bson.M{"$geoWithin": bson.M{"$box": box}}
+}
+
+// radiusCondition returns the condition of the coordinates at a distance lower or equal
+// than the radius from the center.
+func radiusCondition(center Coordinate, radius float64) bson.M {
+	return bson.M{"$geoWithin": bson.M{"$center": bson.A{bson.A{center.X, center.Y}, radius}}}
+}
 
+// nearCondition returns the condition that sorts the coordinates by their distance to the
+// point.
+func nearCondition(point Coordinate) bson.M {
+	return bson.M{"$near": bson.A{point.X, point.Y}}
+}
+
+// InRadius gets the spots at a distance lower or equal than the radius from the center
+// sorted by distance, the filter can be nil to use all the spots.
+func (ss *SpotService) InRadius(ctx context.Context, sf *SpotFilter, center Coordinate, radius float64) ([]Spot, error) {
+	if err := validateRadius(radius); err != nil {
+		return nil, err
+	}
+
+	spots, err := ss.findNear(ctx, sf, radiusCondition(center, radius))
+	if err != nil {
+		return nil, err
+	}
+
+	sortByDistance(spots, center)
+
+	return spots, nil
+}
+
+// Nearest gets the spots closer to the point sorted by distance, the filter can be nil to
+// use all the spots.
+func (ss *SpotService) Nearest(ctx context.Context, sf *SpotFilter, point Coordinate, limit int) ([]Spot, error) {
+	if err := validateLimit(limit); err != nil {
+		return nil, err
+	}
+
+	spots, err := ss.findNear(ctx, sf, nearCondition(point), options.Find().SetLimit(int64(limit)))
+	if err != nil {
+		return nil, err
+	}
+
+	// $near doesn't sort the ties
+	sortByDistance(spots, point)
+
+	return spots, nil
+}
+
+// Page gets a page of the spots that satisfy the query, the query can be nil to use all
+// the spots.
+func (ss *SpotService) Page(ctx context.Context, sq *SpotQuery, opts *ListOptions) (*SpotPage, error) {
+	if sq == nil {
+		sq = &SpotQuery{}
+	}
+
+	if err := sq.validate(); err != nil {
+		return nil, err
+	}
+
+	p, err := newPage("spot", opts, spotSortFields)
+	if err != nil {
+		return nil, err
+	}
+
+	spots := ss.db.Database(DBName(ctx)).Collection("spots")
+	filter := sq.toMongoFilter()
+
+	total, err := spots.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("counting the spots: %w", err)
+	}
+
+	if p.cursor != nil {
+		filter = bson.M{"$and": bson.A{filter, p.mongoCursor()}}
+	}
+
+	// one more spot is read to know if there is a next page
+	cursor, err := spots.Find(ctx, filter, options.Find().SetSort(p.mongoSort()).SetLimit(int64(p.limit+1)))
+	if err != nil {
+		return nil, fmt.Errorf("finding the spots: %w", err)
+	}
+
+	defer cursor.Close(ctx)
+
+	res := &SpotPage{Items: make([]Spot, 0), Total: total}
+
+	if err := cursor.All(ctx, &res.Items); err != nil {
+		return nil, fmt.Errorf("can't decode spots: %w", err)
+	}
+
+	if len(res.Items) > p.limit {
+		res.Items = res.Items[:p.limit]
+		last := &res.Items[p.limit-1]
+		res.NextCursor = p.next(p.field.spot(last), last.ID)
+	}
+
+	return res, nil
+}
+
+// BatchCreate creates the spots like Create does, the spots that can't be created are
+// reported in their results and the rest are inserted with a single bulk write.
+func (ss *SpotService) BatchCreate(ctx context.Context, spots []Spot) ([]BatchResult, error) {
+	return batchCreate(ctx, ss.repo, spots, ss.writeBatch)
+}
+
+// CreateMany creates all the spots or none of them with a single bulk write, the ids are
+// set in the spots. The first spot that can't be created fails the write with a
+// SpotError.
+func (ss *SpotService) CreateMany(ctx context.Context, spots []Spot) error {
+	return createMany(ctx, ss.repo, spots, ss.writeBatch)
+}
+
+// BatchUpdate updates the spots like Update does, the spots that can't be updated are
+// reported in their results and the rest are replaced with a single bulk write.
+func (ss *SpotService) BatchUpdate(ctx context.Context, spots []Spot) ([]BatchResult, error) {
+	return batchUpdate(ctx, ss.repo, spots, ss.writeBatch)
+}
+
+// BatchDelete deletes the spots by id, the ones with a version other than zero are only
+// deleted at that version. The spots that can't be deleted are reported in their results
+// and the rest are deleted with a single bulk write.
+func (ss *SpotService) BatchDelete(ctx context.Context, spots []Spot) ([]BatchResult, error) {
+	return batchDelete(ctx, ss.repo, spots, ss.writeBatch)
+}
+
+// writeBatch writes the spots of the batch with a bulk write and sets the spot_ids of
+// each quadrant changed with another one. Every write is conditioned on the version read
+// by the batch, so nothing is written when something changed meanwhile.
+func (ss *SpotService) writeBatch(ctx context.Context, b *spotBatch) error {
+	db := ss.db.Database(DBName(ctx))
+
+	spots, quadrants, err := b.mongoWrites()
+	if err != nil {
+		return err
+	}
+
+	res, err := db.Collection("spots").BulkWrite(ctx, spots)
+	if err != nil {
+		return fmt.Errorf("writing the spots: %w", err)
+	}
+
+	if res.InsertedCount+res.MatchedCount+res.DeletedCount != int64(len(spots)) {
+		return batchChangedError()
+	}
+
+	if len(quadrants) == 0 {
+		return nil
+	}
+
+	res, err = db.Collection("quadrants").BulkWrite(ctx, quadrants)
+	if err != nil {
+		return fmt.Errorf("updating the quadrants: %w", err)
+	}
+
+	if res.MatchedCount != int64(len(quadrants)) {
+		return batchChangedError()
+	}
+
+	return nil
+}
+
+// mongoWrites returns the models of the bulk writes of the batch, one for the spots and
+// another one for the quadrants changed.
+func (b *spotBatch) mongoWrites() (spots, quadrants []mongo.WriteModel, err error) {
+	spots = make([]mongo.WriteModel, 0, len(b.writes))
+
+	for _, w := range b.writes {
+		id, err := primitive.ObjectIDFromHex(w.spot.ID)
 		if err != nil {
-			return false, err
+			return nil, nil, fmt.Errorf("wrong spot id%s", w.spot.ID)
 		}
 
-		return true, nil
+		filter := bson.M{"_id": id, "version": versionFilter(w.version)}
+
+		switch {
+		case w.insert:
+			doc := w.spot.mongoDocument()
+			doc["_id"] = id
+
+			spots = append(spots, mongo.NewInsertOneModel().SetDocument(doc))
+		case w.remove:
+			spots = append(spots, mongo.NewDeleteOneModel().SetFilter(filter))
+		default:
+			spots = append(spots, mongo.NewReplaceOneModel().SetFilter(filter).SetReplacement(w.spot.mongoDocument()))
+		}
 	}
 
-	return false, errors.New("filter is wrong")
+	quadrants = make([]mongo.WriteModel, 0, len(b.changed))
+
+	for _, qID := range b.changed {
+		q := b.quadrants[qID]
+
+		id, err := primitive.ObjectIDFromHex(q.ID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("wrong quadrant id%s", q.ID)
+		}
+
+		if q.SpotIDs == nil {
+			q.SpotIDs = []string{}
+		}
+
+		quadrants = append(quadrants, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": id, "version": versionFilter(q.Version)}).
+			SetUpdate(bson.M{"$set": bson.M{"spot_ids": q.SpotIDs}, "$inc": bson.M{"version": 1}}),
+		)
+	}
+
+	return spots, quadrants, nil
+}
+
+// mongoDocument returns the attributes of the spot written by the replaces and the bulk
+// writes, the empty ones are omitted like the bson tags of Spot do so the filters and the
+// pages see the spots the same way whichever route wrote them.
+func (s *Spot) mongoDocument() bson.M {
+	doc := bson.M{
+		"gold_amount": s.GoldAmount,
+		"version":     s.Version,
+	}
+
+	if s.MazeID != "" {
+		doc["maze_id"] = s.MazeID
+	}
+
+	if s.Name != "" {
+		doc["name"] = s.Name
+	}
+
+	if s.Kind != "" {
+		doc["kind"] = s.Kind
+	}
+
+	if s.Penalty != 0 {
+		doc["penalty"] = s.Penalty
+	}
+
+	if s.Coordinate != nil {
+		doc["Coordinate"] = s.Coordinate
+	}
+
+	if s.QuadrantID != "" {
+		doc["quadrant_id"] = s.QuadrantID
+	}
+
+	return doc
+}
+
+// batchChangedError is returned when a spot or a quadrant written by a batch changed after
+// the batch read it.
+func batchChangedError() error {
+	ce := &ConflictError{Entity: "spot"}
+	ce.add("items", "the spots changed while the batch was written, retry it")
+
+	return ce
 }
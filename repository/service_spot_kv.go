@@ -0,0 +1,615 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SpotKVService represents a kvService that contains the key/value storage.
+type SpotKVService kvService
+
+// SpotKVService validate if it satisfy the SpotStore interface.
+var _ SpotStore = &SpotKVService{}
+
+// Create creates a new spot in a maze, when the quadrant isn't given it is placed in the
+// quadrant of the maze that contains its coordinate.
+func (ss *SpotKVService) Create(ctx context.Context, s *Spot) (string, error) {
+	ve := &ValidationError{Entity: "spot"}
+
+	if s.Name == "" {
+		ve.add("name", "must be specified")
+	}
+
+	if s.Coordinate == nil {
+		ve.add("Coordinate", "must be specified")
+	}
+
+	if err := ve.err(); err != nil {
+		return "", err
+	}
+
+	err := kvUpdate(ctx, ss.db, func(ctx context.Context, tx kvTx) error {
+		q, err := placeSpot(ctx, ss.repo, s)
+		if err != nil {
+			return err
+		}
+
+		s.Version = 1
+
+		doc := *s
+		doc.ID = primitive.NewObjectID().Hex()
+
+		if err := kvPut(tx, spotsCollection, doc.ID, &doc); err != nil {
+			return fmt.Errorf("inserting a spot: %w", err)
+		}
+
+		q.Spots = nil
+		q.SpotIDs = append(q.SpotIDs, doc.ID)
+		q.Version++
+
+		if err := kvPut(tx, quadrantsCollection, q.ID, q); err != nil {
+			return fmt.Errorf("updating a quadrant: %w", err)
+		}
+
+		s.ID = doc.ID
+
+		return nil
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return s.ID, nil
+}
+
+// Get gets a spot in a maze.
+func (ss *SpotKVService) Get(ctx context.Context, sf *SpotFilter) (*Spot, error) {
+	if sf.ID == "" {
+		return nil, invalidField("spot", "id", "must be specified")
+	}
+
+	if err := sf.validate(); err != nil {
+		return nil, err
+	}
+
+	spot := &Spot{}
+
+	err := kvView(ctx, ss.db, func(ctx context.Context, tx kvTx) error {
+		if err := kvGet(tx, spotsCollection, sf.ID, spot); err != nil {
+			return err
+		}
+
+		if !sf.matches(spot) {
+			return &NotFoundError{Entity: "spot", ID: sf.ID}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return spot, nil
+}
+
+// Update updates the attributes of the spot given in su, the zero values are left
+// unchanged.
+func (ss *SpotKVService) Update(ctx context.Context, su *Spot) (*Spot, error) {
+	if su.ID == "" {
+		return nil, invalidField("spot", "id", "must be specified")
+	}
+
+	return ss.save(ctx, su.ID, su.Version, func(ctx context.Context, spot *Spot) (*Quadrant, error) {
+		return placeUpdate(ctx, ss.repo, spot, su)
+	})
+}
+
+// Replace replaces all the attributes of the spot with the ones of s, the zero values
+// are set as well.
+func (ss *SpotKVService) Replace(ctx context.Context, s *Spot) (*Spot, error) {
+	if s.ID == "" {
+		return nil, invalidField("spot", "id", "must be specified")
+	}
+
+	return ss.save(ctx, s.ID, s.Version, func(ctx context.Context, spot *Spot) (*Quadrant, error) {
+		return placeReplace(ctx, ss.repo, spot, s)
+	})
+}
+
+// save writes the spot once place has applied the changes to the stored one and has
+// resolved its quadrant, the write fails when the expected version isn't the stored one.
+// The versions of the quadrants are incremented as well since their spots change.
+func (ss *SpotKVService) save(ctx context.Context, id string, expected uint64, place placeFunc) (*Spot, error) {
+	spot := &Spot{}
+
+	err := kvUpdate(ctx, ss.db, func(ctx context.Context, tx kvTx) error {
+		if err := kvGet(tx, spotsCollection, id, spot); err != nil {
+			return err
+		}
+
+		if err := checkVersion("spot", id, spot.Version, expected); err != nil {
+			return err
+		}
+
+		q, err := place(ctx, spot)
+		if err != nil {
+			return err
+		}
+
+		if spot.QuadrantID != q.ID {
+			if err := ss.move(tx, spot, q.ID); err != nil {
+				return err
+			}
+		} else if err := ss.touch(tx, q.ID); err != nil {
+			return err
+		}
+
+		spot.Version++
+
+		return kvPut(tx, spotsCollection, spot.ID, spot)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return spot, nil
+}
+
+// touch increments the version of the quadrant after a write of one of its spots.
+func (ss *SpotKVService) touch(tx kvTx, quadrantID string) error {
+	q := &Quadrant{}
+
+	if err := kvGet(tx, quadrantsCollection, quadrantID, q); err != nil {
+		return fmt.Errorf("can't reach quadrant: %w", err)
+	}
+
+	q.Version++
+
+	return kvPut(tx, quadrantsCollection, q.ID, q)
+}
+
+// move moves the spot id from the spot_ids of its current quadrant to the new one.
+func (ss *SpotKVService) move(tx kvTx, spot *Spot, quadrantID string) error {
+	nq := &Quadrant{}
+
+	if err := kvGet(tx, quadrantsCollection, quadrantID, nq); err != nil {
+		return fmt.Errorf("can't reach quadrant: %w", err)
+	}
+
+	if nq.MazeID != spot.MazeID {
+		return invalidField("spot", "quadrant_id", "the spot can't be moved to a quadrant of another maze")
+	}
+
+	oq := &Quadrant{}
+
+	if err := kvGet(tx, quadrantsCollection, spot.QuadrantID, oq); err == nil {
+		oq.SpotIDs = removeString(oq.SpotIDs, spot.ID)
+		oq.Version++
+
+		if err := kvPut(tx, quadrantsCollection, oq.ID, oq); err != nil {
+			return err
+		}
+	}
+
+	if !containsString(nq.SpotIDs, spot.ID) {
+		nq.SpotIDs = append(nq.SpotIDs, spot.ID)
+	}
+
+	nq.Version++
+
+	if err := kvPut(tx, quadrantsCollection, nq.ID, nq); err != nil {
+		return err
+	}
+
+	spot.QuadrantID = quadrantID
+
+	return nil
+}
+
+// List gets all spots by quadrant id in a maze.
+func (ss *SpotKVService) List(ctx context.Context, sf *SpotFilter) ([]Spot, error) {
+	if sf.ID != "" {
+		return nil, BadRequest("the SpotFilter.ID attribute must not be specified")
+	}
+
+	if sf.MazeID == "" && sf.QuadrantID == "" && len(sf.SpotsIDs) == 0 {
+		return nil, BadRequest("at least one of SpotFilter.MazeID, SpotFilter.QuadrantID and SpotFilter.SpotIDs attributes must be specified")
+	}
+
+	if err := sf.validate(); err != nil {
+		return nil, fmt.Errorf("decoding filter for Spot.List: %w", err)
+	}
+
+	spots := make([]Spot, 0)
+
+	err := kvView(ctx, ss.db, func(ctx context.Context, tx kvTx) error {
+		return tx.forEach(spotsCollection, func(id string, raw []byte) error {
+			var s Spot
+
+			if err := bson.Unmarshal(raw, &s); err == nil && sf.matches(&s) {
+				spots = append(spots, s)
+			}
+
+			return nil
+		})
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("finding all spots: %w", err)
+	}
+
+	return spots, nil
+}
+
+// Delete deletes the spots of the filter, isRemoved is false when none of them existed.
+func (ss *SpotKVService) Delete(ctx context.Context, sf *SpotFilter) (bool, error) {
+	spotIDs := make([]string, 0)
+
+	switch {
+	case sf.ID != "":
+		spotIDs = append(spotIDs, sf.ID)
+	case sf.MazeID != "", sf.QuadrantID != "":
+		spots, err := ss.List(ctx, sf)
+		if err != nil {
+			return false, err
+		}
+
+		for i := range spots {
+			spotIDs = append(spotIDs, spots[i].ID)
+		}
+
+	case len(sf.SpotsIDs) > 0:
+		spotIDs = sf.SpotsIDs
+	}
+
+	if len(spotIDs) == 0 {
+		return false, BadRequest("filter is wrong")
+	}
+
+	var removed bool
+
+	err := kvUpdate(ctx, ss.db, func(ctx context.Context, tx kvTx) error {
+		for i := range spotIDs {
+			raw, err := tx.get(spotsCollection, spotIDs[i])
+			if err != nil {
+				return err
+			}
+
+			if raw == nil {
+				continue
+			}
+
+			if sf.ID != "" {
+				stored := &Spot{}
+
+				if err := bson.Unmarshal(raw, stored); err != nil {
+					return err
+				}
+
+				if err := checkVersion("spot", stored.ID, stored.Version, sf.Version); err != nil {
+					return err
+				}
+
+				if err := ss.repo.checkUnpublished(ctx, stored.MazeID); err != nil {
+					return err
+				}
+
+				if err := validateKept(ctx, ss.repo, stored, nil); err != nil {
+					return err
+				}
+			}
+
+			removed = true
+
+			if err := tx.delete(spotsCollection, spotIDs[i]); err != nil {
+				return err
+			}
+		}
+
+		return tx.forEach(quadrantsCollection, func(id string, raw []byte) error {
+			q := &Quadrant{}
+
+			if err := bson.Unmarshal(raw, q); err != nil {
+				return err
+			}
+
+			remaining := removeString(q.SpotIDs, spotIDs...)
+			if len(remaining) == len(q.SpotIDs) {
+				return nil
+			}
+
+			q.SpotIDs = remaining
+			q.Version++
+
+			return kvPut(tx, quadrantsCollection, q.ID, q)
+		})
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	return removed, nil
+}
+
+// BatchCreate creates the spots like Create does, the spots that can't be created are
+// reported in their results and the rest are written in the same transaction.
+func (ss *SpotKVService) BatchCreate(ctx context.Context, spots []Spot) ([]BatchResult, error) {
+	return batchCreate(ctx, ss.repo, spots, ss.writeBatch)
+}
+
+// CreateMany creates all the spots or none of them in the same transaction, the ids are
+// set in the spots. The first spot that can't be created fails the write with a
+// SpotError.
+func (ss *SpotKVService) CreateMany(ctx context.Context, spots []Spot) error {
+	return createMany(ctx, ss.repo, spots, ss.writeBatch)
+}
+
+// BatchUpdate updates the spots like Update does, the spots that can't be updated are
+// reported in their results and the rest are written in the same transaction.
+func (ss *SpotKVService) BatchUpdate(ctx context.Context, spots []Spot) ([]BatchResult, error) {
+	return batchUpdate(ctx, ss.repo, spots, ss.writeBatch)
+}
+
+// BatchDelete deletes the spots by id, the ones with a version other than zero are only
+// deleted at that version. The spots that can't be deleted are reported in their results
+// and the rest are deleted in the same transaction.
+func (ss *SpotKVService) BatchDelete(ctx context.Context, spots []Spot) ([]BatchResult, error) {
+	return batchDelete(ctx, ss.repo, spots, ss.writeBatch)
+}
+
+// writeBatch writes the spots of the batch and the quadrants whose spot_ids changed, the
+// batch runs in a writable transaction so nothing changed since it was read.
+func (ss *SpotKVService) writeBatch(ctx context.Context, b *spotBatch) error {
+	return kvUpdate(ctx, ss.db, func(ctx context.Context, tx kvTx) error {
+		for _, w := range b.writes {
+			if w.remove {
+				if err := tx.delete(spotsCollection, w.spot.ID); err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			if err := kvPut(tx, spotsCollection, w.spot.ID, w.spot); err != nil {
+				return fmt.Errorf("writing a spot: %w", err)
+			}
+		}
+
+		for _, id := range b.changed {
+			q := *b.quadrants[id]
+			q.Spots = nil
+			q.Version++
+
+			if err := kvPut(tx, quadrantsCollection, q.ID, &q); err != nil {
+				return fmt.Errorf("updating a quadrant: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// filter returns the spots that satisfy the filter, a nil or empty filter matches all
+// the spots.
+func (ss *SpotKVService) filter(ctx context.Context, sf *SpotFilter) ([]Spot, error) {
+	if sf != nil && sf.ID != "" {
+		if err := sf.validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	spots := make([]Spot, 0)
+
+	err := kvView(ctx, ss.db, func(ctx context.Context, tx kvTx) error {
+		return tx.forEach(spotsCollection, func(id string, raw []byte) error {
+			var s Spot
+
+			if err := bson.Unmarshal(raw, &s); err != nil {
+				return err
+			}
+
+			if sf == nil || sf.matches(&s) {
+				spots = append(spots, s)
+			}
+
+			return nil
+		})
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("finding all spots: %w", err)
+	}
+
+	return spots, nil
+}
+
+// TotalGoldByQuadrant sums the gold of the spots that satisfy the filter by quadrant, the
+// filter can be nil to use all the spots.
+func (ss *SpotKVService) TotalGoldByQuadrant(ctx context.Context, sf *SpotFilter) ([]GoldTotal, error) {
+	return ss.totalGold(ctx, sf, func(s *Spot) string { return s.QuadrantID })
+}
+
+// TotalGoldByMaze sums the gold of the spots that satisfy the filter by maze, the filter
+// can be nil to use all the spots.
+func (ss *SpotKVService) TotalGoldByMaze(ctx context.Context, sf *SpotFilter) ([]GoldTotal, error) {
+	return ss.totalGold(ctx, sf, func(s *Spot) string { return s.MazeID })
+}
+
+// totalGold groups the spots by the key sorted by it, the spots without key are skipped.
+func (ss *SpotKVService) totalGold(ctx context.Context, sf *SpotFilter, key func(s *Spot) string) ([]GoldTotal, error) {
+	spots, err := ss.filter(ctx, sf)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		totals = make([]GoldTotal, 0)
+		index  = make(map[string]int)
+	)
+
+	for i := range spots {
+		k := key(&spots[i])
+		if k == "" {
+			continue
+		}
+
+		pos, ok := index[k]
+		if !ok {
+			pos = len(totals)
+			index[k] = pos
+			totals = append(totals, GoldTotal{ID: k})
+		}
+
+		totals[pos].GoldAmount += spots[i].GoldAmount
+		totals[pos].Spots++
+	}
+
+	sort.Slice(totals, func(i, j int) bool {
+		return totals[i].ID < totals[j].ID
+	})
+
+	return totals, nil
+}
+
+// Richest gets the spots with more gold that satisfy the filter, the filter can be nil to
+// use all the spots.
+func (ss *SpotKVService) Richest(ctx context.Context, sf *SpotFilter, limit int) ([]Spot, error) {
+	if err := validateLimit(limit); err != nil {
+		return nil, err
+	}
+
+	spots, err := ss.filter(ctx, sf)
+	if err != nil {
+		return nil, err
+	}
+
+	// the spots are already sorted by id so the ties keep that order
+	sort.SliceStable(spots, func(i, j int) bool {
+		return spots[i].GoldAmount > spots[j].GoldAmount
+	})
+
+	if len(spots) > limit {
+		spots = spots[:limit]
+	}
+
+	return spots, nil
+}
+
+// index builds a k-d tree with the spots that satisfy the filter, the key/value storages
+// can't index the coordinates.
+func (ss *SpotKVService) index(ctx context.Context, sf *SpotFilter) (*kdTree, error) {
+	spots, err := ss.filter(ctx, sf)
+	if err != nil {
+		return nil, err
+	}
+
+	return newKDTree(spots), nil
+}
+
+// InBox gets the spots inside the region sorted by id, the filter can be nil to use all
+// the spots.
+func (ss *SpotKVService) InBox(ctx context.Context, sf *SpotFilter, r *Region) ([]Spot, error) {
+	if err := validateBox(r); err != nil {
+		return nil, err
+	}
+
+	tree, err := ss.index(ctx, sf)
+	if err != nil {
+		return nil, err
+	}
+
+	return tree.inBox(r), nil
+}
+
+// InRadius gets the spots at a distance lower or equal than the radius from the center
+// sorted by distance, the filter can be nil to use all the spots.
+func (ss *SpotKVService) InRadius(ctx context.Context, sf *SpotFilter, center Coordinate, radius float64) ([]Spot, error) {
+	if err := validateRadius(radius); err != nil {
+		return nil, err
+	}
+
+	tree, err := ss.index(ctx, sf)
+	if err != nil {
+		return nil, err
+	}
+
+	return tree.inRadius(center, radius), nil
+}
+
+// Nearest gets the spots closer to the point sorted by distance, the filter can be nil to
+// use all the spots.
+func (ss *SpotKVService) Nearest(ctx context.Context, sf *SpotFilter, point Coordinate, limit int) ([]Spot, error) {
+	if err := validateLimit(limit); err != nil {
+		return nil, err
+	}
+
+	tree, err := ss.index(ctx, sf)
+	if err != nil {
+		return nil, err
+	}
+
+	return tree.nearest(point, limit), nil
+}
+
+// Page gets a page of the spots that satisfy the query, the query can be nil to use all
+// the spots.
+func (ss *SpotKVService) Page(ctx context.Context, sq *SpotQuery, opts *ListOptions) (*SpotPage, error) {
+	if sq == nil {
+		sq = &SpotQuery{}
+	}
+
+	if err := sq.validate(); err != nil {
+		return nil, err
+	}
+
+	p, err := newPage("spot", opts, spotSortFields)
+	if err != nil {
+		return nil, err
+	}
+
+	spots := make([]Spot, 0)
+
+	err = kvView(ctx, ss.db, func(ctx context.Context, tx kvTx) error {
+		return tx.forEach(spotsCollection, func(id string, raw []byte) error {
+			var s Spot
+
+			if err := bson.Unmarshal(raw, &s); err != nil {
+				return err
+			}
+
+			if sq.matches(&s) {
+				spots = append(spots, s)
+			}
+
+			return nil
+		})
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("finding the spots: %w", err)
+	}
+
+	values := make([]interface{}, len(spots))
+	ids := make([]string, len(spots))
+
+	for i := range spots {
+		values[i], ids[i] = p.field.spot(&spots[i]), spots[i].ID
+	}
+
+	positions, next := p.paginate(values, ids)
+	res := &SpotPage{Items: make([]Spot, 0, len(positions)), NextCursor: next, Total: int64(len(spots))}
+
+	for _, i := range positions {
+		res.Items = append(res.Items, spots[i])
+	}
+
+	return res, nil
+}
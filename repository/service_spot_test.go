@@ -2,215 +2,1245 @@ package repository
 
 import (
 	"context"
-	"os"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func TestSpot_CreateListDelete(t *testing.T) {
-	Test_EnvMongoDBConnectionString(t)
+	forEachRepository(t, func(t *testing.T, ctx context.Context, conn *Repository) {
+		qID, err := conn.Quadrant.Create(ctx, &Quadrant{
+			Type:       TopRight,
+			StartPoint: &Coordinate{X: 0, Y: 0},
+			LimitPoint: &Coordinate{X: 25, Y: 25},
+		})
 
-	var (
-		ctx  = DBNameSet(context.Background(), os.Getenv("DB_NAME"))
-		conn = NewByConnString(os.Getenv("MOGODB_CONN"))
-	)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sID, err := conn.Spot.Create(ctx, &Spot{
+			Name:       "exit",
+			Kind:       Exit,
+			GoldAmount: 4000,
+			Coordinate: &Coordinate{
+				X: 9,
+				Y: 0,
+			},
+			QuadrantID: qID,
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.NotEmpty(t, sID)
+		assert.IsType(t, sID, "")
+
+		sID, err = conn.Spot.Create(ctx, &Spot{
+			Name:       "entrace",
+			Kind:       Entrance,
+			GoldAmount: 9000,
+			Coordinate: &Coordinate{
+				X: 0,
+				Y: 10,
+			},
+			QuadrantID: qID,
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.NotEmpty(t, sID)
+		assert.IsType(t, sID, "")
+
+		spots, err := conn.Spot.List(ctx, &SpotFilter{QuadrantID: qID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.NotEmpty(t, spots)
+		assert.Len(t, spots, 2)
 
-	qID, err := conn.Quadrant.Create(ctx, &Quadrant{
-		Type:       TopRight,
-		StartPoint: &Coordinate{X: 0, Y: 0},
-		LimitPoint: &Coordinate{X: 25, Y: 25},
+		isRemoved, err := conn.Spot.Delete(ctx, &SpotFilter{QuadrantID: qID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.True(t, isRemoved)
+		assert.EqualValues(t, true, isRemoved)
+
+		if _, err := conn.Quadrant.Delete(ctx, &QuadrantFilter{ID: qID}); err != nil {
+			t.Fatal(err)
+		}
 	})
+}
 
-	if err != nil {
-		t.Fatal(err)
-	}
+func TestSpot_GetDelete(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, ctx context.Context, conn *Repository) {
+		qID, err := conn.Quadrant.Create(ctx, &Quadrant{
+			Type:       TopRight,
+			StartPoint: &Coordinate{X: 0, Y: 0},
+			LimitPoint: &Coordinate{X: 25, Y: 25},
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expectedValue := &Spot{
+			Name:       "exit",
+			Kind:       Exit,
+			GoldAmount: 4000,
+			Coordinate: &Coordinate{
+				X: 9,
+				Y: 0,
+			},
+			QuadrantID: qID,
+		}
+
+		sID, err := conn.Spot.Create(ctx, expectedValue)
 
-	sID, err := conn.Spot.Create(ctx, &Spot{
-		Name:       "exit",
-		GoldAmount: "4000",
-		Coordinate: &Coordinate{
-			X: 9,
-			Y: 0,
-		},
-		QuadrantID: qID,
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.NotEmpty(t, sID)
+		assert.IsType(t, sID, "")
+
+		got, err := conn.Spot.Get(ctx, &SpotFilter{ID: sID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expectedValue.ID = got.ID
+
+		assert.NotEmpty(t, got)
+		assert.IsType(t, &Spot{}, got)
+		assert.EqualValues(t, expectedValue, got)
+
+		isRemoved, err := conn.Spot.Delete(ctx, &SpotFilter{ID: got.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.True(t, isRemoved)
+		assert.EqualValues(t, true, isRemoved)
+
+		if _, err := conn.Quadrant.Delete(ctx, &QuadrantFilter{ID: qID}); err != nil {
+			t.Fatal(err)
+		}
 	})
+}
 
-	if err != nil {
-		t.Fatal(err)
-	}
+func TestSpot_filterSpotList(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, ctx context.Context, conn *Repository) {
+		qID, err := conn.Quadrant.Create(ctx, &Quadrant{
+			Type:       TopRight,
+			StartPoint: &Coordinate{X: 0, Y: 0},
+			LimitPoint: &Coordinate{X: 25, Y: 25},
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sID1, err := conn.Spot.Create(ctx, &Spot{
+			Name:       "exit",
+			Kind:       Exit,
+			GoldAmount: 4000,
+			Coordinate: &Coordinate{
+				X: 9,
+				Y: 0,
+			},
+			QuadrantID: qID,
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.NotEmpty(t, sID1)
+		assert.IsType(t, sID1, "")
+
+		sID2, err := conn.Spot.Create(ctx, &Spot{
+			Name:       "entrace",
+			Kind:       Entrance,
+			GoldAmount: 9000,
+			Coordinate: &Coordinate{
+				X: 0,
+				Y: 10,
+			},
+			QuadrantID: qID,
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.NotEmpty(t, sID2)
+		assert.IsType(t, sID2, "")
+
+		spots, err := conn.Spot.List(ctx, &SpotFilter{SpotsIDs: []string{sID1, sID2}})
+		if err != nil {
+			t.Fatal(err)
+		}
 
-	assert.NotEmpty(t, sID)
-	assert.IsType(t, sID, "")
-
-	sID, err = conn.Spot.Create(ctx, &Spot{
-		Name:       "entrace",
-		GoldAmount: "9000",
-		Coordinate: &Coordinate{
-			X: 0,
-			Y: 10,
-		},
-		QuadrantID: qID,
+		assert.NotEmpty(t, spots)
+		assert.Len(t, spots, 2)
+
+		isRemoved, err := conn.Spot.Delete(ctx, &SpotFilter{QuadrantID: qID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.True(t, isRemoved)
+		assert.EqualValues(t, true, isRemoved)
+
+		if _, err := conn.Quadrant.Delete(ctx, &QuadrantFilter{ID: qID}); err != nil {
+			t.Fatal(err)
+		}
 	})
+}
 
-	if err != nil {
-		t.Fatal(err)
-	}
+func TestSpot_UpdateMovesSpotBetweenQuadrants(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, ctx context.Context, conn *Repository) {
+		q1ID, err := conn.Quadrant.Create(ctx, &Quadrant{
+			Type:       TopLeft,
+			StartPoint: &Coordinate{X: 0, Y: 0},
+			LimitPoint: &Coordinate{X: 25, Y: 25},
+		})
 
-	assert.NotEmpty(t, sID)
-	assert.IsType(t, sID, "")
+		if err != nil {
+			t.Fatal(err)
+		}
 
-	spots, err := conn.Spot.List(ctx, &SpotFilter{QuadrantID: qID})
-	if err != nil {
-		t.Fatal(err)
-	}
+		q2ID, err := conn.Quadrant.Create(ctx, &Quadrant{
+			Type:       TopRight,
+			StartPoint: &Coordinate{X: 25, Y: 0},
+			LimitPoint: &Coordinate{X: 50, Y: 25},
+		})
 
-	assert.NotEmpty(t, spots)
-	assert.Len(t, spots, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
 
-	isRemoved, err := conn.Spot.Delete(ctx, &SpotFilter{QuadrantID: qID})
-	if err != nil {
-		t.Fatal(err)
-	}
+		sID, err := conn.Spot.Create(ctx, &Spot{
+			Name:       "exit",
+			Kind:       Exit,
+			GoldAmount: 4000,
+			Coordinate: &Coordinate{X: 9, Y: 0},
+			QuadrantID: q1ID,
+		})
 
-	assert.True(t, isRemoved)
-	assert.EqualValues(t, true, isRemoved)
+		if err != nil {
+			t.Fatal(err)
+		}
 
-	if _, err := conn.Quadrant.Delete(ctx, &QuadrantFilter{ID: qID}); err != nil {
-		t.Fatal(err)
-	}
+		got, err := conn.Spot.Update(ctx, &Spot{ID: sID, Coordinate: &Coordinate{X: 30, Y: 0}, QuadrantID: q2ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, q2ID, got.QuadrantID)
+
+		q1, err := conn.Quadrant.Get(ctx, &QuadrantFilter{ID: q1ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Empty(t, q1.SpotIDs)
+
+		q2, err := conn.Quadrant.Get(ctx, &QuadrantFilter{ID: q2ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, []string{sID}, q2.SpotIDs)
+		assert.Len(t, q2.Spots, 1)
+
+		if _, err := conn.Quadrant.Delete(ctx, &QuadrantFilter{ID: q1ID}); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := conn.Quadrant.Delete(ctx, &QuadrantFilter{ID: q2ID}); err != nil {
+			t.Fatal(err)
+		}
+	})
 }
 
-func TestSpot_GetDelete(t *testing.T) {
-	Test_EnvMongoDBConnectionString(t)
+func TestSpot_Replace(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, ctx context.Context, conn *Repository) {
+		qID, err := conn.Quadrant.Create(ctx, &Quadrant{
+			Type:       TopLeft,
+			StartPoint: &Coordinate{X: 0, Y: 0},
+			LimitPoint: &Coordinate{X: 25, Y: 25},
+		})
 
-	var (
-		ctx  = DBNameSet(context.Background(), os.Getenv("DB_NAME"))
-		conn = NewByConnString(os.Getenv("MOGODB_CONN"))
-	)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sID, err := conn.Spot.Create(ctx, &Spot{
+			Name:       "trap",
+			Kind:       Trap,
+			Penalty:    5,
+			GoldAmount: 40,
+			Coordinate: &Coordinate{X: 9, Y: 9},
+			QuadrantID: qID,
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// unlike Update, the zero values are set
+		got, err := conn.Spot.Replace(ctx, &Spot{ID: sID, Name: "treasure", Coordinate: &Coordinate{X: 0, Y: 9}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, Treasure, got.Kind)
+		assert.Equal(t, uint(0), got.Penalty)
+		assert.Equal(t, uint(0), got.GoldAmount)
+		assert.Equal(t, qID, got.QuadrantID)
 
-	qID, err := conn.Quadrant.Create(ctx, &Quadrant{
-		Type:       TopRight,
-		StartPoint: &Coordinate{X: 0, Y: 0},
-		LimitPoint: &Coordinate{X: 25, Y: 25},
+		got, err = conn.Spot.Get(ctx, &SpotFilter{ID: sID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, Coordinate{X: 0, Y: 9}, *got.Coordinate)
+		assert.Equal(t, uint(0), got.GoldAmount)
+
+		var ve *ValidationError
+
+		_, err = conn.Spot.Replace(ctx, &Spot{ID: sID, Coordinate: &Coordinate{X: 1, Y: 1}})
+		assert.True(t, errors.As(err, &ve))
+
+		_, err = conn.Spot.Replace(ctx, &Spot{ID: primitive.NewObjectID().Hex(), Name: "x", Coordinate: &Coordinate{}})
+		assert.Equal(t, CodeNotFound, Code(err))
+
+		if _, err := conn.Quadrant.Delete(ctx, &QuadrantFilter{ID: qID}); err != nil {
+			t.Fatal(err)
+		}
 	})
+}
 
-	if err != nil {
-		t.Fatal(err)
-	}
+func TestSpot_Version(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, ctx context.Context, conn *Repository) {
+		qID, err := conn.Quadrant.Create(ctx, &Quadrant{
+			Type:       TopLeft,
+			StartPoint: &Coordinate{X: 0, Y: 0},
+			LimitPoint: &Coordinate{X: 25, Y: 25},
+		})
 
-	expectedValue := &Spot{
-		Name:       "exit",
-		GoldAmount: "4000",
-		Coordinate: &Coordinate{
-			X: 9,
-			Y: 0,
-		},
-		QuadrantID: qID,
-	}
+		if err != nil {
+			t.Fatal(err)
+		}
 
-	sID, err := conn.Spot.Create(ctx, expectedValue)
+		s := &Spot{Name: "gold", Coordinate: &Coordinate{X: 1, Y: 1}, QuadrantID: qID}
 
-	if err != nil {
-		t.Fatal(err)
-	}
+		if _, err := conn.Spot.Create(ctx, s); err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, uint64(1), s.Version)
+
+		got, err := conn.Spot.Update(ctx, &Spot{ID: s.ID, GoldAmount: 5, Version: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, uint64(2), got.Version)
+
+		// the writes based on the first version are stale
+		var ve *VersionError
+
+		_, err = conn.Spot.Update(ctx, &Spot{ID: s.ID, GoldAmount: 7, Version: 1})
+		assert.True(t, errors.As(err, &ve))
+		assert.Equal(t, uint64(2), ve.Version)
+		assert.Equal(t, CodeConflict, Code(err))
+
+		_, err = conn.Spot.Replace(ctx, &Spot{ID: s.ID, Name: "gold", Coordinate: &Coordinate{}, Version: 1})
+		assert.True(t, errors.As(err, &ve))
+
+		// the writes without version aren't conditional
+		got, err = conn.Spot.Update(ctx, &Spot{ID: s.ID, GoldAmount: 7})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, uint64(3), got.Version)
+
+		// the quadrant was created, got the spot and its two updates
+		q, err := conn.Quadrant.Get(ctx, &QuadrantFilter{ID: qID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, uint64(4), q.Version)
+
+		_, err = conn.Quadrant.Update(ctx, &Quadrant{ID: qID, LimitPoint: &Coordinate{X: 30, Y: 30}, Version: 3})
+		assert.True(t, errors.As(err, &ve))
+
+		// the deletes at a stale version leave the spot and its quadrant unchanged
+		_, err = conn.Spot.Delete(ctx, &SpotFilter{ID: s.ID, Version: 2})
+		assert.True(t, errors.As(err, &ve))
+		assert.Equal(t, uint64(3), ve.Version)
+
+		if _, err := conn.Spot.Get(ctx, &SpotFilter{ID: s.ID}); err != nil {
+			t.Fatal(err)
+		}
+
+		isRemoved, err := conn.Spot.Delete(ctx, &SpotFilter{ID: s.ID, Version: 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.True(t, isRemoved)
+
+		q, err = conn.Quadrant.Update(ctx, &Quadrant{ID: qID, LimitPoint: &Coordinate{X: 30, Y: 30}, Version: 5})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, uint64(6), q.Version)
+
+		_, err = conn.Quadrant.Delete(ctx, &QuadrantFilter{ID: qID, Version: 5})
+		assert.True(t, errors.As(err, &ve))
+		assert.Equal(t, uint64(6), ve.Version)
+
+		if _, err := conn.Quadrant.Delete(ctx, &QuadrantFilter{ID: qID, Version: 6}); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestSpot_placement(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, ctx context.Context, conn *Repository) {
+		maze := &Maze{Name: "labyrinth", Width: 50, Height: 50}
+
+		if _, err := conn.Maze.Create(ctx, maze); err != nil {
+			t.Fatal(err)
+		}
+
+		var ve *ValidationError
+
+		// out of the bounds of the quadrant
+		_, err := conn.Spot.Create(ctx, &Spot{
+			Name:       "exit",
+			Coordinate: &Coordinate{X: 30, Y: 0},
+			QuadrantID: maze.QuadrantIDs[0],
+		})
+
+		assert.True(t, errors.As(err, &ve))
+
+		sID, err := conn.Spot.Create(ctx, &Spot{
+			Name:       "exit",
+			Coordinate: &Coordinate{X: 9, Y: 0},
+			QuadrantID: maze.QuadrantIDs[0],
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the coordinate is used by another spot
+		var ce *ConflictError
+
+		_, err = conn.Spot.Create(ctx, &Spot{
+			Name:       "entrance",
+			Coordinate: &Coordinate{X: 9, Y: 0},
+			QuadrantID: maze.QuadrantIDs[0],
+		})
+
+		assert.True(t, errors.As(err, &ce))
+		assert.Equal(t, CodeConflict, Code(err))
+
+		// placed in the quadrant that contains the coordinate
+		auto := &Spot{MazeID: maze.ID, Name: "entrance", Coordinate: &Coordinate{X: 40, Y: 40}}
+
+		if _, err := conn.Spot.Create(ctx, auto); err != nil {
+			t.Fatal(err)
+		}
+
+		q, err := conn.Quadrant.Get(ctx, &QuadrantFilter{ID: auto.QuadrantID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, BottomRight, q.Type)
+		assert.Equal(t, []string{auto.ID}, q.SpotIDs)
+
+		// the spot can't be moved over another one
+		_, err = conn.Spot.Update(ctx, &Spot{ID: sID, Coordinate: &Coordinate{X: 40, Y: 40}})
+		assert.True(t, errors.As(err, &ce))
+
+		// a new coordinate moves the spot to the quadrant that contains it
+		got, err := conn.Spot.Update(ctx, &Spot{ID: sID, Coordinate: &Coordinate{X: 40, Y: 10}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, maze.QuadrantIDs[1], got.QuadrantID)
+
+		if _, err := conn.Maze.Delete(ctx, &MazeFilter{ID: maze.ID}); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestSpot_gold(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, ctx context.Context, conn *Repository) {
+		maze := &Maze{Name: "labyrinth", Width: 50, Height: 50}
+
+		if _, err := conn.Maze.Create(ctx, maze); err != nil {
+			t.Fatal(err)
+		}
+
+		spots := []Spot{
+			{Name: "a", GoldAmount: 100, Coordinate: &Coordinate{X: 1, Y: 1}},
+			{Name: "b", GoldAmount: 300, Coordinate: &Coordinate{X: 2, Y: 1}},
+			{Name: "c", GoldAmount: 50, Coordinate: &Coordinate{X: 30, Y: 1}},
+			{Name: "d", GoldAmount: 0, Coordinate: &Coordinate{X: 30, Y: 30}},
+		}
+
+		for i := range spots {
+			spots[i].MazeID = maze.ID
+
+			if _, err := conn.Spot.Create(ctx, &spots[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		byMaze, err := conn.Spot.TotalGoldByMaze(ctx, &SpotFilter{MazeID: maze.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, []GoldTotal{{ID: maze.ID, GoldAmount: 450, Spots: 4}}, byMaze)
+
+		byQuadrant, err := conn.Spot.TotalGoldByQuadrant(ctx, &SpotFilter{MazeID: maze.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the quadrants are created in order so their ids are sorted
+		assert.Equal(t, []GoldTotal{
+			{ID: maze.QuadrantIDs[0], GoldAmount: 400, Spots: 2},
+			{ID: maze.QuadrantIDs[1], GoldAmount: 50, Spots: 1},
+			{ID: maze.QuadrantIDs[3], GoldAmount: 0, Spots: 1},
+		}, byQuadrant)
+
+		richest, err := conn.Spot.Richest(ctx, &SpotFilter{MazeID: maze.ID}, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Len(t, richest, 2)
+		assert.Equal(t, "b", richest[0].Name)
+		assert.Equal(t, "a", richest[1].Name)
+
+		_, err = conn.Spot.Richest(ctx, &SpotFilter{MazeID: maze.ID}, 0)
+		assert.Error(t, err)
+
+		if _, err := conn.Maze.Delete(ctx, &MazeFilter{ID: maze.ID}); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestSpot_kinds(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, ctx context.Context, conn *Repository) {
+		maze := &Maze{Name: "labyrinth", Width: 50, Height: 50}
+
+		if _, err := conn.Maze.Create(ctx, maze); err != nil {
+			t.Fatal(err)
+		}
+
+		var ve *ValidationError
+
+		invalid := []Spot{
+			{Name: "x", Kind: "DOOR", Coordinate: &Coordinate{X: 1, Y: 1}},
+			{Name: "x", Kind: Trap, Coordinate: &Coordinate{X: 1, Y: 1}},
+			{Name: "x", Kind: Exit, Penalty: 10, Coordinate: &Coordinate{X: 1, Y: 1}},
+			{Name: "x", Kind: Wall, GoldAmount: 10, Coordinate: &Coordinate{X: 1, Y: 1}},
+		}
+
+		for i := range invalid {
+			invalid[i].MazeID = maze.ID
+
+			_, err := conn.Spot.Create(ctx, &invalid[i])
+			assert.True(t, errors.As(err, &ve), invalid[i])
+		}
+
+		spots := []Spot{
+			{Name: "in", Kind: Entrance, Coordinate: &Coordinate{X: 0, Y: 0}},
+			{Name: "out", Kind: Exit, Coordinate: &Coordinate{X: 49, Y: 49}},
+			{Name: "spikes", Kind: Trap, Penalty: 50, Coordinate: &Coordinate{X: 10, Y: 10}},
+			{Name: "stone", Kind: Wall, Coordinate: &Coordinate{X: 11, Y: 10}},
+		}
+
+		for i := range spots {
+			spots[i].MazeID = maze.ID
+
+			if _, err := conn.Spot.Create(ctx, &spots[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		assert.True(t, spots[3].IsBlocking())
+		assert.NoError(t, ValidateMazeSpots(spots))
+		assert.Error(t, ValidateMazeSpots(spots[2:]))
+
+		// a second entrance in another quadrant
+		_, err := conn.Spot.Create(ctx, &Spot{
+			MazeID:     maze.ID,
+			Name:       "in",
+			Kind:       Entrance,
+			Coordinate: &Coordinate{X: 40, Y: 0},
+		})
+
+		assert.True(t, errors.As(err, &ve))
+
+		// a trap turned into a treasure loses its penalty
+		got, err := conn.Spot.Update(ctx, &Spot{ID: spots[2].ID, Kind: Treasure, GoldAmount: 20})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Zero(t, got.Penalty)
+
+		traps, err := conn.Spot.List(ctx, &SpotFilter{MazeID: maze.ID, Kind: Trap})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Empty(t, traps)
+
+		walls, err := conn.Spot.List(ctx, &SpotFilter{MazeID: maze.ID, Kind: Wall})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Len(t, walls, 1)
+
+		// the maze can't lose its only exit or entrance, neither deleted nor changed of kind
+		var ce *ConflictError
 
-	assert.NotEmpty(t, sID)
-	assert.IsType(t, sID, "")
+		_, err = conn.Spot.Delete(ctx, &SpotFilter{ID: spots[1].ID})
+		assert.True(t, errors.As(err, &ce))
 
-	got, err := conn.Spot.Get(ctx, &SpotFilter{ID: sID})
+		_, err = conn.Spot.Update(ctx, &Spot{ID: spots[1].ID, Kind: Treasure})
+		assert.True(t, errors.As(err, &ce))
+
+		_, err = conn.Spot.Replace(ctx, &Spot{ID: spots[1].ID, Name: "out", Kind: Trap, Penalty: 5, Coordinate: &Coordinate{X: 49, Y: 49}})
+		assert.True(t, errors.As(err, &ce))
+
+		_, err = conn.Spot.Delete(ctx, &SpotFilter{ID: spots[0].ID})
+		assert.True(t, errors.As(err, &ce))
+
+		exits, err := conn.Spot.List(ctx, &SpotFilter{MazeID: maze.ID, Kind: Exit})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Len(t, exits, 1)
+
+		// once there is another exit the first one can go
+		door := &Spot{MazeID: maze.ID, Name: "door", Kind: Exit, Coordinate: &Coordinate{X: 49, Y: 0}}
+
+		if _, err := conn.Spot.Create(ctx, door); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := conn.Spot.Update(ctx, &Spot{ID: spots[1].ID, Kind: Treasure}); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = conn.Spot.Delete(ctx, &SpotFilter{ID: door.ID})
+		assert.True(t, errors.As(err, &ce))
+
+		// the maze is deleted with all its spots
+		if _, err := conn.Maze.Delete(ctx, &MazeFilter{ID: maze.ID}); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestSpot_spatial(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, ctx context.Context, conn *Repository) {
+		// the spatial queries of MongoDB need the index created by the migration
+		if err := conn.Migrate(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		maze := &Maze{Name: "labyrinth", Width: 50, Height: 50}
+
+		if _, err := conn.Maze.Create(ctx, maze); err != nil {
+			t.Fatal(err)
+		}
+
+		spots := []Spot{
+			{Name: "a", Coordinate: &Coordinate{X: 10, Y: 10}},
+			{Name: "b", Coordinate: &Coordinate{X: 13, Y: 14}},
+			{Name: "c", Coordinate: &Coordinate{X: 20, Y: 10}},
+			{Name: "d", Coordinate: &Coordinate{X: 40, Y: 40}},
+			{Name: "e", Kind: Trap, Penalty: 5, Coordinate: &Coordinate{X: 11, Y: 10}},
+		}
+
+		for i := range spots {
+			spots[i].MazeID = maze.ID
+
+			if _, err := conn.Spot.Create(ctx, &spots[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		names := func(spots []Spot) []string {
+			n := make([]string, 0, len(spots))
+
+			for i := range spots {
+				n = append(n, spots[i].Name)
+			}
+
+			return n
+		}
+
+		filter := &SpotFilter{MazeID: maze.ID}
+
+		// the limit point of the box is exclusive
+		got, err := conn.Spot.InBox(ctx, filter, &Region{StartPoint: Coordinate{X: 10, Y: 10}, LimitPoint: Coordinate{X: 20, Y: 15}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.ElementsMatch(t, []string{"a", "b", "e"}, names(got))
+
+		// the distance from a to b is 5
+		got, err = conn.Spot.InRadius(ctx, filter, Coordinate{X: 10, Y: 10}, 5)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, []string{"a", "e", "b"}, names(got))
+
+		got, err = conn.Spot.Nearest(ctx, filter, Coordinate{X: 19, Y: 11}, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, []string{"c", "b"}, names(got))
+
+		// the kind narrows the candidates
+		got, err = conn.Spot.Nearest(ctx, &SpotFilter{MazeID: maze.ID, Kind: Trap}, Coordinate{X: 40, Y: 40}, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, []string{"e"}, names(got))
+
+		var ve *ValidationError
+
+		_, err = conn.Spot.InBox(ctx, filter, &Region{StartPoint: Coordinate{X: 10, Y: 10}, LimitPoint: Coordinate{X: 10, Y: 15}})
+		assert.True(t, errors.As(err, &ve))
+
+		_, err = conn.Spot.InRadius(ctx, filter, Coordinate{}, -1)
+		assert.True(t, errors.As(err, &ve))
+
+		_, err = conn.Spot.Nearest(ctx, filter, Coordinate{}, 0)
+		assert.True(t, errors.As(err, &ve))
+	})
+}
+
+func TestSpot_Page(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, ctx context.Context, conn *Repository) {
+		maze := &Maze{Name: "labyrinth", Width: 50, Height: 50}
+
+		if _, err := conn.Maze.Create(ctx, maze); err != nil {
+			t.Fatal(err)
+		}
+
+		spots := []Spot{
+			{Name: "gold-a", GoldAmount: 30, Coordinate: &Coordinate{X: 1, Y: 1}},
+			{Name: "gold-b", GoldAmount: 10, Coordinate: &Coordinate{X: 2, Y: 1}},
+			{Name: "gold-c", GoldAmount: 30, Coordinate: &Coordinate{X: 30, Y: 1}},
+			{Name: "gold-d", GoldAmount: 20, Coordinate: &Coordinate{X: 30, Y: 30}},
+			{Name: "trap", Kind: Trap, Penalty: 3, Coordinate: &Coordinate{X: 3, Y: 3}},
+		}
+
+		ids := make([]string, len(spots))
+
+		for i := range spots {
+			spots[i].MazeID = maze.ID
+
+			id, err := conn.Spot.Create(ctx, &spots[i])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ids[i] = id
+		}
+
+		// walk all the pages, the ties are sorted by id in the same direction
+		query := &SpotQuery{MazeID: maze.ID, NamePrefix: "gold-"}
+		opts := &ListOptions{Sort: "-gold_amount", Limit: 3}
+
+		var names []string
+
+		for {
+			page, err := conn.Spot.Page(ctx, query, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, int64(4), page.Total)
+
+			for _, s := range page.Items {
+				names = append(names, s.Name)
+			}
+
+			if page.NextCursor == "" {
+				break
+			}
+
+			opts.Cursor = page.NextCursor
+		}
+
+		first, second := "gold-a", "gold-c"
+		if ids[2] > ids[0] {
+			first, second = second, first
+		}
+
+		assert.Equal(t, []string{first, second, "gold-d", "gold-b"}, names)
+
+		// the rest of the filters
+		min, max := uint(15), uint(30)
+
+		page, err := conn.Spot.Page(ctx, &SpotQuery{
+			MazeID:  maze.ID,
+			MinGold: &min,
+			MaxGold: &max,
+			Box:     &Region{LimitPoint: Coordinate{X: 31, Y: 2}},
+		}, &ListOptions{Sort: "x"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Len(t, page.Items, 2)
+		assert.Equal(t, "gold-a", page.Items[0].Name)
+		assert.Equal(t, "gold-c", page.Items[1].Name)
+		assert.Empty(t, page.NextCursor)
+
+		page, err = conn.Spot.Page(ctx, &SpotQuery{MazeID: maze.ID, Kind: Trap}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Len(t, page.Items, 1)
+
+		// the cursor must be used with the same sort
+		byName, err := conn.Spot.Page(ctx, query, &ListOptions{Sort: "name", Limit: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var ve *ValidationError
+
+		_, err = conn.Spot.Page(ctx, query, &ListOptions{Sort: "-name", Cursor: byName.NextCursor})
+		assert.True(t, errors.As(err, &ve))
+
+		_, err = conn.Spot.Page(ctx, query, &ListOptions{Cursor: "not-a-cursor"})
+		assert.True(t, errors.As(err, &ve))
+
+		_, err = conn.Spot.Page(ctx, query, &ListOptions{Sort: "color"})
+		assert.True(t, errors.As(err, &ve))
+
+		_, err = conn.Spot.Page(ctx, &SpotQuery{MinGold: &max, MaxGold: &min}, nil)
+		assert.True(t, errors.As(err, &ve))
+	})
+}
+
+func TestSpot_Batch(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, ctx context.Context, conn *Repository) {
+		maze := &Maze{Name: "labyrinth", Width: 50, Height: 50}
+
+		if _, err := conn.Maze.Create(ctx, maze); err != nil {
+			t.Fatal(err)
+		}
+
+		codes := func(results []BatchResult) []ErrorCode {
+			got := make([]ErrorCode, 0, len(results))
+
+			for _, r := range results {
+				if r.Err == nil {
+					got = append(got, "")
+
+					continue
+				}
+
+				got = append(got, Code(r.Err))
+			}
+
+			return got
+		}
+
+		quadrant := func(i int) *Quadrant {
+			q, err := conn.Quadrant.Get(ctx, &QuadrantFilter{ID: maze.QuadrantIDs[i]})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return q
+		}
+
+		before := quadrant(0).Version
+
+		// the spots are checked against the ones created before them in the batch
+		results, err := conn.Spot.BatchCreate(ctx, []Spot{
+			{MazeID: maze.ID, Name: "entrance", Kind: Entrance, Coordinate: &Coordinate{X: 1, Y: 1}},
+			{MazeID: maze.ID, Name: "gold", Coordinate: &Coordinate{X: 1, Y: 1}},
+			{MazeID: maze.ID, Name: "another entrance", Kind: Entrance, Coordinate: &Coordinate{X: 30, Y: 30}},
+			{MazeID: maze.ID, Coordinate: &Coordinate{X: 3, Y: 3}},
+			{MazeID: maze.ID, Name: "gold", GoldAmount: 5, Coordinate: &Coordinate{X: 2, Y: 2}},
+			{MazeID: maze.ID, Name: "exit", Kind: Exit, Coordinate: &Coordinate{X: 40, Y: 2}},
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, []ErrorCode{"", CodeConflict, CodeValidation, CodeValidation, "", ""}, codes(results))
+
+		entrance, gold, exit := results[0].ID, results[4].ID, results[5].ID
+
+		// a single write by quadrant
+		q := quadrant(0)
+		assert.Equal(t, []string{entrance, gold}, q.SpotIDs)
+		assert.Equal(t, before+1, q.Version)
+		assert.Equal(t, []string{exit}, quadrant(1).SpotIDs)
+
+		stored, err := conn.Spot.Get(ctx, &SpotFilter{ID: gold})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, results[4].Spot, stored)
+
+		results, err = conn.Spot.BatchUpdate(ctx, []Spot{
+			{ID: entrance, Coordinate: &Coordinate{X: 40, Y: 3}},
+			{ID: gold, Version: 5},
+			{ID: gold, Coordinate: &Coordinate{X: 40, Y: 3}},
+			{ID: primitive.NewObjectID().Hex(), Name: "ghost"},
+			{ID: exit, Version: 1, GoldAmount: 7},
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, []ErrorCode{"", CodeConflict, CodeConflict, CodeNotFound, ""}, codes(results))
+		assert.Equal(t, maze.QuadrantIDs[1], results[0].Spot.QuadrantID)
+		assert.EqualValues(t, 2, results[4].Spot.Version)
+		assert.Equal(t, []string{gold}, quadrant(0).SpotIDs)
+		assert.Equal(t, []string{exit, entrance}, quadrant(1).SpotIDs)
+
+		// the only entrance of the maze can't be deleted
+		results, err = conn.Spot.BatchDelete(ctx, []Spot{
+			{ID: gold, Version: 1},
+			{ID: gold},
+			{ID: entrance},
+			{ID: exit, Version: 1},
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, []ErrorCode{"", CodeNotFound, CodeConflict, CodeConflict}, codes(results))
+		assert.Empty(t, quadrant(0).SpotIDs)
+		assert.Equal(t, []string{exit, entrance}, quadrant(1).SpotIDs)
+
+		var ve *ValidationError
+
+		_, err = conn.Spot.BatchDelete(ctx, nil)
+		assert.True(t, errors.As(err, &ve))
+
+		_, err = conn.Spot.BatchCreate(ctx, make([]Spot, MaxBatchSize+1))
+		assert.True(t, errors.As(err, &ve))
+
+		if _, err := conn.Maze.Delete(ctx, &MazeFilter{ID: maze.ID}); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestSpot_CreateMany(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, ctx context.Context, conn *Repository) {
+		maze := &Maze{Name: "labyrinth", Width: 50, Height: 50}
+
+		if _, err := conn.Maze.Create(ctx, maze); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := conn.Spot.Create(ctx, &Spot{Name: "entrance", Kind: Entrance, MazeID: maze.ID, Coordinate: &Coordinate{X: 0, Y: 0}}); err != nil {
+			t.Fatal(err)
+		}
+
+		var se *SpotError
+
+		// the spots are checked against the stored ones and each other, none is created
+		// when one of them fails
+		for i, spots := range [][]Spot{
+			{{Name: "gold", MazeID: maze.ID, Coordinate: &Coordinate{X: 1, Y: 1}}, {Name: "copy", MazeID: maze.ID, Coordinate: &Coordinate{X: 0, Y: 0}}},
+			{{Name: "gold", MazeID: maze.ID, Coordinate: &Coordinate{X: 1, Y: 1}}, {Name: "copy", MazeID: maze.ID, Coordinate: &Coordinate{X: 1, Y: 1}}},
+			{{Name: "gold", MazeID: maze.ID, Coordinate: &Coordinate{X: 1, Y: 1}}, {Name: "door", Kind: Entrance, MazeID: maze.ID, Coordinate: &Coordinate{X: 40, Y: 40}}},
+		} {
+			err := conn.Spot.CreateMany(ctx, spots)
+			assert.True(t, errors.As(err, &se), "case %d", i)
+			assert.Equal(t, 1, se.Index, "case %d", i)
+		}
+
+		spots := []Spot{
+			{Name: "gold", MazeID: maze.ID, GoldAmount: 3, Coordinate: &Coordinate{X: 1, Y: 1}},
+			{Name: "spike", Kind: Trap, Penalty: 2, MazeID: maze.ID, Coordinate: &Coordinate{X: 40, Y: 40}},
+			{Name: "exit", Kind: Exit, MazeID: maze.ID, Coordinate: &Coordinate{X: 49, Y: 49}},
+		}
+
+		if err := conn.Spot.CreateMany(ctx, spots); err != nil {
+			t.Fatal(err)
+		}
+
+		stored, err := conn.Spot.List(ctx, &SpotFilter{MazeID: maze.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Len(t, stored, 4)
+
+		for _, s := range spots {
+			q, err := conn.Quadrant.Get(ctx, &QuadrantFilter{ID: s.QuadrantID})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Contains(t, q.SpotIDs, s.ID)
+			assert.True(t, q.Contains(*s.Coordinate))
+		}
+	})
+}
+
+func TestSpot_PageByPenalty(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, ctx context.Context, conn *Repository) {
+		maze := &Maze{Name: "labyrinth", Width: 50, Height: 50}
+
+		if _, err := conn.Maze.Create(ctx, maze); err != nil {
+			t.Fatal(err)
+		}
+
+		// the treasures don't have a penalty, so it is omitted in their documents
+		spots := []Spot{
+			{Name: "gold-a", Coordinate: &Coordinate{X: 1, Y: 1}},
+			{Name: "trap-a", Kind: Trap, Penalty: 2, Coordinate: &Coordinate{X: 2, Y: 1}},
+			{Name: "gold-b", Coordinate: &Coordinate{X: 3, Y: 1}},
+			{Name: "trap-b", Kind: Trap, Penalty: 5, Coordinate: &Coordinate{X: 4, Y: 1}},
+			{Name: "gold-c", Coordinate: &Coordinate{X: 5, Y: 1}},
+		}
+
+		for i := range spots {
+			spots[i].MazeID = maze.ID
+
+			if _, err := conn.Spot.Create(ctx, &spots[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		walk := func(sort string) []string {
+			opts := &ListOptions{Sort: sort, Limit: 1}
+
+			var names []string
+
+			for {
+				page, err := conn.Spot.Page(ctx, &SpotQuery{MazeID: maze.ID}, opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				for _, s := range page.Items {
+					names = append(names, s.Name)
+				}
+
+				if page.NextCursor == "" {
+					return names
+				}
+
+				opts.Cursor = page.NextCursor
+			}
+		}
+
+		// the ids grow in the order the spots were created
+		assert.Equal(t, []string{"gold-a", "gold-b", "gold-c", "trap-a", "trap-b"}, walk("penalty"))
+		assert.Equal(t, []string{"trap-b", "trap-a", "gold-c", "gold-b", "gold-a"}, walk("-penalty"))
+	})
+}
+
+// the MongoDB queries are checked by their filters and pipelines since the tests only run
+// against MongoDB when its connection string is set
+func TestSpot_mongoFilters(t *testing.T) {
+	id, other := primitive.NewObjectID(), primitive.NewObjectID()
+
+	filter, err := (&SpotFilter{ID: id.Hex(), MazeID: "maze", Kind: Exit}).toMongoFilter()
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	expectedValue.ID = got.ID
-
-	assert.NotEmpty(t, got)
-	assert.IsType(t, &Spot{}, got)
-	assert.EqualValues(t, expectedValue, got)
+	assert.Equal(t, bson.M{"_id": id, "maze_id": "maze", "kind": Exit}, filter)
 
-	isRemoved, err := conn.Spot.Delete(ctx, &SpotFilter{ID: got.ID})
+	filter, err = (&SpotFilter{SpotsIDs: []string{id.Hex(), other.Hex()}, QuadrantID: "quadrant"}).toMongoFilter()
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	assert.True(t, isRemoved)
-	assert.EqualValues(t, true, isRemoved)
+	assert.Equal(t, bson.M{"_id": bson.M{"$in": []primitive.ObjectID{id, other}}, "quadrant_id": "quadrant"}, filter)
 
-	if _, err := conn.Quadrant.Delete(ctx, &QuadrantFilter{ID: qID}); err != nil {
-		t.Fatal(err)
+	for _, sf := range []*SpotFilter{nil, {}, {Kind: "DOOR"}, {ID: "x"}, {SpotsIDs: []string{id.Hex()}, Version: 2}} {
+		_, err := sf.toMongoFilter()
+		assert.Error(t, err, sf)
 	}
+
+	// the aggregations use all the spots without filter
+	match, err := (*SpotFilter)(nil).toMongoMatch()
+	assert.NoError(t, err)
+	assert.Equal(t, bson.M{}, match)
 }
 
-func TestSpot_filterSpotList(t *testing.T) {
-	Test_EnvMongoDBConnectionString(t)
+func TestSpot_mongoPipelines(t *testing.T) {
+	match := bson.M{"maze_id": "maze"}
+
+	assert.Equal(t, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$match", Value: bson.M{"quadrant_id": bson.M{"$nin": bson.A{nil, ""}}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":         "$quadrant_id",
+			"gold_amount": bson.M{"$sum": "$gold_amount"},
+			"spots":       bson.M{"$sum": 1},
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}, totalGoldPipeline(match, "quadrant_id"))
+
+	assert.Equal(t, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$sort", Value: bson.D{{Key: "gold_amount", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: 3}},
+	}, richestPipeline(match, 3))
+}
+
+func TestSpot_mongoSpatialConditions(t *testing.T) {
+	// the limit point of the box is exclusive, so the cells before it are the last ones
+	box := boxCondition(&Region{StartPoint: Coordinate{X: 1, Y: 2}, LimitPoint: Coordinate{X: 5, Y: 6}})
+	assert.Equal(t, bson.M{"$geoWithin": bson.M{"$box": bson.A{bson.A{uint(1), uint(2)}, bson.A{4.5, 5.5}}}}, box)
+
+	assert.Equal(t, bson.M{"$geoWithin": bson.M{"$center": bson.A{bson.A{uint(3), uint(4)}, 2.5}}},
+		radiusCondition(Coordinate{X: 3, Y: 4}, 2.5))
+
+	assert.Equal(t, bson.M{"$near": bson.A{uint(3), uint(4)}}, nearCondition(Coordinate{X: 3, Y: 4}))
+}
 
+func TestSpot_mongoBatchWrites(t *testing.T) {
 	var (
-		ctx  = DBNameSet(context.Background(), os.Getenv("DB_NAME"))
-		conn = NewByConnString(os.Getenv("MOGODB_CONN"))
+		created = &Spot{ID: primitive.NewObjectID().Hex(), Name: "gold", QuadrantID: "q", Version: 1}
+		updated = &Spot{ID: primitive.NewObjectID().Hex(), Name: "silver", QuadrantID: "q", Version: 4}
+		deleted = &Spot{ID: primitive.NewObjectID().Hex(), Name: "copper", Version: 2}
+		qID     = primitive.NewObjectID()
 	)
 
-	qID, err := conn.Quadrant.Create(ctx, &Quadrant{
-		Type:       TopRight,
-		StartPoint: &Coordinate{X: 0, Y: 0},
-		LimitPoint: &Coordinate{X: 25, Y: 25},
-	})
+	b := newSpotBatch(nil, 3)
+	b.writes = []spotWrite{
+		{spot: created, insert: true},
+		{spot: updated, version: 3},
+		{spot: deleted, version: 2, remove: true},
+	}
+	b.quadrants[qID.Hex()] = &Quadrant{ID: qID.Hex(), Version: 7}
+	b.changed = []string{qID.Hex()}
 
+	spots, quadrants, err := b.mongoWrites()
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	sID1, err := conn.Spot.Create(ctx, &Spot{
-		Name:       "exit",
-		GoldAmount: "4000",
-		Coordinate: &Coordinate{
-			X: 9,
-			Y: 0,
-		},
-		QuadrantID: qID,
-	})
+	assert.Len(t, spots, 3)
 
-	if err != nil {
-		t.Fatal(err)
+	id := func(s *Spot) primitive.ObjectID {
+		oid, _ := primitive.ObjectIDFromHex(s.ID)
+
+		return oid
 	}
 
-	assert.NotEmpty(t, sID1)
-	assert.IsType(t, sID1, "")
-
-	sID2, err := conn.Spot.Create(ctx, &Spot{
-		Name:       "entrace",
-		GoldAmount: "9000",
-		Coordinate: &Coordinate{
-			X: 0,
-			Y: 10,
-		},
-		QuadrantID: qID,
-	})
+	// the inserts keep the id given by the batch
+	insert, ok := spots[0].(*mongo.InsertOneModel)
+	if assert.True(t, ok) {
+		doc := created.mongoDocument()
+		doc["_id"] = id(created)
 
-	if err != nil {
-		t.Fatal(err)
+		assert.Equal(t, doc, insert.Document)
 	}
 
-	assert.NotEmpty(t, sID2)
-	assert.IsType(t, sID2, "")
+	// the replaces and deletes are conditioned on the version read
+	replace, ok := spots[1].(*mongo.ReplaceOneModel)
+	if assert.True(t, ok) {
+		assert.Equal(t, bson.M{"_id": id(updated), "version": uint64(3)}, replace.Filter)
+		assert.Equal(t, updated.mongoDocument(), replace.Replacement)
+	}
 
-	spots, err := conn.Spot.List(ctx, &SpotFilter{SpotsIDs: []string{sID1, sID2}})
-	if err != nil {
-		t.Fatal(err)
+	remove, ok := spots[2].(*mongo.DeleteOneModel)
+	if assert.True(t, ok) {
+		assert.Equal(t, bson.M{"_id": id(deleted), "version": uint64(2)}, remove.Filter)
 	}
 
-	assert.NotEmpty(t, spots)
-	assert.Len(t, spots, 2)
+	// a single write by quadrant, the empty spot_ids are stored as an empty array
+	assert.Len(t, quadrants, 1)
 
-	isRemoved, err := conn.Spot.Delete(ctx, &SpotFilter{QuadrantID: qID})
-	if err != nil {
-		t.Fatal(err)
+	update, ok := quadrants[0].(*mongo.UpdateOneModel)
+	if assert.True(t, ok) {
+		assert.Equal(t, bson.M{"_id": qID, "version": uint64(7)}, update.Filter)
+		assert.Equal(t, bson.M{"$set": bson.M{"spot_ids": []string{}}, "$inc": bson.M{"version": 1}}, update.Update)
 	}
 
-	assert.True(t, isRemoved)
-	assert.EqualValues(t, true, isRemoved)
+	b.writes = []spotWrite{{spot: &Spot{ID: "wrong"}}}
 
-	if _, err := conn.Quadrant.Delete(ctx, &QuadrantFilter{ID: qID}); err != nil {
-		t.Fatal(err)
+	_, _, err = b.mongoWrites()
+	assert.Error(t, err)
+}
+
+func TestSpot_mongoDocument(t *testing.T) {
+	decode := func(v interface{}) bson.M {
+		raw, err := bson.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var doc bson.M
+
+		if err := bson.Unmarshal(raw, &doc); err != nil {
+			t.Fatal(err)
+		}
+
+		return doc
+	}
+
+	// the bulk writes store the same document as the single writes
+	spots := []Spot{
+		{Name: "gold", GoldAmount: 5, Kind: Treasure, QuadrantID: "q", Coordinate: &Coordinate{X: 1, Y: 2}, Version: 1},
+		{Name: "spikes", MazeID: "maze", Kind: Trap, Penalty: 3, QuadrantID: "q", Coordinate: &Coordinate{}, Version: 2},
+	}
+
+	for i := range spots {
+		assert.Equal(t, decode(&spots[i]), decode(spots[i].mongoDocument()), spots[i].Name)
 	}
 }
@@ -0,0 +1,236 @@
+package repository
+
+import (
+	"math"
+	"sort"
+)
+
+// maxIndexedCoordinate is the upper bound of the 2d index of the spot coordinates in
+// MongoDB, the coordinates of the spots must be lower than it.
+const maxIndexedCoordinate = 1 << 32
+
+// validateBox checks the region used by InBox.
+func validateBox(r *Region) error {
+	ve := &ValidationError{Entity: "spot"}
+
+	if r == nil {
+		ve.add("box", "must be specified")
+	} else if r.StartPoint.X >= r.LimitPoint.X || r.StartPoint.Y >= r.LimitPoint.Y {
+		ve.add("box", "the limit point must be greater than the start point on both axes")
+	}
+
+	return ve.err()
+}
+
+// validateRadius checks the radius used by InRadius.
+func validateRadius(radius float64) error {
+	if radius < 0 || math.IsNaN(radius) || math.IsInf(radius, 0) {
+		ve := &ValidationError{Entity: "spot"}
+		ve.add("radius", "must be a non-negative number")
+
+		return ve
+	}
+
+	return nil
+}
+
+// inBox reports if the coordinate is inside the region, the start point is inclusive and
+// the limit point is exclusive.
+func inBox(c Coordinate, r *Region) bool {
+	return c.X >= r.StartPoint.X && c.X < r.LimitPoint.X && c.Y >= r.StartPoint.Y && c.Y < r.LimitPoint.Y
+}
+
+// distance2 returns the squared euclidean distance between the coordinates.
+func distance2(a, b Coordinate) float64 {
+	dx := float64(a.X) - float64(b.X)
+	dy := float64(a.Y) - float64(b.Y)
+
+	return dx*dx + dy*dy
+}
+
+// sortByDistance sorts the spots by their distance to the point, the ties are sorted by
+// id so all the backends return the same order.
+func sortByDistance(spots []Spot, point Coordinate) {
+	sort.SliceStable(spots, func(i, j int) bool {
+		di, dj := distance2(*spots[i].Coordinate, point), distance2(*spots[j].Coordinate, point)
+		if di != dj {
+			return di < dj
+		}
+
+		return spots[i].ID < spots[j].ID
+	})
+}
+
+// kdNode represents a spot of the k-d tree, the spots of the left branch have a lower
+// or equal coordinate in the axis of the node and the ones of the right branch a greater
+// or equal one.
+type kdNode struct {
+	spot        *Spot
+	axis        int
+	left, right *kdNode
+}
+
+// kdTree is an in-process spatial index of spots, it is used by the backends that can't
+// index the coordinates themselves.
+type kdTree struct {
+	root *kdNode
+}
+
+// axisValue returns the coordinate of the axis, 0 is x and 1 is y.
+func axisValue(c Coordinate, axis int) uint {
+	if axis == 0 {
+		return c.X
+	}
+
+	return c.Y
+}
+
+// newKDTree builds a balanced tree with the spots that have a coordinate.
+func newKDTree(spots []Spot) *kdTree {
+	nodes := make([]*Spot, 0, len(spots))
+
+	for i := range spots {
+		if spots[i].Coordinate != nil {
+			nodes = append(nodes, &spots[i])
+		}
+	}
+
+	return &kdTree{root: buildKD(nodes, 0)}
+}
+
+func buildKD(spots []*Spot, axis int) *kdNode {
+	if len(spots) == 0 {
+		return nil
+	}
+
+	sort.Slice(spots, func(i, j int) bool {
+		return axisValue(*spots[i].Coordinate, axis) < axisValue(*spots[j].Coordinate, axis)
+	})
+
+	median := len(spots) / 2
+	next := (axis + 1) % 2
+
+	return &kdNode{
+		spot:  spots[median],
+		axis:  axis,
+		left:  buildKD(spots[:median], next),
+		right: buildKD(spots[median+1:], next),
+	}
+}
+
+// inBox returns the spots inside the region sorted by id.
+func (t *kdTree) inBox(r *Region) []Spot {
+	spots := make([]Spot, 0)
+
+	var walk func(n *kdNode)
+
+	walk = func(n *kdNode) {
+		if n == nil {
+			return
+		}
+
+		c := *n.spot.Coordinate
+		if inBox(c, r) {
+			spots = append(spots, *n.spot)
+		}
+
+		v := axisValue(c, n.axis)
+
+		if axisValue(r.StartPoint, n.axis) <= v {
+			walk(n.left)
+		}
+
+		if axisValue(r.LimitPoint, n.axis) > v {
+			walk(n.right)
+		}
+	}
+
+	walk(t.root)
+
+	sort.Slice(spots, func(i, j int) bool {
+		return spots[i].ID < spots[j].ID
+	})
+
+	return spots
+}
+
+// inRadius returns the spots at a distance lower or equal than the radius from the point
+// sorted by distance.
+func (t *kdTree) inRadius(point Coordinate, radius float64) []Spot {
+	spots := make([]Spot, 0)
+	r2 := radius * radius
+
+	var walk func(n *kdNode)
+
+	walk = func(n *kdNode) {
+		if n == nil {
+			return
+		}
+
+		c := *n.spot.Coordinate
+		if distance2(c, point) <= r2 {
+			spots = append(spots, *n.spot)
+		}
+
+		diff := float64(axisValue(point, n.axis)) - float64(axisValue(c, n.axis))
+
+		if diff <= radius {
+			walk(n.left)
+		}
+
+		if diff >= -radius {
+			walk(n.right)
+		}
+	}
+
+	walk(t.root)
+	sortByDistance(spots, point)
+
+	return spots
+}
+
+// nearest returns the k spots closer to the point sorted by distance.
+func (t *kdTree) nearest(point Coordinate, k int) []Spot {
+	best := make([]Spot, 0, k+1)
+
+	// worse reports if the spot can't enter the best ones found so far
+	worse := func(d float64) bool {
+		return len(best) == k && d > distance2(*best[k-1].Coordinate, point)
+	}
+
+	var walk func(n *kdNode)
+
+	walk = func(n *kdNode) {
+		if n == nil {
+			return
+		}
+
+		c := *n.spot.Coordinate
+		if !worse(distance2(c, point)) {
+			best = append(best, *n.spot)
+			sortByDistance(best, point)
+
+			if len(best) > k {
+				best = best[:k]
+			}
+		}
+
+		diff := float64(axisValue(point, n.axis)) - float64(axisValue(c, n.axis))
+
+		near, far := n.left, n.right
+		if diff > 0 {
+			near, far = n.right, n.left
+		}
+
+		walk(near)
+
+		// the other branch can only contain closer spots when the plane is close enough
+		if !worse(diff * diff) {
+			walk(far)
+		}
+	}
+
+	walk(t.root)
+
+	return best
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"math/rand"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// the results of the tree must be the same as checking all the spots
+func TestKDTree(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	spots := make([]Spot, 300)
+
+	for i := range spots {
+		spots[i] = Spot{
+			ID:         strconv.Itoa(1000 + i),
+			Coordinate: &Coordinate{X: uint(r.Intn(60)), Y: uint(r.Intn(40))},
+		}
+	}
+
+	tree := newKDTree(append([]Spot(nil), spots...))
+
+	for i := 0; i < 50; i++ {
+		point := Coordinate{X: uint(r.Intn(70)), Y: uint(r.Intn(50))}
+
+		box := &Region{StartPoint: point, LimitPoint: Coordinate{X: point.X + uint(r.Intn(20)) + 1, Y: point.Y + uint(r.Intn(20)) + 1}}
+		radius := r.Float64() * 15
+		k := r.Intn(10) + 1
+
+		var wantBox, wantRadius []Spot
+
+		for _, s := range spots {
+			if inBox(*s.Coordinate, box) {
+				wantBox = append(wantBox, s)
+			}
+
+			if distance2(*s.Coordinate, point) <= radius*radius {
+				wantRadius = append(wantRadius, s)
+			}
+		}
+
+		sort.Slice(wantBox, func(i, j int) bool { return wantBox[i].ID < wantBox[j].ID })
+		sortByDistance(wantRadius, point)
+
+		wantNearest := append([]Spot(nil), spots...)
+		sortByDistance(wantNearest, point)
+
+		assert.Equal(t, len(wantBox), len(tree.inBox(box)))
+		assert.Equal(t, len(wantRadius), len(tree.inRadius(point, radius)))
+
+		if len(wantBox) > 0 {
+			assert.Equal(t, wantBox, tree.inBox(box))
+		}
+
+		if len(wantRadius) > 0 {
+			assert.Equal(t, wantRadius, tree.inRadius(point, radius))
+		}
+
+		assert.Equal(t, wantNearest[:k], tree.nearest(point, k))
+	}
+
+	assert.Empty(t, newKDTree(nil).nearest(Coordinate{}, 3))
+}
@@ -0,0 +1,541 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MaxBatchSize is the maximum number of spots written by a batch.
+const MaxBatchSize = 100
+
+// BatchResult represents the outcome of a spot of a batch, Err is set when the spot
+// couldn't be written and the rest of the batch is written anyway.
+type BatchResult struct {
+	ID   string
+	Spot *Spot
+	Err  error
+}
+
+// SpotError represents the error of the spot of CreateMany at Index, none of the spots
+// are created.
+type SpotError struct {
+	Index int
+	Err   error
+}
+
+// Error implements the error interface.
+func (se *SpotError) Error() string {
+	return fmt.Sprintf("creating the spot %d: %v", se.Index, se.Err)
+}
+
+// Unwrap returns the error of the spot.
+func (se *SpotError) Unwrap() error {
+	return se.Err
+}
+
+// batchWriteFunc writes the spots and the quadrants changed by a batch.
+type batchWriteFunc func(ctx context.Context, b *spotBatch) error
+
+// spotWrite represents a spot written by a batch, version is the stored one that the
+// write replaces.
+type spotWrite struct {
+	spot    *Spot
+	version uint64
+	insert  bool
+	remove  bool
+}
+
+// spotBatch places the spots of a batch one after another, it reads each quadrant once
+// and keeps the spots placed in it so the later spots of the batch are checked against
+// them. Once all the spots are placed the writes are done together, a single write by
+// quadrant whose spot_ids changed.
+type spotBatch struct {
+	repo *Repository
+
+	quadrants map[string]*Quadrant
+	// mazes contains the ids of the quadrants of the mazes read.
+	mazes map[string][]string
+	// spots contains the stored spots updated or deleted by the batch.
+	spots map[string]*Spot
+	// unpublished contains the ids of the mazes checked to be unpublished.
+	unpublished map[string]bool
+
+	results []BatchResult
+	writes  []spotWrite
+	// changed contains the ids of the quadrants to write in the order they changed.
+	changed []string
+}
+
+var _ quadrantReader = &spotBatch{}
+
+func newSpotBatch(repo *Repository, n int) *spotBatch {
+	return &spotBatch{
+		repo:        repo,
+		quadrants:   make(map[string]*Quadrant),
+		mazes:       make(map[string][]string),
+		spots:       make(map[string]*Spot, n),
+		unpublished: make(map[string]bool),
+		results:     make([]BatchResult, n),
+	}
+}
+
+// validateBatchSize checks the number of spots of a batch.
+func validateBatchSize(n int) error {
+	if n == 0 || n > MaxBatchSize {
+		ve := &ValidationError{Entity: "spot"}
+		ve.add("items", "must contain from 1 to %d spots", MaxBatchSize)
+
+		return ve
+	}
+
+	return nil
+}
+
+// runBatch places each spot with place and writes the batch in a transaction, the
+// results are in the order of the spots.
+func runBatch(ctx context.Context, repo *Repository, spots []Spot, write batchWriteFunc,
+	place func(ctx context.Context, b *spotBatch, i int, s *Spot) error) ([]BatchResult, error) {
+	if err := validateBatchSize(len(spots)); err != nil {
+		return nil, err
+	}
+
+	var results []BatchResult
+
+	err := repo.Transaction(ctx, func(ctx context.Context) error {
+		// the transaction can be retried, so the batch starts again from the spots given
+		b := newSpotBatch(repo, len(spots))
+
+		if err := b.load(ctx, spots); err != nil {
+			return err
+		}
+
+		for i := range spots {
+			s := spots[i]
+
+			if err := place(ctx, b, i, &s); err != nil {
+				b.results[i] = BatchResult{ID: s.ID, Err: err}
+			}
+		}
+
+		results = b.results
+
+		if len(b.writes) == 0 {
+			return nil
+		}
+
+		return write(ctx, b)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// batchCreate creates the spots like SpotStore.Create does.
+func batchCreate(ctx context.Context, repo *Repository, spots []Spot, write batchWriteFunc) ([]BatchResult, error) {
+	return runBatch(ctx, repo, spots, write, func(ctx context.Context, b *spotBatch, i int, s *Spot) error {
+		s.ID = ""
+
+		if s.Name == "" {
+			ve := &ValidationError{Entity: "spot"}
+			ve.add("name", "must be specified")
+
+			return ve
+		}
+
+		q, err := placeSpot(ctx, b, s)
+		if err != nil {
+			return err
+		}
+
+		s.ID = primitive.NewObjectID().Hex()
+		s.QuadrantID = q.ID
+		s.Version = 1
+
+		if err := b.place(ctx, s, ""); err != nil {
+			return err
+		}
+
+		b.writes = append(b.writes, spotWrite{spot: s, insert: true})
+		b.results[i] = BatchResult{ID: s.ID, Spot: s}
+
+		return nil
+	})
+}
+
+// batchUpdate updates the spots like SpotStore.Update does, the zero values are left
+// unchanged.
+func batchUpdate(ctx context.Context, repo *Repository, spots []Spot, write batchWriteFunc) ([]BatchResult, error) {
+	return runBatch(ctx, repo, spots, write, func(ctx context.Context, b *spotBatch, i int, su *Spot) error {
+		stored, err := b.stored(su)
+		if err != nil {
+			return err
+		}
+
+		spot := *stored
+
+		q, err := placeUpdate(ctx, b, &spot, su)
+		if err != nil {
+			return err
+		}
+
+		from := spot.QuadrantID
+		spot.QuadrantID = q.ID
+		spot.Version++
+
+		if err := b.place(ctx, &spot, from); err != nil {
+			return err
+		}
+
+		b.writes = append(b.writes, spotWrite{spot: &spot, version: stored.Version})
+		b.results[i] = BatchResult{ID: spot.ID, Spot: &spot}
+
+		// the later updates of the same spot start from this one
+		*stored = spot
+
+		return nil
+	})
+}
+
+// batchDelete deletes the spots by id, the ones with a version other than zero are only
+// deleted at that version.
+func batchDelete(ctx context.Context, repo *Repository, spots []Spot, write batchWriteFunc) ([]BatchResult, error) {
+	return runBatch(ctx, repo, spots, write, func(ctx context.Context, b *spotBatch, i int, sd *Spot) error {
+		stored, err := b.stored(sd)
+		if err != nil {
+			return err
+		}
+
+		if err := b.checkUnpublished(ctx, stored.MazeID); err != nil {
+			return err
+		}
+
+		if err := validateKept(ctx, b, stored, nil); err != nil {
+			return err
+		}
+
+		if err := b.removeFrom(ctx, stored.QuadrantID, stored.ID); err != nil {
+			return err
+		}
+
+		b.writes = append(b.writes, spotWrite{spot: stored, version: stored.Version, remove: true})
+		b.results[i] = BatchResult{ID: stored.ID}
+
+		delete(b.spots, stored.ID)
+
+		return nil
+	})
+}
+
+// createMany creates the spots like a batch but all of them or none, it is meant for the
+// mazes created in one go, e.g. generated or imported. The coordinates and the entrances
+// of the spots placed are kept in maps instead of in the spots of each quadrant, so each
+// spot is checked in constant time however many spots the maze gets.
+func createMany(ctx context.Context, repo *Repository, spots []Spot, write batchWriteFunc) error {
+	if len(spots) == 0 {
+		return nil
+	}
+
+	return repo.Transaction(ctx, func(ctx context.Context) error {
+		// the transaction can be retried, so the batch starts again from the spots given
+		b := newSpotBatch(repo, 0)
+		idx := &spotIndex{
+			coordinates: make(map[string]map[Coordinate]string),
+			entrances:   make(map[string]string),
+		}
+
+		for i := range spots {
+			if err := b.create(ctx, &spots[i], idx); err != nil {
+				return &SpotError{Index: i, Err: err}
+			}
+		}
+
+		return write(ctx, b)
+	})
+}
+
+// spotIndex contains the spots placed by createMany.
+type spotIndex struct {
+	// coordinates contains the ids of the spots of each quadrant by coordinate.
+	coordinates map[string]map[Coordinate]string
+	// entrances contains the entrance of each maze, or of each quadrant without maze.
+	entrances map[string]string
+}
+
+// create places a new spot of createMany, it is kept in the spot_ids of its quadrant but
+// not in its spots, the index is used instead.
+func (b *spotBatch) create(ctx context.Context, s *Spot, idx *spotIndex) error {
+	ve := &ValidationError{Entity: "spot"}
+
+	if s.Name == "" {
+		ve.add("name", "must be specified")
+	}
+
+	located, err := locateSpot(ctx, b, s, ve)
+	if err != nil {
+		return err
+	}
+
+	// the quadrants of a maze are returned as copies, the batch writes its own ones
+	q := b.quadrants[located.ID]
+
+	scope := s.MazeID
+	if scope == "" {
+		scope = q.ID
+	}
+
+	if s.Kind == Entrance {
+		if other, ok := idx.entrances[scope]; ok {
+			ve.add("kind", "the maze already has the %s spot %s", Entrance, other)
+		} else if err := validateEntrance(ctx, b, s, q, ve); err != nil {
+			return err
+		}
+	}
+
+	if err := ve.err(); err != nil {
+		return err
+	}
+
+	used, ok := idx.coordinates[q.ID]
+	if !ok {
+		used = make(map[Coordinate]string, len(q.Spots))
+
+		for i := range q.Spots {
+			if q.Spots[i].Coordinate != nil {
+				used[*q.Spots[i].Coordinate] = q.Spots[i].ID
+			}
+		}
+
+		idx.coordinates[q.ID] = used
+	}
+
+	if other, ok := used[*s.Coordinate]; ok {
+		ce := &ConflictError{Entity: "spot"}
+		ce.add("Coordinate", "is already used by the spot %s", other)
+
+		return ce
+	}
+
+	s.ID = primitive.NewObjectID().Hex()
+	s.QuadrantID = q.ID
+	s.Version = 1
+
+	used[*s.Coordinate] = s.ID
+
+	if s.Kind == Entrance {
+		idx.entrances[scope] = s.ID
+	}
+
+	q.SpotIDs = append(q.SpotIDs, s.ID)
+	b.change(q.ID)
+	b.writes = append(b.writes, spotWrite{spot: s, insert: true})
+
+	return nil
+}
+
+// load reads the stored spots of the batch with a single query.
+func (b *spotBatch) load(ctx context.Context, spots []Spot) error {
+	ids := make([]string, 0, len(spots))
+
+	for i := range spots {
+		if _, err := primitive.ObjectIDFromHex(spots[i].ID); err == nil {
+			ids = append(ids, spots[i].ID)
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	stored, err := b.repo.Spot.List(ctx, &SpotFilter{SpotsIDs: ids})
+	if err != nil {
+		return err
+	}
+
+	for i := range stored {
+		b.spots[stored[i].ID] = &stored[i]
+	}
+
+	return nil
+}
+
+// stored returns the stored spot with the id of s, it must be at the version of s unless
+// it is zero.
+func (b *spotBatch) stored(s *Spot) (*Spot, error) {
+	if s.ID == "" {
+		ve := &ValidationError{Entity: "spot"}
+		ve.add("id", "must be specified")
+
+		return nil, ve
+	}
+
+	stored, ok := b.spots[s.ID]
+	if !ok {
+		return nil, &NotFoundError{Entity: "spot", ID: s.ID}
+	}
+
+	if err := checkVersion("spot", s.ID, stored.Version, s.Version); err != nil {
+		return nil, err
+	}
+
+	return stored, nil
+}
+
+// quadrant returns the quadrant of the batch, it is read the first time.
+func (b *spotBatch) quadrant(ctx context.Context, id string) (*Quadrant, error) {
+	if q, ok := b.quadrants[id]; ok {
+		return q, nil
+	}
+
+	q, err := b.repo.Quadrant.Get(ctx, &QuadrantFilter{ID: id})
+	if err != nil {
+		return nil, err
+	}
+
+	b.quadrants[id] = q
+
+	return q, nil
+}
+
+// mazeQuadrants returns the quadrants of the maze, the ones already read by the batch
+// are kept since they contain its spots.
+func (b *spotBatch) mazeQuadrants(ctx context.Context, mazeID string) ([]Quadrant, error) {
+	ids, ok := b.mazes[mazeID]
+
+	if !ok {
+		quadrants, err := b.repo.Quadrant.List(ctx, &QuadrantFilter{MazeID: mazeID})
+		if err != nil {
+			return nil, err
+		}
+
+		ids = make([]string, 0, len(quadrants))
+
+		for i := range quadrants {
+			if _, ok := b.quadrants[quadrants[i].ID]; !ok {
+				b.quadrants[quadrants[i].ID] = &quadrants[i]
+			}
+
+			ids = append(ids, quadrants[i].ID)
+		}
+
+		b.mazes[mazeID] = ids
+	}
+
+	quadrants := make([]Quadrant, 0, len(ids))
+
+	for _, id := range ids {
+		quadrants = append(quadrants, *b.quadrants[id])
+	}
+
+	return quadrants, nil
+}
+
+// kindSpots returns the spots of the kind in the maze including the ones placed by the
+// batch.
+func (b *spotBatch) kindSpots(ctx context.Context, mazeID string, kind SpotKind) ([]Spot, error) {
+	quadrants, err := b.mazeQuadrants(ctx, mazeID)
+	if err != nil {
+		return nil, err
+	}
+
+	spots := make([]Spot, 0)
+
+	for i := range quadrants {
+		for j := range quadrants[i].Spots {
+			if quadrants[i].Spots[j].Kind == kind {
+				spots = append(spots, quadrants[i].Spots[j])
+			}
+		}
+	}
+
+	return spots, nil
+}
+
+// checkUnpublished fails when the maze is published, each maze is read once.
+func (b *spotBatch) checkUnpublished(ctx context.Context, mazeID string) error {
+	if b.unpublished[mazeID] {
+		return nil
+	}
+
+	if err := b.repo.checkUnpublished(ctx, mazeID); err != nil {
+		return err
+	}
+
+	b.unpublished[mazeID] = true
+
+	return nil
+}
+
+// place keeps the spot in its quadrant and removes it from the quadrant it comes from,
+// the quadrant is written with the batch even when the spot doesn't move.
+func (b *spotBatch) place(ctx context.Context, s *Spot, from string) error {
+	if from != "" && from != s.QuadrantID {
+		if err := b.removeFrom(ctx, from, s.ID); err != nil {
+			return err
+		}
+	}
+
+	q := b.quadrants[s.QuadrantID]
+	b.change(q.ID)
+
+	if !containsString(q.SpotIDs, s.ID) {
+		q.SpotIDs = append(q.SpotIDs, s.ID)
+	}
+
+	for i := range q.Spots {
+		if q.Spots[i].ID == s.ID {
+			q.Spots[i] = *s
+
+			return nil
+		}
+	}
+
+	q.Spots = append(q.Spots, *s)
+
+	return nil
+}
+
+// removeFrom removes the spot from the quadrant, the quadrants that don't exist are
+// skipped since the spot can be removed anyway.
+func (b *spotBatch) removeFrom(ctx context.Context, quadrantID, id string) error {
+	if quadrantID == "" {
+		return nil
+	}
+
+	var nf *NotFoundError
+
+	q, err := b.quadrant(ctx, quadrantID)
+	if errors.As(err, &nf) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	b.change(q.ID)
+
+	q.SpotIDs = removeString(q.SpotIDs, id)
+
+	for i := range q.Spots {
+		if q.Spots[i].ID == id {
+			q.Spots = append(q.Spots[:i], q.Spots[i+1:]...)
+
+			break
+		}
+	}
+
+	return nil
+}
+
+// change marks the quadrant to be written with the batch.
+func (b *spotBatch) change(quadrantID string) {
+	if !containsString(b.changed, quadrantID) {
+		b.changed = append(b.changed, quadrantID)
+	}
+}
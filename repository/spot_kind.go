@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+)
+
+// SpotKind defines what a spot represents in the maze.
+type SpotKind string
+
+const (
+	// Entrance represents the spot where the maze starts, a maze has exactly one.
+	Entrance = SpotKind("ENTRANCE")
+
+	// Exit represents a spot where the maze ends, a maze has at least one.
+	Exit = SpotKind("EXIT")
+
+	// Treasure represents a spot that contains gold, it is the kind by default.
+	Treasure = SpotKind("TREASURE")
+
+	// Trap represents a spot that takes the penalty from who steps on it.
+	Trap = SpotKind("TRAP")
+
+	// Wall represents a spot that blocks the movement.
+	Wall = SpotKind("WALL")
+)
+
+// isValidSpotKind reports if the kind is one of the existing ones.
+func isValidSpotKind(sk SpotKind) bool {
+	switch sk {
+	case Entrance, Exit, Treasure, Trap, Wall:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsBlocking reports if the spot blocks the movement.
+func (s *Spot) IsBlocking() bool {
+	return s.Kind == Wall
+}
+
+// validateKind checks the rules of the kind of the spot:
+//   - only the traps carry a penalty and it must be greater than zero.
+//   - the walls don't carry gold.
+func (s *Spot) validateKind(ve *ValidationError) {
+	if !isValidSpotKind(s.Kind) {
+		ve.add("kind", "must be one of %s, %s, %s, %s and %s", Entrance, Exit, Treasure, Trap, Wall)
+
+		return
+	}
+
+	if s.Kind == Trap && s.Penalty == 0 {
+		ve.add("penalty", "must be greater than zero in a %s spot", Trap)
+	}
+
+	if s.Kind != Trap && s.Penalty != 0 {
+		ve.add("penalty", "can only be set in a %s spot", Trap)
+	}
+
+	if s.Kind == Wall && s.GoldAmount != 0 {
+		ve.add("gold_amount", "can't be set in a %s spot", Wall)
+	}
+}
+
+// validateEntrance checks that the spot isn't a second entrance, the spots of a maze are
+// checked in the whole maze while the rest are only checked in their quadrant.
+func validateEntrance(ctx context.Context, qr quadrantReader, s *Spot, q *Quadrant, ve *ValidationError) error {
+	if s.Kind != Entrance {
+		return nil
+	}
+
+	spots := q.Spots
+
+	if s.MazeID != "" {
+		var err error
+
+		spots, err = qr.kindSpots(ctx, s.MazeID, Entrance)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i := range spots {
+		if spots[i].ID != s.ID && spots[i].Kind == Entrance {
+			ve.add("kind", "the maze already has the %s spot %s", Entrance, spots[i].ID)
+		}
+	}
+
+	return nil
+}
+
+// validateKept checks that the write doesn't take the only entrance or the only exit away
+// from the maze of the stored spot, s is the spot written or nil when it is deleted. The
+// maze and quadrant deletes remove their spots anyway.
+func validateKept(ctx context.Context, qr quadrantReader, stored, s *Spot) error {
+	if stored.MazeID == "" || (stored.Kind != Entrance && stored.Kind != Exit) {
+		return nil
+	}
+
+	if s != nil && s.Kind == stored.Kind {
+		return nil
+	}
+
+	spots, err := qr.kindSpots(ctx, stored.MazeID, stored.Kind)
+	if err != nil {
+		return err
+	}
+
+	for i := range spots {
+		if spots[i].ID != stored.ID {
+			return nil
+		}
+	}
+
+	ce := &ConflictError{Entity: "spot"}
+	ce.add("kind", "the spot %s is the only %s of the maze %s", stored.ID, stored.Kind, stored.MazeID)
+
+	return ce
+}
+
+// ValidateMazeSpots checks that the spots of a maze contain exactly one entrance and at
+// least one exit. Those rules can't be checked on each write since the spots of a maze
+// are created one by one, so it must be checked once the maze is complete, the writes
+// only keep the maze from losing its only entrance or exit.
+func ValidateMazeSpots(spots []Spot) error {
+	var entrances, exits int
+
+	for i := range spots {
+		switch spots[i].Kind {
+		case Entrance:
+			entrances++
+		case Exit:
+			exits++
+		}
+	}
+
+	ve := &ValidationError{Entity: "maze"}
+
+	if entrances != 1 {
+		ve.add("spots", "must contain exactly one %s spot, found %d", Entrance, entrances)
+	}
+
+	if exits == 0 {
+		ve.add("spots", "must contain at least one %s spot", Exit)
+	}
+
+	return ve.err()
+}
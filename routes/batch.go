@@ -0,0 +1,109 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/PacoDw/maze_challenge/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// SpotBatchRequest represents the body of the batch writes of spots.
+type SpotBatchRequest struct {
+	Items []repository.Spot `json:"items"`
+}
+
+// SpotBatchResult represents the result of an item of a batch, either the spot written
+// or the error that made the item fail with its HTTP status.
+type SpotBatchResult struct {
+	Index  int              `json:"index"`
+	Status int              `json:"status"`
+	ID     string           `json:"id,omitempty"`
+	Spot   *repository.Spot `json:"spot,omitempty"`
+	Error  *ErrorBody       `json:"error,omitempty"`
+}
+
+// SpotBatchResponse represents the results of a batch in the order of its items.
+type SpotBatchResponse struct {
+	Items  []SpotBatchResult `json:"items"`
+	Failed int               `json:"failed"`
+}
+
+// spotBatchActions contains the batch handlers by the action of their path, the param
+// includes the colon that follows /v1/spots.
+var spotBatchActions = map[string]gin.HandlerFunc{
+	":batchCreate": BatchCreateSpots,
+	":batchUpdate": BatchUpdateSpots,
+	":batchDelete": BatchDeleteSpots,
+}
+
+// SpotBatchAction runs the batch of the path, e.g. /v1/spots:batchCreate. The router reads
+// what follows /v1/spots as the :action param, so the batches are dispatched by its value.
+var SpotBatchAction = func(c *gin.Context) {
+	handler, ok := spotBatchActions[c.Param("action")]
+	if !ok {
+		respondError(c, &repository.NotFoundError{Entity: "spot action", ID: c.Param("action")})
+
+		return
+	}
+
+	handler(c)
+}
+
+// BatchCreateSpots creates up to repository.MaxBatchSize spots in a single request.
+var BatchCreateSpots = spotBatch(repository.SpotStore.BatchCreate)
+
+// BatchUpdateSpots updates up to repository.MaxBatchSize spots in a single request, only
+// the attributes sent are changed.
+var BatchUpdateSpots = spotBatch(repository.SpotStore.BatchUpdate)
+
+// BatchDeleteSpots deletes up to repository.MaxBatchSize spots in a single request, only
+// the id and the version of the items are used.
+var BatchDeleteSpots = spotBatch(repository.SpotStore.BatchDelete)
+
+// spotBatch returns the handler of a batch route. Each item succeeds or fails on its own,
+// so the response is 200 with the result of every item unless the whole batch fails.
+func spotBatch(batch func(ss repository.SpotStore, ctx context.Context, spots []repository.Spot) ([]repository.BatchResult, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
+		if !ok {
+			respondError(c, repository.ErrNoConnection)
+
+			return
+		}
+
+		req := &SpotBatchRequest{}
+
+		if err := c.ShouldBindJSON(req); err != nil {
+			respondError(c, badRequest(err))
+
+			return
+		}
+
+		results, err := batch(repo.Spot, c, req.Items)
+		if err != nil {
+			respondError(c, err)
+
+			return
+		}
+
+		res := SpotBatchResponse{Items: make([]SpotBatchResult, 0, len(results))}
+
+		for i, r := range results {
+			item := SpotBatchResult{Index: i, Status: http.StatusOK, ID: r.ID, Spot: r.Spot}
+
+			if r.Err != nil {
+				body := errorResponse(c, r.Err).Error
+				body.RequestID = ""
+
+				item.Status = errorStatus[body.Code]
+				item.Error = &body
+				res.Failed++
+			}
+
+			res.Items = append(res.Items, item)
+		}
+
+		c.JSON(http.StatusOK, res)
+	}
+}
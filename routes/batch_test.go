@@ -0,0 +1,72 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/PacoDw/maze_challenge/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SpotBatch(t *testing.T) {
+	router := newTestRouter()
+	maze, spot := newPatchMaze(t, router)
+
+	batch := func(action string, items []repository.Spot) SpotBatchResponse {
+		t.Helper()
+
+		res := serve(t, router, http.MethodPost, "/v1/spots:"+action, SpotBatchRequest{Items: items})
+		assert.Equal(t, 200, res.Code, res.Body.String())
+
+		var got SpotBatchResponse
+
+		if err := json.Unmarshal(res.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+
+		return got
+	}
+
+	got := batch("batchCreate", []repository.Spot{
+		{MazeID: maze.ID, Name: "silver", GoldAmount: 3, Coordinate: &repository.Coordinate{X: 12, Y: 4}},
+		{MazeID: maze.ID, Name: "copper", Coordinate: &repository.Coordinate{X: 2, Y: 3}},
+		{MazeID: maze.ID, Name: "spike", Kind: repository.Trap, Coordinate: &repository.Coordinate{X: 5, Y: 15}},
+	})
+
+	assert.Equal(t, 2, got.Failed)
+	assert.Equal(t, 200, got.Items[0].Status)
+	assert.Equal(t, "silver", got.Items[0].Spot.Name)
+	assert.Equal(t, 409, got.Items[1].Status)
+	assert.Equal(t, repository.CodeConflict, got.Items[1].Error.Code)
+	assert.Equal(t, 400, got.Items[2].Status)
+	assert.Equal(t, "penalty", got.Items[2].Error.Fields[0].Field)
+
+	silver := got.Items[0].Spot
+
+	got = batch("batchUpdate", []repository.Spot{
+		{ID: spot.ID, GoldAmount: 20},
+		{ID: silver.ID, Version: 7, GoldAmount: 4},
+	})
+
+	assert.Equal(t, 1, got.Failed)
+	assert.EqualValues(t, 20, got.Items[0].Spot.GoldAmount)
+	assert.Equal(t, 409, got.Items[1].Status)
+
+	got = batch("batchDelete", []repository.Spot{{ID: spot.ID}, {ID: silver.ID, Version: silver.Version}})
+	assert.Equal(t, 0, got.Failed)
+	assert.Equal(t, silver.ID, got.Items[1].ID)
+
+	res := serve(t, router, http.MethodGet, "/maze/"+maze.ID+"/spot", nil)
+	assert.Equal(t, "[]", res.Body.String())
+
+	res = serve(t, router, http.MethodPost, "/v1/spots:batchDelete", SpotBatchRequest{})
+	assert.Equal(t, 400, res.Code)
+
+	res = serve(t, router, http.MethodPost, "/v1/spots:batchMerge", SpotBatchRequest{Items: []repository.Spot{{ID: spot.ID}}})
+	assert.Equal(t, 404, res.Code)
+
+	// the paths that only start like the collection aren't batches
+	res = serve(t, router, http.MethodPost, "/v1/spotsbatchCreate", SpotBatchRequest{Items: []repository.Spot{{ID: spot.ID}}})
+	assert.Equal(t, 404, res.Code)
+}
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deprecated is a middleware for the routes kept for the clients of the API without
+// version, each call is logged so the remaining clients can be found, and the response
+// points to the route that replaces it with the Deprecation and Link headers.
+func Deprecated(successor string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log.Printf("deprecated route %s %s called by %s (request %s), use %s instead",
+			c.Request.Method, c.FullPath(), c.ClientIP(), requestID(c), successor)
+
+		c.Header("Deprecation", "true")
+		c.Header("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+
+		c.Next()
+	}
+}
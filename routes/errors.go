@@ -0,0 +1,126 @@
+package routes
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+
+	"github.com/PacoDw/maze_challenge/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader is the header that carries the id of a request, it is sent back in the
+// response and in the body of the errors so they can be found in the logs.
+const RequestIDHeader = "X-Request-ID"
+
+// errorStatus contains the HTTP status of each error code.
+var errorStatus = map[repository.ErrorCode]int{
+	repository.CodeBadRequest:           http.StatusBadRequest,
+	repository.CodeValidation:           http.StatusBadRequest,
+	repository.CodeNotFound:             http.StatusNotFound,
+	repository.CodeConflict:             http.StatusConflict,
+	repository.CodePreconditionFailed:   http.StatusPreconditionFailed,
+	repository.CodeIdempotencyKeyReused: http.StatusUnprocessableEntity,
+	repository.CodeUnavailable:          http.StatusServiceUnavailable,
+	repository.CodeInternal:             http.StatusInternalServerError,
+}
+
+// ErrorBody represents an error sent to the clients, the validation and conflict errors
+// include the attributes that must be fixed.
+type ErrorBody struct {
+	Code      repository.ErrorCode    `json:"code"`
+	Message   string                  `json:"message"`
+	Fields    []repository.FieldError `json:"fields,omitempty"`
+	Details   interface{}             `json:"details,omitempty"`
+	RequestID string                  `json:"request_id,omitempty"`
+}
+
+// ErrorResponse represents the body of all the error responses.
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+// RequestID is a middleware that keeps the X-Request-ID sent by the client, or a new one
+// when it isn't sent, in the context and in the response.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if id == "" {
+			id = newRequestID()
+		}
+
+		c.Set(RequestIDHeader, id)
+		c.Header(RequestIDHeader, id)
+
+		c.Next()
+	}
+}
+
+// newRequestID returns 16 random bytes encoded as hex.
+func newRequestID() string {
+	b := make([]byte, 16)
+
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+// requestID returns the id of the request set by the RequestID middleware.
+func requestID(c *gin.Context) string {
+	if id := c.GetString(RequestIDHeader); id != "" {
+		return id
+	}
+
+	return c.GetHeader(RequestIDHeader)
+}
+
+// errorResponse returns the body used to respond an error.
+func errorResponse(c *gin.Context, err error) ErrorResponse {
+	body := ErrorBody{
+		Code:      repository.Code(err),
+		Message:   err.Error(),
+		RequestID: requestID(c),
+	}
+
+	var (
+		ve *repository.ValidationError
+		ce *repository.ConflictError
+		pe *preconditionError
+	)
+
+	switch {
+	case errors.As(err, &ve):
+		body.Fields = ve.Fields
+	case errors.As(err, &ce):
+		body.Fields = ce.Fields
+	}
+
+	if errors.As(err, &pe) {
+		body.Code = repository.CodePreconditionFailed
+	}
+
+	return ErrorResponse{Error: body}
+}
+
+// badRequest marks an error as caused by the request, e.g. a body that can't be decoded,
+// so it is responded with the BAD_REQUEST code instead of INTERNAL.
+func badRequest(err error) error {
+	return &repository.RequestError{Err: err}
+}
+
+// respondError responds the error with the HTTP status of its code.
+func respondError(c *gin.Context, err error) {
+	res := errorResponse(c, err)
+
+	c.JSON(errorStatus[res.Error.Code], res)
+}
+
+// respondErrorDetails responds the error like respondError with the details that explain
+// it, e.g. the validation report of a maze.
+func respondErrorDetails(c *gin.Context, err error, details interface{}) {
+	res := errorResponse(c, err)
+	res.Error.Details = details
+
+	c.JSON(errorStatus[res.Error.Code], res)
+}
@@ -0,0 +1,118 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/PacoDw/maze_challenge/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ErrorResponses(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code int
+	}{
+		{name: "bad request", err: repository.BadRequest("query param limit must be a number"), code: 400},
+		{name: "bad body", err: badRequest(errors.New("invalid character 'x' looking for beginning of value")), code: 400},
+		{name: "validation", err: &repository.ValidationError{Entity: "spot", Fields: []repository.FieldError{{Field: "name", Message: "must be specified"}}}, code: 400},
+		{name: "not found", err: &repository.NotFoundError{Entity: "spot", ID: "1"}, code: 404},
+		{name: "conflict", err: &repository.ConflictError{Entity: "spot"}, code: 409},
+		{name: "unavailable", err: &repository.UnavailableError{Err: errors.New("timeout")}, code: 503},
+		{name: "no connection", err: repository.ErrNoConnection, code: 500},
+		{name: "internal", err: errors.New("database not open"), code: 500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(RequestID())
+			router.GET("/", func(c *gin.Context) { respondError(c, tt.err) })
+
+			req := httptest.NewRequest(http.MethodGet, "/", bytes.NewBuffer([]byte{}))
+			req.Header.Set(RequestIDHeader, "request-1")
+
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+
+			assert.Equal(t, tt.code, res.Code)
+			assert.Equal(t, "request-1", res.Header().Get(RequestIDHeader))
+
+			var got ErrorResponse
+			if err := json.Unmarshal(res.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, repository.Code(tt.err), got.Error.Code)
+			assert.Equal(t, tt.err.Error(), got.Error.Message)
+			assert.Equal(t, "request-1", got.Error.RequestID)
+
+			var ve *repository.ValidationError
+			if errors.As(tt.err, &ve) {
+				assert.Equal(t, ve.Fields, got.Error.Fields)
+			}
+		})
+	}
+
+	// a new id is created when the client doesn't send it
+	router := gin.New()
+	router.Use(RequestID())
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", bytes.NewBuffer([]byte{})))
+
+	assert.Len(t, res.Header().Get(RequestIDHeader), 32)
+}
+
+func Test_SpotNotFound(t *testing.T) {
+	param := gin.Param{Key: "id", Value: "000000000000000000000000"}
+
+	req := httptest.NewRequest(http.MethodGet, "/", bytes.NewBuffer([]byte{}))
+	res := makeScopedRequest(req, GetSpot, param)
+
+	assert.Equal(t, 404, res.Code)
+	assert.Contains(t, res.Body.String(), `"code":"NOT_FOUND"`)
+
+	req = httptest.NewRequest(http.MethodDelete, "/", bytes.NewBuffer([]byte{}))
+	res = makeScopedRequest(req, DeleteSpot, param)
+
+	assert.Equal(t, 404, res.Code)
+}
+
+func Test_UnknownMazeAndQuadrant(t *testing.T) {
+	router := newTestRouter()
+	unknown := "000000000000000000000000"
+
+	for _, path := range []string{
+		"/maze/" + unknown + "/path?from=" + unknown + "&to=" + unknown,
+		"/maze/" + unknown + "/route?steps=10",
+		"/maze/" + unknown + "/validate",
+		"/maze/" + unknown + "/render",
+	} {
+		res := serve(t, router, http.MethodGet, path, nil)
+		assert.Equal(t, 404, res.Code, path)
+		assert.Contains(t, res.Body.String(), `"code":"NOT_FOUND"`, path)
+	}
+
+	res := serve(t, router, http.MethodPost, "/v1/quadrants", repository.Quadrant{
+		MazeID:     unknown,
+		Type:       repository.TopLeft,
+		StartPoint: &repository.Coordinate{},
+		LimitPoint: &repository.Coordinate{X: 5, Y: 5},
+	})
+	assert.Equal(t, 404, res.Code, res.Body.String())
+
+	res = serve(t, router, http.MethodPost, "/v1/spots", repository.Spot{
+		QuadrantID: unknown,
+		Name:       "gold",
+		Coordinate: &repository.Coordinate{X: 1, Y: 1},
+	})
+	assert.Equal(t, 404, res.Code, res.Body.String())
+}
@@ -0,0 +1,104 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/PacoDw/maze_challenge/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// preconditionError is a version error of a write conditioned with the If-Match header, it
+// is responded with 412 Precondition Failed instead of 409 Conflict.
+type preconditionError struct {
+	err error
+}
+
+// Error implements the error interface.
+func (pe *preconditionError) Error() string {
+	return pe.err.Error()
+}
+
+// Unwrap returns the version error.
+func (pe *preconditionError) Unwrap() error {
+	return pe.err
+}
+
+// etag returns the ETag of a version of a resource.
+func etag(version uint64) string {
+	return strconv.Quote(strconv.FormatUint(version, 10))
+}
+
+// setETag sets the ETag of the version in the response.
+func setETag(c *gin.Context, version uint64) {
+	c.Header("ETag", etag(version))
+}
+
+// notModified reports if the If-None-Match header matches the version, the reads respond
+// 304 Not Modified without body then. The ETags are compared with the weak comparison.
+func notModified(c *gin.Context, version uint64) bool {
+	header := strings.TrimSpace(c.GetHeader("If-None-Match"))
+	if header == "" {
+		return false
+	}
+
+	if header == "*" {
+		return true
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == etag(version) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ifMatch returns the version required by the If-Match header, it is zero when the header
+// isn't sent or it is * since any version matches. The header must contain one strong
+// ETag given by this API.
+func ifMatch(c *gin.Context) (uint64, error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+
+	version, err := strconv.Unquote(header)
+	if err != nil || strings.HasPrefix(header, "W/") {
+		return 0, &preconditionError{err: fmt.Errorf("the If-Match header must contain one strong ETag, e.g. %s", etag(1))}
+	}
+
+	v, err := strconv.ParseUint(version, 10, 64)
+	if err != nil || v == 0 {
+		return 0, &preconditionError{err: fmt.Errorf("the ETag %s doesn't match any version", header)}
+	}
+
+	return v, nil
+}
+
+// conditional returns the error of a write, the version errors of the writes conditioned
+// with the If-Match header are failed preconditions.
+func conditional(c *gin.Context, err error) error {
+	var ve *repository.VersionError
+
+	if c.GetHeader("If-Match") != "" && errors.As(err, &ve) {
+		return &preconditionError{err: err}
+	}
+
+	return err
+}
+
+// checkIfMatch returns a failed precondition when the version required by the If-Match
+// header isn't the stored one, a zero expected version matches any version.
+func checkIfMatch(entity, id string, version, expected uint64) error {
+	if expected == 0 || expected == version {
+		return nil
+	}
+
+	return &preconditionError{
+		err: &repository.VersionError{Entity: entity, ID: id, Version: version, Expected: expected},
+	}
+}
@@ -0,0 +1,165 @@
+package routes
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// serveHeaders sends a request with the raw JSON body and the headers to the router.
+func serveHeaders(router *gin.Engine, method, path string, headers map[string]string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	return res
+}
+
+func Test_SpotETags(t *testing.T) {
+	router := newTestRouter()
+	_, spot := newPatchMaze(t, router)
+	path := "/v1/spots/" + spot.ID
+
+	res := serveHeaders(router, http.MethodGet, path, nil, "")
+	assert.Equal(t, 200, res.Code)
+	assert.Equal(t, `"1"`, res.Header().Get("ETag"))
+	assert.Contains(t, res.Body.String(), `"version":1`)
+
+	// the client already has the current version
+	res = serveHeaders(router, http.MethodGet, path, map[string]string{"If-None-Match": `"0", W/"1"`}, "")
+	assert.Equal(t, 304, res.Code)
+	assert.Empty(t, res.Body.String())
+
+	res = serveHeaders(router, http.MethodPatch, path, map[string]string{"If-Match": `"1"`}, `{"gold_amount":0}`)
+	assert.Equal(t, 200, res.Code)
+	assert.Equal(t, `"2"`, res.Header().Get("ETag"))
+
+	res = serveHeaders(router, http.MethodGet, path, map[string]string{"If-None-Match": `"1"`}, "")
+	assert.Equal(t, 200, res.Code)
+
+	// the writes based on the first version are stale
+	res = serveHeaders(router, http.MethodPatch, path, map[string]string{"If-Match": `"1"`}, `{"gold_amount":5}`)
+	assert.Equal(t, 412, res.Code)
+	assert.Contains(t, res.Body.String(), `"code":"PRECONDITION_FAILED"`)
+
+	body := `{"name":"gold","gold_amount":5,"Coordinate":{"x":2,"y":3}}`
+
+	res = serveHeaders(router, http.MethodPut, path, map[string]string{"If-Match": `"1"`}, body)
+	assert.Equal(t, 412, res.Code)
+
+	res = serveHeaders(router, http.MethodPut, path, map[string]string{"If-Match": `W/"2"`}, body)
+	assert.Equal(t, 412, res.Code)
+
+	// the version can be sent in the body as well, then a stale one is a conflict
+	res = serveHeaders(router, http.MethodPut, path, nil, `{"name":"gold","Coordinate":{"x":2,"y":3},"version":1}`)
+	assert.Equal(t, 409, res.Code)
+	assert.Contains(t, res.Body.String(), `"code":"CONFLICT"`)
+
+	res = serveHeaders(router, http.MethodPut, path, map[string]string{"If-Match": `"2"`}, body)
+	assert.Equal(t, 200, res.Code)
+	assert.Equal(t, `"3"`, res.Header().Get("ETag"))
+
+	res = serveHeaders(router, http.MethodDelete, path, map[string]string{"If-Match": `"2"`}, "")
+	assert.Equal(t, 412, res.Code)
+
+	res = serveHeaders(router, http.MethodDelete, path, map[string]string{"If-Match": `"3"`}, "")
+	assert.Equal(t, 200, res.Code)
+}
+
+func Test_QuadrantETags(t *testing.T) {
+	router := newTestRouter()
+	_, spot := newPatchMaze(t, router)
+	path := "/v1/quadrants/" + spot.QuadrantID
+
+	res := serveHeaders(router, http.MethodGet, path, nil, "")
+	assert.Equal(t, 200, res.Code)
+
+	tag := res.Header().Get("ETag")
+	assert.NotEmpty(t, tag)
+
+	res = serveHeaders(router, http.MethodGet, path, map[string]string{"If-None-Match": tag}, "")
+	assert.Equal(t, 304, res.Code)
+
+	// the quadrant contains its spots so their writes change its version
+	res = serveHeaders(router, http.MethodPatch, "/v1/spots/"+spot.ID, nil, `{"gold_amount":1}`)
+	assert.Equal(t, 200, res.Code)
+
+	res = serveHeaders(router, http.MethodGet, path, map[string]string{"If-None-Match": tag}, "")
+	assert.Equal(t, 200, res.Code)
+	assert.NotEqual(t, tag, res.Header().Get("ETag"))
+
+	res = serveHeaders(router, http.MethodPatch, path, map[string]string{"If-Match": tag}, `{}`)
+	assert.Equal(t, 412, res.Code)
+
+	res = serveHeaders(router, http.MethodPatch, path, map[string]string{"If-Match": "1"}, `{}`)
+	assert.Equal(t, 412, res.Code)
+
+	res = serveHeaders(router, http.MethodDelete, path, map[string]string{"If-Match": tag}, "")
+	assert.Equal(t, 412, res.Code)
+
+	// the quadrants of a maze are only deleted with it
+	res = serveHeaders(router, http.MethodDelete, path, nil, "")
+	assert.Equal(t, 409, res.Code)
+	assert.Contains(t, res.Body.String(), `"field":"maze_id"`)
+
+	res = serveHeaders(router, http.MethodGet, "/v1/spots/"+spot.ID, nil, "")
+	assert.Equal(t, 200, res.Code)
+}
+
+func Test_MazeRoutesETags(t *testing.T) {
+	router := newTestRouter()
+	maze, spot := newPatchMaze(t, router)
+	spotPath := "/maze/" + maze.ID + "/spot/" + spot.ID
+	quadrantPath := "/maze/" + maze.ID + "/quadrant/" + spot.QuadrantID
+
+	res := serveHeaders(router, http.MethodGet, spotPath, nil, "")
+	assert.Equal(t, 200, res.Code)
+	assert.Equal(t, `"1"`, res.Header().Get("ETag"))
+
+	res = serveHeaders(router, http.MethodGet, spotPath, map[string]string{"If-None-Match": `"1"`}, "")
+	assert.Equal(t, 304, res.Code)
+
+	res = serveHeaders(router, http.MethodPatch, spotPath, map[string]string{"If-Match": `"1"`}, `{"gold_amount":3}`)
+	assert.Equal(t, 200, res.Code)
+	assert.Equal(t, `"2"`, res.Header().Get("ETag"))
+
+	// the writes based on the first version are stale
+	res = serveHeaders(router, http.MethodPatch, spotPath, map[string]string{"If-Match": `"1"`}, `{"gold_amount":5}`)
+	assert.Equal(t, 412, res.Code)
+	assert.Contains(t, res.Body.String(), `"code":"PRECONDITION_FAILED"`)
+
+	res = serveHeaders(router, http.MethodGet, quadrantPath, nil, "")
+	assert.Equal(t, 200, res.Code)
+
+	tag := res.Header().Get("ETag")
+	assert.NotEmpty(t, tag)
+
+	res = serveHeaders(router, http.MethodGet, quadrantPath, map[string]string{"If-None-Match": tag}, "")
+	assert.Equal(t, 304, res.Code)
+
+	res = serveHeaders(router, http.MethodPatch, quadrantPath, map[string]string{"If-Match": `"1"`}, `{}`)
+	assert.Equal(t, 412, res.Code)
+
+	res = serveHeaders(router, http.MethodPatch, quadrantPath, map[string]string{"If-Match": tag}, `{}`)
+	assert.Equal(t, 200, res.Code)
+	assert.NotEqual(t, tag, res.Header().Get("ETag"))
+
+	res = serveHeaders(router, http.MethodDelete, spotPath, map[string]string{"If-Match": `"1"`}, "")
+	assert.Equal(t, 412, res.Code)
+
+	res = serveHeaders(router, http.MethodDelete, spotPath, map[string]string{"If-Match": `"2"`}, "")
+	assert.Equal(t, 200, res.Code)
+
+	res = serveHeaders(router, http.MethodGet, spotPath, nil, "")
+	assert.Equal(t, 404, res.Code)
+}
@@ -0,0 +1,64 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/PacoDw/maze_challenge/generator"
+	"github.com/PacoDw/maze_challenge/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// MazeAction runs the POST actions of the maze collection, e.g. /maze/generate. The
+// router can't register a static path next to /maze/:id, so the actions are dispatched
+// by the name received in :id.
+var MazeAction = func(c *gin.Context) {
+	switch c.Param("id") {
+	case "generate":
+		GenerateMaze(c)
+	case "import":
+		ImportMaze(c)
+	default:
+		respondError(c, &repository.NotFoundError{Entity: "maze action", ID: c.Param("id")})
+	}
+}
+
+// GenerateMaze creates a complete maze from a seed, with its walls, entrance, exit,
+// gold and traps.
+var GenerateMaze = func(c *gin.Context) {
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
+	if !ok {
+		respondError(c, repository.ErrNoConnection)
+
+		return
+	}
+
+	cfg := generator.Config{}
+
+	if err := c.ShouldBindJSON(&cfg); err != nil {
+		respondError(c, badRequest(err))
+
+		return
+	}
+
+	res, err := generator.Generate(cfg)
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	if err := res.Save(c, repo); err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	maze, err := repo.Maze.Get(c, &repository.MazeFilter{ID: res.Maze.ID})
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, maze)
+}
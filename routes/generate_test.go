@@ -0,0 +1,68 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/PacoDw/maze_challenge/generator"
+	"github.com/PacoDw/maze_challenge/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GenerateMaze(t *testing.T) {
+	action := gin.Param{Key: "id", Value: "generate"}
+
+	t.Run("generate a maze", func(t *testing.T) {
+		blob := testExpectedBody(t, generator.Config{
+			Seed:        11,
+			Algorithm:   generator.Kruskal,
+			Name:        "generated",
+			Width:       10,
+			Height:      8,
+			GoldSpots:   6,
+			TrapDensity: 0.1,
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/maze/generate", blob)
+		res := makeScopedRequest(req, MazeAction, action)
+
+		assert.Equal(t, 200, res.Code)
+
+		var maze repository.Maze
+		err := json.Unmarshal(res.Body.Bytes(), &maze)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Len(t, maze.Quadrants, 4)
+
+		spots, err := testRepo.Spot.List(context.Background(), &repository.SpotFilter{MazeID: maze.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the entrance, the exit, the gold and 7 traps in the 72 cells left
+		assert.Len(t, spots, 2+6+7)
+		assert.NoError(t, repository.ValidateMazeSpots(spots))
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		blob := testExpectedBody(t, generator.Config{Name: "generated", Algorithm: "eller", Width: 10, Height: 8})
+
+		req := httptest.NewRequest(http.MethodPost, "/maze/generate", blob)
+		res := makeScopedRequest(req, MazeAction, action)
+
+		assert.Equal(t, 400, res.Code)
+	})
+
+	t.Run("unknown action", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/maze/other", testExpectedBody(t, struct{}{}))
+		res := makeScopedRequest(req, MazeAction, gin.Param{Key: "id", Value: "other"})
+
+		assert.Equal(t, 404, res.Code)
+	})
+}
@@ -0,0 +1,96 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/PacoDw/maze_challenge/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// parseCoordinate parses a coordinate written as x,y.
+func parseCoordinate(s string) (repository.Coordinate, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		return repository.Coordinate{}, repository.BadRequest("the coordinate %q must be written as x,y", s)
+	}
+
+	x, err := strconv.ParseUint(strings.TrimSpace(parts[0]), 10, 64)
+	if err != nil {
+		return repository.Coordinate{}, repository.BadRequest("the coordinate %q must be written as x,y", s)
+	}
+
+	y, err := strconv.ParseUint(strings.TrimSpace(parts[1]), 10, 64)
+	if err != nil {
+		return repository.Coordinate{}, repository.BadRequest("the coordinate %q must be written as x,y", s)
+	}
+
+	return repository.Coordinate{X: uint(x), Y: uint(y)}, nil
+}
+
+// GetMazeCells gets the cells of a maze with their walls, the query params start and
+// limit can be used to get a region, e.g. ?start=0,0&limit=10,10.
+var GetMazeCells = func(c *gin.Context) {
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
+	if !ok {
+		respondError(c, repository.ErrNoConnection)
+
+		return
+	}
+
+	var region *repository.Region
+
+	if c.Query("start") != "" || c.Query("limit") != "" {
+		start, err := parseCoordinate(c.Query("start"))
+		if err != nil {
+			respondError(c, err)
+
+			return
+		}
+
+		limit, err := parseCoordinate(c.Query("limit"))
+		if err != nil {
+			respondError(c, err)
+
+			return
+		}
+
+		region = &repository.Region{StartPoint: start, LimitPoint: limit}
+	}
+
+	cells, err := repo.Grid.Cells(c, &repository.GridFilter{MazeID: c.Param("id")}, region)
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, cells)
+}
+
+// UpdateMazeCells sets the walls of the cells of a maze.
+var UpdateMazeCells = func(c *gin.Context) {
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
+	if !ok {
+		respondError(c, repository.ErrNoConnection)
+
+		return
+	}
+
+	cells := make([]repository.Cell, 0)
+
+	if err := c.ShouldBindJSON(&cells); err != nil {
+		respondError(c, badRequest(err))
+
+		return
+	}
+
+	if err := repo.Grid.SetCells(c, &repository.GridFilter{MazeID: c.Param("id")}, cells); err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, cells)
+}
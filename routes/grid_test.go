@@ -0,0 +1,68 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/PacoDw/maze_challenge/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MazeCells(t *testing.T) {
+	maze := &repository.Maze{Name: "labyrinth", Width: 4, Height: 4}
+
+	if _, err := testRepo.Maze.Create(context.Background(), maze); err != nil {
+		t.Fatal(err)
+	}
+
+	param := gin.Param{Key: "id", Value: maze.ID}
+
+	// set the walls of a cell
+	t.Run("update cells", func(t *testing.T) {
+		blob := testExpectedBody(t, []repository.Cell{
+			{X: 1, Y: 1, North: true, East: true, South: false, West: false},
+		})
+
+		req := httptest.NewRequest(http.MethodPatch, "/cells", blob)
+		res := makeScopedRequest(req, UpdateMazeCells, param)
+
+		assert.Equal(t, 200, res.Code)
+
+		// the border can't be opened
+		blob = testExpectedBody(t, []repository.Cell{{X: 0, Y: 0}})
+
+		req = httptest.NewRequest(http.MethodPatch, "/cells", blob)
+		res = makeScopedRequest(req, UpdateMazeCells, param)
+
+		assert.Equal(t, 400, res.Code)
+	})
+
+	// read a region
+	t.Run("read cells", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/cells?start=1,0&limit=2,2", bytes.NewBuffer([]byte{}))
+		res := makeScopedRequest(req, GetMazeCells, param)
+
+		assert.Equal(t, 200, res.Code)
+
+		var got []repository.Cell
+		err := json.Unmarshal(res.Body.Bytes(), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, []repository.Cell{
+			{X: 1, Y: 0, North: true, South: true},
+			{X: 1, Y: 1, North: true, East: true},
+		}, got)
+
+		req = httptest.NewRequest(http.MethodGet, "/cells?start=1&limit=2,2", bytes.NewBuffer([]byte{}))
+		res = makeScopedRequest(req, GetMazeCells, param)
+
+		assert.Equal(t, 400, res.Code)
+	})
+}
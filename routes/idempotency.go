@@ -0,0 +1,208 @@
+package routes
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/PacoDw/maze_challenge/repository"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// IdempotencyKeyHeader is the header that identifies a request that can be retried, the
+	// response of the first attempt is replayed to the retries sent with the same key.
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	// IdempotentReplayedHeader is set in the responses replayed from a previous attempt.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	// maxIdempotencyKeyLength is the length of the longest idempotency key accepted.
+	maxIdempotencyKeyLength = 255
+)
+
+// IdempotencyTTL is how long the responses of the requests sent with an idempotency key
+// are replayed, after that the key can be used by a new request.
+var IdempotencyTTL = 24 * time.Hour
+
+// replayedHeaders are the headers of the response that are stored to be replayed.
+var replayedHeaders = []string{"Content-Type", "ETag"}
+
+// idempotencyWriter keeps a copy of the body written in the response.
+type idempotencyWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// Write implements the io.Writer interface.
+func (iw *idempotencyWriter) Write(b []byte) (int, error) {
+	iw.body.Write(b)
+
+	return iw.ResponseWriter.Write(b)
+}
+
+// WriteString implements the io.StringWriter interface.
+func (iw *idempotencyWriter) WriteString(s string) (int, error) {
+	iw.body.WriteString(s)
+
+	return iw.ResponseWriter.WriteString(s)
+}
+
+// Idempotent is a middleware for the routes that create or delete resources, so the
+// clients can retry them safely. The requests sent with the Idempotency-Key header are
+// answered once, the response is stored for IdempotencyTTL and it is replayed to the
+// retries sent with the same key, method, path and body. Sending the key with another
+// request is rejected with 422, while the first attempt is handled the retries are
+// rejected with 409. The failures of the server aren't stored so they can be retried.
+func Idempotent() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+
+			return
+		}
+
+		if err := validateIdempotencyKey(key); err != nil {
+			respondError(c, err)
+			c.Abort()
+
+			return
+		}
+
+		repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
+		if !ok {
+			respondError(c, repository.ErrNoConnection)
+			c.Abort()
+
+			return
+		}
+
+		fingerprint, err := requestFingerprint(c)
+		if err != nil {
+			respondError(c, err)
+			c.Abort()
+
+			return
+		}
+
+		record := &repository.IdempotencyRecord{
+			Key:         key,
+			Fingerprint: fingerprint,
+			ExpiresAt:   time.Now().Add(IdempotencyTTL),
+		}
+
+		stored, err := repo.Idempotency.Reserve(c, record)
+		if err != nil {
+			respondError(c, err)
+			c.Abort()
+
+			return
+		}
+
+		if stored != nil {
+			replay(c, stored)
+			c.Abort()
+
+			return
+		}
+
+		writer := &idempotencyWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		completed := false
+
+		// the key is released when the response isn't stored, even if the handler panics
+		defer func() {
+			if completed {
+				return
+			}
+
+			if err := repo.Idempotency.Release(c, key); err != nil {
+				log.Printf("releasing the idempotency key %s (request %s): %s", key, requestID(c), err)
+			}
+		}()
+
+		c.Next()
+
+		if writer.Status() >= http.StatusInternalServerError {
+			return
+		}
+
+		record.Status = writer.Status()
+		record.Body = writer.body.Bytes()
+		record.Header = make(map[string]string)
+
+		for _, h := range replayedHeaders {
+			if v := writer.Header().Get(h); v != "" {
+				record.Header[h] = v
+			}
+		}
+
+		if err := repo.Idempotency.Complete(c, record); err != nil {
+			log.Printf("saving the response of the idempotency key %s (request %s): %s", key, requestID(c), err)
+
+			return
+		}
+
+		completed = true
+	}
+}
+
+// validateIdempotencyKey checks that the key has between 1 and 255 visible ASCII
+// characters, e.g. a UUID.
+func validateIdempotencyKey(key string) error {
+	if len(key) > maxIdempotencyKeyLength {
+		return repository.BadRequest("the %s header must not be longer than %d characters", IdempotencyKeyHeader, maxIdempotencyKeyLength)
+	}
+
+	for i := 0; i < len(key); i++ {
+		if key[i] < '!' || key[i] > '~' {
+			return repository.BadRequest("the %s header must only contain visible ASCII characters", IdempotencyKeyHeader)
+		}
+	}
+
+	return nil
+}
+
+// requestFingerprint returns the hash of the method, path and body of the request, the
+// body is read and set again so the handlers can bind it.
+func requestFingerprint(c *gin.Context) (string, error) {
+	var body []byte
+
+	if c.Request.Body != nil {
+		b, err := ioutil.ReadAll(c.Request.Body)
+		if err != nil {
+			return "", repository.BadRequest("reading the body: %w", err)
+		}
+
+		body = b
+		c.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	h := sha256.New()
+
+	fmt.Fprintf(h, "%s %s\n", c.Request.Method, c.Request.URL.Path)
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// replay responds the stored response of a previous attempt of the request.
+func replay(c *gin.Context, stored *repository.IdempotencyRecord) {
+	for h, v := range stored.Header {
+		c.Header(h, v)
+	}
+
+	c.Header(IdempotentReplayedHeader, "true")
+	c.Status(stored.Status)
+
+	if len(stored.Body) > 0 {
+		_, _ = c.Writer.Write(stored.Body)
+	}
+}
@@ -0,0 +1,121 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/PacoDw/maze_challenge/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_IdempotentCreate(t *testing.T) {
+	router := newTestRouter()
+	maze, _ := newPatchMaze(t, router)
+	headers := map[string]string{IdempotencyKeyHeader: "create-" + maze.ID}
+	body := `{"name":"silver","maze_id":"` + maze.ID + `","gold_amount":3,"Coordinate":{"x":12,"y":4}}`
+
+	res := serveHeaders(router, http.MethodPost, "/spot/create", headers, body)
+	assert.Equal(t, 200, res.Code)
+	assert.Empty(t, res.Header().Get(IdempotentReplayedHeader))
+
+	var spot repository.Spot
+
+	if err := json.Unmarshal(res.Body.Bytes(), &spot); err != nil {
+		t.Fatal(err)
+	}
+
+	// the retry gets the same spot instead of creating another one
+	retry := serveHeaders(router, http.MethodPost, "/spot/create", headers, body)
+	assert.Equal(t, 200, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, res.Body.String(), retry.Body.String())
+	assert.Equal(t, res.Header().Get("ETag"), retry.Header().Get("ETag"))
+	assert.Contains(t, retry.Header().Get("Content-Type"), "application/json")
+
+	var quadrant repository.Quadrant
+
+	res = serveHeaders(router, http.MethodGet, "/v1/quadrants/"+spot.QuadrantID, nil, "")
+	if err := json.Unmarshal(res.Body.Bytes(), &quadrant); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []string{spot.ID}, quadrant.SpotIDs)
+
+	// the key can't be used with another body or in another route
+	res = serveHeaders(router, http.MethodPost, "/spot/create", headers, strings.Replace(body, `"x":12`, `"x":13`, 1))
+	assert.Equal(t, 422, res.Code)
+	assert.Contains(t, res.Body.String(), `"code":"IDEMPOTENCY_KEY_REUSED"`)
+
+	res = serveHeaders(router, http.MethodPost, "/v1/spots", headers, body)
+	assert.Equal(t, 422, res.Code)
+
+	// the requests without key aren't idempotent
+	res = serveHeaders(router, http.MethodPost, "/v1/spots", nil, body)
+	assert.Equal(t, 409, res.Code)
+
+	res = serveHeaders(router, http.MethodPost, "/v1/spots", map[string]string{IdempotencyKeyHeader: strings.Repeat("k", 256)}, body)
+	assert.Equal(t, 400, res.Code)
+}
+
+func Test_IdempotentDelete(t *testing.T) {
+	router := newTestRouter()
+	_, spot := newPatchMaze(t, router)
+	headers := map[string]string{IdempotencyKeyHeader: "delete-" + spot.ID}
+	path := "/v1/spots/" + spot.ID
+
+	res := serveHeaders(router, http.MethodDelete, path, headers, "")
+	assert.Equal(t, 200, res.Code)
+
+	// the retry gets the first response instead of 404
+	retry := serveHeaders(router, http.MethodDelete, path, headers, "")
+	assert.Equal(t, 200, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, res.Body.String(), retry.Body.String())
+
+	res = serveHeaders(router, http.MethodDelete, path, nil, "")
+	assert.Equal(t, 404, res.Code)
+
+	// the errors of the request are replayed as well
+	headers[IdempotencyKeyHeader] = "delete-missing-" + spot.ID
+
+	res = serveHeaders(router, http.MethodDelete, "/v1/quadrants/000000000000000000000000", headers, "")
+	assert.Equal(t, 404, res.Code)
+
+	res = serveHeaders(router, http.MethodDelete, "/v1/quadrants/000000000000000000000000", headers, "")
+	assert.Equal(t, 404, res.Code)
+	assert.Equal(t, "true", res.Header().Get(IdempotentReplayedHeader))
+}
+
+func Test_IdempotentPanic(t *testing.T) {
+	router := gin.New()
+	router.Use(gin.Recovery(), RequestID(), repository.GinMiddleware(testRepo))
+
+	calls := 0
+
+	router.POST("/panic", Idempotent(), func(c *gin.Context) {
+		calls++
+
+		if calls == 1 {
+			panic("the handler failed")
+		}
+
+		c.JSON(http.StatusOK, calls)
+	})
+
+	headers := map[string]string{IdempotencyKeyHeader: "panic-" + newRequestID()}
+
+	res := serveHeaders(router, http.MethodPost, "/panic", headers, "{}")
+	assert.Equal(t, 500, res.Code)
+
+	// the key was released, so the retry is handled instead of being rejected with 409
+	res = serveHeaders(router, http.MethodPost, "/panic", headers, "{}")
+	assert.Equal(t, 200, res.Code)
+	assert.Equal(t, "2", res.Body.String())
+
+	res = serveHeaders(router, http.MethodPost, "/panic", headers, "{}")
+	assert.Equal(t, "true", res.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, 2, calls)
+}
@@ -0,0 +1,170 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/PacoDw/maze_challenge/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// parseListOptions reads the query params sort, limit and cursor.
+func parseListOptions(c *gin.Context) (*repository.ListOptions, error) {
+	opts := &repository.ListOptions{Sort: c.Query("sort"), Cursor: c.Query("cursor")}
+
+	if l := c.Query("limit"); l != "" {
+		limit, err := strconv.Atoi(l)
+		if err != nil {
+			return nil, repository.BadRequest("query param limit must be a number")
+		}
+
+		opts.Limit = limit
+	}
+
+	return opts, nil
+}
+
+// parseBox reads a box written as start_x,start_y,limit_x,limit_y, it returns nil when
+// the box isn't given.
+func parseBox(s string) (*repository.Region, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return nil, repository.BadRequest("the box %q must be written as start_x,start_y,limit_x,limit_y", s)
+	}
+
+	start, err := parseCoordinate(parts[0] + "," + parts[1])
+	if err != nil {
+		return nil, repository.BadRequest("the box %q must be written as start_x,start_y,limit_x,limit_y", s)
+	}
+
+	limit, err := parseCoordinate(parts[2] + "," + parts[3])
+	if err != nil {
+		return nil, repository.BadRequest("the box %q must be written as start_x,start_y,limit_x,limit_y", s)
+	}
+
+	return &repository.Region{StartPoint: start, LimitPoint: limit}, nil
+}
+
+// parseGold reads a query param with an amount of gold, it returns nil when it isn't given.
+func parseGold(c *gin.Context, name string) (*uint, error) {
+	s := c.Query(name)
+	if s == "" {
+		return nil, nil
+	}
+
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return nil, repository.BadRequest("query param %s must be a non-negative number", name)
+	}
+
+	gold := uint(n)
+
+	return &gold, nil
+}
+
+// parseSpotQuery reads the filters of ListSpots.
+func parseSpotQuery(c *gin.Context) (*repository.SpotQuery, error) {
+	sq := &repository.SpotQuery{
+		MazeID:     c.Query("maze_id"),
+		QuadrantID: c.Query("quadrant_id"),
+		Kind:       repository.SpotKind(c.Query("kind")),
+		NamePrefix: c.Query("name_prefix"),
+	}
+
+	var err error
+
+	if sq.MinGold, err = parseGold(c, "min_gold"); err != nil {
+		return nil, err
+	}
+
+	if sq.MaxGold, err = parseGold(c, "max_gold"); err != nil {
+		return nil, err
+	}
+
+	if sq.Box, err = parseBox(c.Query("box")); err != nil {
+		return nil, err
+	}
+
+	return sq, nil
+}
+
+// ListSpots gets a page of spots, they can be filtered with the query params maze_id,
+// quadrant_id, kind, name_prefix, min_gold, max_gold and box, e.g.
+// ?kind=TREASURE&min_gold=10&box=0,0,10,10&sort=-gold_amount&limit=20. The next page is
+// requested with the next_cursor of the response in the query param cursor.
+var ListSpots = func(c *gin.Context) {
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
+	if !ok {
+		respondError(c, repository.ErrNoConnection)
+
+		return
+	}
+
+	sq, err := parseSpotQuery(c)
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	opts, err := parseListOptions(c)
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	page, err := repo.Spot.Page(c, sq, opts)
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// ListQuadrants gets a page of quadrants, they can be filtered with the query params
+// maze_id, type and box, which returns the quadrants that overlap it.
+var ListQuadrants = func(c *gin.Context) {
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
+	if !ok {
+		respondError(c, repository.ErrNoConnection)
+
+		return
+	}
+
+	box, err := parseBox(c.Query("box"))
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	opts, err := parseListOptions(c)
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	qq := &repository.QuadrantQuery{
+		MazeID: c.Query("maze_id"),
+		Type:   repository.ParseQuadrantType(c.Query("type")),
+		Box:    box,
+	}
+
+	page, err := repo.Quadrant.Page(c, qq, opts)
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
@@ -0,0 +1,149 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/PacoDw/maze_challenge/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ListSpots(t *testing.T) {
+	maze := &repository.Maze{Name: "labyrinth", Width: 20, Height: 20}
+
+	if _, err := testRepo.Maze.Create(context.Background(), maze); err != nil {
+		t.Fatal(err)
+	}
+
+	spots := []*repository.Spot{
+		{MazeID: maze.ID, Name: "gold-a", GoldAmount: 5, Coordinate: &repository.Coordinate{X: 2, Y: 2}},
+		{MazeID: maze.ID, Name: "gold-b", GoldAmount: 50, Coordinate: &repository.Coordinate{X: 5, Y: 6}},
+		{MazeID: maze.ID, Name: "gold-c", GoldAmount: 20, Coordinate: &repository.Coordinate{X: 15, Y: 15}},
+		{MazeID: maze.ID, Name: "trap", Kind: repository.Trap, Penalty: 3, Coordinate: &repository.Coordinate{X: 1, Y: 15}},
+	}
+
+	for _, s := range spots {
+		if _, err := testRepo.Spot.Create(context.Background(), s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		code  int
+		names []string
+		total int64
+	}{
+		{name: "sorted by gold", query: "&sort=-gold_amount", code: 200, names: []string{"gold-b", "gold-c", "gold-a", "trap"}, total: 4},
+		{name: "first page", query: "&sort=name&limit=2", code: 200, names: []string{"gold-a", "gold-b"}, total: 4},
+		{name: "gold range", query: "&min_gold=10&max_gold=40", code: 200, names: []string{"gold-c"}, total: 1},
+		{name: "kind", query: "&kind=TRAP", code: 200, names: []string{"trap"}, total: 1},
+		{name: "name prefix and box", query: "&name_prefix=gold&box=0,0,10,10&sort=x", code: 200, names: []string{"gold-a", "gold-b"}, total: 2},
+		{name: "wrong sort", query: "&sort=color", code: 400},
+		{name: "wrong box", query: "&box=0,0,10", code: 400},
+		{name: "wrong gold", query: "&min_gold=-1", code: 400},
+		{name: "wrong cursor", query: "&cursor=abc", code: 400},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/?maze_id="+maze.ID+tt.query, bytes.NewBuffer([]byte{}))
+			res := makeScopedRequest(req, ListSpots)
+
+			assert.Equal(t, tt.code, res.Code)
+
+			if tt.code != 200 {
+				return
+			}
+
+			var got repository.SpotPage
+			if err := json.Unmarshal(res.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+
+			names := make([]string, 0, len(got.Items))
+
+			for _, s := range got.Items {
+				names = append(names, s.Name)
+			}
+
+			assert.Equal(t, tt.names, names)
+			assert.Equal(t, tt.total, got.Total)
+		})
+	}
+
+	// the cursor of the first page gives the rest of the spots
+	req := httptest.NewRequest(http.MethodGet, "/?maze_id="+maze.ID+"&sort=name&limit=2", bytes.NewBuffer([]byte{}))
+	res := makeScopedRequest(req, ListSpots)
+
+	var first repository.SpotPage
+	if err := json.Unmarshal(res.Body.Bytes(), &first); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NotEmpty(t, first.NextCursor)
+
+	req = httptest.NewRequest(http.MethodGet, "/?maze_id="+maze.ID+"&sort=name&limit=2&cursor="+first.NextCursor, bytes.NewBuffer([]byte{}))
+	res = makeScopedRequest(req, ListSpots)
+
+	var second repository.SpotPage
+	if err := json.Unmarshal(res.Body.Bytes(), &second); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, second.Items, 2)
+	assert.Equal(t, "gold-c", second.Items[0].Name)
+	assert.Equal(t, "trap", second.Items[1].Name)
+	assert.Empty(t, second.NextCursor)
+}
+
+func Test_ListQuadrants(t *testing.T) {
+	maze := &repository.Maze{Name: "labyrinth", Width: 20, Height: 20}
+
+	if _, err := testRepo.Maze.Create(context.Background(), maze); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		code  int
+		types []repository.QuadrantType
+	}{
+		{name: "type", query: "&type=TOP_RIGHT", code: 200, types: []repository.QuadrantType{repository.TopRight}},
+		{name: "box", query: "&box=0,0,5,15&sort=-y", code: 200, types: []repository.QuadrantType{repository.BottomLeft, repository.TopLeft}},
+		{name: "wrong type", query: "&type=MIDDLE", code: 400},
+		{name: "wrong limit", query: "&limit=many", code: 400},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/?maze_id="+maze.ID+tt.query, bytes.NewBuffer([]byte{}))
+			res := makeScopedRequest(req, ListQuadrants)
+
+			assert.Equal(t, tt.code, res.Code)
+
+			if tt.code != 200 {
+				return
+			}
+
+			var got repository.QuadrantPage
+			if err := json.Unmarshal(res.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+
+			types := make([]repository.QuadrantType, 0, len(got.Items))
+
+			for _, q := range got.Items {
+				types = append(types, q.Type)
+			}
+
+			assert.Equal(t, tt.types, types)
+		})
+	}
+}
@@ -0,0 +1,559 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/PacoDw/maze_challenge/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// CreateMaze creates a maze with its four quadrants.
+var CreateMaze = func(c *gin.Context) {
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
+	if !ok {
+		respondError(c, repository.ErrNoConnection)
+
+		return
+	}
+
+	maze := &repository.Maze{}
+
+	if err := c.ShouldBindJSON(maze); err != nil {
+		respondError(c, badRequest(err))
+
+		return
+	}
+
+	if _, err := repo.Maze.Create(c, maze); err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, maze)
+}
+
+// GetMaze gets a maze with its quadrants.
+var GetMaze = func(c *gin.Context) {
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
+	if !ok {
+		respondError(c, repository.ErrNoConnection)
+
+		return
+	}
+
+	id := c.Param("id")
+	if id == "" {
+		respondError(c, repository.BadRequest("param :id must not be empty"))
+
+		return
+	}
+
+	maze, err := repo.Maze.Get(c, &repository.MazeFilter{ID: id})
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, maze)
+}
+
+// ListMazes lists all the mazes.
+var ListMazes = func(c *gin.Context) {
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
+	if !ok {
+		respondError(c, repository.ErrNoConnection)
+
+		return
+	}
+
+	mazes, err := repo.Maze.List(c)
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, mazes)
+}
+
+// UpdateMaze updates the name of a maze.
+var UpdateMaze = func(c *gin.Context) {
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
+	if !ok {
+		respondError(c, repository.ErrNoConnection)
+
+		return
+	}
+
+	maze := &repository.Maze{}
+
+	// the attributes are optional in an update so the binding validation is skipped
+	if err := c.ShouldBindJSON(maze); err != nil && !isValidationError(err) {
+		respondError(c, badRequest(err))
+
+		return
+	}
+
+	maze.ID = c.Param("id")
+
+	maze, err := repo.Maze.Update(c, maze)
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, maze)
+}
+
+// DeleteMaze deletes a maze with its quadrants and spots.
+var DeleteMaze = func(c *gin.Context) {
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
+	if !ok {
+		respondError(c, repository.ErrNoConnection)
+
+		return
+	}
+
+	id := c.Param("id")
+	if id == "" {
+		respondError(c, repository.BadRequest("param :id must not be empty"))
+
+		return
+	}
+
+	_, err := repo.Maze.Delete(c, &repository.MazeFilter{ID: id})
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, "")
+}
+
+// ListMazeQuadrants lists the quadrants of a maze.
+var ListMazeQuadrants = func(c *gin.Context) {
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
+	if !ok {
+		respondError(c, repository.ErrNoConnection)
+
+		return
+	}
+
+	id := c.Param("id")
+	if id == "" {
+		respondError(c, repository.BadRequest("param :id must not be empty"))
+
+		return
+	}
+
+	quadrants, err := repo.Quadrant.List(c, &repository.QuadrantFilter{MazeID: id})
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, quadrants)
+}
+
+// GetMazeQuadrant gets a quadrant of a maze.
+var GetMazeQuadrant = func(c *gin.Context) {
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
+	if !ok {
+		respondError(c, repository.ErrNoConnection)
+
+		return
+	}
+
+	quadrant, err := repo.Quadrant.Get(c, &repository.QuadrantFilter{
+		ID:     c.Param("quadrant_id"),
+		MazeID: c.Param("id"),
+	})
+
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	setETag(c, quadrant.Version)
+
+	if notModified(c, quadrant.Version) {
+		c.Status(http.StatusNotModified)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, quadrant)
+}
+
+// UpdateMazeQuadrant updates a quadrant of a maze, the update fails when the version of
+// the If-Match header isn't the stored one.
+var UpdateMazeQuadrant = func(c *gin.Context) {
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
+	if !ok {
+		respondError(c, repository.ErrNoConnection)
+
+		return
+	}
+
+	quadrant := &repository.Quadrant{}
+
+	if err := c.ShouldBindJSON(quadrant); err != nil && !isValidationError(err) {
+		respondError(c, badRequest(err))
+
+		return
+	}
+
+	expected, err := ifMatch(c)
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	// the quadrant must belong to the maze
+	cq, err := repo.Quadrant.Get(c, &repository.QuadrantFilter{
+		ID:     c.Param("quadrant_id"),
+		MazeID: c.Param("id"),
+	})
+
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	quadrant.ID = cq.ID
+	quadrant.Type = ""
+
+	// the version of If-Match takes precedence over the one of the body
+	if expected != 0 {
+		quadrant.Version = expected
+	}
+
+	quadrant, err = repo.Quadrant.Update(c, quadrant)
+	if err != nil {
+		respondError(c, conditional(c, err))
+
+		return
+	}
+
+	setETag(c, quadrant.Version)
+	c.JSON(http.StatusOK, quadrant)
+}
+
+// CreateMazeSpot creates a spot in a quadrant of a maze.
+var CreateMazeSpot = func(c *gin.Context) {
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
+	if !ok {
+		respondError(c, repository.ErrNoConnection)
+
+		return
+	}
+
+	spot := &repository.Spot{}
+
+	if err := c.ShouldBindJSON(spot); err != nil {
+		respondError(c, badRequest(err))
+
+		return
+	}
+
+	spot.MazeID = c.Param("id")
+
+	id, err := repo.Spot.Create(c, spot)
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	spot.ID = id
+
+	setETag(c, spot.Version)
+	c.JSON(http.StatusOK, spot)
+}
+
+// ListMazeSpots lists the spots of a maze, they can be filtered by kind.
+var ListMazeSpots = func(c *gin.Context) {
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
+	if !ok {
+		respondError(c, repository.ErrNoConnection)
+
+		return
+	}
+
+	id := c.Param("id")
+	if id == "" {
+		respondError(c, repository.BadRequest("param :id must not be empty"))
+
+		return
+	}
+
+	spots, err := repo.Spot.List(c, &repository.SpotFilter{
+		MazeID: id,
+		Kind:   repository.SpotKind(c.Query("kind")),
+	})
+
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, spots)
+}
+
+// GetMazeSpot gets a spot of a maze.
+var GetMazeSpot = func(c *gin.Context) {
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
+	if !ok {
+		respondError(c, repository.ErrNoConnection)
+
+		return
+	}
+
+	spot, err := repo.Spot.Get(c, &repository.SpotFilter{
+		ID:     c.Param("spot_id"),
+		MazeID: c.Param("id"),
+	})
+
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	setETag(c, spot.Version)
+
+	if notModified(c, spot.Version) {
+		c.Status(http.StatusNotModified)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, spot)
+}
+
+// UpdateMazeSpot updates a spot of a maze, the update fails when the version of the
+// If-Match header isn't the stored one.
+var UpdateMazeSpot = func(c *gin.Context) {
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
+	if !ok {
+		respondError(c, repository.ErrNoConnection)
+
+		return
+	}
+
+	spot := &repository.Spot{}
+
+	if err := c.ShouldBindJSON(spot); err != nil && !isValidationError(err) {
+		respondError(c, badRequest(err))
+
+		return
+	}
+
+	expected, err := ifMatch(c)
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	// the spot must belong to the maze
+	cs, err := repo.Spot.Get(c, &repository.SpotFilter{
+		ID:     c.Param("spot_id"),
+		MazeID: c.Param("id"),
+	})
+
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	spot.ID = cs.ID
+	spot.MazeID = cs.MazeID
+
+	// the version of If-Match takes precedence over the one of the body
+	if expected != 0 {
+		spot.Version = expected
+	}
+
+	spot, err = repo.Spot.Update(c, spot)
+	if err != nil {
+		respondError(c, conditional(c, err))
+
+		return
+	}
+
+	setETag(c, spot.Version)
+	c.JSON(http.StatusOK, spot)
+}
+
+// DeleteMazeSpot deletes a spot of a maze, the delete fails when the version of the
+// If-Match header isn't the stored one.
+var DeleteMazeSpot = func(c *gin.Context) {
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
+	if !ok {
+		respondError(c, repository.ErrNoConnection)
+
+		return
+	}
+
+	expected, err := ifMatch(c)
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	// the spot must belong to the maze
+	cs, err := repo.Spot.Get(c, &repository.SpotFilter{
+		ID:     c.Param("spot_id"),
+		MazeID: c.Param("id"),
+	})
+
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	// the version required by If-Match is checked by the delete itself
+	isRemoved, err := repo.Spot.Delete(c, &repository.SpotFilter{ID: cs.ID, Version: expected})
+	if err != nil {
+		respondError(c, conditional(c, err))
+
+		return
+	}
+
+	if !isRemoved {
+		respondError(c, &repository.NotFoundError{Entity: "spot", ID: cs.ID})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, "")
+}
+
+// isValidationError reports whether err was returned by the binding validation, it is used
+// by the updates where the required attributes are optional.
+func isValidationError(err error) bool {
+	var ve validator.ValidationErrors
+
+	return errors.As(err, &ve)
+}
+
+// mazeGold represents the gold of a maze with the total of each quadrant.
+type mazeGold struct {
+	repository.GoldTotal
+	Quadrants []repository.GoldTotal `json:"quadrants"`
+}
+
+// GetMazeGold gets the total gold of a maze and of each one of its quadrants.
+var GetMazeGold = func(c *gin.Context) {
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
+	if !ok {
+		respondError(c, repository.ErrNoConnection)
+
+		return
+	}
+
+	maze, err := repo.Maze.Get(c, &repository.MazeFilter{ID: c.Param("id")})
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	filter := &repository.SpotFilter{MazeID: maze.ID}
+
+	byMaze, err := repo.Spot.TotalGoldByMaze(c, filter)
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	byQuadrant, err := repo.Spot.TotalGoldByQuadrant(c, filter)
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	res := mazeGold{
+		GoldTotal: repository.GoldTotal{ID: maze.ID},
+		Quadrants: make([]repository.GoldTotal, 0, len(maze.QuadrantIDs)),
+	}
+
+	if len(byMaze) > 0 {
+		res.GoldTotal = byMaze[0]
+	}
+
+	// the quadrants without spots aren't aggregated
+	for _, id := range maze.QuadrantIDs {
+		total := repository.GoldTotal{ID: id}
+
+		for i := range byQuadrant {
+			if byQuadrant[i].ID == id {
+				total = byQuadrant[i]
+			}
+		}
+
+		res.Quadrants = append(res.Quadrants, total)
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// defaultRichestLimit is the number of spots returned by ListRichestMazeSpots when the
+// limit isn't given.
+const defaultRichestLimit = 10
+
+// ListRichestMazeSpots lists the spots of a maze with more gold.
+var ListRichestMazeSpots = func(c *gin.Context) {
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
+	if !ok {
+		respondError(c, repository.ErrNoConnection)
+
+		return
+	}
+
+	id := c.Param("id")
+	if id == "" {
+		respondError(c, repository.BadRequest("param :id must not be empty"))
+
+		return
+	}
+
+	limit := defaultRichestLimit
+
+	if v := c.Query("limit"); v != "" {
+		var err error
+
+		if limit, err = strconv.Atoi(v); err != nil {
+			respondError(c, repository.BadRequest("query limit must be a number"))
+
+			return
+		}
+	}
+
+	spots, err := repo.Spot.Richest(c, &repository.SpotFilter{MazeID: id}, limit)
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, spots)
+}
@@ -0,0 +1,309 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/PacoDw/maze_challenge/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// makeScopedRequest works like makeRequest but the params are given since the scoped
+// routes have more than one of them.
+func makeScopedRequest(req *http.Request, handler gin.HandlerFunc, params ...gin.Param) *httptest.ResponseRecorder {
+	var (
+		w    = httptest.NewRecorder()
+		c, _ = gin.CreateTestContext(w)
+	)
+
+	c.Params = params
+	c.Request = req
+
+	c.Set(string(repository.ContextRepoConn), testRepo)
+
+	handler(c)
+
+	return w
+}
+
+func Test_MazeCreateGetDelete(t *testing.T) {
+	var maze repository.Maze
+
+	// Create a maze
+	t.Run("create a maze", func(t *testing.T) {
+		blob := testExpectedBody(t, repository.Maze{
+			Name:   "labyrinth",
+			Width:  50,
+			Height: 50,
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/maze", blob)
+		res := makeRequest(req, CreateMaze)
+
+		assert.Equal(t, 200, res.Code)
+
+		err := json.Unmarshal(res.Body.Bytes(), &maze)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.NotEmpty(t, maze.ID)
+		assert.Len(t, maze.Quadrants, 4)
+	})
+
+	// read the maze
+	t.Run("read a maze", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/maze/%s", maze.ID), bytes.NewBuffer([]byte{}))
+		res := makeRequest(req, GetMaze)
+
+		assert.Equal(t, 200, res.Code)
+
+		var got repository.Maze
+		err := json.Unmarshal(res.Body.Bytes(), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, maze.Name, got.Name)
+		assert.Equal(t, maze.QuadrantIDs, got.QuadrantIDs)
+	})
+
+	// rename the maze
+	t.Run("update a maze", func(t *testing.T) {
+		blob := testExpectedBody(t, repository.Maze{Name: "maze"})
+
+		req := httptest.NewRequest(http.MethodPatch, fmt.Sprintf("/maze/%s", maze.ID), blob)
+		res := makeScopedRequest(req, UpdateMaze, gin.Param{Key: "id", Value: maze.ID})
+
+		assert.Equal(t, 200, res.Code)
+
+		var got repository.Maze
+		err := json.Unmarshal(res.Body.Bytes(), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, "maze", got.Name)
+		assert.Equal(t, maze.Width, got.Width)
+	})
+
+	// Remove the maze
+	t.Run("remove a maze", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/maze/%s", maze.ID), bytes.NewBuffer([]byte{}))
+		res := makeRequest(req, DeleteMaze)
+
+		assert.Equal(t, 200, res.Code)
+	})
+}
+
+func Test_MazeCreateTooLarge(t *testing.T) {
+	router := newTestRouter()
+
+	res := serve(t, router, http.MethodPost, "/maze", repository.Maze{Name: "labyrinth", Width: 100000, Height: 100000})
+	assert.Equal(t, 400, res.Code)
+	assert.Contains(t, res.Body.String(), `"code":"VALIDATION_FAILED"`)
+	assert.Contains(t, res.Body.String(), `"field":"width"`)
+}
+
+func Test_MazeSpots(t *testing.T) {
+	var maze, other repository.Maze
+
+	for _, m := range []*repository.Maze{&maze, &other} {
+		req := httptest.NewRequest(http.MethodPost, "/maze", testExpectedBody(t, repository.Maze{
+			Name:   "labyrinth",
+			Width:  50,
+			Height: 50,
+		}))
+
+		res := makeRequest(req, CreateMaze)
+
+		if err := json.Unmarshal(res.Body.Bytes(), m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var spot repository.Spot
+
+	// Create a spot in the maze
+	t.Run("create a spot", func(t *testing.T) {
+		blob := testExpectedBody(t, repository.Spot{
+			Name:       "exit",
+			GoldAmount: 200,
+			Coordinate: &repository.Coordinate{X: 10, Y: 3},
+			QuadrantID: maze.QuadrantIDs[0],
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/spot", blob)
+		res := makeScopedRequest(req, CreateMazeSpot, gin.Param{Key: "id", Value: maze.ID})
+
+		assert.Equal(t, 200, res.Code)
+
+		err := json.Unmarshal(res.Body.Bytes(), &spot)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, maze.ID, spot.MazeID)
+	})
+
+	// the spot can't be created using a quadrant of another maze
+	t.Run("create a spot in another maze", func(t *testing.T) {
+		blob := testExpectedBody(t, repository.Spot{
+			Name:       "exit",
+			GoldAmount: 200,
+			Coordinate: &repository.Coordinate{X: 10, Y: 3},
+			QuadrantID: other.QuadrantIDs[0],
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/spot", blob)
+		res := makeScopedRequest(req, CreateMazeSpot, gin.Param{Key: "id", Value: maze.ID})
+
+		assert.Equal(t, 400, res.Code)
+	})
+
+	// the spot is only visible in its maze
+	t.Run("read a spot", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/spot", bytes.NewBuffer([]byte{}))
+
+		res := makeScopedRequest(req, GetMazeSpot,
+			gin.Param{Key: "id", Value: maze.ID},
+			gin.Param{Key: "spot_id", Value: spot.ID},
+		)
+
+		assert.Equal(t, 200, res.Code)
+
+		res = makeScopedRequest(req, GetMazeSpot,
+			gin.Param{Key: "id", Value: other.ID},
+			gin.Param{Key: "spot_id", Value: spot.ID},
+		)
+
+		assert.Equal(t, 404, res.Code)
+	})
+
+	// update the spot
+	t.Run("update a spot", func(t *testing.T) {
+		blob := testExpectedBody(t, repository.Spot{Name: "entrance"})
+
+		req := httptest.NewRequest(http.MethodPatch, "/spot", blob)
+
+		res := makeScopedRequest(req, UpdateMazeSpot,
+			gin.Param{Key: "id", Value: maze.ID},
+			gin.Param{Key: "spot_id", Value: spot.ID},
+		)
+
+		assert.Equal(t, 200, res.Code)
+
+		var got repository.Spot
+		err := json.Unmarshal(res.Body.Bytes(), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, "entrance", got.Name)
+		assert.Equal(t, spot.GoldAmount, got.GoldAmount)
+	})
+
+	// list the spots of the maze
+	t.Run("list the spots", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/spot", bytes.NewBuffer([]byte{}))
+		res := makeScopedRequest(req, ListMazeSpots, gin.Param{Key: "id", Value: maze.ID})
+
+		assert.Equal(t, 200, res.Code)
+
+		var got []repository.Spot
+		err := json.Unmarshal(res.Body.Bytes(), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Len(t, got, 1)
+
+		req = httptest.NewRequest(http.MethodGet, "/spot?kind=TRAP", bytes.NewBuffer([]byte{}))
+		res = makeScopedRequest(req, ListMazeSpots, gin.Param{Key: "id", Value: maze.ID})
+
+		assert.Equal(t, 200, res.Code)
+		assert.JSONEq(t, "[]", res.Body.String())
+	})
+
+	// Remove the spot
+	t.Run("remove a spot", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/spot", bytes.NewBuffer([]byte{}))
+
+		res := makeScopedRequest(req, DeleteMazeSpot,
+			gin.Param{Key: "id", Value: other.ID},
+			gin.Param{Key: "spot_id", Value: spot.ID},
+		)
+
+		assert.Equal(t, 404, res.Code)
+
+		res = makeScopedRequest(req, DeleteMazeSpot,
+			gin.Param{Key: "id", Value: maze.ID},
+			gin.Param{Key: "spot_id", Value: spot.ID},
+		)
+
+		assert.Equal(t, 200, res.Code)
+	})
+}
+
+func Test_MazeGold(t *testing.T) {
+	maze := &repository.Maze{Name: "labyrinth", Width: 50, Height: 50}
+
+	if _, err := testRepo.Maze.Create(context.Background(), maze); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, amount := range []uint{100, 300} {
+		_, err := testRepo.Spot.Create(context.Background(), &repository.Spot{
+			MazeID:     maze.ID,
+			Name:       "treasure",
+			GoldAmount: amount,
+			Coordinate: &repository.Coordinate{X: uint(i), Y: 0},
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the total of the maze and its quadrants
+	t.Run("gold of a maze", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/gold", bytes.NewBuffer([]byte{}))
+		res := makeScopedRequest(req, GetMazeGold, gin.Param{Key: "id", Value: maze.ID})
+
+		assert.Equal(t, 200, res.Code)
+
+		var got mazeGold
+		err := json.Unmarshal(res.Body.Bytes(), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, uint(400), got.GoldAmount)
+		assert.Len(t, got.Quadrants, 4)
+		assert.Equal(t, repository.GoldTotal{ID: maze.QuadrantIDs[0], GoldAmount: 400, Spots: 2}, got.Quadrants[0])
+		assert.Equal(t, repository.GoldTotal{ID: maze.QuadrantIDs[1]}, got.Quadrants[1])
+	})
+
+	// the richest spots
+	t.Run("richest spots", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/richest?limit=1", bytes.NewBuffer([]byte{}))
+		res := makeScopedRequest(req, ListRichestMazeSpots, gin.Param{Key: "id", Value: maze.ID})
+
+		assert.Equal(t, 200, res.Code)
+
+		var got []repository.Spot
+		err := json.Unmarshal(res.Body.Bytes(), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Len(t, got, 1)
+		assert.Equal(t, uint(300), got[0].GoldAmount)
+	})
+}
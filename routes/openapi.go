@@ -0,0 +1,277 @@
+package routes
+
+import (
+	"net/http"
+	"regexp"
+	"sync"
+
+	"github.com/PacoDw/maze_challenge/generator"
+	"github.com/PacoDw/maze_challenge/openapi"
+	"github.com/PacoDw/maze_challenge/patch"
+	"github.com/PacoDw/maze_challenge/repository"
+	"github.com/PacoDw/maze_challenge/solver"
+	"github.com/PacoDw/maze_challenge/transfer"
+	"github.com/gin-gonic/gin"
+)
+
+// param represents a query param of a route.
+type param struct {
+	name        string
+	description string
+}
+
+// apiRoute describes a route in the OpenAPI document, body and response are values of the
+// types they use so their schemas are derived from them.
+type apiRoute struct {
+	summary    string
+	tag        string
+	deprecated bool
+	params     []param
+	headers    []param
+	query      interface{}
+	body       interface{}
+	response   interface{}
+	// produces lists the content types of the routes that don't respond JSON.
+	produces []string
+	// consumes lists the content types of the bodies that are also accepted besides JSON.
+	consumes []string
+	// patch marks the routes whose body is a JSON merge patch or a JSON patch of the body.
+	patch bool
+	// actions describes by path the actions of the routes that dispatch them by a param,
+	// e.g. POST /v1/spots:action, each action is a different operation in the document.
+	actions map[string]apiRoute
+}
+
+var (
+	// ifMatchHeader is the header of the writes conditioned on the version of the resource.
+	ifMatchHeader = []param{{"If-Match", "ETag of the version that must be replaced, 412 is responded when it is stale"}}
+
+	// ifNoneMatchHeader is the header of the reads of a resource that the client already has.
+	ifNoneMatchHeader = []param{{"If-None-Match", "ETags of the versions the client has, 304 is responded when one of them is current"}}
+
+	// idempotencyKeyHeader is the header of the creates and deletes that can be retried.
+	idempotencyKeyHeader = []param{{"Idempotency-Key", "unique key of the request, its retries with the same key and body get the first response"}}
+
+	// ifMatchIdempotencyKeyHeaders are the headers of the deletes conditioned on the version.
+	ifMatchIdempotencyKeyHeaders = []param{ifMatchHeader[0], idempotencyKeyHeader[0]}
+)
+
+// listDescriptions contains the descriptions of the query params of the lists.
+var listDescriptions = map[string]string{
+	"box":    "start_x,start_y,limit_x,limit_y, the limit is exclusive",
+	"sort":   "name of a field, prefixed with - to sort in descending order",
+	"limit":  "items of the page, 20 by default and 100 at most",
+	"cursor": "next_cursor of the previous page",
+}
+
+// importParams are the attributes of the maze imported from a CSV document.
+var importParams = []param{
+	{"name", "name of the maze of a CSV document"},
+	{"width", "width of the maze of a CSV document"},
+	{"height", "height of the maze of a CSV document"},
+}
+
+// kindParam is the filter of the spots by kind.
+var kindParam = param{"kind", "ENTRANCE, EXIT, TREASURE, TRAP or WALL"}
+
+// apiRoutes contains the description of all the routes by METHOD /path like they are
+// registered in the router.
+var apiRoutes = map[string]apiRoute{
+	"POST /maze": {summary: "Create a maze with its four quadrants", tag: "mazes", body: repository.Maze{}, response: repository.Maze{}, headers: idempotencyKeyHeader},
+	"POST /maze/:id": {actions: map[string]apiRoute{
+		"/maze/generate": {summary: "Generate a maze from a seed", tag: "mazes", headers: idempotencyKeyHeader, body: generator.Config{}, response: repository.Maze{}},
+		"/maze/import":   {summary: "Import a maze from a JSON, YAML or CSV document", tag: "mazes", params: importParams, headers: idempotencyKeyHeader, body: transfer.Document{}, consumes: []string{"application/yaml", "text/csv"}, response: repository.Maze{}},
+	}},
+	"GET /maze":        {summary: "List the mazes", tag: "mazes", response: []repository.Maze{}},
+	"GET /maze/:id":    {summary: "Get a maze with its quadrants", tag: "mazes", response: repository.Maze{}},
+	"PATCH /maze/:id":  {summary: "Update the name of a maze", tag: "mazes", body: repository.Maze{}, response: repository.Maze{}},
+	"DELETE /maze/:id": {summary: "Delete a maze with its quadrants and spots", tag: "mazes", headers: idempotencyKeyHeader},
+
+	"GET /maze/:id/quadrant":                {summary: "List the quadrants of a maze", tag: "mazes", response: []repository.Quadrant{}},
+	"GET /maze/:id/quadrant/:quadrant_id":   {summary: "Get a quadrant of a maze", tag: "mazes", response: repository.Quadrant{}},
+	"PATCH /maze/:id/quadrant/:quadrant_id": {summary: "Update a quadrant of a maze", tag: "mazes", body: repository.Quadrant{}, response: repository.Quadrant{}},
+
+	"POST /maze/:id/spot":            {summary: "Create a spot in a maze", tag: "mazes", body: repository.Spot{}, response: repository.Spot{}, headers: idempotencyKeyHeader},
+	"GET /maze/:id/spot":             {summary: "List the spots of a maze", tag: "mazes", params: []param{kindParam}, response: []repository.Spot{}},
+	"GET /maze/:id/spot/:spot_id":    {summary: "Get a spot of a maze", tag: "mazes", response: repository.Spot{}},
+	"PATCH /maze/:id/spot/:spot_id":  {summary: "Update a spot of a maze", tag: "mazes", body: repository.Spot{}, response: repository.Spot{}},
+	"DELETE /maze/:id/spot/:spot_id": {summary: "Delete a spot of a maze", tag: "mazes", headers: idempotencyKeyHeader},
+
+	"GET /maze/:id/gold":    {summary: "Get the total gold of a maze and of each quadrant", tag: "mazes", response: mazeGold{}},
+	"GET /maze/:id/richest": {summary: "List the spots with more gold", tag: "mazes", params: []param{{"limit", "number of spots, 10 by default"}}, response: []repository.Spot{}},
+	"GET /maze/:id/within":  {summary: "List the spots in a box or a radius", tag: "mazes", params: []param{{"start", "x,y"}, {"limit", "x,y, exclusive"}, {"center", "x,y"}, {"radius", "distance to the center"}, kindParam}, response: []repository.Spot{}},
+	"GET /maze/:id/nearest": {summary: "List the spots closer to a point", tag: "mazes", params: []param{{"point", "x,y"}, {"limit", "number of spots, 5 by default"}, kindParam}, response: []repository.Spot{}},
+
+	"GET /maze/:id/cells":   {summary: "Get the cells of a maze with their walls", tag: "mazes", params: []param{{"start", "x,y"}, {"limit", "x,y, exclusive"}}, response: []repository.Cell{}},
+	"PATCH /maze/:id/cells": {summary: "Set the walls of the cells of a maze", tag: "mazes", body: []repository.Cell{}, response: []repository.Cell{}},
+
+	"GET /maze/:id/path":  {summary: "Get the shortest path between two spots", tag: "mazes", params: []param{{"from", "id of a spot"}, {"to", "id of a spot"}, {"algorithm", "astar (default) or bfs"}}, response: solver.Path{}},
+	"GET /maze/:id/route": {summary: "Get the route with the most gold within the steps", tag: "mazes", params: []param{{"steps", "maximum number of steps"}}, response: solver.Route{}},
+
+	"GET /maze/:id/validate":   {summary: "Get the validation report of a maze", tag: "mazes", response: solver.Report{}},
+	"GET /maze/:id/export":     {summary: "Export a maze with its quadrants, spots and walls", tag: "mazes", params: []param{{"format", "json (default), yaml or csv, which only contains the spots"}}, response: transfer.Document{}},
+	"GET /maze/:id/render":     {summary: "Draw a maze", tag: "mazes", params: []param{{"format", "txt (default), svg or png"}, {"from", "id of a spot"}, {"to", "id of a spot"}, {"algorithm", "astar (default) or bfs"}}, produces: []string{"text/plain", "image/svg+xml", "image/png"}},
+	"POST /maze/:id/publish":   {summary: "Publish a maze once it passes the validation", tag: "mazes", response: repository.Maze{}},
+	"DELETE /maze/:id/publish": {summary: "Unpublish a maze", tag: "mazes", response: repository.Maze{}},
+
+	"POST /v1/spots": {summary: "Create a spot", tag: "spots", body: repository.Spot{}, response: repository.Spot{}, headers: idempotencyKeyHeader},
+	"POST /v1/spots:action": {actions: map[string]apiRoute{
+		"/v1/spots:batchCreate": {summary: "Create up to 100 spots, the result of each item is responded", tag: "spots", body: SpotBatchRequest{}, response: SpotBatchResponse{}, headers: idempotencyKeyHeader},
+		"/v1/spots:batchUpdate": {summary: "Update up to 100 spots, the result of each item is responded", tag: "spots", body: SpotBatchRequest{}, response: SpotBatchResponse{}, headers: idempotencyKeyHeader},
+		"/v1/spots:batchDelete": {summary: "Delete up to 100 spots by id and version, the result of each item is responded", tag: "spots", body: SpotBatchRequest{}, response: SpotBatchResponse{}, headers: idempotencyKeyHeader},
+	}},
+	"GET /v1/spots":        {summary: "List the spots in pages", tag: "spots", query: repository.SpotQuery{}, response: repository.SpotPage{}},
+	"GET /v1/spots/:id":    {summary: "Get a spot", tag: "spots", headers: ifNoneMatchHeader, response: repository.Spot{}},
+	"PATCH /v1/spots/:id":  {summary: "Apply a JSON merge patch or a JSON patch to a spot", tag: "spots", body: repository.Spot{}, response: repository.Spot{}, patch: true, headers: ifMatchHeader},
+	"PUT /v1/spots/:id":    {summary: "Replace a spot", tag: "spots", headers: ifMatchHeader, body: repository.Spot{}, response: repository.Spot{}},
+	"DELETE /v1/spots/:id": {summary: "Delete a spot", tag: "spots", headers: ifMatchIdempotencyKeyHeaders},
+
+	"POST /v1/quadrants":       {summary: "Create a quadrant", tag: "quadrants", body: repository.Quadrant{}, response: repository.Quadrant{}, headers: idempotencyKeyHeader},
+	"GET /v1/quadrants":        {summary: "List the quadrants in pages", tag: "quadrants", query: repository.QuadrantQuery{}, response: repository.QuadrantPage{}},
+	"GET /v1/quadrants/:id":    {summary: "Get a quadrant", tag: "quadrants", headers: ifNoneMatchHeader, response: repository.Quadrant{}},
+	"PATCH /v1/quadrants/:id":  {summary: "Apply a JSON merge patch or a JSON patch to a quadrant", tag: "quadrants", body: repository.Quadrant{}, response: repository.Quadrant{}, patch: true, headers: ifMatchHeader},
+	"PUT /v1/quadrants/:id":    {summary: "Replace a quadrant", tag: "quadrants", headers: ifMatchHeader, body: repository.Quadrant{}, response: repository.Quadrant{}},
+	"DELETE /v1/quadrants/:id": {summary: "Delete a quadrant with its spots", tag: "quadrants", headers: ifMatchIdempotencyKeyHeaders},
+
+	"GET /spot":               {summary: "List the spots in pages, use GET /v1/spots", tag: "spots", deprecated: true, query: repository.SpotQuery{}, response: repository.SpotPage{}},
+	"POST /spot/create":       {summary: "Create a spot, use POST /v1/spots", tag: "spots", deprecated: true, body: repository.Spot{}, response: repository.Spot{}, headers: idempotencyKeyHeader},
+	"GET /spot/read/:id":      {summary: "Get a spot, use GET /v1/spots/{id}", tag: "spots", deprecated: true, response: repository.Spot{}},
+	"PATCH /spot/update":      {summary: "Update the spot with the id of the body, use PATCH /v1/spots/{id}", tag: "spots", deprecated: true, body: repository.Spot{}, response: repository.Spot{}},
+	"DELETE /spot/delete/:id": {summary: "Delete a spot, use DELETE /v1/spots/{id}", tag: "spots", deprecated: true, headers: idempotencyKeyHeader},
+
+	"GET /quadrant":               {summary: "List the quadrants in pages, use GET /v1/quadrants", tag: "quadrants", deprecated: true, query: repository.QuadrantQuery{}, response: repository.QuadrantPage{}},
+	"POST /quadrant/create":       {summary: "Create a quadrant, use POST /v1/quadrants", tag: "quadrants", deprecated: true, body: repository.Quadrant{}, response: repository.Quadrant{}, headers: idempotencyKeyHeader},
+	"GET /quadrant/read/:id":      {summary: "Get a quadrant, use GET /v1/quadrants/{id}", tag: "quadrants", deprecated: true, response: repository.Quadrant{}},
+	"PATCH /quadrant/update":      {summary: "Update the quadrant with the id of the body, use PATCH /v1/quadrants/{id}", tag: "quadrants", deprecated: true, body: repository.Quadrant{}, response: repository.Quadrant{}},
+	"DELETE /quadrant/delete/:id": {summary: "Delete a quadrant, use DELETE /v1/quadrants/{id}", tag: "quadrants", deprecated: true, headers: idempotencyKeyHeader},
+
+	"GET /openapi.json": {summary: "Get this document", tag: "docs", response: map[string]interface{}{}},
+}
+
+// pathParam matches the params of the paths of the router.
+var pathParam = regexp.MustCompile(`:([a-z_]+)`)
+
+// NewOpenAPI creates the OpenAPI document of the routes, the routes without an entry in
+// apiRoutes are left out.
+func NewOpenAPI(routes gin.RoutesInfo) *openapi.Document {
+	doc := openapi.New(openapi.Info{
+		Title:       "Maze challenge",
+		Description: "Mazes split in quadrants with spots of gold, traps and walls.",
+		Version:     "1.0.0",
+	})
+
+	doc.Enum(repository.SpotKind(""), repository.Entrance, repository.Exit, repository.Treasure, repository.Trap, repository.Wall)
+	doc.Enum(repository.QuadrantType(""), repository.TopLeft, repository.TopRight, repository.BottomLeft, repository.BottomRight)
+	doc.Enum(generator.Algorithm(""), generator.Backtracker, generator.Prim, generator.Kruskal, generator.Wilson)
+	doc.Enum(generator.Distribution(""), generator.Uniform, generator.Normal, generator.Exponential)
+
+	for _, r := range routes {
+		ar, ok := apiRoutes[r.Method+" "+r.Path]
+		if !ok {
+			continue
+		}
+
+		// the paths of the actions are static, their colons aren't params
+		for path, action := range ar.actions {
+			action := action
+			doc.Add(r.Method, path, newOperation(doc, "", &action))
+		}
+
+		if len(ar.actions) == 0 {
+			doc.Add(r.Method, pathParam.ReplaceAllString(r.Path, "{$1}"), newOperation(doc, r.Path, &ar))
+		}
+	}
+
+	return doc
+}
+
+// newOperation creates the operation of the route.
+func newOperation(doc *openapi.Document, path string, ar *apiRoute) *openapi.Operation {
+	op := &openapi.Operation{
+		Summary:    ar.summary,
+		Tags:       []string{ar.tag},
+		Deprecated: ar.deprecated,
+		Responses: map[string]openapi.Response{
+			"default": {Description: "error", Content: doc.JSONContent(ErrorResponse{})},
+		},
+	}
+
+	for _, m := range pathParam.FindAllStringSubmatch(path, -1) {
+		op.Parameters = append(op.Parameters, openapi.Parameter{
+			Name:     m[1],
+			In:       "path",
+			Required: true,
+			Schema:   &openapi.Schema{Type: "string"},
+		})
+	}
+
+	if ar.query != nil {
+		op.Parameters = append(op.Parameters, doc.QueryParameters(ar.query, listDescriptions)...)
+		op.Parameters = append(op.Parameters, doc.QueryParameters(repository.ListOptions{}, listDescriptions)...)
+	}
+
+	for _, p := range ar.params {
+		op.Parameters = append(op.Parameters, openapi.Parameter{
+			Name:        p.name,
+			In:          "query",
+			Description: p.description,
+			Schema:      &openapi.Schema{Type: "string"},
+		})
+	}
+
+	for _, h := range ar.headers {
+		op.Parameters = append(op.Parameters, openapi.Parameter{
+			Name:        h.name,
+			In:          "header",
+			Description: h.description,
+			Schema:      &openapi.Schema{Type: "string"},
+		})
+	}
+
+	if ar.body != nil {
+		op.RequestBody = &openapi.RequestBody{Required: true, Content: doc.JSONContent(ar.body)}
+	}
+
+	for _, ct := range ar.consumes {
+		op.RequestBody.Content[ct] = openapi.MediaType{Schema: &openapi.Schema{Type: "string"}}
+	}
+
+	if ar.patch {
+		op.RequestBody.Content[patch.MergePatchType] = op.RequestBody.Content["application/json"]
+		op.RequestBody.Content[patch.JSONPatchType] = openapi.MediaType{Schema: doc.Schema([]patch.Operation{})}
+	}
+
+	ok := openapi.Response{Description: "success"}
+
+	switch {
+	case ar.response != nil:
+		ok.Content = doc.JSONContent(ar.response)
+	case len(ar.produces) > 0:
+		ok.Content = make(map[string]openapi.MediaType, len(ar.produces))
+
+		for _, ct := range ar.produces {
+			ok.Content[ct] = openapi.MediaType{Schema: &openapi.Schema{Type: "string"}}
+		}
+	}
+
+	op.Responses["200"] = ok
+
+	return op
+}
+
+// ServeOpenAPI serves the OpenAPI document of the routes of the router, it is created with
+// the first request once all the routes are registered.
+func ServeOpenAPI(router *gin.Engine) gin.HandlerFunc {
+	var (
+		once sync.Once
+		doc  *openapi.Document
+	)
+
+	return func(c *gin.Context) {
+		once.Do(func() {
+			doc = NewOpenAPI(router.Routes())
+		})
+
+		c.JSON(http.StatusOK, doc)
+	}
+}
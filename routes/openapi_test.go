@@ -0,0 +1,109 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test_OpenAPIRoutes fails when a route is registered without an entry in apiRoutes or an
+// entry is kept after its route is removed.
+func Test_OpenAPIRoutes(t *testing.T) {
+	router := newTestRouter()
+
+	registered := make(map[string]bool)
+
+	for _, r := range router.Routes() {
+		key := r.Method + " " + r.Path
+		registered[key] = true
+
+		_, ok := apiRoutes[key]
+		assert.True(t, ok, "the route %s must be described in apiRoutes", key)
+	}
+
+	for key := range apiRoutes {
+		assert.True(t, registered[key], "the route %s of apiRoutes isn't registered", key)
+	}
+
+	operations := 0
+
+	for _, r := range router.Routes() {
+		if actions := len(apiRoutes[r.Method+" "+r.Path].actions); actions > 0 {
+			operations += actions
+		} else {
+			operations++
+		}
+	}
+
+	doc := NewOpenAPI(router.Routes())
+	assert.Len(t, doc.Operations(), operations)
+	assert.Contains(t, doc.Operations(), "POST /v1/spots:batchCreate")
+}
+
+func Test_OpenAPIDocument(t *testing.T) {
+	router := newTestRouter()
+
+	res := serve(t, router, http.MethodGet, "/openapi.json", nil)
+	assert.Equal(t, 200, res.Code)
+
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]map[string]struct {
+			Deprecated  bool `json:"deprecated"`
+			RequestBody struct {
+				Content map[string]json.RawMessage `json:"content"`
+			} `json:"requestBody"`
+			Parameters []struct {
+				Name string `json:"name"`
+				In   string `json:"in"`
+			} `json:"parameters"`
+		} `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]json.RawMessage `json:"properties"`
+				Required   []string                   `json:"required"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+
+	if err := json.Unmarshal(res.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+
+	get := doc.Paths["/v1/spots/{id}"]["get"]
+	assert.Equal(t, "id", get.Parameters[0].Name)
+	assert.Equal(t, "path", get.Parameters[0].In)
+	assert.True(t, doc.Paths["/spot/read/{id}"]["get"].Deprecated)
+
+	content := doc.Paths["/v1/spots/{id}"]["patch"].RequestBody.Content
+	assert.Contains(t, content, "application/merge-patch+json")
+	assert.Contains(t, content, "application/json-patch+json")
+	assert.Contains(t, doc.Components.Schemas, "Operation")
+
+	names := make([]string, 0)
+	for _, p := range doc.Paths["/v1/spots"]["get"].Parameters {
+		names = append(names, p.Name)
+	}
+
+	assert.Contains(t, names, "name_prefix")
+	assert.Contains(t, names, "cursor")
+
+	spot := doc.Components.Schemas["Spot"]
+	assert.Contains(t, spot.Properties, "gold_amount")
+	assert.Contains(t, spot.Properties, "Coordinate")
+	assert.ElementsMatch(t, []string{"name", "Coordinate"}, spot.Required)
+	assert.Contains(t, doc.Components.Schemas, "Coordinate")
+	assert.Contains(t, doc.Components.Schemas, "ErrorResponse")
+
+	generate := doc.Paths["/maze/generate"]["post"].RequestBody.Content
+	assert.Contains(t, string(generate["application/json"]), "#/components/schemas/Config")
+
+	imported := doc.Paths["/maze/import"]["post"].RequestBody.Content
+	assert.Contains(t, string(imported["application/json"]), "#/components/schemas/Document")
+	assert.Contains(t, imported, "text/csv")
+	assert.NotContains(t, doc.Paths["/maze/{id}"], "post")
+}
@@ -0,0 +1,99 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/PacoDw/maze_challenge/patch"
+	"github.com/PacoDw/maze_challenge/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// applyPatch applies the patch of the body to the stored resource and decodes the result
+// into patched, which is validated like the bodies of the PUT requests. The Content-Type
+// selects the kind of patch, application/json-patch+json is a JSON patch (RFC 6902) while
+// application/merge-patch+json and application/json are a JSON merge patch (RFC 7396).
+func applyPatch(c *gin.Context, entity string, stored, patched interface{}) error {
+	doc, err := json.Marshal(stored)
+	if err != nil {
+		return fmt.Errorf("encoding the %s: %w", entity, err)
+	}
+
+	body, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		return repository.BadRequest("reading the body: %w", err)
+	}
+
+	var result []byte
+
+	switch c.ContentType() {
+	case patch.JSONPatchType:
+		ops := make([]patch.Operation, 0)
+
+		if err := json.Unmarshal(body, &ops); err != nil {
+			return repository.BadRequest("the body must be a JSON patch: %w", err)
+		}
+
+		result, err = patch.Apply(doc, ops)
+	case patch.MergePatchType, binding.MIMEJSON, "":
+		result, err = patch.Merge(doc, body)
+	default:
+		return repository.BadRequest("the Content-Type must be %s or %s", patch.MergePatchType, patch.JSONPatchType)
+	}
+
+	var te *patch.TestError
+
+	switch {
+	case errors.As(err, &te):
+		return &repository.ConflictError{
+			Entity: entity,
+			Fields: []repository.FieldError{{Field: te.Path, Message: "doesn't match the test operation"}},
+		}
+	case err != nil:
+		return badRequest(err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(result))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(patched); err != nil {
+		return repository.BadRequest("the patched %s is invalid: %w", entity, err)
+	}
+
+	if err := binding.Validator.ValidateStruct(patched); err != nil {
+		return badRequest(err)
+	}
+
+	return nil
+}
+
+// legacyTarget represents the attributes of the body of the legacy update routes that
+// select the resource to patch.
+type legacyTarget struct {
+	ID     string                  `json:"id"`
+	MazeID string                  `json:"maze_id"`
+	Type   repository.QuadrantType `json:"type"`
+}
+
+// readLegacyTarget reads the attributes that select the resource from the body of a legacy
+// update route, the body is kept so it can be applied as a merge patch afterwards.
+func readLegacyTarget(c *gin.Context) (*legacyTarget, error) {
+	body, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, repository.BadRequest("reading the body: %w", err)
+	}
+
+	c.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	target := &legacyTarget{}
+
+	if err := json.Unmarshal(body, target); err != nil {
+		return nil, repository.BadRequest("the body must be a JSON object: %w", err)
+	}
+
+	return target, nil
+}
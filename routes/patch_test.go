@@ -0,0 +1,179 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/PacoDw/maze_challenge/patch"
+	"github.com/PacoDw/maze_challenge/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// servePatch sends a PATCH request with the raw body and its content type to the router.
+func servePatch(router *gin.Engine, path, contentType, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPatch, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", contentType)
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	return res
+}
+
+// newPatchMaze creates a maze of 20x20 with a spot in its top left quadrant.
+func newPatchMaze(t *testing.T, router *gin.Engine) (repository.Maze, repository.Spot) {
+	t.Helper()
+
+	var (
+		maze repository.Maze
+		spot repository.Spot
+	)
+
+	res := serve(t, router, http.MethodPost, "/maze", repository.Maze{Name: "labyrinth", Width: 20, Height: 20})
+	if err := json.Unmarshal(res.Body.Bytes(), &maze); err != nil {
+		t.Fatal(err)
+	}
+
+	res = serve(t, router, http.MethodPost, "/v1/spots", repository.Spot{
+		MazeID:     maze.ID,
+		Name:       "gold",
+		GoldAmount: 10,
+		Coordinate: &repository.Coordinate{X: 2, Y: 3},
+	})
+	if err := json.Unmarshal(res.Body.Bytes(), &spot); err != nil {
+		t.Fatal(err)
+	}
+
+	return maze, spot
+}
+
+func Test_PatchSpot(t *testing.T) {
+	router := newTestRouter()
+	_, spot := newPatchMaze(t, router)
+	path := "/v1/spots/" + spot.ID
+
+	// the zero values are set and the members that aren't sent are kept
+	res := servePatch(router, path, patch.MergePatchType, `{"gold_amount":0,"Coordinate":{"x":0}}`)
+	assert.Equal(t, 200, res.Code)
+
+	var got repository.Spot
+
+	if err := json.Unmarshal(res.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, uint(0), got.GoldAmount)
+	assert.Equal(t, repository.Coordinate{X: 0, Y: 3}, *got.Coordinate)
+	assert.Equal(t, "gold", got.Name)
+
+	// application/json is taken as a merge patch
+	res = servePatch(router, path, "application/json", `{"name":"silver"}`)
+	assert.Equal(t, 200, res.Code)
+	assert.Contains(t, res.Body.String(), `"name":"silver"`)
+
+	res = servePatch(router, path, patch.JSONPatchType, `[
+		{"op":"test","path":"/gold_amount","value":0},
+		{"op":"replace","path":"/gold_amount","value":25},
+		{"op":"replace","path":"/Coordinate/y","value":0}
+	]`)
+	assert.Equal(t, 200, res.Code)
+	assert.Contains(t, res.Body.String(), `"gold_amount":25`)
+	assert.Contains(t, res.Body.String(), `"Coordinate":{"x":0,"y":0}`)
+
+	// a failed test operation means that the spot changed after it was read
+	res = servePatch(router, path, patch.JSONPatchType, `[{"op":"test","path":"/gold_amount","value":0}]`)
+	assert.Equal(t, 409, res.Code)
+	assert.Contains(t, res.Body.String(), `"field":"/gold_amount"`)
+
+	// the result is validated like a replacement
+	res = servePatch(router, path, patch.MergePatchType, `{"name":null}`)
+	assert.Equal(t, 400, res.Code)
+
+	res = servePatch(router, path, patch.MergePatchType, `{"kind":"TRAP"}`)
+	assert.Equal(t, 400, res.Code)
+	assert.Contains(t, res.Body.String(), `"field":"penalty"`)
+
+	res = servePatch(router, path, patch.MergePatchType, `{"color":"red"}`)
+	assert.Equal(t, 400, res.Code)
+
+	res = servePatch(router, path, patch.JSONPatchType, `[{"op":"remove","path":"/penalty"}]`)
+	assert.Equal(t, 400, res.Code)
+
+	res = servePatch(router, path, "text/plain", `{"name":"copper"}`)
+	assert.Equal(t, 400, res.Code)
+
+	// the spot is placed in the quadrant that contains its new coordinate
+	res = servePatch(router, path, patch.MergePatchType, `{"Coordinate":{"x":15,"y":15}}`)
+	assert.Equal(t, 200, res.Code)
+
+	if err := json.Unmarshal(res.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NotEqual(t, spot.QuadrantID, got.QuadrantID)
+
+	res = servePatch(router, "/v1/spots/000000000000000000000000", patch.MergePatchType, `{}`)
+	assert.Equal(t, 404, res.Code)
+}
+
+func Test_ReplaceSpot(t *testing.T) {
+	router := newTestRouter()
+	_, spot := newPatchMaze(t, router)
+
+	res := serve(t, router, http.MethodPut, "/v1/spots/"+spot.ID, map[string]interface{}{
+		"name":       "trap",
+		"kind":       repository.Trap,
+		"penalty":    3,
+		"Coordinate": repository.Coordinate{X: 0, Y: 0},
+	})
+	assert.Equal(t, 200, res.Code)
+
+	var got repository.Spot
+
+	if err := json.Unmarshal(res.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	// the gold that wasn't sent is replaced by zero
+	assert.Equal(t, uint(0), got.GoldAmount)
+	assert.Equal(t, repository.Trap, got.Kind)
+	assert.Equal(t, repository.Coordinate{}, *got.Coordinate)
+
+	res = serve(t, router, http.MethodPut, "/v1/spots/"+spot.ID, map[string]interface{}{
+		"name":       "gold",
+		"maze_id":    "000000000000000000000000",
+		"Coordinate": repository.Coordinate{X: 1, Y: 1},
+	})
+	assert.Equal(t, 400, res.Code)
+	assert.Contains(t, res.Body.String(), `"field":"maze_id"`)
+}
+
+func Test_PatchQuadrant(t *testing.T) {
+	router := newTestRouter()
+	_, spot := newPatchMaze(t, router)
+	path := "/v1/quadrants/" + spot.QuadrantID
+
+	// the quadrants must keep covering the maze
+	res := servePatch(router, path, patch.JSONPatchType, `[{"op":"replace","path":"/limit_point/x","value":5}]`)
+	assert.Equal(t, 400, res.Code)
+	assert.Contains(t, res.Body.String(), `"code":"VALIDATION_FAILED"`)
+
+	res = servePatch(router, path, patch.MergePatchType, `{"spot_ids":["000000000000000000000000"]}`)
+	assert.Equal(t, 400, res.Code)
+	assert.Contains(t, res.Body.String(), `"field":"spot_ids"`)
+
+	res = servePatch(router, path, patch.JSONPatchType, `[{"op":"test","path":"/type","value":"TOP_RIGHT"}]`)
+	assert.Equal(t, 409, res.Code)
+
+	// the spots removed from the quadrant are deleted
+	res = servePatch(router, path, patch.JSONPatchType, `[{"op":"remove","path":"/spot_ids/0"}]`)
+	assert.Equal(t, 200, res.Code)
+	assert.NotContains(t, res.Body.String(), spot.ID)
+
+	res = serve(t, router, http.MethodGet, "/v1/spots/"+spot.ID, nil)
+	assert.Equal(t, 404, res.Code)
+}
@@ -0,0 +1,77 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/PacoDw/maze_challenge/repository"
+	"github.com/PacoDw/maze_challenge/solver"
+	"github.com/gin-gonic/gin"
+)
+
+// GetMazePath finds the shortest path between two spots of a maze, e.g.
+// ?from=<spot_id>&to=<spot_id>, the query param algorithm can be bfs or astar (default).
+var GetMazePath = func(c *gin.Context) {
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
+	if !ok {
+		respondError(c, repository.ErrNoConnection)
+
+		return
+	}
+
+	if c.Query("from") == "" || c.Query("to") == "" {
+		respondError(c, repository.BadRequest("query params from and to must not be empty"))
+
+		return
+	}
+
+	s, err := solver.Load(c, repo, c.Param("id"))
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	path, err := s.SolveSpots(solver.Algorithm(c.Query("algorithm")), c.Query("from"), c.Query("to"))
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, path)
+}
+
+// GetMazeRoute finds the route that collects the most gold from the entrance to an exit
+// of a maze within a number of steps, e.g. ?steps=100.
+var GetMazeRoute = func(c *gin.Context) {
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
+	if !ok {
+		respondError(c, repository.ErrNoConnection)
+
+		return
+	}
+
+	steps, err := strconv.Atoi(c.Query("steps"))
+	if err != nil {
+		respondError(c, repository.BadRequest("query param steps must be a number"))
+
+		return
+	}
+
+	s, err := solver.Load(c, repo, c.Param("id"))
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	route, err := s.PlanRoute(steps)
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, route)
+}
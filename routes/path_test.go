@@ -0,0 +1,127 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/PacoDw/maze_challenge/repository"
+	"github.com/PacoDw/maze_challenge/solver"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MazePath(t *testing.T) {
+	maze := &repository.Maze{Name: "labyrinth", Width: 4, Height: 4}
+
+	if _, err := testRepo.Maze.Create(context.Background(), maze); err != nil {
+		t.Fatal(err)
+	}
+
+	entrance := &repository.Spot{
+		MazeID:     maze.ID,
+		Name:       "entrance",
+		Kind:       repository.Entrance,
+		Coordinate: &repository.Coordinate{X: 0, Y: 0},
+	}
+
+	exit := &repository.Spot{
+		MazeID:     maze.ID,
+		Name:       "exit",
+		Kind:       repository.Exit,
+		Coordinate: &repository.Coordinate{X: 3, Y: 3},
+	}
+
+	for _, s := range []*repository.Spot{entrance, exit} {
+		if _, err := testRepo.Spot.Create(context.Background(), s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	param := gin.Param{Key: "id", Value: maze.ID}
+
+	t.Run("find the path", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/path?from="+entrance.ID+"&to="+exit.ID, bytes.NewBuffer([]byte{}))
+		res := makeScopedRequest(req, GetMazePath, param)
+
+		assert.Equal(t, 200, res.Code)
+
+		var path solver.Path
+		err := json.Unmarshal(res.Body.Bytes(), &path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, 6, path.Steps)
+		assert.Len(t, path.Coordinates, 7)
+		assert.Equal(t, maze.QuadrantIDs[0], path.Quadrants[0].ID)
+		assert.Equal(t, repository.BottomRight, path.Quadrants[len(path.Quadrants)-1].Type)
+	})
+
+	t.Run("missing spots", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/path?from="+entrance.ID, bytes.NewBuffer([]byte{}))
+		res := makeScopedRequest(req, GetMazePath, param)
+
+		assert.Equal(t, 400, res.Code)
+
+		req = httptest.NewRequest(http.MethodGet, "/path?from="+entrance.ID+"&to=000000000000000000000000", bytes.NewBuffer([]byte{}))
+		res = makeScopedRequest(req, GetMazePath, param)
+
+		assert.Equal(t, 400, res.Code)
+	})
+}
+
+func Test_MazeRoute(t *testing.T) {
+	maze := &repository.Maze{Name: "labyrinth", Width: 4, Height: 4}
+
+	if _, err := testRepo.Maze.Create(context.Background(), maze); err != nil {
+		t.Fatal(err)
+	}
+
+	spots := []*repository.Spot{
+		{MazeID: maze.ID, Name: "entrance", Kind: repository.Entrance, Coordinate: &repository.Coordinate{X: 0, Y: 0}},
+		{MazeID: maze.ID, Name: "exit", Kind: repository.Exit, Coordinate: &repository.Coordinate{X: 3, Y: 0}},
+		{MazeID: maze.ID, Name: "treasure", GoldAmount: 30, Coordinate: &repository.Coordinate{X: 0, Y: 3}},
+	}
+
+	for _, s := range spots {
+		if _, err := testRepo.Spot.Create(context.Background(), s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	param := gin.Param{Key: "id", Value: maze.ID}
+
+	t.Run("plan the route", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/route?steps=9", bytes.NewBuffer([]byte{}))
+		res := makeScopedRequest(req, GetMazeRoute, param)
+
+		assert.Equal(t, 200, res.Code)
+
+		var route solver.Route
+		err := json.Unmarshal(res.Body.Bytes(), &route)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, uint(30), route.GoldAmount)
+		assert.Equal(t, 9, route.Steps)
+		assert.Len(t, route.Stops, 3)
+		assert.Equal(t, spots[2].ID, route.Stops[1].SpotID)
+	})
+
+	t.Run("without enough steps", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/route?steps=2", bytes.NewBuffer([]byte{}))
+		res := makeScopedRequest(req, GetMazeRoute, param)
+
+		assert.Equal(t, 400, res.Code)
+
+		req = httptest.NewRequest(http.MethodGet, "/route", bytes.NewBuffer([]byte{}))
+		res = makeScopedRequest(req, GetMazeRoute, param)
+
+		assert.Equal(t, 400, res.Code)
+	})
+}
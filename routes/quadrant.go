@@ -1,7 +1,6 @@
 package routes
 
 import (
-	"errors"
 	"net/http"
 
 	"github.com/PacoDw/maze_challenge/repository"
@@ -10,11 +9,9 @@ import (
 
 // CreateQuadrant creates a quadrant.
 var CreateQuadrant = func(c *gin.Context) {
-	repo, ok := c.MustGet("mongoRepoConn").(*repository.MongoDBService)
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": errors.New("no connection with database").Error(),
-		})
+		respondError(c, repository.ErrNoConnection)
 
 		return
 	}
@@ -22,44 +19,51 @@ var CreateQuadrant = func(c *gin.Context) {
 	quadrant := &repository.Quadrant{}
 
 	if err := c.ShouldBindJSON(quadrant); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, badRequest(err))
 
 		return
 	}
 
 	id, err := repo.Quadrant.Create(c, quadrant)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, err)
 
 		return
 	}
 
 	quadrant.ID = id
 
+	setETag(c, quadrant.Version)
 	c.JSON(http.StatusOK, quadrant)
 }
 
 // GetQuadrant creates a quadrant.
 var GetQuadrant = func(c *gin.Context) {
-	repo, ok := c.MustGet("mongoRepoConn").(*repository.MongoDBService)
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": errors.New("no connection with database").Error(),
-		})
+		respondError(c, repository.ErrNoConnection)
 
 		return
 	}
 
 	id := c.Param("id")
 	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": errors.New("param :id must not be empty")})
+		respondError(c, repository.BadRequest("param :id must not be empty"))
 
 		return
 	}
 
 	quadrant, err := repo.Quadrant.Get(c, &repository.QuadrantFilter{ID: id})
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, err)
+
+		return
+	}
+
+	setETag(c, quadrant.Version)
+
+	if notModified(c, quadrant.Version) {
+		c.Status(http.StatusNotModified)
 
 		return
 	}
@@ -67,59 +71,199 @@ var GetQuadrant = func(c *gin.Context) {
 	c.JSON(http.StatusOK, quadrant)
 }
 
-// UpdateQuadrant updates a quadrant.
+// UpdateQuadrant applies the body as a JSON merge patch to the quadrant with the id of the
+// body like PatchQuadrant does, so the attributes that aren't sent are left unchanged.
+// Without an id the quadrant is found by its maze_id and type.
 var UpdateQuadrant = func(c *gin.Context) {
-	repo, ok := c.MustGet("mongoRepoConn").(*repository.MongoDBService)
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": errors.New("no connection with database").Error(),
-		})
+		respondError(c, repository.ErrNoConnection)
 
 		return
 	}
 
-	quadrant := &repository.Quadrant{}
-
-	if err := c.ShouldBindJSON(quadrant); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	target, err := readLegacyTarget(c)
+	if err != nil {
+		respondError(c, err)
 
 		return
 	}
 
-	quadrant, err := repo.Quadrant.Update(c, quadrant)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	id := target.ID
 
-		return
+	if id == "" {
+		stored, err := legacyQuadrant(c, repo, target)
+		if err != nil {
+			respondError(c, err)
+
+			return
+		}
+
+		id = stored.ID
 	}
 
-	c.JSON(http.StatusOK, quadrant)
+	patchQuadrant(c, repo, id)
+}
+
+// legacyQuadrant finds the quadrant of the type in the maze of the target, the type of a
+// quadrant is only unique in its maze.
+func legacyQuadrant(c *gin.Context, repo *repository.Repository, target *legacyTarget) (*repository.Quadrant, error) {
+	ve := &repository.ValidationError{Entity: "quadrant"}
+
+	switch {
+	case target.Type == "":
+		ve.Fields = append(ve.Fields, repository.FieldError{Field: "id", Message: "must be specified"})
+	case target.MazeID == "":
+		ve.Fields = append(ve.Fields, repository.FieldError{Field: "maze_id", Message: "must be specified to find the quadrant by type"})
+	default:
+		return repo.Quadrant.Get(c, &repository.QuadrantFilter{MazeID: target.MazeID, Type: target.Type})
+	}
+
+	return nil, ve
 }
 
 // DeleteQuadrant deletes a quadrant.
 var DeleteQuadrant = func(c *gin.Context) {
-	repo, ok := c.MustGet("mongoRepoConn").(*repository.MongoDBService)
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": errors.New("no connection with database").Error(),
-		})
+		respondError(c, repository.ErrNoConnection)
 
 		return
 	}
 
 	id := c.Param("id")
 	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": errors.New("param :id must not be empty")})
+		respondError(c, repository.BadRequest("param :id must not be empty"))
+
+		return
+	}
+
+	expected, err := ifMatch(c)
+	if err != nil {
+		respondError(c, err)
 
 		return
 	}
 
-	_, err := repo.Quadrant.Delete(c, &repository.QuadrantFilter{ID: id})
+	// the version required by If-Match is checked by the delete itself
+	isRemoved, err := repo.Quadrant.Delete(c, &repository.QuadrantFilter{ID: id, Version: expected})
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, conditional(c, err))
+
+		return
+	}
+
+	if !isRemoved {
+		respondError(c, &repository.NotFoundError{Entity: "quadrant", ID: id})
 
 		return
 	}
 
 	c.JSON(http.StatusOK, "{}")
 }
+
+// PatchQuadrant applies a JSON merge patch or a JSON patch to the stored quadrant and
+// replaces it with the result, the id is taken from the path. The replacement requires
+// the version that was patched so it fails when another write happened meanwhile.
+var PatchQuadrant = func(c *gin.Context) {
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
+	if !ok {
+		respondError(c, repository.ErrNoConnection)
+
+		return
+	}
+
+	patchQuadrant(c, repo, c.Param("id"))
+}
+
+// patchQuadrant applies the patch of the body to the quadrant with the id and responds
+// the result.
+func patchQuadrant(c *gin.Context, repo *repository.Repository, id string) {
+	expected, err := ifMatch(c)
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	stored, err := repo.Quadrant.Get(c, &repository.QuadrantFilter{ID: id})
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	if err := checkIfMatch("quadrant", id, stored.Version, expected); err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	quadrant := &repository.Quadrant{}
+
+	if err := applyPatch(c, "quadrant", stored, quadrant); err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	// the version of If-Match takes precedence over the one set by the patch
+	if expected != 0 {
+		quadrant.Version = expected
+	}
+
+	quadrant.ID = id
+
+	quadrant, err = repo.Quadrant.Replace(c, quadrant)
+	if err != nil {
+		respondError(c, conditional(c, err))
+
+		return
+	}
+
+	setETag(c, quadrant.Version)
+	c.JSON(http.StatusOK, quadrant)
+}
+
+// ReplaceQuadrant replaces the quadrant with the body, the spots that aren't in its
+// spot_ids are deleted and the id is taken from the path.
+var ReplaceQuadrant = func(c *gin.Context) {
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
+	if !ok {
+		respondError(c, repository.ErrNoConnection)
+
+		return
+	}
+
+	quadrant := &repository.Quadrant{}
+
+	if err := c.ShouldBindJSON(quadrant); err != nil {
+		respondError(c, badRequest(err))
+
+		return
+	}
+
+	expected, err := ifMatch(c)
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	// the version of If-Match takes precedence over the one of the body
+	if expected != 0 {
+		quadrant.Version = expected
+	}
+
+	quadrant.ID = c.Param("id")
+
+	quadrant, err = repo.Quadrant.Replace(c, quadrant)
+	if err != nil {
+		respondError(c, conditional(c, err))
+
+		return
+	}
+
+	setETag(c, quadrant.Version)
+	c.JSON(http.StatusOK, quadrant)
+}
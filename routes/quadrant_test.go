@@ -13,8 +13,6 @@ import (
 )
 
 func Test_QuadrantCreateDelete(t *testing.T) {
-	Test_EnvMongoDBConnectionString(t)
-
 	var quadrantID string
 
 	// Create a quadrant
@@ -49,6 +47,7 @@ func Test_QuadrantCreateDelete(t *testing.T) {
 		}
 
 		expected.ID = got.ID
+		expected.Version = 1
 
 		assert.Equal(t, expected, got)
 
@@ -67,8 +66,6 @@ func Test_QuadrantCreateDelete(t *testing.T) {
 }
 
 func Test_QuadrantGet(t *testing.T) {
-	Test_EnvMongoDBConnectionString(t)
-
 	var (
 		expectedBody []byte
 		quadrantID   string
@@ -135,8 +132,6 @@ func Test_QuadrantGet(t *testing.T) {
 }
 
 func Test_QuadrantUpdate(t *testing.T) {
-	Test_EnvMongoDBConnectionString(t)
-
 	var quadrantID string
 
 	// Create a quadrant
@@ -199,9 +194,37 @@ func Test_QuadrantUpdate(t *testing.T) {
 			t.Fatal(err)
 		}
 
+		expected.Version = 2
+
 		assert.Equal(t, expected, got)
 	})
 
+	// the body is a merge patch, so the attributes that aren't sent are kept
+	t.Run("update a quadrant partially", func(t *testing.T) {
+		body := fmt.Sprintf(`{"id":%q,"start_point":{"x":4,"y":2}}`, quadrantID)
+
+		req := httptest.NewRequest(http.MethodPatch, "/update", bytes.NewBufferString(body))
+		res := makeRequest(req, UpdateQuadrant)
+
+		assert.Equal(t, 200, res.Code)
+
+		var got repository.Quadrant
+		if err := json.Unmarshal(res.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, repository.BottomLeft, got.Type)
+		assert.Equal(t, &repository.Coordinate{X: 4, Y: 2}, got.StartPoint)
+		assert.Equal(t, &repository.Coordinate{X: 25, Y: 25}, got.LimitPoint)
+
+		// the type of a quadrant is only unique in its maze
+		req = httptest.NewRequest(http.MethodPatch, "/update", bytes.NewBufferString(`{"type":"BOTTOM_LEFT"}`))
+		res = makeRequest(req, UpdateQuadrant)
+
+		assert.Equal(t, 400, res.Code)
+		assert.Contains(t, res.Body.String(), `"field":"maze_id"`)
+	})
+
 	// Remove the quadrant
 	t.Run("remove a quadrant", func(t *testing.T) {
 		url := fmt.Sprintf("/delete/%s", quadrantID)
@@ -0,0 +1,54 @@
+package routes
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/PacoDw/maze_challenge/render"
+	"github.com/PacoDw/maze_challenge/repository"
+	"github.com/PacoDw/maze_challenge/solver"
+	"github.com/gin-gonic/gin"
+)
+
+// RenderMaze draws a maze as text, SVG or PNG, e.g. ?format=svg, the path between two
+// spots is drawn as well when the query params from and to are given.
+var RenderMaze = func(c *gin.Context) {
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
+	if !ok {
+		respondError(c, repository.ErrNoConnection)
+
+		return
+	}
+
+	s, err := solver.Load(c, repo, c.Param("id"))
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	scene := &render.Scene{Grid: s.Grid(), Quadrants: s.Quadrants(), Spots: s.Spots()}
+
+	if c.Query("from") != "" || c.Query("to") != "" {
+		path, err := s.SolveSpots(solver.Algorithm(c.Query("algorithm")), c.Query("from"), c.Query("to"))
+		if err != nil {
+			respondError(c, err)
+
+			return
+		}
+
+		scene.Path = path.Coordinates
+	}
+
+	format := render.Format(c.DefaultQuery("format", string(render.Text)))
+
+	var buf bytes.Buffer
+
+	if err := render.Render(&buf, format, scene); err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	c.Data(http.StatusOK, format.ContentType(), buf.Bytes())
+}
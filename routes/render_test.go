@@ -0,0 +1,58 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/PacoDw/maze_challenge/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RenderMaze(t *testing.T) {
+	maze := &repository.Maze{Name: "labyrinth", Width: 4, Height: 4}
+
+	if _, err := testRepo.Maze.Create(context.Background(), maze); err != nil {
+		t.Fatal(err)
+	}
+
+	entrance := &repository.Spot{MazeID: maze.ID, Name: "entrance", Kind: repository.Entrance, Coordinate: &repository.Coordinate{X: 0, Y: 0}}
+	exit := &repository.Spot{MazeID: maze.ID, Name: "exit", Kind: repository.Exit, Coordinate: &repository.Coordinate{X: 3, Y: 0}}
+
+	for _, s := range []*repository.Spot{entrance, exit} {
+		if _, err := testRepo.Spot.Create(context.Background(), s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	param := gin.Param{Key: "id", Value: maze.ID}
+	path := "&from=" + entrance.ID + "&to=" + exit.ID
+
+	tests := []struct {
+		query       string
+		code        int
+		contentType string
+		contains    string
+	}{
+		{query: "?format=txt" + path, code: 200, contentType: "text/plain; charset=utf-8", contains: "| E   . : .   X |"},
+		{query: "?format=svg" + path, code: 200, contentType: "image/svg+xml", contains: "<polyline"},
+		{query: "?format=png", code: 200, contentType: "image/png", contains: "PNG"},
+		{query: "?format=gif", code: 400},
+		{query: "?from=" + entrance.ID, code: 400},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/render"+tt.query, bytes.NewBuffer([]byte{}))
+		res := makeScopedRequest(req, RenderMaze, param)
+
+		assert.Equal(t, tt.code, res.Code, tt.query)
+
+		if tt.code == 200 {
+			assert.Equal(t, tt.contentType, res.Header().Get("Content-Type"), tt.query)
+			assert.Contains(t, res.Body.String(), tt.contains, tt.query)
+		}
+	}
+}
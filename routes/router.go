@@ -0,0 +1,85 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// Register adds all the routes of the API to the router, the routes that create or delete
+// resources accept the Idempotency-Key header so they can be retried.
+func Register(router *gin.Engine) {
+	router.GET("/openapi.json", ServeOpenAPI(router))
+
+	maze := router.Group("/maze")
+	{
+		maze.POST("", Idempotent(), CreateMaze)
+		maze.POST("/:id", Idempotent(), MazeAction)
+		maze.GET("", ListMazes)
+		maze.GET("/:id", GetMaze)
+		maze.PATCH("/:id", UpdateMaze)
+		maze.DELETE("/:id", Idempotent(), DeleteMaze)
+
+		maze.GET("/:id/quadrant", ListMazeQuadrants)
+		maze.GET("/:id/quadrant/:quadrant_id", GetMazeQuadrant)
+		maze.PATCH("/:id/quadrant/:quadrant_id", UpdateMazeQuadrant)
+
+		maze.POST("/:id/spot", Idempotent(), CreateMazeSpot)
+		maze.GET("/:id/spot", ListMazeSpots)
+		maze.GET("/:id/spot/:spot_id", GetMazeSpot)
+		maze.PATCH("/:id/spot/:spot_id", UpdateMazeSpot)
+		maze.DELETE("/:id/spot/:spot_id", Idempotent(), DeleteMazeSpot)
+
+		maze.GET("/:id/gold", GetMazeGold)
+		maze.GET("/:id/richest", ListRichestMazeSpots)
+		maze.GET("/:id/within", ListMazeSpotsWithin)
+		maze.GET("/:id/nearest", ListNearestMazeSpots)
+
+		maze.GET("/:id/cells", GetMazeCells)
+		maze.PATCH("/:id/cells", UpdateMazeCells)
+
+		maze.GET("/:id/path", GetMazePath)
+		maze.GET("/:id/route", GetMazeRoute)
+
+		maze.GET("/:id/validate", ValidateMaze)
+		maze.GET("/:id/render", RenderMaze)
+		maze.GET("/:id/export", ExportMaze)
+		maze.POST("/:id/publish", PublishMaze)
+		maze.DELETE("/:id/publish", UnpublishMaze)
+	}
+
+	v1 := router.Group("/v1")
+	{
+		v1.POST("/spots", Idempotent(), CreateSpot)
+		v1.POST("/spots:action", Idempotent(), SpotBatchAction)
+		v1.GET("/spots", ListSpots)
+		v1.GET("/spots/:id", GetSpot)
+		v1.PATCH("/spots/:id", PatchSpot)
+		v1.PUT("/spots/:id", ReplaceSpot)
+		v1.DELETE("/spots/:id", Idempotent(), DeleteSpot)
+
+		v1.POST("/quadrants", Idempotent(), CreateQuadrant)
+		v1.GET("/quadrants", ListQuadrants)
+		v1.GET("/quadrants/:id", GetQuadrant)
+		v1.PATCH("/quadrants/:id", PatchQuadrant)
+		v1.PUT("/quadrants/:id", ReplaceQuadrant)
+		v1.DELETE("/quadrants/:id", Idempotent(), DeleteQuadrant)
+	}
+
+	// The paths of the first version of the API are kept until its clients move to /v1
+	spot := router.Group("/spot", Deprecated("/v1/spots"))
+	{
+		spot.GET("", ListSpots)
+		spot.POST("/create", Idempotent(), CreateSpot)
+		spot.GET("/read/:id", GetSpot)
+		spot.PATCH("/update", UpdateSpot)
+		spot.DELETE("/delete/:id", Idempotent(), DeleteSpot)
+	}
+
+	quadrant := router.Group("/quadrant", Deprecated("/v1/quadrants"))
+	{
+		quadrant.GET("", ListQuadrants)
+		quadrant.POST("/create", Idempotent(), CreateQuadrant)
+		quadrant.GET("/read/:id", GetQuadrant)
+		quadrant.PATCH("/update", UpdateQuadrant)
+		quadrant.DELETE("/delete/:id", Idempotent(), DeleteQuadrant)
+	}
+}
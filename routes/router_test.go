@@ -0,0 +1,143 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/PacoDw/maze_challenge/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestRouter returns a router with all the routes backed by the test repository.
+func newTestRouter() *gin.Engine {
+	router := gin.New()
+	router.Use(RequestID(), repository.GinMiddleware(testRepo))
+
+	Register(router)
+
+	return router
+}
+
+// serve sends a request with the body encoded as JSON to the router.
+func serve(t *testing.T, router *gin.Engine, method, path string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+
+	raw := []byte{}
+
+	if body != nil {
+		var err error
+
+		if raw, err = json.Marshal(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req := httptest.NewRequest(method, path, bytes.NewBuffer(raw))
+	req.Header.Set("Content-Type", "application/json")
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	return res
+}
+
+func Test_V1Spots(t *testing.T) {
+	router := newTestRouter()
+
+	var maze repository.Maze
+
+	res := serve(t, router, http.MethodPost, "/maze", repository.Maze{Name: "labyrinth", Width: 20, Height: 20})
+	assert.Equal(t, 200, res.Code)
+
+	if err := json.Unmarshal(res.Body.Bytes(), &maze); err != nil {
+		t.Fatal(err)
+	}
+
+	var spot repository.Spot
+
+	res = serve(t, router, http.MethodPost, "/v1/spots", repository.Spot{
+		MazeID:     maze.ID,
+		Name:       "gold",
+		GoldAmount: 10,
+		Coordinate: &repository.Coordinate{X: 2, Y: 3},
+	})
+	assert.Equal(t, 200, res.Code)
+	assert.Empty(t, res.Header().Get("Deprecation"))
+
+	if err := json.Unmarshal(res.Body.Bytes(), &spot); err != nil {
+		t.Fatal(err)
+	}
+
+	res = serve(t, router, http.MethodGet, "/v1/spots/"+spot.ID, nil)
+	assert.Equal(t, 200, res.Code)
+
+	// only the attributes sent are changed
+	res = serve(t, router, http.MethodPatch, "/v1/spots/"+spot.ID, map[string]interface{}{"gold_amount": 30})
+	assert.Equal(t, 200, res.Code)
+
+	if err := json.Unmarshal(res.Body.Bytes(), &spot); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, uint(30), spot.GoldAmount)
+	assert.Equal(t, "gold", spot.Name)
+
+	// the replacement must contain the required attributes
+	res = serve(t, router, http.MethodPut, "/v1/spots/"+spot.ID, map[string]interface{}{"gold_amount": 40})
+	assert.Equal(t, 400, res.Code)
+
+	res = serve(t, router, http.MethodPut, "/v1/spots/"+spot.ID, repository.Spot{
+		Name:       "more gold",
+		GoldAmount: 40,
+		Coordinate: &repository.Coordinate{X: 2, Y: 4},
+	})
+	assert.Equal(t, 200, res.Code)
+
+	res = serve(t, router, http.MethodGet, "/v1/spots?maze_id="+maze.ID, nil)
+	assert.Equal(t, 200, res.Code)
+	assert.Contains(t, res.Body.String(), `"name":"more gold"`)
+
+	res = serve(t, router, http.MethodDelete, "/v1/spots/"+spot.ID, nil)
+	assert.Equal(t, 200, res.Code)
+
+	res = serve(t, router, http.MethodGet, "/v1/spots/"+spot.ID, nil)
+	assert.Equal(t, 404, res.Code)
+}
+
+func Test_V1Quadrants(t *testing.T) {
+	router := newTestRouter()
+
+	var maze repository.Maze
+
+	res := serve(t, router, http.MethodPost, "/maze", repository.Maze{Name: "labyrinth", Width: 20, Height: 20})
+	if err := json.Unmarshal(res.Body.Bytes(), &maze); err != nil {
+		t.Fatal(err)
+	}
+
+	res = serve(t, router, http.MethodGet, "/v1/quadrants?maze_id="+maze.ID, nil)
+	assert.Equal(t, 200, res.Code)
+	assert.Contains(t, res.Body.String(), `"total":4`)
+
+	res = serve(t, router, http.MethodGet, "/v1/quadrants/"+maze.QuadrantIDs[0], nil)
+	assert.Equal(t, 200, res.Code)
+
+	res = serve(t, router, http.MethodPatch, "/v1/quadrants/000000000000000000000000", map[string]interface{}{})
+	assert.Equal(t, 404, res.Code)
+}
+
+func Test_DeprecatedRoutes(t *testing.T) {
+	router := newTestRouter()
+
+	res := serve(t, router, http.MethodGet, "/spot/read/000000000000000000000000", nil)
+	assert.Equal(t, 404, res.Code)
+	assert.Equal(t, "true", res.Header().Get("Deprecation"))
+	assert.Equal(t, `</v1/spots>; rel="successor-version"`, res.Header().Get("Link"))
+
+	res = serve(t, router, http.MethodGet, "/quadrant/read/000000000000000000000000", nil)
+	assert.Equal(t, "true", res.Header().Get("Deprecation"))
+	assert.Equal(t, `</v1/quadrants>; rel="successor-version"`, res.Header().Get("Link"))
+}
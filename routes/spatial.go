@@ -0,0 +1,114 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/PacoDw/maze_challenge/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultNearestLimit is the number of spots returned by ListNearestMazeSpots when the
+// limit isn't given.
+const defaultNearestLimit = 5
+
+// ListMazeSpotsWithin gets the spots of a maze inside a box, e.g. ?start=0,0&limit=10,10,
+// or within a distance of a point, e.g. ?center=5,5&radius=3. The query param kind can be
+// used to filter them.
+var ListMazeSpotsWithin = func(c *gin.Context) {
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
+	if !ok {
+		respondError(c, repository.ErrNoConnection)
+
+		return
+	}
+
+	filter := &repository.SpotFilter{MazeID: c.Param("id"), Kind: repository.SpotKind(c.Query("kind"))}
+
+	var (
+		spots []repository.Spot
+		err   error
+	)
+
+	switch {
+	case c.Query("center") != "":
+		var center repository.Coordinate
+
+		center, err = parseCoordinate(c.Query("center"))
+		if err != nil {
+			break
+		}
+
+		var radius float64
+
+		radius, err = strconv.ParseFloat(c.Query("radius"), 64)
+		if err != nil {
+			err = repository.BadRequest("query param radius must be a number")
+
+			break
+		}
+
+		spots, err = repo.Spot.InRadius(c, filter, center, radius)
+	case c.Query("start") != "":
+		var start, limit repository.Coordinate
+
+		if start, err = parseCoordinate(c.Query("start")); err != nil {
+			break
+		}
+
+		if limit, err = parseCoordinate(c.Query("limit")); err != nil {
+			break
+		}
+
+		spots, err = repo.Spot.InBox(c, filter, &repository.Region{StartPoint: start, LimitPoint: limit})
+	default:
+		err = repository.BadRequest("query params start and limit or center and radius must be specified")
+	}
+
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, spots)
+}
+
+// ListNearestMazeSpots gets the spots of a maze closer to a point sorted by distance, e.g.
+// ?point=5,5&limit=3&kind=TREASURE.
+var ListNearestMazeSpots = func(c *gin.Context) {
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
+	if !ok {
+		respondError(c, repository.ErrNoConnection)
+
+		return
+	}
+
+	point, err := parseCoordinate(c.Query("point"))
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	limit := defaultNearestLimit
+
+	if l := c.Query("limit"); l != "" {
+		if limit, err = strconv.Atoi(l); err != nil {
+			respondError(c, repository.BadRequest("query param limit must be a number"))
+
+			return
+		}
+	}
+
+	filter := &repository.SpotFilter{MazeID: c.Param("id"), Kind: repository.SpotKind(c.Query("kind"))}
+
+	spots, err := repo.Spot.Nearest(c, filter, point, limit)
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, spots)
+}
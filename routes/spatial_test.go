@@ -0,0 +1,79 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/PacoDw/maze_challenge/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MazeSpatialQueries(t *testing.T) {
+	maze := &repository.Maze{Name: "labyrinth", Width: 20, Height: 20}
+
+	if _, err := testRepo.Maze.Create(context.Background(), maze); err != nil {
+		t.Fatal(err)
+	}
+
+	spots := []*repository.Spot{
+		{MazeID: maze.ID, Name: "a", Coordinate: &repository.Coordinate{X: 2, Y: 2}},
+		{MazeID: maze.ID, Name: "b", Coordinate: &repository.Coordinate{X: 5, Y: 6}},
+		{MazeID: maze.ID, Name: "c", Kind: repository.Trap, Penalty: 3, Coordinate: &repository.Coordinate{X: 15, Y: 15}},
+	}
+
+	for _, s := range spots {
+		if _, err := testRepo.Spot.Create(context.Background(), s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	param := gin.Param{Key: "id", Value: maze.ID}
+
+	tests := []struct {
+		name    string
+		handler gin.HandlerFunc
+		query   string
+		code    int
+		names   []string
+	}{
+		{name: "box", handler: ListMazeSpotsWithin, query: "?start=0,0&limit=10,10", code: 200, names: []string{"a", "b"}},
+		{name: "radius", handler: ListMazeSpotsWithin, query: "?center=5,5&radius=5", code: 200, names: []string{"b", "a"}},
+		{name: "missing params", handler: ListMazeSpotsWithin, query: "", code: 400},
+		{name: "wrong radius", handler: ListMazeSpotsWithin, query: "?center=5,5&radius=far", code: 400},
+		{name: "nearest", handler: ListNearestMazeSpots, query: "?point=14,14&limit=2", code: 200, names: []string{"c", "b"}},
+		{name: "nearest by kind", handler: ListNearestMazeSpots, query: "?point=0,0&kind=TRAP", code: 200, names: []string{"c"}},
+		{name: "nearest without point", handler: ListNearestMazeSpots, query: "?limit=2", code: 400},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/"+tt.query, bytes.NewBuffer([]byte{}))
+			res := makeScopedRequest(req, tt.handler, param)
+
+			assert.Equal(t, tt.code, res.Code)
+
+			if tt.code != 200 {
+				return
+			}
+
+			var got []repository.Spot
+			err := json.Unmarshal(res.Body.Bytes(), &got)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			names := make([]string, 0, len(got))
+
+			for _, s := range got {
+				names = append(names, s.Name)
+			}
+
+			assert.Equal(t, tt.names, names)
+		})
+	}
+}
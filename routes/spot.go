@@ -1,7 +1,6 @@
 package routes
 
 import (
-	"errors"
 	"net/http"
 
 	"github.com/PacoDw/maze_challenge/repository"
@@ -10,11 +9,9 @@ import (
 
 // CreateSpot creates a spot.
 var CreateSpot = func(c *gin.Context) {
-	repo, ok := c.MustGet("mongoRepoConn").(*repository.MongoDBService)
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": errors.New("no connection with database").Error(),
-		})
+		respondError(c, repository.ErrNoConnection)
 
 		return
 	}
@@ -22,44 +19,51 @@ var CreateSpot = func(c *gin.Context) {
 	spot := &repository.Spot{}
 
 	if err := c.ShouldBindJSON(spot); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, badRequest(err))
 
 		return
 	}
 
 	id, err := repo.Spot.Create(c, spot)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, err)
 
 		return
 	}
 
 	spot.ID = id
 
+	setETag(c, spot.Version)
 	c.JSON(http.StatusOK, spot)
 }
 
 // GetSpot creates a spot.
 var GetSpot = func(c *gin.Context) {
-	repo, ok := c.MustGet("mongoRepoConn").(*repository.MongoDBService)
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": errors.New("no connection with database").Error(),
-		})
+		respondError(c, repository.ErrNoConnection)
 
 		return
 	}
 
 	id := c.Param("id")
 	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": errors.New("param :id must not be empty")})
+		respondError(c, repository.BadRequest("param :id must not be empty"))
 
 		return
 	}
 
 	spot, err := repo.Spot.Get(c, &repository.SpotFilter{ID: id})
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, err)
+
+		return
+	}
+
+	setETag(c, spot.Version)
+
+	if notModified(c, spot.Version) {
+		c.Status(http.StatusNotModified)
 
 		return
 	}
@@ -67,59 +71,183 @@ var GetSpot = func(c *gin.Context) {
 	c.JSON(http.StatusOK, spot)
 }
 
-// UpdateSpot updates a spot.
+// UpdateSpot applies the body as a JSON merge patch to the spot with the id of the body
+// like PatchSpot does, so the attributes that aren't sent are left unchanged.
 var UpdateSpot = func(c *gin.Context) {
-	repo, ok := c.MustGet("mongoRepoConn").(*repository.MongoDBService)
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": errors.New("no connection with database").Error(),
-		})
+		respondError(c, repository.ErrNoConnection)
 
 		return
 	}
 
-	spot := &repository.Spot{}
-
-	if err := c.ShouldBindJSON(spot); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	target, err := readLegacyTarget(c)
+	if err != nil {
+		respondError(c, err)
 
 		return
 	}
 
-	spot, err := repo.Spot.Update(c, spot)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if target.ID == "" {
+		respondError(c, &repository.ValidationError{
+			Entity: "spot",
+			Fields: []repository.FieldError{{Field: "id", Message: "must be specified"}},
+		})
 
 		return
 	}
 
-	c.JSON(http.StatusOK, spot)
+	patchSpot(c, repo, target.ID)
 }
 
 // DeleteSpot deletes a spot.
 var DeleteSpot = func(c *gin.Context) {
-	repo, ok := c.MustGet("mongoRepoConn").(*repository.MongoDBService)
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": errors.New("no connection with database").Error(),
-		})
+		respondError(c, repository.ErrNoConnection)
 
 		return
 	}
 
 	id := c.Param("id")
 	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": errors.New("param :id must not be empty")})
+		respondError(c, repository.BadRequest("param :id must not be empty"))
+
+		return
+	}
+
+	expected, err := ifMatch(c)
+	if err != nil {
+		respondError(c, err)
 
 		return
 	}
 
-	_, err := repo.Spot.Delete(c, &repository.SpotFilter{ID: id})
+	// the version required by If-Match is checked by the delete itself
+	isRemoved, err := repo.Spot.Delete(c, &repository.SpotFilter{ID: id, Version: expected})
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, conditional(c, err))
+
+		return
+	}
+
+	if !isRemoved {
+		respondError(c, &repository.NotFoundError{Entity: "spot", ID: id})
 
 		return
 	}
 
 	c.JSON(http.StatusOK, "")
 }
+
+// PatchSpot applies a JSON merge patch or a JSON patch to the stored spot and replaces it
+// with the result, the id is taken from the path. Unless the patch changes its quadrant,
+// the spot of a maze is placed in the quadrant that contains its coordinate. The
+// replacement requires the version that was patched so it fails when another write
+// happened meanwhile.
+var PatchSpot = func(c *gin.Context) {
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
+	if !ok {
+		respondError(c, repository.ErrNoConnection)
+
+		return
+	}
+
+	patchSpot(c, repo, c.Param("id"))
+}
+
+// patchSpot applies the patch of the body to the spot with the id and responds the result.
+func patchSpot(c *gin.Context, repo *repository.Repository, id string) {
+	expected, err := ifMatch(c)
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	stored, err := repo.Spot.Get(c, &repository.SpotFilter{ID: id})
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	if err := checkIfMatch("spot", id, stored.Version, expected); err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	spot := &repository.Spot{}
+
+	if err := applyPatch(c, "spot", stored, spot); err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	// the version of If-Match takes precedence over the one set by the patch
+	if expected != 0 {
+		spot.Version = expected
+	}
+
+	spot.ID = id
+
+	if spot.QuadrantID == stored.QuadrantID {
+		spot.QuadrantID = ""
+	}
+
+	spot, err = repo.Spot.Replace(c, spot)
+	if err != nil {
+		respondError(c, conditional(c, err))
+
+		return
+	}
+
+	setETag(c, spot.Version)
+	c.JSON(http.StatusOK, spot)
+}
+
+// ReplaceSpot replaces the spot with the body, which must contain all the required
+// attributes, the id is taken from the path. The attributes that aren't sent are set to
+// their zero values.
+var ReplaceSpot = func(c *gin.Context) {
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
+	if !ok {
+		respondError(c, repository.ErrNoConnection)
+
+		return
+	}
+
+	spot := &repository.Spot{}
+
+	if err := c.ShouldBindJSON(spot); err != nil {
+		respondError(c, badRequest(err))
+
+		return
+	}
+
+	expected, err := ifMatch(c)
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	// the version of If-Match takes precedence over the one of the body
+	if expected != 0 {
+		spot.Version = expected
+	}
+
+	spot.ID = c.Param("id")
+
+	spot, err = repo.Spot.Replace(c, spot)
+	if err != nil {
+		respondError(c, conditional(c, err))
+
+		return
+	}
+
+	setETag(c, spot.Version)
+	c.JSON(http.StatusOK, spot)
+}
@@ -2,17 +2,16 @@ package routes
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
-	"os"
 	"strings"
 	"testing"
 
 	"github.com/PacoDw/maze_challenge/repository"
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -32,26 +31,32 @@ func makeRequest(req *http.Request, handler gin.HandlerFunc) *httptest.ResponseR
 
 	c.Request = req
 
-	conn := repository.NewByConnString(os.Getenv("MOGODB_CONN"))
-
-	c.Set("mongoRepoConn", conn)
-
-	c.Set(string(repository.ContextDBName), os.Getenv("DB_NAME"))
+	c.Set(string(repository.ContextRepoConn), testRepo)
 
 	handler(c)
 
 	return w
 }
 
-func Test_EnvMongoDBConnectionString(t *testing.T) {
-	// check connection string is not empty
-	if os.Getenv("MOGODB_CONN") == "" {
-		t.Logf("we can't read env variable trying find it in .env file...")
+// testRepo is the repository shared by the routes tests, it keeps the data in memory so
+// the tests don't need a live database.
+var testRepo = repository.NewMemory()
 
-		if err := godotenv.Load("../.env"); err != nil {
-			t.Fatalf("error loading .env file %+v", err)
-		}
+// testQuadrantID creates a quadrant that can be used to create spots in the tests.
+func testQuadrantID(t *testing.T) string {
+	t.Helper()
+
+	id, err := testRepo.Quadrant.Create(context.Background(), &repository.Quadrant{
+		Type:       repository.TopLeft,
+		StartPoint: &repository.Coordinate{X: 0, Y: 0},
+		LimitPoint: &repository.Coordinate{X: 25, Y: 25},
+	})
+
+	if err != nil {
+		t.Fatal(err)
 	}
+
+	return id
 }
 
 func testExpectedBody(t *testing.T, expectedBody interface{}) *bytes.Buffer {
@@ -66,20 +71,22 @@ func testExpectedBody(t *testing.T, expectedBody interface{}) *bytes.Buffer {
 }
 
 func Test_SpotCreateDelete(t *testing.T) {
-	Test_EnvMongoDBConnectionString(t)
-
-	var spotID string
+	var (
+		spotID     string
+		quadrantID = testQuadrantID(t)
+	)
 
 	// Create a spot
 	t.Run("create a spot", func(t *testing.T) {
 		blob := testExpectedBody(t, repository.Spot{
 			Name:       "exit",
-			GoldAmount: "200",
+			Kind:       repository.Exit,
+			GoldAmount: 200,
 			Coordinate: &repository.Coordinate{
 				X: 10,
 				Y: 3,
 			},
-			QuadrantID: "601989b15f19695a0a281bef",
+			QuadrantID: quadrantID,
 		})
 
 		var expected repository.Spot
@@ -100,6 +107,7 @@ func Test_SpotCreateDelete(t *testing.T) {
 		}
 
 		expected.ID = got.ID
+		expected.Version = 1
 
 		assert.Equal(t, expected, got)
 
@@ -118,23 +126,23 @@ func Test_SpotCreateDelete(t *testing.T) {
 }
 
 func Test_SpotGet(t *testing.T) {
-	Test_EnvMongoDBConnectionString(t)
-
 	var (
 		expectedBody []byte
 		spotID       string
+		quadrantID   = testQuadrantID(t)
 	)
 
 	// Create a spot
 	t.Run("create a spot", func(t *testing.T) {
 		blob := testExpectedBody(t, repository.Spot{
 			Name:       "exit",
-			GoldAmount: "200",
+			Kind:       repository.Exit,
+			GoldAmount: 200,
 			Coordinate: &repository.Coordinate{
 				X: 10,
 				Y: 3,
 			},
-			QuadrantID: "601989b15f19695a0a281bef",
+			QuadrantID: quadrantID,
 		})
 
 		req := httptest.NewRequest(http.MethodPost, "/create", blob)
@@ -184,20 +192,22 @@ func Test_SpotGet(t *testing.T) {
 }
 
 func Test_SpotUpdate(t *testing.T) {
-	Test_EnvMongoDBConnectionString(t)
-
-	var spotID string
+	var (
+		spotID     string
+		quadrantID = testQuadrantID(t)
+	)
 
 	// Create a spot
 	t.Run("create a spot", func(t *testing.T) {
 		blob := testExpectedBody(t, repository.Spot{
 			Name:       "exit",
-			GoldAmount: "200",
+			Kind:       repository.Exit,
+			GoldAmount: 200,
 			Coordinate: &repository.Coordinate{
 				X: 10,
 				Y: 3,
 			},
-			QuadrantID: "601989b15f19695a0a281bef",
+			QuadrantID: quadrantID,
 		})
 
 		req := httptest.NewRequest(http.MethodPost, "/create", blob)
@@ -219,12 +229,13 @@ func Test_SpotUpdate(t *testing.T) {
 		blob := testExpectedBody(t, repository.Spot{
 			ID:         spotID,
 			Name:       "entrace",
-			GoldAmount: "500",
+			Kind:       repository.Entrance,
+			GoldAmount: 500,
 			Coordinate: &repository.Coordinate{
 				X: 21,
 				Y: 4,
 			},
-			QuadrantID: "601989b15f19695a0a281bef",
+			QuadrantID: quadrantID,
 		})
 
 		var expected repository.Spot
@@ -244,9 +255,43 @@ func Test_SpotUpdate(t *testing.T) {
 			t.Fatal(err)
 		}
 
+		expected.Version = 2
+
 		assert.Equal(t, expected, got)
 	})
 
+	// the body is a merge patch, so the attributes that aren't sent are kept
+	t.Run("update a spot partially", func(t *testing.T) {
+		body := fmt.Sprintf(`{"id":%q,"gold_amount":7}`, spotID)
+
+		req := httptest.NewRequest(http.MethodPatch, "/update", bytes.NewBufferString(body))
+		res := makeRequest(req, UpdateSpot)
+
+		assert.Equal(t, 200, res.Code)
+
+		var got repository.Spot
+		if err := json.Unmarshal(res.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, "entrace", got.Name)
+		assert.EqualValues(t, 7, got.GoldAmount)
+		assert.EqualValues(t, 3, got.Version)
+
+		req = httptest.NewRequest(http.MethodPatch, "/update", bytes.NewBufferString(`{"gold_amount":7}`))
+		res = makeRequest(req, UpdateSpot)
+
+		assert.Equal(t, 400, res.Code)
+		assert.Contains(t, res.Body.String(), `"field":"id"`)
+
+		// the If-Match header is checked like in PATCH /v1/spots/{id}
+		req = httptest.NewRequest(http.MethodPatch, "/update", bytes.NewBufferString(body))
+		req.Header.Set("If-Match", etag(1))
+		res = makeRequest(req, UpdateSpot)
+
+		assert.Equal(t, 412, res.Code)
+	})
+
 	// Remove the spot
 	t.Run("remove a spot", func(t *testing.T) {
 		url := fmt.Sprintf("/delete/%s", spotID)
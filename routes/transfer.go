@@ -0,0 +1,113 @@
+package routes
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/PacoDw/maze_challenge/repository"
+	"github.com/PacoDw/maze_challenge/transfer"
+	"github.com/gin-gonic/gin"
+)
+
+// ImportMaze creates a maze with its spots and walls from a document in one go, either
+// all of it is stored or nothing is. The Content-Type selects the format, a CSV document
+// only lists the spots so the name, width and height of the maze are query params.
+var ImportMaze = func(c *gin.Context) {
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
+	if !ok {
+		respondError(c, repository.ErrNoConnection)
+
+		return
+	}
+
+	format, err := transfer.FormatOf(c.GetHeader("Content-Type"))
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	doc, err := transfer.Decode(c.Request.Body, format)
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	if format == transfer.CSV {
+		if err := parseMazeShape(c, doc); err != nil {
+			respondError(c, err)
+
+			return
+		}
+	}
+
+	maze, err := transfer.Import(c, repo, doc)
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, maze)
+}
+
+// parseMazeShape reads the name, width and height of the maze of a CSV document, the
+// query params that aren't numbers are reported together.
+func parseMazeShape(c *gin.Context, doc *transfer.Document) error {
+	doc.Name = c.Query("name")
+	ve := &repository.ValidationError{Entity: "maze"}
+
+	for _, side := range []struct {
+		name string
+		n    *uint
+	}{{"width", &doc.Width}, {"height", &doc.Height}} {
+		n, err := strconv.ParseUint(c.Query(side.name), 10, 32)
+		if err != nil {
+			ve.Fields = append(ve.Fields, repository.FieldError{Field: side.name, Message: "must be a non-negative number"})
+
+			continue
+		}
+
+		*side.n = uint(n)
+	}
+
+	if len(ve.Fields) != 0 {
+		return ve
+	}
+
+	return nil
+}
+
+// ExportMaze responds the document of a maze with its quadrants, spots and walls, e.g.
+// ?format=yaml, the CSV format only contains the spots.
+var ExportMaze = func(c *gin.Context) {
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
+	if !ok {
+		respondError(c, repository.ErrNoConnection)
+
+		return
+	}
+
+	doc, err := transfer.Export(c, repo, c.Param("id"))
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	format := transfer.Format(c.DefaultQuery("format", string(transfer.JSON)))
+
+	var buf bytes.Buffer
+
+	if err := transfer.Encode(&buf, format, doc); err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"maze-%s.%s\"", c.Param("id"), format))
+	c.Data(http.StatusOK, format.ContentType(), buf.Bytes())
+}
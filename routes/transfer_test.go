@@ -0,0 +1,79 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/PacoDw/maze_challenge/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ImportExportMaze(t *testing.T) {
+	router := newTestRouter()
+	maze, spot := newPatchMaze(t, router)
+
+	for _, f := range []struct {
+		format, contentType, query string
+	}{
+		{format: "json", contentType: "application/json"},
+		{format: "yaml", contentType: "application/yaml"},
+		{format: "csv", contentType: "text/csv", query: "?" + url.Values{"name": {maze.Name}, "width": {"20"}, "height": {"20"}}.Encode()},
+	} {
+		exported := serveHeaders(router, http.MethodGet, "/maze/"+maze.ID+"/export?format="+f.format, nil, "")
+		assert.Equal(t, 200, exported.Code, f.format)
+		assert.Contains(t, exported.Header().Get("Content-Type"), f.contentType)
+		assert.Contains(t, exported.Body.String(), spot.Name)
+
+		res := serveHeaders(router, http.MethodPost, "/maze/import"+f.query,
+			map[string]string{"Content-Type": f.contentType}, exported.Body.String())
+		assert.Equal(t, 200, res.Code, res.Body.String())
+
+		var imported repository.Maze
+
+		if err := json.Unmarshal(res.Body.Bytes(), &imported); err != nil {
+			t.Fatal(err)
+		}
+
+		assert.NotEqual(t, maze.ID, imported.ID)
+		assert.Len(t, imported.Quadrants, 4)
+
+		// the imported maze is exported as the same document
+		res = serveHeaders(router, http.MethodGet, "/maze/"+imported.ID+"/export?format="+f.format, nil, "")
+		assert.Equal(t, exported.Body.String(), res.Body.String(), f.format)
+	}
+
+	res := serveHeaders(router, http.MethodGet, "/maze/"+maze.ID+"/export?format=xml", nil, "")
+	assert.Equal(t, 400, res.Code)
+
+	res = serveHeaders(router, http.MethodGet, "/maze/000000000000000000000000/export", nil, "")
+	assert.Equal(t, 404, res.Code)
+}
+
+func Test_ImportMaze_Invalid(t *testing.T) {
+	router := newTestRouter()
+
+	res := serveHeaders(router, http.MethodPost, "/maze/import", nil,
+		`{"name":"labyrinth","width":10,"height":10,"spots":[{"name":"gold","x":1,"y":1},{"name":"gold","x":1,"y":1}]}`)
+	assert.Equal(t, 400, res.Code)
+	assert.Contains(t, res.Body.String(), `"field":"spots[1].x"`)
+
+	// nothing is stored when a spot fails
+	res = serveHeaders(router, http.MethodPost, "/maze/import", nil,
+		`{"name":"atomic","width":10,"height":10,"spots":[{"name":"gold","x":1,"y":1},{"name":"wall","kind":"WALL","gold_amount":3,"x":2,"y":2}]}`)
+	assert.Equal(t, 400, res.Code)
+	assert.Contains(t, res.Body.String(), `"field":"spots[1].gold_amount"`)
+
+	res = serveHeaders(router, http.MethodGet, "/maze", nil, "")
+	assert.NotContains(t, res.Body.String(), `"name":"atomic"`)
+
+	res = serveHeaders(router, http.MethodPost, "/maze/import?name=csv&width=ten&height=10",
+		map[string]string{"Content-Type": "text/csv"}, "name,x,y\ngold,1,1\n")
+	assert.Equal(t, 400, res.Code)
+	assert.Contains(t, res.Body.String(), `"field":"width"`)
+	assert.NotContains(t, res.Body.String(), `"field":"height"`)
+
+	res = serveHeaders(router, http.MethodPost, "/maze/import", map[string]string{"Content-Type": "text/plain"}, "labyrinth")
+	assert.Equal(t, 400, res.Code)
+}
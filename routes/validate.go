@@ -0,0 +1,113 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/PacoDw/maze_challenge/repository"
+	"github.com/PacoDw/maze_challenge/solver"
+	"github.com/gin-gonic/gin"
+)
+
+// PublishRequiresValidation sets if the mazes must pass the validation before they can be
+// published.
+var PublishRequiresValidation = true
+
+// errInvalidMaze is returned when a maze that doesn't pass the validation is published.
+var errInvalidMaze = &repository.ConflictError{
+	Entity: "maze",
+	Fields: []repository.FieldError{
+		{Field: "published", Message: "can't be set until the maze passes the validation"},
+	},
+}
+
+// ValidateMaze checks that the maze can be solved and reports the spots that can't be
+// reached, its isolated regions and its dead ends.
+var ValidateMaze = func(c *gin.Context) {
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
+	if !ok {
+		respondError(c, repository.ErrNoConnection)
+
+		return
+	}
+
+	s, err := solver.Load(c, repo, c.Param("id"))
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, s.Validate())
+}
+
+// PublishMaze marks a maze as published, when PublishRequiresValidation is set the maze
+// must pass the validation and the report is returned otherwise.
+var PublishMaze = func(c *gin.Context) {
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
+	if !ok {
+		respondError(c, repository.ErrNoConnection)
+
+		return
+	}
+
+	var (
+		maze   *repository.Maze
+		report *solver.Report
+	)
+
+	// the maze is checked and published in the same transaction so it can't change
+	// between both steps
+	err := repo.Transaction(c, func(ctx context.Context) error {
+		if PublishRequiresValidation {
+			s, err := solver.Load(ctx, repo, c.Param("id"))
+			if err != nil {
+				return err
+			}
+
+			if report = s.Validate(); !report.Valid {
+				return errInvalidMaze
+			}
+		}
+
+		var err error
+
+		maze, err = repo.Maze.Publish(ctx, &repository.MazeFilter{ID: c.Param("id")}, true)
+
+		return err
+	})
+
+	if errors.Is(err, errInvalidMaze) {
+		respondErrorDetails(c, err, report)
+
+		return
+	}
+
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, maze)
+}
+
+// UnpublishMaze marks a maze as unpublished.
+var UnpublishMaze = func(c *gin.Context) {
+	repo, ok := c.MustGet(string(repository.ContextRepoConn)).(*repository.Repository)
+	if !ok {
+		respondError(c, repository.ErrNoConnection)
+
+		return
+	}
+
+	maze, err := repo.Maze.Publish(c, &repository.MazeFilter{ID: c.Param("id")}, false)
+	if err != nil {
+		respondError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, maze)
+}
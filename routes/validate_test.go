@@ -0,0 +1,102 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/PacoDw/maze_challenge/repository"
+	"github.com/PacoDw/maze_challenge/solver"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MazeValidateAndPublish(t *testing.T) {
+	maze := &repository.Maze{Name: "labyrinth", Width: 4, Height: 4}
+
+	if _, err := testRepo.Maze.Create(context.Background(), maze); err != nil {
+		t.Fatal(err)
+	}
+
+	entrance := &repository.Spot{
+		MazeID:     maze.ID,
+		Name:       "entrance",
+		Kind:       repository.Entrance,
+		Coordinate: &repository.Coordinate{X: 0, Y: 0},
+	}
+
+	if _, err := testRepo.Spot.Create(context.Background(), entrance); err != nil {
+		t.Fatal(err)
+	}
+
+	param := gin.Param{Key: "id", Value: maze.ID}
+
+	// the maze doesn't have an exit yet
+	t.Run("validate an incomplete maze", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/validate", bytes.NewBuffer([]byte{}))
+		res := makeScopedRequest(req, ValidateMaze, param)
+
+		assert.Equal(t, 200, res.Code)
+
+		var report solver.Report
+		err := json.Unmarshal(res.Body.Bytes(), &report)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.False(t, report.Valid)
+		assert.Len(t, report.Errors, 1)
+
+		req = httptest.NewRequest(http.MethodPost, "/publish", bytes.NewBuffer([]byte{}))
+		res = makeScopedRequest(req, PublishMaze, param)
+
+		assert.Equal(t, 409, res.Code)
+		assert.Contains(t, res.Body.String(), `"details"`)
+	})
+
+	t.Run("publish without validation", func(t *testing.T) {
+		PublishRequiresValidation = false
+		defer func() { PublishRequiresValidation = true }()
+
+		req := httptest.NewRequest(http.MethodPost, "/publish", bytes.NewBuffer([]byte{}))
+		res := makeScopedRequest(req, PublishMaze, param)
+
+		assert.Equal(t, 200, res.Code)
+
+		req = httptest.NewRequest(http.MethodDelete, "/publish", bytes.NewBuffer([]byte{}))
+		res = makeScopedRequest(req, UnpublishMaze, param)
+
+		assert.Equal(t, 200, res.Code)
+		assert.Contains(t, res.Body.String(), `"published":false`)
+	})
+
+	t.Run("publish a valid maze", func(t *testing.T) {
+		exit := &repository.Spot{
+			MazeID:     maze.ID,
+			Name:       "exit",
+			Kind:       repository.Exit,
+			Coordinate: &repository.Coordinate{X: 3, Y: 3},
+		}
+
+		if _, err := testRepo.Spot.Create(context.Background(), exit); err != nil {
+			t.Fatal(err)
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/publish", bytes.NewBuffer([]byte{}))
+		res := makeScopedRequest(req, PublishMaze, param)
+
+		assert.Equal(t, 200, res.Code)
+		assert.Contains(t, res.Body.String(), `"published":true`)
+
+		// the published maze can't change until it is unpublished
+		body := `{"name":"trap","kind":"TRAP","penalty":2,"Coordinate":{"x":2,"y":1}}`
+		req = httptest.NewRequest(http.MethodPost, "/spot", bytes.NewBufferString(body))
+		res = makeScopedRequest(req, CreateMazeSpot, param)
+
+		assert.Equal(t, 409, res.Code)
+		assert.Contains(t, res.Body.String(), `"field":"published"`)
+	})
+}
@@ -0,0 +1,384 @@
+package solver
+
+import "github.com/PacoDw/maze_challenge/repository"
+
+// ErrNoRoute is returned when no exit can be reached within the step budget.
+var ErrNoRoute = repository.BadRequest("no exit can be reached from the entrance within the step budget")
+
+// exactLimit is the number of spots with gold up to which the route is searched exactly,
+// the exact search keeps a state for each subset of them so it grows as 2^n.
+const exactLimit = 15
+
+// unreachable marks the pairs of spots without a path between them.
+const unreachable = -1
+
+// RouteStop represents a spot visited by a route.
+type RouteStop struct {
+	SpotID     string                `json:"spot_id"`
+	Name       string                `json:"name"`
+	Kind       repository.SpotKind   `json:"kind"`
+	Coordinate repository.Coordinate `json:"coordinate"`
+	GoldAmount uint                  `json:"gold_amount"`
+	Steps      int                   `json:"steps"`
+}
+
+// Route represents the spots visited from the entrance to an exit, Steps of each stop is
+// the number of steps walked when it is reached. Exact is false when the route was found
+// with the heuristic, so there could be a better one.
+type Route struct {
+	Stops      []RouteStop `json:"stops"`
+	GoldAmount uint        `json:"gold_amount"`
+	Steps      int         `json:"steps"`
+	Exact      bool        `json:"exact"`
+}
+
+// planner keeps the spots of the route and the distances between them, the index 0 is
+// the entrance, then the spots with gold and then the exits.
+type planner struct {
+	spots    []*repository.Spot
+	dist     [][]int
+	gold     []uint
+	budget   int
+	treasure int
+	exits    []int
+}
+
+// PlanRoute finds the route that collects the most gold starting at the entrance and
+// ending at an exit in at most budget steps. The gold of a spot is collected the first
+// time it is visited. When there are few spots with gold all the routes are explored,
+// otherwise the route is built inserting the spots that give more gold per extra step.
+func (s *Solver) PlanRoute(budget int) (*Route, error) {
+	if budget < 0 {
+		return nil, &repository.ValidationError{Entity: "route", Fields: []repository.FieldError{
+			{Field: "steps", Message: "must be greater than or equal to 0"},
+		}}
+	}
+
+	if err := repository.ValidateMazeSpots(s.spots); err != nil {
+		return nil, err
+	}
+
+	p := s.newPlanner(budget)
+
+	var (
+		order []int
+		steps int
+		ok    bool
+		exact = p.treasure <= exactLimit
+	)
+
+	if exact {
+		order, steps, ok = p.exact()
+	} else {
+		order, steps, ok = p.heuristic()
+	}
+
+	if !ok {
+		return nil, ErrNoRoute
+	}
+
+	return p.route(order, steps, exact), nil
+}
+
+// newPlanner computes the distances between the entrance, the spots with gold that can be
+// reached within the budget and the exits.
+func (s *Solver) newPlanner(budget int) *planner {
+	var (
+		entrance  *repository.Spot
+		exits     []*repository.Spot
+		treasures []*repository.Spot
+	)
+
+	for i := range s.spots {
+		spot := &s.spots[i]
+
+		switch {
+		case spot.Coordinate == nil || spot.IsBlocking():
+		case spot.Kind == repository.Entrance:
+			entrance = spot
+		case spot.Kind == repository.Exit:
+			exits = append(exits, spot)
+		case spot.GoldAmount > 0:
+			treasures = append(treasures, spot)
+		}
+	}
+
+	fromEntrance := s.Distances(*entrance.Coordinate)
+
+	p := &planner{budget: budget, spots: []*repository.Spot{entrance}}
+
+	for _, t := range treasures {
+		if d, ok := fromEntrance[*t.Coordinate]; ok && d <= budget {
+			p.spots = append(p.spots, t)
+		}
+	}
+
+	p.treasure = len(p.spots) - 1
+
+	for _, e := range exits {
+		p.exits = append(p.exits, len(p.spots))
+		p.spots = append(p.spots, e)
+	}
+
+	p.dist = make([][]int, len(p.spots))
+	p.gold = make([]uint, len(p.spots))
+
+	for i, spot := range p.spots {
+		distances := fromEntrance
+		if i > 0 {
+			distances = s.Distances(*spot.Coordinate)
+		}
+
+		p.dist[i] = make([]int, len(p.spots))
+		p.gold[i] = spot.GoldAmount
+
+		for j := range p.spots {
+			d, ok := distances[*p.spots[j].Coordinate]
+			if !ok {
+				d = unreachable
+			}
+
+			p.dist[i][j] = d
+		}
+	}
+
+	return p
+}
+
+// better reports if the route with gold and steps beats the best one found so far.
+func better(gold uint, steps int, bestGold uint, bestSteps int, found bool) bool {
+	return !found || gold > bestGold || (gold == bestGold && steps < bestSteps)
+}
+
+// exact explores every subset of the spots with gold keeping the shortest walk that ends
+// in each of them, the order returned contains the indexes of the visited spots.
+func (p *planner) exact() (order []int, steps int, ok bool) {
+	n := p.treasure
+	size := 1 << uint(n)
+
+	// walk[mask*n+i] is the shortest walk from the entrance that visits the spots of the
+	// mask and ends at the spot i, parent is the previous spot of that walk.
+	walk := make([]int, size*n)
+	parent := make([]int, size*n)
+	maskGold := make([]uint, size)
+
+	for i := range walk {
+		walk[i] = unreachable
+	}
+
+	for i := 0; i < n; i++ {
+		if d := p.dist[0][i+1]; d != unreachable && d <= p.budget {
+			walk[(1<<uint(i))*n+i] = d
+			parent[(1<<uint(i))*n+i] = -1
+		}
+	}
+
+	var (
+		bestGold uint
+		bestMask int
+		bestLast = -1
+		bestExit int
+	)
+
+	// the route that goes straight to an exit
+	for _, e := range p.exits {
+		if d := p.dist[0][e]; d != unreachable && d <= p.budget && better(p.gold[e], d, bestGold, steps, ok) {
+			bestGold, steps, bestExit, ok = p.gold[e], d, e, true
+		}
+	}
+
+	for mask := 1; mask < size; mask++ {
+		for i := 0; i < n; i++ {
+			if mask&(1<<uint(i)) != 0 {
+				maskGold[mask] = maskGold[mask&^(1<<uint(i))] + p.gold[i+1]
+
+				break
+			}
+		}
+
+		for i := 0; i < n; i++ {
+			w := walk[mask*n+i]
+			if w == unreachable {
+				continue
+			}
+
+			for _, e := range p.exits {
+				d := p.dist[i+1][e]
+				if d == unreachable || w+d > p.budget {
+					continue
+				}
+
+				if gold := maskGold[mask] + p.gold[e]; better(gold, w+d, bestGold, steps, ok) {
+					bestGold, steps, bestMask, bestLast, bestExit, ok = gold, w+d, mask, i, e, true
+				}
+			}
+
+			for j := 0; j < n; j++ {
+				if mask&(1<<uint(j)) != 0 {
+					continue
+				}
+
+				d := p.dist[i+1][j+1]
+				if d == unreachable || w+d > p.budget {
+					continue
+				}
+
+				next := (mask|1<<uint(j))*n + j
+				if walk[next] == unreachable || w+d < walk[next] {
+					walk[next] = w + d
+					parent[next] = i
+				}
+			}
+		}
+	}
+
+	if !ok {
+		return nil, 0, false
+	}
+
+	order = []int{bestExit}
+
+	for mask, i := bestMask, bestLast; i != -1; {
+		order = append(order, i+1)
+		prev := parent[mask*n+i]
+		mask &^= 1 << uint(i)
+		i = prev
+	}
+
+	order = append(order, 0)
+
+	for i, j := 0, len(order)-1; i < j; i, j = i+1, j-1 {
+		order[i], order[j] = order[j], order[i]
+	}
+
+	return order, steps, true
+}
+
+// heuristic builds a route for each exit inserting the spot with more gold per extra
+// step while it fits in the budget, the order of the route is improved with 2-opt after
+// each insertion to leave room for more spots.
+func (p *planner) heuristic() (order []int, steps int, ok bool) {
+	var bestGold uint
+
+	for _, e := range p.exits {
+		d := p.dist[0][e]
+		if d == unreachable || d > p.budget {
+			continue
+		}
+
+		route := []int{0, e}
+		length := d
+		gold := p.gold[e]
+		visited := make([]bool, len(p.spots))
+
+		for {
+			t, pos, added, found := p.bestInsertion(route, length, visited)
+			if !found {
+				break
+			}
+
+			route = append(route[:pos], append([]int{t}, route[pos:]...)...)
+			length += added
+			gold += p.gold[t]
+			visited[t] = true
+
+			length = p.twoOpt(route, length)
+		}
+
+		if better(gold, length, bestGold, steps, ok) {
+			order, steps, bestGold, ok = route, length, gold, true
+		}
+	}
+
+	return order, steps, ok
+}
+
+// bestInsertion finds the spot and the position that give more gold per extra step.
+func (p *planner) bestInsertion(route []int, length int, visited []bool) (t, pos, added int, found bool) {
+	var bestRatio float64
+
+	for c := 1; c <= p.treasure; c++ {
+		if visited[c] {
+			continue
+		}
+
+		for i := 1; i < len(route); i++ {
+			a, b := route[i-1], route[i]
+
+			if p.dist[a][c] == unreachable || p.dist[c][b] == unreachable {
+				continue
+			}
+
+			extra := p.dist[a][c] + p.dist[c][b] - p.dist[a][b]
+			if length+extra > p.budget {
+				continue
+			}
+
+			if ratio := float64(p.gold[c]) / float64(extra+1); !found || ratio > bestRatio {
+				t, pos, added, bestRatio, found = c, i, extra, ratio, true
+			}
+		}
+	}
+
+	return t, pos, added, found
+}
+
+// twoOpt reverses the segments of the route between the entrance and the exit while that
+// makes it shorter, it returns the new length.
+func (p *planner) twoOpt(route []int, length int) int {
+	for improved := true; improved; {
+		improved = false
+
+		for i := 1; i < len(route)-2; i++ {
+			for j := i + 1; j < len(route)-1; j++ {
+				a, b, c, d := route[i-1], route[i], route[j], route[j+1]
+
+				if p.dist[a][c] == unreachable || p.dist[b][d] == unreachable {
+					continue
+				}
+
+				delta := p.dist[a][c] + p.dist[b][d] - p.dist[a][b] - p.dist[c][d]
+				if delta >= 0 {
+					continue
+				}
+
+				for l, r := i, j; l < r; l, r = l+1, r-1 {
+					route[l], route[r] = route[r], route[l]
+				}
+
+				length += delta
+				improved = true
+			}
+		}
+	}
+
+	return length
+}
+
+// route builds the response with the spots of the order.
+func (p *planner) route(order []int, steps int, exact bool) *Route {
+	r := &Route{Stops: make([]RouteStop, 0, len(order)), Steps: steps, Exact: exact}
+
+	walked := 0
+
+	for i, idx := range order {
+		if i > 0 {
+			walked += p.dist[order[i-1]][idx]
+		}
+
+		spot := p.spots[idx]
+
+		r.Stops = append(r.Stops, RouteStop{
+			SpotID:     spot.ID,
+			Name:       spot.Name,
+			Kind:       spot.Kind,
+			Coordinate: *spot.Coordinate,
+			GoldAmount: spot.GoldAmount,
+			Steps:      walked,
+		})
+
+		r.GoldAmount += spot.GoldAmount
+	}
+
+	return r
+}
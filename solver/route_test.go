@@ -0,0 +1,102 @@
+package solver
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/PacoDw/maze_challenge/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+// newRouteSolver creates an open 5x5 maze with the entrance and the exit in the top
+// corners and three spots with gold.
+func newRouteSolver() *Solver {
+	g := repository.NewGrid("", 5, 5, false)
+
+	spots := []repository.Spot{
+		{ID: "entrance", Kind: repository.Entrance, Coordinate: &repository.Coordinate{X: 0, Y: 0}},
+		{ID: "exit", Kind: repository.Exit, Coordinate: &repository.Coordinate{X: 4, Y: 0}},
+		{ID: "a", Kind: repository.Treasure, GoldAmount: 100, Coordinate: &repository.Coordinate{X: 0, Y: 4}},
+		{ID: "b", Kind: repository.Treasure, GoldAmount: 10, Coordinate: &repository.Coordinate{X: 2, Y: 0}},
+		{ID: "c", Kind: repository.Treasure, GoldAmount: 50, Coordinate: &repository.Coordinate{X: 4, Y: 4}},
+		{ID: "wall", Kind: repository.Wall, Coordinate: &repository.Coordinate{X: 2, Y: 2}},
+	}
+
+	return New(g, nil, spots)
+}
+
+func stopIDs(r *Route) []string {
+	ids := make([]string, 0, len(r.Stops))
+
+	for _, s := range r.Stops {
+		ids = append(ids, s.SpotID)
+	}
+
+	return ids
+}
+
+func TestSolver_PlanRoute(t *testing.T) {
+	s := newRouteSolver()
+
+	tests := []struct {
+		budget int
+		gold   uint
+		steps  int
+		order  []string
+	}{
+		{budget: 4, gold: 10, steps: 4, order: []string{"entrance", "b", "exit"}},
+		{budget: 12, gold: 150, steps: 12, order: []string{"entrance", "a", "c", "exit"}},
+		{budget: 16, gold: 160, steps: 16, order: []string{"entrance", "a", "c", "b", "exit"}},
+	}
+
+	for _, tt := range tests {
+		r, err := s.PlanRoute(tt.budget)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.True(t, r.Exact)
+		assert.Equal(t, tt.gold, r.GoldAmount, tt.budget)
+		assert.Equal(t, tt.steps, r.Steps, tt.budget)
+		assert.Equal(t, tt.order, stopIDs(r), tt.budget)
+		assert.Equal(t, r.Steps, r.Stops[len(r.Stops)-1].Steps, tt.budget)
+	}
+
+	_, err := s.PlanRoute(3)
+	assert.Equal(t, ErrNoRoute, err)
+
+	var ve *repository.ValidationError
+
+	_, err = s.PlanRoute(-1)
+	assert.True(t, errors.As(err, &ve))
+
+	// a maze without exit
+	_, err = New(s.grid, nil, s.spots[:1]).PlanRoute(10)
+	assert.True(t, errors.As(err, &ve))
+}
+
+func TestSolver_heuristic(t *testing.T) {
+	s := newRouteSolver()
+
+	for _, budget := range []int{4, 12, 16, 40} {
+		p := s.newPlanner(budget)
+
+		exact, exactSteps, ok := p.exact()
+		assert.True(t, ok)
+
+		order, steps, ok := p.heuristic()
+		assert.True(t, ok)
+
+		// the heuristic keeps the budget and can't beat the exact search
+		assert.LessOrEqual(t, steps, budget)
+		assert.LessOrEqual(t, p.route(order, steps, false).GoldAmount, p.route(exact, exactSteps, true).GoldAmount)
+		assert.Equal(t, 0, order[0])
+		assert.Equal(t, p.exits[0], order[len(order)-1])
+	}
+
+	// with enough steps it collects all the gold as well
+	p := s.newPlanner(16)
+	order, _, _ := p.heuristic()
+
+	assert.Equal(t, uint(160), p.route(order, 0, false).GoldAmount)
+}
@@ -0,0 +1,159 @@
+package solver
+
+import (
+	"container/heap"
+
+	"github.com/PacoDw/maze_challenge/repository"
+)
+
+// BFS finds the shortest path between the points exploring the cells by distance.
+func (s *Solver) BFS(from, to repository.Coordinate) (*Path, error) {
+	if err := s.validatePoints(from, to); err != nil {
+		return nil, err
+	}
+
+	parents := map[repository.Coordinate]repository.Coordinate{from: from}
+	queue := []repository.Coordinate{from}
+
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+
+		if c == to {
+			return s.newPath(parents, from, to), nil
+		}
+
+		for _, next := range s.Neighbours(c) {
+			if _, ok := parents[next]; ok {
+				continue
+			}
+
+			parents[next] = c
+			queue = append(queue, next)
+		}
+	}
+
+	return nil, ErrNoPath
+}
+
+// Distances returns the number of steps from the point to each cell that can be reached.
+func (s *Solver) Distances(from repository.Coordinate) map[repository.Coordinate]int {
+	distances := make(map[repository.Coordinate]int)
+
+	if s.Blocked(from) {
+		return distances
+	}
+
+	distances[from] = 0
+	queue := []repository.Coordinate{from}
+
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+
+		for _, next := range s.Neighbours(c) {
+			if _, ok := distances[next]; ok {
+				continue
+			}
+
+			distances[next] = distances[c] + 1
+			queue = append(queue, next)
+		}
+	}
+
+	return distances
+}
+
+// manhattan returns the manhattan distance between the points, it never overestimates
+// the steps because the moves are only horizontal or vertical.
+func manhattan(a, b repository.Coordinate) int {
+	dx := int(a.X) - int(b.X)
+	if dx < 0 {
+		dx = -dx
+	}
+
+	dy := int(a.Y) - int(b.Y)
+	if dy < 0 {
+		dy = -dy
+	}
+
+	return dx + dy
+}
+
+// node represents a cell waiting to be explored by A*.
+type node struct {
+	c     repository.Coordinate
+	cost  int
+	score int
+	order int
+}
+
+// frontier is a min heap of nodes by score, the ties are broken by the order they were
+// pushed so the result doesn't depend on the heap internals.
+type frontier []node
+
+func (f frontier) Len() int { return len(f) }
+
+func (f frontier) Less(i, j int) bool {
+	if f[i].score != f[j].score {
+		return f[i].score < f[j].score
+	}
+
+	return f[i].order < f[j].order
+}
+
+func (f frontier) Swap(i, j int) { f[i], f[j] = f[j], f[i] }
+
+func (f *frontier) Push(x interface{}) { *f = append(*f, x.(node)) }
+
+func (f *frontier) Pop() interface{} {
+	old := *f
+	n := old[len(old)-1]
+	*f = old[:len(old)-1]
+
+	return n
+}
+
+// AStar finds the shortest path between the points exploring first the cells closer to
+// the target.
+func (s *Solver) AStar(from, to repository.Coordinate) (*Path, error) {
+	if err := s.validatePoints(from, to); err != nil {
+		return nil, err
+	}
+
+	var (
+		parents = map[repository.Coordinate]repository.Coordinate{from: from}
+		costs   = map[repository.Coordinate]int{from: 0}
+		open    = &frontier{{c: from, score: manhattan(from, to)}}
+		order   = 1
+	)
+
+	for open.Len() > 0 {
+		n := heap.Pop(open).(node)
+
+		if n.c == to {
+			return s.newPath(parents, from, to), nil
+		}
+
+		// skip the stale entries of cells already reached with a lower cost
+		if n.cost > costs[n.c] {
+			continue
+		}
+
+		for _, next := range s.Neighbours(n.c) {
+			cost := n.cost + 1
+
+			if known, ok := costs[next]; ok && known <= cost {
+				continue
+			}
+
+			costs[next] = cost
+			parents[next] = n.c
+
+			heap.Push(open, node{c: next, cost: cost, score: cost + manhattan(next, to), order: order})
+			order++
+		}
+	}
+
+	return nil, ErrNoPath
+}
@@ -0,0 +1,235 @@
+// Package solver finds paths between the spots of a maze, it moves over the cells of the
+// maze grid without crossing its walls or the WALL spots, and it can go through any of
+// the quadrants of the maze.
+package solver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/PacoDw/maze_challenge/repository"
+)
+
+// ErrNoPath is returned when the walls of the maze don't leave a way between the points.
+var ErrNoPath = repository.BadRequest("there is no path between the points")
+
+// Algorithm defines the search used to find a path.
+type Algorithm string
+
+const (
+	// BFS explores the cells by distance, it doesn't need any heuristic.
+	BFS Algorithm = "bfs"
+
+	// AStar explores first the cells closer to the target by their manhattan distance.
+	AStar Algorithm = "astar"
+)
+
+// PathQuadrant represents a quadrant crossed by a path.
+type PathQuadrant struct {
+	ID   string                  `json:"id"`
+	Type repository.QuadrantType `json:"type"`
+}
+
+// Path represents the way between two points of a maze.
+type Path struct {
+	Coordinates []repository.Coordinate `json:"coordinates"`
+	Steps       int                     `json:"steps"`
+	Quadrants   []PathQuadrant          `json:"quadrants"`
+}
+
+// Solver finds paths in a maze.
+type Solver struct {
+	grid      *repository.Grid
+	quadrants []repository.Quadrant
+	spots     []repository.Spot
+	blocked   map[repository.Coordinate]bool
+}
+
+// New creates a solver for the grid, the spots that block the movement like the walls
+// can't be crossed and the quadrants are used to report the ones crossed by the paths.
+func New(g *repository.Grid, quadrants []repository.Quadrant, spots []repository.Spot) *Solver {
+	s := &Solver{
+		grid:      g,
+		quadrants: quadrants,
+		spots:     spots,
+		blocked:   make(map[repository.Coordinate]bool),
+	}
+
+	for i := range spots {
+		if spots[i].IsBlocking() && spots[i].Coordinate != nil {
+			s.blocked[*spots[i].Coordinate] = true
+		}
+	}
+
+	return s
+}
+
+// Load creates a solver with the grid, quadrants and spots of the maze.
+func Load(ctx context.Context, repo *repository.Repository, mazeID string) (*Solver, error) {
+	m, err := repo.Maze.Get(ctx, &repository.MazeFilter{ID: mazeID})
+	if err != nil {
+		return nil, fmt.Errorf("can't reach the maze: %w", err)
+	}
+
+	g, err := repo.Grid.Get(ctx, &repository.GridFilter{MazeID: m.ID})
+	if err != nil {
+		return nil, fmt.Errorf("can't reach the grid of the maze: %w", err)
+	}
+
+	spots, err := repo.Spot.List(ctx, &repository.SpotFilter{MazeID: m.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	return New(g, m.Quadrants, spots), nil
+}
+
+// Spot returns the spot of the maze with the id.
+func (s *Solver) Spot(id string) (*repository.Spot, bool) {
+	for i := range s.spots {
+		if s.spots[i].ID == id {
+			return &s.spots[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// Grid returns the grid of the maze.
+func (s *Solver) Grid() *repository.Grid {
+	return s.grid
+}
+
+// Quadrants returns the quadrants of the maze.
+func (s *Solver) Quadrants() []repository.Quadrant {
+	return s.quadrants
+}
+
+// Spots returns the spots of the maze.
+func (s *Solver) Spots() []repository.Spot {
+	return s.spots
+}
+
+// Blocked reports if the cell can't be visited.
+func (s *Solver) Blocked(c repository.Coordinate) bool {
+	return !s.grid.Contains(c) || s.blocked[c]
+}
+
+// Neighbours returns the cells that can be reached from c in a single step.
+func (s *Solver) Neighbours(c repository.Coordinate) []repository.Coordinate {
+	neighbours := make([]repository.Coordinate, 0, len(repository.Directions))
+
+	for _, d := range repository.Directions {
+		if s.grid.HasWall(c, d) {
+			continue
+		}
+
+		next, ok := s.grid.Move(c, d)
+		if !ok || s.Blocked(next) {
+			continue
+		}
+
+		neighbours = append(neighbours, next)
+	}
+
+	return neighbours
+}
+
+// Solve finds the shortest path between the two points with the algorithm.
+func (s *Solver) Solve(algorithm Algorithm, from, to repository.Coordinate) (*Path, error) {
+	switch algorithm {
+	case BFS:
+		return s.BFS(from, to)
+	case AStar, "":
+		return s.AStar(from, to)
+	default:
+		return nil, repository.BadRequest("unknown algorithm %q, it must be %s or %s", algorithm, BFS, AStar)
+	}
+}
+
+// SolveSpots finds the shortest path between the two spots of the maze.
+func (s *Solver) SolveSpots(algorithm Algorithm, fromID, toID string) (*Path, error) {
+	ve := &repository.ValidationError{Entity: "path"}
+
+	from, ok := s.Spot(fromID)
+	if !ok {
+		ve.Fields = append(ve.Fields, repository.FieldError{Field: "from", Message: "must be a spot of the maze"})
+	}
+
+	to, ok := s.Spot(toID)
+	if !ok {
+		ve.Fields = append(ve.Fields, repository.FieldError{Field: "to", Message: "must be a spot of the maze"})
+	}
+
+	if len(ve.Fields) > 0 {
+		return nil, ve
+	}
+
+	return s.Solve(algorithm, *from.Coordinate, *to.Coordinate)
+}
+
+// validatePoints checks that both points can be visited.
+func (s *Solver) validatePoints(from, to repository.Coordinate) error {
+	ve := &repository.ValidationError{Entity: "path"}
+
+	if s.Blocked(from) {
+		ve.Fields = append(ve.Fields, repository.FieldError{Field: "from", Message: "must be a free cell of the maze"})
+	}
+
+	if s.Blocked(to) {
+		ve.Fields = append(ve.Fields, repository.FieldError{Field: "to", Message: "must be a free cell of the maze"})
+	}
+
+	if len(ve.Fields) > 0 {
+		return ve
+	}
+
+	return nil
+}
+
+// quadrant returns the quadrant that contains the coordinate.
+func (s *Solver) quadrant(c repository.Coordinate) (*repository.Quadrant, bool) {
+	for i := range s.quadrants {
+		if s.quadrants[i].Contains(c) {
+			return &s.quadrants[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// newPath builds the path walking back the parents from the target, the quadrants are
+// listed in the order the path enters them.
+func (s *Solver) newPath(parents map[repository.Coordinate]repository.Coordinate, from, to repository.Coordinate) *Path {
+	coordinates := []repository.Coordinate{to}
+
+	for c := to; c != from; {
+		c = parents[c]
+		coordinates = append(coordinates, c)
+	}
+
+	for i, j := 0, len(coordinates)-1; i < j; i, j = i+1, j-1 {
+		coordinates[i], coordinates[j] = coordinates[j], coordinates[i]
+	}
+
+	p := &Path{
+		Coordinates: coordinates,
+		Steps:       len(coordinates) - 1,
+		Quadrants:   make([]PathQuadrant, 0),
+	}
+
+	for i := range coordinates {
+		q, ok := s.quadrant(coordinates[i])
+		if !ok {
+			continue
+		}
+
+		if n := len(p.Quadrants); n > 0 && p.Quadrants[n-1].ID == q.ID {
+			continue
+		}
+
+		p.Quadrants = append(p.Quadrants, PathQuadrant{ID: q.ID, Type: q.Type})
+	}
+
+	return p
+}
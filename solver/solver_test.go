@@ -0,0 +1,110 @@
+package solver
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/PacoDw/maze_challenge/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestSolver creates a 4x4 maze with a wall that splits the columns 1 and 2 except in
+// the last row, and a WALL spot in 0,2:
+//
+//	+-+-+-+-+
+//	|S  |   |
+//	+ + + + +
+//	|   |   |
+//	+ + + + +
+//	|#  |  E|
+//	+ + + + +
+//	|       |
+//	+-+-+-+-+
+func newTestSolver(t *testing.T) *Solver {
+	g := repository.NewGrid("", 4, 4, false)
+
+	for y := uint(0); y < 3; y++ {
+		if err := g.SetWall(repository.Coordinate{X: 1, Y: y}, repository.East, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	quadrants := []repository.Quadrant{
+		{ID: "tl", Type: repository.TopLeft, StartPoint: &repository.Coordinate{X: 0, Y: 0}, LimitPoint: &repository.Coordinate{X: 2, Y: 2}},
+		{ID: "tr", Type: repository.TopRight, StartPoint: &repository.Coordinate{X: 2, Y: 0}, LimitPoint: &repository.Coordinate{X: 4, Y: 2}},
+		{ID: "bl", Type: repository.BottomLeft, StartPoint: &repository.Coordinate{X: 0, Y: 2}, LimitPoint: &repository.Coordinate{X: 2, Y: 4}},
+		{ID: "br", Type: repository.BottomRight, StartPoint: &repository.Coordinate{X: 2, Y: 2}, LimitPoint: &repository.Coordinate{X: 4, Y: 4}},
+	}
+
+	spots := []repository.Spot{
+		{ID: "start", Kind: repository.Entrance, Coordinate: &repository.Coordinate{X: 0, Y: 0}},
+		{ID: "end", Kind: repository.Exit, Coordinate: &repository.Coordinate{X: 3, Y: 2}},
+		{ID: "wall", Kind: repository.Wall, Coordinate: &repository.Coordinate{X: 0, Y: 2}},
+	}
+
+	return New(g, quadrants, spots)
+}
+
+func TestSolver_algorithms(t *testing.T) {
+	s := newTestSolver(t)
+
+	for _, algorithm := range []Algorithm{BFS, AStar} {
+		p, err := s.SolveSpots(algorithm, "start", "end")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, 7, p.Steps, algorithm)
+		assert.Len(t, p.Coordinates, 8, algorithm)
+		assert.Equal(t, repository.Coordinate{X: 0, Y: 0}, p.Coordinates[0], algorithm)
+		assert.Equal(t, repository.Coordinate{X: 3, Y: 2}, p.Coordinates[7], algorithm)
+
+		// the path goes down the second column, the WALL spot blocks the first one
+		assert.Contains(t, p.Coordinates, repository.Coordinate{X: 1, Y: 2}, algorithm)
+		assert.NotContains(t, p.Coordinates, repository.Coordinate{X: 0, Y: 2}, algorithm)
+
+		for i := 1; i < len(p.Coordinates); i++ {
+			assert.Equal(t, 1, manhattan(p.Coordinates[i-1], p.Coordinates[i]), algorithm)
+		}
+
+		assert.Equal(t, []PathQuadrant{
+			{ID: "tl", Type: repository.TopLeft},
+			{ID: "bl", Type: repository.BottomLeft},
+			{ID: "br", Type: repository.BottomRight},
+		}, p.Quadrants, algorithm)
+	}
+}
+
+func TestSolver_errors(t *testing.T) {
+	s := newTestSolver(t)
+
+	// the same point
+	p, err := s.Solve(AStar, repository.Coordinate{X: 1, Y: 1}, repository.Coordinate{X: 1, Y: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 0, p.Steps)
+
+	// closing the last row leaves the exit out of reach
+	if err := s.grid.SetWall(repository.Coordinate{X: 1, Y: 3}, repository.East, true); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.SolveSpots(BFS, "start", "end")
+	assert.Equal(t, ErrNoPath, err)
+
+	_, err = s.SolveSpots(AStar, "start", "end")
+	assert.Equal(t, ErrNoPath, err)
+
+	var ve *repository.ValidationError
+
+	_, err = s.SolveSpots(BFS, "start", "wall")
+	assert.True(t, errors.As(err, &ve))
+
+	_, err = s.SolveSpots(BFS, "start", "missing")
+	assert.True(t, errors.As(err, &ve))
+
+	_, err = s.SolveSpots("dfs", "start", "end")
+	assert.Error(t, err)
+}
@@ -0,0 +1,222 @@
+package solver
+
+import (
+	"errors"
+
+	"github.com/PacoDw/maze_challenge/repository"
+)
+
+// SpotIssue represents a spot that can't be reached from the entrance.
+type SpotIssue struct {
+	ID         string                `json:"id"`
+	Name       string                `json:"name"`
+	Kind       repository.SpotKind   `json:"kind"`
+	Coordinate repository.Coordinate `json:"coordinate"`
+}
+
+// IsolatedRegion represents a group of connected cells that can't be reached from the
+// entrance, Start is its first cell by row.
+type IsolatedRegion struct {
+	Start repository.Coordinate `json:"start"`
+	Cells int                   `json:"cells"`
+	Spots int                   `json:"spots"`
+}
+
+// DeadEnds represents the statistics of the cells with a single way out, the length of a
+// dead end is the number of cells of the corridor that leads to it from the last junction.
+type DeadEnds struct {
+	Count         int     `json:"count"`
+	Ratio         float64 `json:"ratio"`
+	MaxLength     int     `json:"max_length"`
+	AverageLength float64 `json:"average_length"`
+}
+
+// Report represents the result of the validation of a maze. It is valid when it has
+// exactly one entrance, at least one exit and every spot can be reached from the
+// entrance, the isolated regions and the dead ends are only informative.
+type Report struct {
+	Valid            bool                    `json:"valid"`
+	Errors           []repository.FieldError `json:"errors"`
+	UnreachableSpots []SpotIssue             `json:"unreachable_spots"`
+	IsolatedRegions  []IsolatedRegion        `json:"isolated_regions"`
+	DeadEnds         DeadEnds                `json:"dead_ends"`
+}
+
+// Validate checks that the maze can be solved and that all its spots can be reached.
+func (s *Solver) Validate() *Report {
+	report := &Report{
+		Errors:           make([]repository.FieldError, 0),
+		UnreachableSpots: make([]SpotIssue, 0),
+		IsolatedRegions:  make([]IsolatedRegion, 0),
+	}
+
+	var ve *repository.ValidationError
+
+	if err := repository.ValidateMazeSpots(s.spots); errors.As(err, &ve) {
+		report.Errors = append(report.Errors, ve.Fields...)
+	}
+
+	// the reachability is only known when there is a single entrance
+	if e := s.entrance(); e != nil {
+		reached := s.Distances(*e.Coordinate)
+
+		for i := range s.spots {
+			spot := &s.spots[i]
+
+			if spot.IsBlocking() || spot.Coordinate == nil {
+				continue
+			}
+
+			if _, ok := reached[*spot.Coordinate]; !ok {
+				report.UnreachableSpots = append(report.UnreachableSpots, SpotIssue{
+					ID:         spot.ID,
+					Name:       spot.Name,
+					Kind:       spot.Kind,
+					Coordinate: *spot.Coordinate,
+				})
+			}
+		}
+
+		report.IsolatedRegions = s.isolatedRegions(reached)
+	}
+
+	report.DeadEnds = s.deadEnds()
+	report.Valid = len(report.Errors) == 0 && len(report.UnreachableSpots) == 0
+
+	return report
+}
+
+// entrance returns the entrance of the maze when it has exactly one.
+func (s *Solver) entrance() *repository.Spot {
+	var entrance *repository.Spot
+
+	for i := range s.spots {
+		if s.spots[i].Kind != repository.Entrance || s.spots[i].Coordinate == nil {
+			continue
+		}
+
+		if entrance != nil {
+			return nil
+		}
+
+		entrance = &s.spots[i]
+	}
+
+	return entrance
+}
+
+// isolatedRegions groups the free cells that weren't reached from the entrance by the
+// regions they are connected in.
+func (s *Solver) isolatedRegions(reached map[repository.Coordinate]int) []IsolatedRegion {
+	regions := make([]IsolatedRegion, 0)
+	seen := make(map[repository.Coordinate]bool)
+
+	spots := make(map[repository.Coordinate]int)
+
+	for i := range s.spots {
+		if s.spots[i].Coordinate != nil && !s.spots[i].IsBlocking() {
+			spots[*s.spots[i].Coordinate]++
+		}
+	}
+
+	for y := uint(0); y < s.grid.Height; y++ {
+		for x := uint(0); x < s.grid.Width; x++ {
+			start := repository.Coordinate{X: x, Y: y}
+
+			if _, ok := reached[start]; ok || seen[start] || s.Blocked(start) {
+				continue
+			}
+
+			region := IsolatedRegion{Start: start}
+			seen[start] = true
+			queue := []repository.Coordinate{start}
+
+			for len(queue) > 0 {
+				c := queue[0]
+				queue = queue[1:]
+
+				region.Cells++
+				region.Spots += spots[c]
+
+				for _, next := range s.Neighbours(c) {
+					if !seen[next] {
+						seen[next] = true
+						queue = append(queue, next)
+					}
+				}
+			}
+
+			regions = append(regions, region)
+		}
+	}
+
+	return regions
+}
+
+// deadEnds computes the statistics of the dead ends of the free cells.
+func (s *Solver) deadEnds() DeadEnds {
+	var (
+		stats DeadEnds
+		free  int
+		total int
+	)
+
+	for y := uint(0); y < s.grid.Height; y++ {
+		for x := uint(0); x < s.grid.Width; x++ {
+			c := repository.Coordinate{X: x, Y: y}
+
+			if s.Blocked(c) {
+				continue
+			}
+
+			free++
+
+			if len(s.Neighbours(c)) != 1 {
+				continue
+			}
+
+			length := s.corridor(c)
+
+			stats.Count++
+			total += length
+
+			if length > stats.MaxLength {
+				stats.MaxLength = length
+			}
+		}
+	}
+
+	if free > 0 {
+		stats.Ratio = float64(stats.Count) / float64(free)
+	}
+
+	if stats.Count > 0 {
+		stats.AverageLength = float64(total) / float64(stats.Count)
+	}
+
+	return stats
+}
+
+// corridor returns the number of cells walked from the dead end until a junction or
+// another dead end is reached.
+func (s *Solver) corridor(c repository.Coordinate) int {
+	prev, length := c, 1
+
+	for {
+		var next []repository.Coordinate
+
+		for _, n := range s.Neighbours(c) {
+			if n != prev {
+				next = append(next, n)
+			}
+		}
+
+		// the corridor ends in a junction or in the other side of a closed corridor
+		if len(next) != 1 || len(s.Neighbours(next[0])) > 2 {
+			return length
+		}
+
+		prev, c = c, next[0]
+		length++
+	}
+}
@@ -0,0 +1,54 @@
+package solver
+
+import (
+	"testing"
+
+	"github.com/PacoDw/maze_challenge/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSolver_Validate(t *testing.T) {
+	s := newTestSolver(t)
+
+	report := s.Validate()
+
+	assert.True(t, report.Valid)
+	assert.Empty(t, report.Errors)
+	assert.Empty(t, report.UnreachableSpots)
+	assert.Empty(t, report.IsolatedRegions)
+
+	// closing the last row splits the maze in two halves
+	if err := s.grid.SetWall(repository.Coordinate{X: 1, Y: 3}, repository.East, true); err != nil {
+		t.Fatal(err)
+	}
+
+	report = s.Validate()
+
+	assert.False(t, report.Valid)
+	assert.Equal(t, []SpotIssue{
+		{ID: "end", Kind: repository.Exit, Coordinate: repository.Coordinate{X: 3, Y: 2}},
+	}, report.UnreachableSpots)
+	assert.Equal(t, []IsolatedRegion{
+		{Start: repository.Coordinate{X: 2, Y: 0}, Cells: 8, Spots: 1},
+	}, report.IsolatedRegions)
+
+	// a maze without exit
+	report = New(s.grid, nil, s.spots[:1]).Validate()
+
+	assert.False(t, report.Valid)
+	assert.Len(t, report.Errors, 1)
+	assert.Equal(t, "spots", report.Errors[0].Field)
+}
+
+func TestSolver_deadEnds(t *testing.T) {
+	// a corridor of three cells has two dead ends
+	report := New(repository.NewGrid("", 3, 1, false), nil, nil).Validate()
+
+	assert.Equal(t, DeadEnds{Count: 2, Ratio: 2.0 / 3, MaxLength: 3, AverageLength: 3}, report.DeadEnds)
+
+	// the WALL spot leaves a single way out of the cell under it
+	report = newTestSolver(t).Validate()
+
+	assert.Equal(t, 1, report.DeadEnds.Count)
+	assert.Equal(t, 1, report.DeadEnds.MaxLength)
+}
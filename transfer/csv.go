@@ -0,0 +1,165 @@
+package transfer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/PacoDw/maze_challenge/repository"
+)
+
+// csvHeader contains the columns of the CSV documents in the order they are written, the
+// documents read can have them in any order and only name, x and y are required.
+var csvHeader = []string{"name", "kind", "gold_amount", "penalty", "x", "y"}
+
+// encodeCSV writes the spots of the quadrants and of the document.
+func encodeCSV(w io.Writer, doc *Document) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	write := func(spots []Spot) error {
+		for i := range spots {
+			s := &spots[i]
+
+			err := cw.Write([]string{
+				s.Name,
+				string(s.Kind),
+				strconv.FormatUint(uint64(s.GoldAmount), 10),
+				strconv.FormatUint(uint64(s.Penalty), 10),
+				strconv.FormatUint(uint64(s.X), 10),
+				strconv.FormatUint(uint64(s.Y), 10),
+			})
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	for i := range doc.Quadrants {
+		if err := write(doc.Quadrants[i].Spots); err != nil {
+			return err
+		}
+	}
+
+	if err := write(doc.Spots); err != nil {
+		return err
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+// decodeCSV reads the spots of a CSV document, the first row must be the header.
+func decodeCSV(r io.Reader) ([]Spot, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, repository.BadRequest("the CSV document must start with the header %s", strings.Join(csvHeader, ","))
+	}
+
+	if err != nil {
+		return nil, repository.BadRequest("reading the CSV document: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		if !containsColumn(name) {
+			return nil, repository.BadRequest("unknown CSV column %q, the columns are %s", name, strings.Join(csvHeader, ","))
+		}
+
+		columns[name] = i
+	}
+
+	for _, name := range []string{"name", "x", "y"} {
+		if _, ok := columns[name]; !ok {
+			return nil, repository.BadRequest("the CSV document must have the column %s", name)
+		}
+	}
+
+	spots := make([]Spot, 0)
+
+	// the header is the first row
+	for row := 2; ; row++ {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, repository.BadRequest("reading the CSV document: %w", err)
+		}
+
+		spot, err := parseCSVSpot(columns, record)
+		if err != nil {
+			return nil, repository.BadRequest("row %d of the CSV document: %w", row, err)
+		}
+
+		spots = append(spots, spot)
+	}
+
+	return spots, nil
+}
+
+// parseCSVSpot reads a spot from the values of a row, the empty numbers are zero.
+func parseCSVSpot(columns map[string]int, record []string) (Spot, error) {
+	var (
+		spot   Spot
+		values = make(map[string]string, len(columns))
+	)
+
+	for name, i := range columns {
+		values[name] = strings.TrimSpace(record[i])
+	}
+
+	spot.Name = values["name"]
+	spot.Kind = repository.SpotKind(strings.ToUpper(values["kind"]))
+
+	numbers := map[string]*uint{
+		"gold_amount": &spot.GoldAmount,
+		"penalty":     &spot.Penalty,
+		"x":           &spot.X,
+		"y":           &spot.Y,
+	}
+
+	for _, name := range csvHeader {
+		n, ok := numbers[name]
+		if !ok || values[name] == "" {
+			continue
+		}
+
+		v, err := strconv.ParseUint(values[name], 10, 32)
+		if err != nil {
+			return spot, fmt.Errorf("%s %q is not a non-negative integer", name, values[name])
+		}
+
+		*n = uint(v)
+	}
+
+	return spot, nil
+}
+
+// containsColumn reports if the name is one of the columns of the CSV documents.
+func containsColumn(name string) bool {
+	for _, column := range csvHeader {
+		if column == name {
+			return true
+		}
+	}
+
+	return false
+}
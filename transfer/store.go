@@ -0,0 +1,307 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/PacoDw/maze_challenge/repository"
+)
+
+// quadrantOrder is the order of the quadrants in the exported documents.
+var quadrantOrder = map[repository.QuadrantType]int{
+	repository.TopLeft:     0,
+	repository.TopRight:    1,
+	repository.BottomLeft:  2,
+	repository.BottomRight: 3,
+}
+
+// Export returns the document of the maze, its spots are sorted by row and column.
+func Export(ctx context.Context, repo *repository.Repository, mazeID string) (*Document, error) {
+	maze, err := repo.Maze.Get(ctx, &repository.MazeFilter{ID: mazeID})
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &Document{
+		Name:      maze.Name,
+		Width:     maze.Width,
+		Height:    maze.Height,
+		Quadrants: make([]Quadrant, 0, len(maze.Quadrants)),
+	}
+
+	sort.SliceStable(maze.Quadrants, func(i, j int) bool {
+		return quadrantOrder[maze.Quadrants[i].Type] < quadrantOrder[maze.Quadrants[j].Type]
+	})
+
+	for i := range maze.Quadrants {
+		q := &maze.Quadrants[i]
+
+		if q.StartPoint == nil || q.LimitPoint == nil {
+			return nil, fmt.Errorf("the quadrant %s has no start or limit point", q.ID)
+		}
+
+		dq := Quadrant{
+			Type:       q.Type,
+			StartPoint: *q.StartPoint,
+			LimitPoint: *q.LimitPoint,
+			Spots:      make([]Spot, 0, len(q.Spots)),
+		}
+
+		for j := range q.Spots {
+			s := &q.Spots[j]
+
+			if s.Coordinate == nil {
+				continue
+			}
+
+			dq.Spots = append(dq.Spots, Spot{
+				Name:       s.Name,
+				Kind:       s.Kind,
+				GoldAmount: s.GoldAmount,
+				Penalty:    s.Penalty,
+				X:          s.Coordinate.X,
+				Y:          s.Coordinate.Y,
+			})
+		}
+
+		sort.Slice(dq.Spots, func(i, j int) bool {
+			if dq.Spots[i].Y != dq.Spots[j].Y {
+				return dq.Spots[i].Y < dq.Spots[j].Y
+			}
+
+			return dq.Spots[i].X < dq.Spots[j].X
+		})
+
+		doc.Quadrants = append(doc.Quadrants, dq)
+	}
+
+	var nf *repository.NotFoundError
+
+	grid, err := repo.Grid.Get(ctx, &repository.GridFilter{MazeID: mazeID})
+
+	switch {
+	case errors.As(err, &nf):
+		// the mazes created before the grids existed have no walls
+	case err != nil:
+		return nil, err
+	default:
+		doc.Walls = encodeWalls(grid)
+	}
+
+	return doc, nil
+}
+
+// Import validates the whole document and creates the maze with its spots and walls in a
+// single transaction, so either all of it is stored or nothing is. The maze is returned
+// with its quadrants.
+func Import(ctx context.Context, repo *repository.Repository, doc *Document) (*repository.Maze, error) {
+	maze := &repository.Maze{Name: doc.Name, Width: doc.Width, Height: doc.Height}
+
+	if err := doc.validate(maze); err != nil {
+		return nil, err
+	}
+
+	err := repo.Transaction(ctx, func(ctx context.Context) error {
+		if _, err := repo.Maze.Create(ctx, maze); err != nil {
+			return err
+		}
+
+		if len(doc.Walls) > 0 {
+			grid := doc.grid()
+			grid.MazeID = maze.ID
+
+			if err := repo.Grid.Put(ctx, grid); err != nil {
+				return err
+			}
+		}
+
+		quadrantIDs := make(map[repository.QuadrantType]string, len(maze.Quadrants))
+
+		for i := range maze.Quadrants {
+			quadrantIDs[maze.Quadrants[i].Type] = maze.Quadrants[i].ID
+		}
+
+		// the spots are created together, paths keeps where each one is in the document
+		var (
+			spots []repository.Spot
+			paths []string
+		)
+
+		for i := range doc.Quadrants {
+			for j := range doc.Quadrants[i].Spots {
+				spots = append(spots, newSpot(&doc.Quadrants[i].Spots[j], maze.ID, quadrantIDs[doc.Quadrants[i].Type]))
+				paths = append(paths, fmt.Sprintf("quadrants[%d].spots[%d]", i, j))
+			}
+		}
+
+		for j := range doc.Spots {
+			spots = append(spots, newSpot(&doc.Spots[j], maze.ID, ""))
+			paths = append(paths, fmt.Sprintf("spots[%d]", j))
+		}
+
+		err := repo.Spot.CreateMany(ctx, spots)
+
+		var se *repository.SpotError
+		if errors.As(err, &se) {
+			return spotError(paths[se.Index], se.Err)
+		}
+
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.Maze.Get(ctx, &repository.MazeFilter{ID: maze.ID})
+}
+
+// newSpot returns the spot of the document in the maze, without quadrant it is placed in
+// the one that contains it.
+func newSpot(s *Spot, mazeID, quadrantID string) repository.Spot {
+	return repository.Spot{
+		Name:       s.Name,
+		Kind:       s.Kind,
+		GoldAmount: s.GoldAmount,
+		Penalty:    s.Penalty,
+		Coordinate: &repository.Coordinate{X: s.X, Y: s.Y},
+		MazeID:     mazeID,
+		QuadrantID: quadrantID,
+	}
+}
+
+// spotError returns the error of a spot of the document, the attributes of the validation
+// and conflict errors are prefixed with the path of the spot, e.g. spots[2].penalty.
+func spotError(path string, err error) error {
+	var (
+		ve *repository.ValidationError
+		ce *repository.ConflictError
+	)
+
+	prefix := func(fields []repository.FieldError) []repository.FieldError {
+		prefixed := make([]repository.FieldError, 0, len(fields))
+
+		for _, f := range fields {
+			prefixed = append(prefixed, repository.FieldError{
+				Field:   path + "." + strings.ToLower(f.Field),
+				Message: f.Message,
+			})
+		}
+
+		return prefixed
+	}
+
+	switch {
+	case errors.As(err, &ve):
+		return &repository.ValidationError{Entity: "maze", Fields: prefix(ve.Fields)}
+	case errors.As(err, &ce):
+		return &repository.ConflictError{Entity: "maze", Fields: prefix(ce.Fields)}
+	default:
+		return fmt.Errorf("creating the spot %s: %w", path, err)
+	}
+}
+
+// validate checks the whole document before anything is stored, the rules of the spots
+// that depend on the stored data, e.g. the kinds, are checked when they are created.
+func (doc *Document) validate(maze *repository.Maze) error {
+	ve := &repository.ValidationError{Entity: "maze"}
+
+	add := func(field, format string, args ...interface{}) {
+		ve.Fields = append(ve.Fields, repository.FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if doc.Name == "" {
+		add("name", "must be specified")
+	}
+
+	if doc.Width < 2 || doc.Width > repository.MaxMazeSide {
+		add("width", "must be between 2 and %d", repository.MaxMazeSide)
+	}
+
+	if doc.Height < 2 || doc.Height > repository.MaxMazeSide {
+		add("height", "must be between 2 and %d", repository.MaxMazeSide)
+	}
+
+	if len(ve.Fields) > 0 {
+		return ve
+	}
+
+	quadrants := make(map[repository.QuadrantType]*repository.Quadrant, 4)
+
+	for _, q := range maze.NewQuadrants() {
+		q := q
+		quadrants[q.Type] = &q
+	}
+
+	if len(doc.Quadrants) != 0 && len(doc.Quadrants) != len(quadrants) {
+		add("quadrants", "must contain the %d quadrants of the maze or none of them", len(quadrants))
+	}
+
+	used := make(map[repository.Coordinate]string)
+
+	checkSpot := func(path string, s *Spot, inside func(c repository.Coordinate) bool) {
+		c := repository.Coordinate{X: s.X, Y: s.Y}
+
+		if s.Name == "" {
+			add(path+".name", "must be specified")
+		}
+
+		if !inside(c) {
+			add(path+".x", "the coordinate %d,%d must be inside its quadrant and the maze of %dx%d",
+				c.X, c.Y, doc.Width, doc.Height)
+		}
+
+		if other, ok := used[c]; ok {
+			add(path+".x", "the coordinate %d,%d is already used by %s", c.X, c.Y, other)
+		}
+
+		used[c] = path
+	}
+
+	seen := make(map[repository.QuadrantType]bool, 4)
+
+	for i := range doc.Quadrants {
+		dq := &doc.Quadrants[i]
+		path := fmt.Sprintf("quadrants[%d]", i)
+
+		q, ok := quadrants[dq.Type]
+
+		switch {
+		case !ok:
+			add(path+".type", "must be one of %s, %s, %s and %s",
+				repository.TopLeft, repository.TopRight, repository.BottomLeft, repository.BottomRight)
+
+			continue
+		case seen[dq.Type]:
+			add(path+".type", "the %s quadrant is repeated", dq.Type)
+		case dq.StartPoint != *q.StartPoint || dq.LimitPoint != *q.LimitPoint:
+			add(path+".limit_point", "the %s quadrant of the maze goes from %d,%d to %d,%d",
+				dq.Type, q.StartPoint.X, q.StartPoint.Y, q.LimitPoint.X, q.LimitPoint.Y)
+		}
+
+		seen[dq.Type] = true
+
+		for j := range dq.Spots {
+			checkSpot(fmt.Sprintf("%s.spots[%d]", path, j), &dq.Spots[j], q.Contains)
+		}
+	}
+
+	insideMaze := func(c repository.Coordinate) bool {
+		return c.X < doc.Width && c.Y < doc.Height
+	}
+
+	for j := range doc.Spots {
+		checkSpot(fmt.Sprintf("spots[%d]", j), &doc.Spots[j], insideMaze)
+	}
+
+	doc.validateWalls(add)
+
+	if len(ve.Fields) > 0 {
+		return ve
+	}
+
+	return nil
+}
@@ -0,0 +1,151 @@
+// Package transfer imports and exports whole mazes with their quadrants, spots and walls
+// as a single document, encoded as JSON, YAML or, for the list of spots, CSV. A document
+// exported in a format is imported again as the same maze.
+package transfer
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime"
+
+	"github.com/PacoDw/maze_challenge/repository"
+	"gopkg.in/yaml.v2"
+)
+
+// Format defines the encoding of a document.
+type Format string
+
+const (
+	// JSON encodes the whole document.
+	JSON Format = "json"
+
+	// YAML encodes the whole document like JSON with the same names.
+	YAML Format = "yaml"
+
+	// CSV encodes the spots of the document, one per row after the header, the name and
+	// the dimensions of the maze are given apart.
+	CSV Format = "csv"
+)
+
+// ContentType returns the media type of the format.
+func (f Format) ContentType() string {
+	switch f {
+	case YAML:
+		return "application/yaml"
+	case CSV:
+		return "text/csv; charset=utf-8"
+	default:
+		return "application/json; charset=utf-8"
+	}
+}
+
+// FormatOf returns the format of the media type, application/json is taken by default.
+func FormatOf(contentType string) (Format, error) {
+	if contentType == "" {
+		return JSON, nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", repository.BadRequest("wrong Content-Type %q: %w", contentType, err)
+	}
+
+	switch mediaType {
+	case "application/json":
+		return JSON, nil
+	case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+		return YAML, nil
+	case "text/csv":
+		return CSV, nil
+	default:
+		return "", repository.BadRequest("unknown Content-Type %q, it must be application/json, application/yaml or text/csv", mediaType)
+	}
+}
+
+// Document represents a maze with everything in it. The quadrants can be left out, then
+// the maze is split in the four default ones, and the spots can be listed in their quadrant
+// or in Spots, where they are placed in the quadrant that contains them. Walls has a row
+// per line of the maze with a digit per cell, the bit 1 is its north wall and the bit 2
+// its west one, the east and south walls are the west and north ones of the neighbours and
+// the border is always closed. The walls are left out when the maze has none inside.
+type Document struct {
+	Name      string     `json:"name" yaml:"name"`
+	Width     uint       `json:"width" yaml:"width"`
+	Height    uint       `json:"height" yaml:"height"`
+	Quadrants []Quadrant `json:"quadrants,omitempty" yaml:"quadrants,omitempty"`
+	Spots     []Spot     `json:"spots,omitempty" yaml:"spots,omitempty"`
+	Walls     []string   `json:"walls,omitempty" yaml:"walls,omitempty"`
+}
+
+// Quadrant represents a quadrant of the document with its spots.
+type Quadrant struct {
+	Type       repository.QuadrantType `json:"type" yaml:"type"`
+	StartPoint repository.Coordinate   `json:"start_point" yaml:"start_point"`
+	LimitPoint repository.Coordinate   `json:"limit_point" yaml:"limit_point"`
+	Spots      []Spot                  `json:"spots,omitempty" yaml:"spots,omitempty"`
+}
+
+// Spot represents a spot of the document, a spot without kind is a treasure.
+type Spot struct {
+	Name       string              `json:"name" yaml:"name"`
+	Kind       repository.SpotKind `json:"kind,omitempty" yaml:"kind,omitempty"`
+	GoldAmount uint                `json:"gold_amount" yaml:"gold_amount"`
+	Penalty    uint                `json:"penalty,omitempty" yaml:"penalty,omitempty"`
+	X          uint                `json:"x" yaml:"x"`
+	Y          uint                `json:"y" yaml:"y"`
+}
+
+// Encode writes the document in the format.
+func Encode(w io.Writer, f Format, doc *Document) error {
+	switch f {
+	case JSON, "":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(doc)
+	case YAML:
+		return yaml.NewEncoder(w).Encode(doc)
+	case CSV:
+		return encodeCSV(w, doc)
+	default:
+		return repository.BadRequest("unknown format %q, it must be %s, %s or %s", f, JSON, YAML, CSV)
+	}
+}
+
+// Decode reads a document in the format, the attributes that don't exist are rejected.
+// The CSV documents only contain spots, so their name and dimensions must be set later.
+func Decode(r io.Reader, f Format) (*Document, error) {
+	doc := &Document{}
+
+	switch f {
+	case JSON, "":
+		dec := json.NewDecoder(r)
+		dec.DisallowUnknownFields()
+
+		if err := dec.Decode(doc); err != nil {
+			return nil, repository.BadRequest("the body must be a JSON document: %w", err)
+		}
+	case YAML:
+		raw, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, repository.BadRequest("reading the document: %w", err)
+		}
+
+		if err := yaml.UnmarshalStrict(bytes.TrimSpace(raw), doc); err != nil {
+			return nil, repository.BadRequest("the body must be a YAML document: %w", err)
+		}
+	case CSV:
+		spots, err := decodeCSV(r)
+		if err != nil {
+			return nil, err
+		}
+
+		doc.Spots = spots
+	default:
+		return nil, repository.BadRequest("unknown format %q, it must be %s, %s or %s", f, JSON, YAML, CSV)
+	}
+
+	return doc, nil
+}
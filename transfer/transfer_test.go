@@ -0,0 +1,210 @@
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/PacoDw/maze_challenge/generator"
+	"github.com/PacoDw/maze_challenge/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+// newGeneratedMaze stores a maze with walls, gold and traps and returns its document.
+func newGeneratedMaze(t *testing.T, ctx context.Context, repo *repository.Repository) *Document {
+	t.Helper()
+
+	res, err := generator.Generate(generator.Config{Seed: 7, Name: "labyrinth", Width: 12, Height: 9, GoldSpots: 6, TrapDensity: 0.05})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := res.Save(ctx, repo); err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := Export(ctx, repo, res.Maze.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return doc
+}
+
+func TestRoundTrip(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		repo = repository.NewMemory()
+		doc  = newGeneratedMaze(t, ctx, repo)
+	)
+
+	assert.Len(t, doc.Quadrants, 4)
+	assert.Len(t, doc.Walls, 9)
+
+	for _, f := range []Format{JSON, YAML} {
+		var buf bytes.Buffer
+
+		if err := Encode(&buf, f, doc); err != nil {
+			t.Fatal(err)
+		}
+
+		decoded, err := Decode(&buf, f)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, doc, decoded, f)
+
+		maze, err := Import(ctx, repo, decoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		exported, err := Export(ctx, repo, maze.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, doc, exported, f)
+	}
+
+	// the CSV documents only contain the spots
+	var buf bytes.Buffer
+
+	if err := Encode(&buf, CSV, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.True(t, strings.HasPrefix(buf.String(), "name,kind,gold_amount,penalty,x,y\n"))
+
+	decoded, err := Decode(&buf, CSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded.Name, decoded.Width, decoded.Height = doc.Name, doc.Width, doc.Height
+
+	maze, err := Import(ctx, repo, decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exported, err := Export(ctx, repo, maze.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, doc.Quadrants, exported.Quadrants)
+	assert.Empty(t, exported.Walls)
+}
+
+func TestDecode(t *testing.T) {
+	doc, err := Decode(strings.NewReader("x, y ,name,KIND,penalty\n1,2,spike,trap,3\n0,0,gold,,\n"), CSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []Spot{
+		{Name: "spike", Kind: repository.Trap, Penalty: 3, X: 1, Y: 2},
+		{Name: "gold", X: 0, Y: 0},
+	}, doc.Spots)
+
+	_, err = Decode(strings.NewReader("name,x\ngold,1\n"), CSV)
+	assert.EqualError(t, err, "the CSV document must have the column y")
+
+	_, err = Decode(strings.NewReader("name,x,y,color\n"), CSV)
+	assert.Error(t, err)
+
+	_, err = Decode(strings.NewReader("name,x,y\ngold,1,-2\n"), CSV)
+	assert.EqualError(t, err, `row 2 of the CSV document: y "-2" is not a non-negative integer`)
+
+	_, err = Decode(strings.NewReader(`{"name":"labyrinth","color":"red"}`), JSON)
+	assert.Error(t, err)
+
+	_, err = Decode(strings.NewReader("name: labyrinth\ncolor: red\n"), YAML)
+	assert.Error(t, err)
+
+	f, err := FormatOf("application/x-yaml; charset=utf-8")
+	assert.NoError(t, err)
+	assert.Equal(t, YAML, f)
+
+	_, err = FormatOf("text/plain")
+	assert.Error(t, err)
+}
+
+func TestImport_Validation(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		repo = repository.NewMemory()
+		ve   *repository.ValidationError
+	)
+
+	_, err := Import(ctx, repo, &Document{
+		Name:   "labyrinth",
+		Width:  10,
+		Height: 10,
+		Quadrants: []Quadrant{
+			{Type: repository.TopLeft, LimitPoint: repository.Coordinate{X: 5, Y: 5}, Spots: []Spot{{Name: "gold", X: 7, Y: 1}}},
+			{Type: repository.TopRight, StartPoint: repository.Coordinate{X: 5}, LimitPoint: repository.Coordinate{X: 10, Y: 6}},
+			{Type: repository.BottomLeft, StartPoint: repository.Coordinate{Y: 5}, LimitPoint: repository.Coordinate{X: 5, Y: 10}},
+			{Type: repository.BottomLeft, StartPoint: repository.Coordinate{Y: 5}, LimitPoint: repository.Coordinate{X: 5, Y: 10}},
+		},
+		Spots: []Spot{{X: 7, Y: 1}},
+		Walls: []string{"0000000000"},
+	})
+
+	assert.True(t, errors.As(err, &ve))
+
+	fields := make([]string, 0, len(ve.Fields))
+
+	for _, f := range ve.Fields {
+		fields = append(fields, f.Field)
+	}
+
+	assert.Equal(t, []string{
+		"quadrants[0].spots[0].x",
+		"quadrants[1].limit_point",
+		"quadrants[3].type",
+		"spots[0].name",
+		"spots[0].x",
+		"walls",
+	}, fields)
+
+	// the spots are checked by the stores as well, then nothing is kept
+	_, err = Import(ctx, repo, &Document{
+		Name:   "labyrinth",
+		Width:  10,
+		Height: 10,
+		Spots:  []Spot{{Name: "gold", GoldAmount: 5, X: 1, Y: 1}, {Name: "spike", Kind: repository.Trap, X: 8, Y: 8}},
+	})
+
+	assert.True(t, errors.As(err, &ve))
+	assert.Equal(t, "spots[1].penalty", ve.Fields[0].Field)
+
+	// the spots of the quadrants are created before the rest
+	_, err = Import(ctx, repo, &Document{
+		Name:   "labyrinth",
+		Width:  10,
+		Height: 10,
+		Quadrants: []Quadrant{
+			{Type: repository.TopLeft, StartPoint: repository.Coordinate{}, LimitPoint: repository.Coordinate{X: 5, Y: 5},
+				Spots: []Spot{{Name: "entrance", Kind: repository.Entrance, X: 0, Y: 0}}},
+			{Type: repository.TopRight, StartPoint: repository.Coordinate{X: 5}, LimitPoint: repository.Coordinate{X: 10, Y: 5}},
+			{Type: repository.BottomLeft, StartPoint: repository.Coordinate{Y: 5}, LimitPoint: repository.Coordinate{X: 5, Y: 10}},
+			{Type: repository.BottomRight, StartPoint: repository.Coordinate{X: 5, Y: 5}, LimitPoint: repository.Coordinate{X: 10, Y: 10}},
+		},
+		Spots: []Spot{{Name: "gold", GoldAmount: 5, X: 1, Y: 1}, {Name: "door", Kind: repository.Entrance, X: 8, Y: 8}},
+	})
+
+	assert.True(t, errors.As(err, &ve))
+	assert.Equal(t, "spots[1].kind", ve.Fields[0].Field)
+
+	mazes, err := repo.Maze.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Empty(t, mazes)
+}
@@ -0,0 +1,108 @@
+package transfer
+
+import (
+	"fmt"
+
+	"github.com/PacoDw/maze_challenge/repository"
+)
+
+// bits of each cell in the rows of the walls.
+const (
+	northWall = 1
+	westWall  = 2
+)
+
+// encodeWalls returns the rows of the walls of the grid, nil when there are no walls
+// inside the maze.
+func encodeWalls(g *repository.Grid) []string {
+	var (
+		rows   = make([]string, 0, g.Height)
+		inside bool
+	)
+
+	for y := uint(0); y < g.Height; y++ {
+		row := make([]byte, g.Width)
+
+		for x := uint(0); x < g.Width; x++ {
+			var (
+				c     = repository.Coordinate{X: x, Y: y}
+				walls byte
+			)
+
+			if g.HasWall(c, repository.North) {
+				walls |= northWall
+				inside = inside || y > 0
+			}
+
+			if g.HasWall(c, repository.West) {
+				walls |= westWall
+				inside = inside || x > 0
+			}
+
+			row[x] = '0' + walls
+		}
+
+		rows = append(rows, string(row))
+	}
+
+	if !inside {
+		return nil
+	}
+
+	return rows
+}
+
+// validateWalls checks that there is a row of walls per line of the maze and a digit per
+// cell in each row.
+func (doc *Document) validateWalls(add func(field, format string, args ...interface{})) {
+	if len(doc.Walls) == 0 {
+		return
+	}
+
+	if uint(len(doc.Walls)) != doc.Height {
+		add("walls", "must contain %d rows, one per line of the maze", doc.Height)
+
+		return
+	}
+
+	for y, row := range doc.Walls {
+		if uint(len(row)) != doc.Width {
+			add(fmt.Sprintf("walls[%d]", y), "must contain %d digits, one per cell", doc.Width)
+
+			continue
+		}
+
+		for x := range row {
+			if row[x] < '0' || row[x] > '0'+(northWall|westWall) {
+				add(fmt.Sprintf("walls[%d]", y), "the cell %d must be a digit from 0 to 3", x)
+
+				break
+			}
+		}
+	}
+}
+
+// grid returns the grid with the walls of the document, the walls of the border are
+// always closed so they are ignored.
+func (doc *Document) grid() *repository.Grid {
+	g := repository.NewGrid("", doc.Width, doc.Height, false)
+
+	for y, row := range doc.Walls {
+		for x := range row {
+			var (
+				c     = repository.Coordinate{X: uint(x), Y: uint(y)}
+				walls = row[x] - '0'
+			)
+
+			if y > 0 {
+				_ = g.SetWall(c, repository.North, walls&northWall != 0)
+			}
+
+			if x > 0 {
+				_ = g.SetWall(c, repository.West, walls&westWall != 0)
+			}
+		}
+	}
+
+	return g
+}